@@ -0,0 +1,44 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+
+// PausedAnnotation suspends the changes stilas makes in GCP for a resource when set to "true", for
+// example to keep manual changes made in the console during an incident. A paused resource still
+// gets its status refreshed from GCP and a Paused condition. When deleting a paused resource would
+// delete its GCP resource, the finalizer is kept and the deletion waits until the annotation is
+// removed. Remove the finalizer by hand to delete it and leave the GCP resource in place. The
+// --paused flag of the manager pauses all resources the same way.
+const PausedAnnotation = "stilas.418.cloud/paused"
+
+// PlanOnlyAnnotation stops stilas from changing anything in GCP for a resource when set to "true".
+// Instead the changes a reconcile would make are written to status.plan and recorded as events.
+// A resource in plan-only mode gets no finalizer, deleting it leaves its GCP resource in place. A
+// resource that already has a finalizer reports the planned deletion in an event and has its
+// finalizer removed without the deletion being applied. The --plan-only flag of the manager puts
+// all resources in plan-only mode.
+const PlanOnlyAnnotation = "stilas.418.cloud/plan-only"
+
+// ExternalNameAnnotation is the name of the GCP resource managed by a resource, see gcpv2.ExternalNameAnnotation
+const ExternalNameAnnotation = gcpv2.ExternalNameAnnotation
+
+// AdoptAnnotation tells stilas that the GCP resource of a resource already exists when set to "true".
+// The resource takes over the existing GCP resource and never creates it, if it is missing the
+// Synced condition is False with the reason AdoptedResourceNotFound. stilasctl import sets it on
+// the resources it writes, together with ExternalNameAnnotation.
+const AdoptAnnotation = "stilas.418.cloud/adopt"
@@ -0,0 +1,176 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/dns/v2"
+)
+
+// dnsSecStates maps the DNSSEC states of Cloud DNS to the values of DnsSecSpec.State
+var dnsSecStates = map[string]string{
+	"on":       "On",
+	"off":      "Off",
+	"transfer": "Transfer",
+}
+
+// forwardingPaths maps the forwarding paths of Cloud DNS to the values of ForwardingTarget.ForwardingPath
+var forwardingPaths = map[string]string{
+	"default": "Default",
+	"private": "Private",
+}
+
+// ConvertFromManagedZone returns the spec of a CloudDnsZone managing zone in project. The settings
+// of zone a CloudDnsZone can not express are listed in the warnings.
+func ConvertFromManagedZone(project string, zone *dns.ManagedZone) (CloudDnsZoneSpec, []string) {
+	var warnings []string
+	spec := CloudDnsZoneSpec{
+		ProjectID:   project,
+		DnsName:     strings.TrimSuffix(zone.DnsName, "."),
+		PrivateZone: zone.Visibility == "private",
+		Description: zone.Description,
+		Labels:      zone.Labels,
+		DnsSecSpec:  DnsSecSpec{State: "Off"},
+	}
+	if zone.CloudLoggingConfig != nil {
+		spec.CloudLoggingSpec.Enabled = zone.CloudLoggingConfig.EnableLogging
+	}
+	if config := zone.DnssecConfig; config != nil {
+		if state, ok := dnsSecStates[strings.ToLower(config.State)]; ok {
+			spec.DnsSecSpec.State = state
+		}
+		spec.DnsSecSpec.NonExistence = strings.EqualFold(config.NonExistence, "nsec3")
+		for _, keySpec := range config.DefaultKeySpecs {
+			imported := &DnsKeySpec{Algorithm: strings.ToUpper(keySpec.Algorithm), KeyLength: keySpec.KeyLength}
+			switch keySpec.KeyType {
+			case DnsKeyTypeKeySigning:
+				spec.DnsSecSpec.KeySigningKeySpec = imported
+			case DnsKeyTypeZoneSigning:
+				spec.DnsSecSpec.ZoneSigningKeySpec = imported
+			}
+		}
+	}
+	if config := zone.PrivateVisibilityConfig; config != nil {
+		for _, network := range config.Networks {
+			ref, ok := networkReference(project, network.NetworkUrl)
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("zone %s is visible to the network %s, which a CloudDnsZone can not refer to", zone.Name, network.NetworkUrl))
+				continue
+			}
+			spec.PrivateNetworks = append(spec.PrivateNetworks, ref)
+		}
+		for _, cluster := range config.GkeClusters {
+			ref, ok := gkeClusterReference(project, cluster.GkeClusterName)
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("zone %s is visible to the GKE cluster %s, which a CloudDnsZone can not refer to", zone.Name, cluster.GkeClusterName))
+				continue
+			}
+			spec.PrivateNetworks = append(spec.PrivateNetworks, ref)
+		}
+	}
+	if config := zone.ForwardingConfig; config != nil {
+		spec.ZoneType = ZoneTypeForwarding
+		spec.ForwardingSpec = &ForwardingSpec{}
+		for _, target := range config.TargetNameServers {
+			spec.ForwardingSpec.TargetNameServers = append(spec.ForwardingSpec.TargetNameServers, ForwardingTarget{
+				IPv4Address:    target.Ipv4Address,
+				ForwardingPath: forwardingPaths[strings.ToLower(target.ForwardingPath)],
+			})
+		}
+	}
+	if config := zone.PeeringConfig; config != nil && config.TargetNetwork != nil {
+		ref, ok := networkReference(project, config.TargetNetwork.NetworkUrl)
+		if ok {
+			spec.ZoneType = ZoneTypePeering
+			spec.PeeringSpec = &PeeringSpec{TargetNetwork: NetworkReference{ProjectID: ref.ProjectID, Network: ref.Network}}
+		} else {
+			warnings = append(warnings, fmt.Sprintf("zone %s peers with the network %s, which a CloudDnsZone can not refer to", zone.Name, config.TargetNetwork.NetworkUrl))
+		}
+	}
+	return spec, warnings
+}
+
+// networkReference returns the reference to the network with the url, leaving out the project
+// when it is the project of the zone
+func networkReference(project string, url string) (PrivateNetworkReference, bool) {
+	_, path, _ := strings.Cut(url, "/projects/")
+	parts := strings.Split(path, "/")
+	if len(parts) != 4 || parts[1] != "global" || parts[2] != "networks" {
+		return PrivateNetworkReference{}, false
+	}
+	ref := PrivateNetworkReference{Network: parts[3]}
+	if parts[0] != project {
+		ref.ProjectID = parts[0]
+	}
+	return ref, true
+}
+
+// gkeClusterReference returns the reference to the GKE cluster with the name, leaving out the
+// project when it is the project of the zone
+func gkeClusterReference(project string, name string) (PrivateNetworkReference, bool) {
+	parts := strings.Split(strings.TrimPrefix(name, "projects/"), "/")
+	if len(parts) != 5 || parts[1] != "locations" || parts[3] != "clusters" {
+		return PrivateNetworkReference{}, false
+	}
+	ref := PrivateNetworkReference{GkeCluster: &GkeClusterReference{Location: parts[2], Name: parts[4]}}
+	if parts[0] != project {
+		ref.ProjectID = parts[0]
+	}
+	return ref, true
+}
+
+// ConvertFromResourceRecordSet returns the spec of a CloudDnsRecord managing rrs in zone, with the
+// name made relative to the zone. The settings of rrs a CloudDnsRecord can not express are listed in
+// the warnings.
+func ConvertFromResourceRecordSet(zone *CloudDnsZone, rrs *dns.ResourceRecordSet) (CloudDnsRecordSpec, []string) {
+	var warnings []string
+	spec := CloudDnsRecordSpec{
+		ZoneRef: CloudDnsZoneReference{Name: zone.Name},
+		Name:    relativeName(rrs.Name, zone.Spec.DnsName),
+		Type:    rrs.Type,
+		TTL:     rrs.Ttl,
+		Rrdatas: rrs.Rrdatas,
+	}
+	if rrs.RoutingPolicy != nil {
+		warnings = append(warnings, fmt.Sprintf("record set %s %s has a routing policy, which a CloudDnsRecord can not express", rrs.Name, rrs.Type))
+	}
+	if len(rrs.SignatureRrdatas) > 0 {
+		warnings = append(warnings, fmt.Sprintf("record set %s %s has signatures, they are left out", rrs.Name, rrs.Type))
+	}
+	return spec, warnings
+}
+
+// IsZoneManagedRecordSet returns true for the record sets Cloud DNS creates with a zone, the SOA
+// and NS records of its apex, they can not be managed by a CloudDnsRecord
+func IsZoneManagedRecordSet(zone *CloudDnsZone, rrs *dns.ResourceRecordSet) bool {
+	return relativeName(rrs.Name, zone.Spec.DnsName) == "@" && (rrs.Type == "SOA" || rrs.Type == "NS")
+}
+
+// relativeName returns fqdn relative to the zone for dnsName, @ for the apex and fqdn itself when
+// it is outside of the zone
+func relativeName(fqdn string, dnsName string) string {
+	zone := strings.TrimSuffix(dnsName, ".") + "."
+	if fqdn == zone {
+		return "@"
+	}
+	if name, ok := strings.CutSuffix(fqdn, "."+zone); ok {
+		return name
+	}
+	return fqdn
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/dns/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Cloud DNS import", func() {
+	zone := &CloudDnsZone{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec:       CloudDnsZoneSpec{ProjectID: "test-project", DnsName: "example.com"},
+	}
+
+	It("Should convert a managed zone", func() {
+		spec, warnings := ConvertFromManagedZone("test-project", &dns.ManagedZone{
+			Name:               "example",
+			DnsName:            "example.com.",
+			Description:        "example zone",
+			Labels:             map[string]string{"team": "dns"},
+			Visibility:         "public",
+			DnssecConfig:       &dns.ManagedZoneDnsSecConfig{State: "on", NonExistence: "nsec3"},
+			CloudLoggingConfig: &dns.ManagedZoneCloudLoggingConfig{EnableLogging: true},
+		})
+		Expect(warnings).To(BeEmpty())
+		Expect(spec).To(Equal(CloudDnsZoneSpec{
+			ProjectID:        "test-project",
+			DnsName:          "example.com",
+			Description:      "example zone",
+			Labels:           map[string]string{"team": "dns"},
+			DnsSecSpec:       DnsSecSpec{State: "On", NonExistence: true},
+			CloudLoggingSpec: CloudLoggingSpec{Enabled: true},
+		}))
+	})
+
+	It("Should import the DNSSEC key specs", func() {
+		spec, _ := ConvertFromManagedZone("test-project", &dns.ManagedZone{
+			Name:    "example",
+			DnsName: "example.com.",
+			DnssecConfig: &dns.ManagedZoneDnsSecConfig{State: "on", NonExistence: "nsec3", DefaultKeySpecs: []*dns.DnsKeySpec{
+				{Algorithm: "rsasha256", KeyLength: 2048, KeyType: "keySigning"},
+				{Algorithm: "ecdsap256sha256", KeyLength: 256, KeyType: "zoneSigning"},
+			}},
+		})
+		Expect(spec.DnsSecSpec.KeySigningKeySpec).To(Equal(&DnsKeySpec{Algorithm: "RSASHA256", KeyLength: 2048}))
+		Expect(spec.DnsSecSpec.ZoneSigningKeySpec).To(Equal(&DnsKeySpec{Algorithm: "ECDSAP256SHA256", KeyLength: 256}))
+	})
+
+	It("Should import forwarding zones", func() {
+		spec, warnings := ConvertFromManagedZone("test-project", &dns.ManagedZone{
+			Name:       "corp",
+			DnsName:    "corp.internal.",
+			Visibility: "private",
+			ForwardingConfig: &dns.ManagedZoneForwardingConfig{
+				TargetNameServers: []*dns.ManagedZoneForwardingConfigNameServerTarget{
+					{Ipv4Address: "10.0.0.53", ForwardingPath: "private"},
+					{Ipv4Address: "10.0.1.53"},
+				},
+			},
+		})
+		Expect(warnings).To(BeEmpty())
+		Expect(spec.PrivateZone).To(BeTrue())
+		Expect(spec.DnsSecSpec.State).To(Equal("Off"))
+		Expect(spec.ZoneType).To(Equal(ZoneTypeForwarding))
+		Expect(spec.ForwardingSpec.TargetNameServers).To(Equal([]ForwardingTarget{
+			{IPv4Address: "10.0.0.53", ForwardingPath: "Private"},
+			{IPv4Address: "10.0.1.53"},
+		}))
+	})
+
+	It("Should import peering zones and warn about the networks it can not refer to", func() {
+		spec, warnings := ConvertFromManagedZone("test-project", &dns.ManagedZone{
+			Name:       "shared",
+			DnsName:    "shared.internal.",
+			Visibility: "private",
+			PeeringConfig: &dns.ManagedZonePeeringConfig{
+				TargetNetwork: &dns.ManagedZonePeeringConfigTargetNetwork{
+					NetworkUrl: "https://www.googleapis.com/compute/v1/projects/host-project/global/networks/shared",
+				},
+			},
+		})
+		Expect(warnings).To(BeEmpty())
+		Expect(spec.ZoneType).To(Equal(ZoneTypePeering))
+		Expect(spec.PeeringSpec.TargetNetwork).To(Equal(NetworkReference{ProjectID: "host-project", Network: "shared"}))
+
+		_, warnings = ConvertFromManagedZone("test-project", &dns.ManagedZone{
+			Name:          "shared",
+			DnsName:       "shared.internal.",
+			Visibility:    "private",
+			PeeringConfig: &dns.ManagedZonePeeringConfig{TargetNetwork: &dns.ManagedZonePeeringConfigTargetNetwork{NetworkUrl: "unknown"}},
+		})
+		Expect(warnings).To(ConsistOf(ContainSubstring("peers with the network unknown")))
+	})
+
+	It("Should import the networks a private zone is visible to", func() {
+		spec, warnings := ConvertFromManagedZone("test-project", &dns.ManagedZone{
+			Name:       "corp",
+			DnsName:    "corp.internal.",
+			Visibility: "private",
+			PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{
+				Networks: []*dns.ManagedZonePrivateVisibilityConfigNetwork{
+					{NetworkUrl: "https://www.googleapis.com/compute/v1/projects/test-project/global/networks/default"},
+					{NetworkUrl: "https://www.googleapis.com/compute/v1/projects/shared-vpc/global/networks/shared"},
+					{NetworkUrl: "not-a-network"},
+				},
+				GkeClusters: []*dns.ManagedZonePrivateVisibilityConfigGKECluster{
+					{GkeClusterName: "projects/test-project/locations/europe-north1/clusters/apps"},
+				},
+			},
+		})
+		Expect(spec.PrivateNetworks).To(Equal([]PrivateNetworkReference{
+			{Network: "default"},
+			{ProjectID: "shared-vpc", Network: "shared"},
+			{GkeCluster: &GkeClusterReference{Location: "europe-north1", Name: "apps"}},
+		}))
+		Expect(warnings).To(ConsistOf(ContainSubstring("not-a-network")))
+	})
+
+	It("Should make record names relative to the zone", func() {
+		spec, warnings := ConvertFromResourceRecordSet(zone, &dns.ResourceRecordSet{
+			Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"},
+		})
+		Expect(warnings).To(BeEmpty())
+		Expect(spec).To(Equal(CloudDnsRecordSpec{
+			ZoneRef: CloudDnsZoneReference{Name: "example"},
+			Name:    "www",
+			Type:    "A",
+			TTL:     300,
+			Rrdatas: []string{"10.0.0.1"},
+		}))
+		record := &CloudDnsRecord{Spec: spec}
+		Expect(record.Fqdn(zone.Spec.DnsName)).To(Equal("www.example.com."))
+
+		spec, _ = ConvertFromResourceRecordSet(zone, &dns.ResourceRecordSet{Name: "example.com.", Type: "MX"})
+		Expect(spec.Name).To(Equal("@"))
+		record.Spec = spec
+		Expect(record.Fqdn(zone.Spec.DnsName)).To(Equal("example.com."))
+	})
+
+	It("Should skip the record sets Cloud DNS manages", func() {
+		Expect(IsZoneManagedRecordSet(zone, &dns.ResourceRecordSet{Name: "example.com.", Type: "SOA"})).To(BeTrue())
+		Expect(IsZoneManagedRecordSet(zone, &dns.ResourceRecordSet{Name: "example.com.", Type: "NS"})).To(BeTrue())
+		Expect(IsZoneManagedRecordSet(zone, &dns.ResourceRecordSet{Name: "team.example.com.", Type: "NS"})).To(BeFalse())
+	})
+
+	It("Should name the zone after the external name annotation", func() {
+		imported := zone.DeepCopy()
+		Expect(imported.GetCloudDnsZoneFullName()).To(Equal("default-example"))
+		imported.Annotations = map[string]string{ExternalNameAnnotation: "example"}
+		Expect(imported.GetCloudDnsZoneFullName()).To(Equal("example"))
+	})
+})
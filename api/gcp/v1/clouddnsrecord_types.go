@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,21 +27,61 @@ import (
 
 // CloudDnsRecordSpec defines the desired state of CloudDnsRecord
 type CloudDnsRecordSpec struct {
-	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	//ZoneRef refers to the CloudDnsZone in the namespace of the record holding the record set
+	// +kubebuilder:validation:Required
+	ZoneRef CloudDnsZoneReference `json:"zoneRef"`
+	//Name of the record set, relative to the DNS name of the zone unless it ends with a dot.
+	//Empty or @ for the apex of the zone
+	// +kubebuilder:example:=www
+	// +kubebuilder:validation:Optional
+	Name string `json:"name,omitempty"`
+	//Type of the record set
+	// +kubebuilder:example:=A
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=A;AAAA;CAA;CNAME;DNSKEY;DS;HTTPS;MX;NAPTR;NS;PTR;SPF;SRV;SSHFP;SVCB;TLSA;TXT
+	Type string `json:"type"`
+	//TTL of the record set in seconds
+	// +kubebuilder:default=300
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	TTL int64 `json:"ttl,omitempty"`
+	//Rrdatas are the records of the record set, like IP addresses for A records
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Rrdatas []string `json:"rrdatas"`
+}
 
-	// Foo is an example field of CloudDnsRecord. Edit clouddnsrecord_types.go to remove/update
-	Foo string `json:"foo,omitempty"`
+// CloudDnsZoneReference refers to a CloudDnsZone in the same namespace
+type CloudDnsZoneReference struct {
+	//Name of the CloudDnsZone
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
 }
 
 // CloudDnsRecordStatus defines the observed state of CloudDnsRecord
 type CloudDnsRecordStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	// Conditions report the state of the last reconcile
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Fqdn is the fully qualified name of the record set managed in the zone, kept to remove it
+	// when the name or type changes
+	Fqdn string `json:"fqdn,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Type is the type of the record set managed in the zone
+	Type string `json:"type,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Plan lists the changes stilas would make in GCP, one per line, only set in plan-only mode
+	Plan string `json:"plan,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Zone",type=string,JSONPath=`.spec.zoneRef.name`
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Fqdn",type=string,JSONPath=`.status.fqdn`
 
 // CloudDnsRecord is the Schema for the clouddnsrecords API
 type CloudDnsRecord struct {
@@ -59,6 +101,19 @@ type CloudDnsRecordList struct {
 	Items           []CloudDnsRecord `json:"items"`
 }
 
+// Fqdn returns the fully qualified name of the record set in a zone for dnsName
+func (r *CloudDnsRecord) Fqdn(dnsName string) string {
+	zone := strings.TrimSuffix(dnsName, ".") + "."
+	switch {
+	case r.Spec.Name == "" || r.Spec.Name == "@":
+		return zone
+	case strings.HasSuffix(r.Spec.Name, "."):
+		return r.Spec.Name
+	default:
+		return r.Spec.Name + "." + zone
+	}
+}
+
 func init() {
 	SchemeBuilder.Register(&CloudDnsRecord{}, &CloudDnsRecordList{})
 }
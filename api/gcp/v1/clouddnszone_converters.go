@@ -0,0 +1,162 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/dns/v2"
+)
+
+// CloudDnsNonExistence returns the nonExistence of Cloud DNS selected by NonExistence
+func (d DnsSecSpec) CloudDnsNonExistence() string {
+	if d.NonExistence {
+		return "nsec3"
+	}
+	return "nsec"
+}
+
+// CloudDnsVisibility returns the visibility of the managed zone of the CloudDnsZone
+func (c *CloudDnsZone) CloudDnsVisibility() string {
+	if c.Spec.PrivateZone {
+		return "private"
+	}
+	return "public"
+}
+
+// ConvertToManagedZone returns the managed zone to create for the CloudDnsZone
+func (c *CloudDnsZone) ConvertToManagedZone() *dns.ManagedZone {
+	return &dns.ManagedZone{
+		Name:                    c.GetCloudDnsZoneFullName(),
+		Description:             c.Spec.Description,
+		DnsName:                 c.Spec.DnsName + ".",
+		Labels:                  c.Spec.Labels,
+		Visibility:              c.CloudDnsVisibility(),
+		PrivateVisibilityConfig: c.CloudDnsPrivateVisibilityConfig(),
+		DnssecConfig: &dns.ManagedZoneDnsSecConfig{
+			NonExistence:    c.Spec.DnsSecSpec.CloudDnsNonExistence(),
+			State:           strings.ToLower(c.Spec.DnsSecSpec.State),
+			DefaultKeySpecs: c.Spec.DnsSecSpec.CloudDnsKeySpecs(),
+		},
+		CloudLoggingConfig: &dns.ManagedZoneCloudLoggingConfig{
+			EnableLogging: c.Spec.CloudLoggingSpec.Enabled,
+		},
+		ForwardingConfig: c.CloudDnsForwardingConfig(),
+		PeeringConfig:    c.CloudDnsPeeringConfig(),
+	}
+}
+
+// CloudDnsForwardingConfig returns the name servers a Forwarding zone forwards to, nil for the
+// other zone types
+func (c *CloudDnsZone) CloudDnsForwardingConfig() *dns.ManagedZoneForwardingConfig {
+	if c.Spec.ZoneType != ZoneTypeForwarding || c.Spec.ForwardingSpec == nil {
+		return nil
+	}
+	config := &dns.ManagedZoneForwardingConfig{}
+	for _, target := range c.Spec.ForwardingSpec.TargetNameServers {
+		config.TargetNameServers = append(config.TargetNameServers, &dns.ManagedZoneForwardingConfigNameServerTarget{
+			Ipv4Address:    target.IPv4Address,
+			ForwardingPath: target.CloudDnsForwardingPath(),
+		})
+	}
+	return config
+}
+
+// CloudDnsForwardingPath returns the forwarding path of Cloud DNS for the target
+func (t ForwardingTarget) CloudDnsForwardingPath() string {
+	if t.ForwardingPath == "" {
+		return "default"
+	}
+	return strings.ToLower(t.ForwardingPath)
+}
+
+// CloudDnsPeeringConfig returns the network a Peering zone peers with, nil for the other zone types
+func (c *CloudDnsZone) CloudDnsPeeringConfig() *dns.ManagedZonePeeringConfig {
+	if c.Spec.ZoneType != ZoneTypePeering || c.Spec.PeeringSpec == nil {
+		return nil
+	}
+	return &dns.ManagedZonePeeringConfig{
+		TargetNetwork: &dns.ManagedZonePeeringConfigTargetNetwork{
+			NetworkUrl: c.Spec.PeeringSpec.TargetNetwork.NetworkUrl(c.Spec.ProjectID),
+		},
+	}
+}
+
+// NetworkUrl returns the url Cloud DNS uses for the network of the reference in project
+func (r PrivateNetworkReference) NetworkUrl(project string) string {
+	return NetworkReference{ProjectID: r.ProjectID, Network: r.Network}.NetworkUrl(project)
+}
+
+// NetworkUrl returns the url Cloud DNS uses for the network of the reference in project
+func (r NetworkReference) NetworkUrl(project string) string {
+	if r.ProjectID != "" {
+		project = r.ProjectID
+	}
+	return fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/global/networks/%s", project, r.Network)
+}
+
+// GkeClusterName returns the name Cloud DNS uses for the GKE cluster of the reference in project
+func (r PrivateNetworkReference) GkeClusterName(project string) string {
+	if r.ProjectID != "" {
+		project = r.ProjectID
+	}
+	return fmt.Sprintf("projects/%s/locations/%s/clusters/%s", project, r.GkeCluster.Location, r.GkeCluster.Name)
+}
+
+// CloudDnsPrivateVisibilityConfig returns the networks and GKE clusters that can resolve the zone
+// sorted by name, nil for public zones
+func (c *CloudDnsZone) CloudDnsPrivateVisibilityConfig() *dns.ManagedZonePrivateVisibilityConfig {
+	if !c.Spec.PrivateZone {
+		return nil
+	}
+	var networks, clusters []string
+	for _, ref := range c.Spec.PrivateNetworks {
+		if ref.Network != "" {
+			networks = append(networks, ref.NetworkUrl(c.Spec.ProjectID))
+		}
+		if ref.GkeCluster != nil {
+			clusters = append(clusters, ref.GkeClusterName(c.Spec.ProjectID))
+		}
+	}
+	return NewPrivateVisibilityConfig(networks, clusters)
+}
+
+// NewPrivateVisibilityConfig returns the config making a private zone visible to the networks and
+// GKE clusters, sorted and without duplicates. The lists are always sent so an empty config
+// removes every network of the zone.
+func NewPrivateVisibilityConfig(networkUrls []string, gkeClusterNames []string) *dns.ManagedZonePrivateVisibilityConfig {
+	config := &dns.ManagedZonePrivateVisibilityConfig{
+		Networks:        []*dns.ManagedZonePrivateVisibilityConfigNetwork{},
+		GkeClusters:     []*dns.ManagedZonePrivateVisibilityConfigGKECluster{},
+		ForceSendFields: []string{"Networks", "GkeClusters"},
+	}
+	networkUrls = slices.Clone(networkUrls)
+	slices.Sort(networkUrls)
+	networkUrls = slices.Compact(networkUrls)
+	for _, url := range networkUrls {
+		config.Networks = append(config.Networks, &dns.ManagedZonePrivateVisibilityConfigNetwork{NetworkUrl: url})
+	}
+	gkeClusterNames = slices.Clone(gkeClusterNames)
+	slices.Sort(gkeClusterNames)
+	gkeClusterNames = slices.Compact(gkeClusterNames)
+	for _, name := range gkeClusterNames {
+		config.GkeClusters = append(config.GkeClusters, &dns.ManagedZonePrivateVisibilityConfigGKECluster{GkeClusterName: name})
+	}
+	return config
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/dns/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("CloudDnsZone converters", func() {
+	var zone *CloudDnsZone
+
+	BeforeEach(func() {
+		zone = &CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec: CloudDnsZoneSpec{
+				ProjectID:   "test-project",
+				DnsName:     "example.com",
+				Description: "example zone",
+			},
+		}
+	})
+
+	It("Should build the managed zone from the spec", func() {
+		zone.Spec.Labels = map[string]string{"team": "dns"}
+		zone.Spec.DnsSecSpec = DnsSecSpec{State: "On", NonExistence: false}
+		zone.Spec.CloudLoggingSpec.Enabled = true
+		mz := zone.ConvertToManagedZone()
+		Expect(mz.Name).To(Equal("default-example"))
+		Expect(mz.DnsName).To(Equal("example.com."))
+		Expect(mz.Description).To(Equal("example zone"))
+		Expect(mz.Labels).To(Equal(map[string]string{"team": "dns"}))
+		Expect(mz.Visibility).To(Equal("public"))
+		Expect(mz.DnssecConfig.State).To(Equal("on"))
+		Expect(mz.DnssecConfig.NonExistence).To(Equal("nsec"))
+		Expect(mz.CloudLoggingConfig.EnableLogging).To(BeTrue())
+	})
+
+	It("Should make private zones private", func() {
+		zone.Spec.PrivateZone = true
+		Expect(zone.ConvertToManagedZone().Visibility).To(Equal("private"))
+	})
+
+	It("Should make private zones visible to their networks", func() {
+		zone.Spec.PrivateNetworks = []PrivateNetworkReference{{Network: "default"}}
+		Expect(zone.ConvertToManagedZone().PrivateVisibilityConfig).To(BeNil())
+
+		zone.Spec.PrivateZone = true
+		zone.Spec.PrivateNetworks = []PrivateNetworkReference{
+			{Network: "default"},
+			{ProjectID: "shared-vpc", Network: "shared"},
+			{GkeCluster: &GkeClusterReference{Location: "europe-north1", Name: "apps"}},
+			{Network: "default"},
+		}
+		config := zone.ConvertToManagedZone().PrivateVisibilityConfig
+		Expect(config.Networks).To(Equal([]*dns.ManagedZonePrivateVisibilityConfigNetwork{
+			{NetworkUrl: "https://www.googleapis.com/compute/v1/projects/shared-vpc/global/networks/shared"},
+			{NetworkUrl: "https://www.googleapis.com/compute/v1/projects/test-project/global/networks/default"},
+		}))
+		Expect(config.GkeClusters).To(Equal([]*dns.ManagedZonePrivateVisibilityConfigGKECluster{
+			{GkeClusterName: "projects/test-project/locations/europe-north1/clusters/apps"},
+		}))
+
+		zone.Spec.PrivateNetworks = nil
+		config = zone.ConvertToManagedZone().PrivateVisibilityConfig
+		Expect(config.Networks).To(BeEmpty())
+		Expect(config.ForceSendFields).To(ContainElements("Networks", "GkeClusters"))
+	})
+
+	It("Should only configure forwarding and peering for their zone types", func() {
+		zone.Spec.PrivateZone = true
+		zone.Spec.ForwardingSpec = &ForwardingSpec{TargetNameServers: []ForwardingTarget{
+			{IPv4Address: "10.0.0.53", ForwardingPath: "Private"},
+			{IPv4Address: "10.0.1.53"},
+		}}
+		zone.Spec.PeeringSpec = &PeeringSpec{TargetNetwork: NetworkReference{ProjectID: "host-project", Network: "shared"}}
+		mz := zone.ConvertToManagedZone()
+		Expect(mz.ForwardingConfig).To(BeNil())
+		Expect(mz.PeeringConfig).To(BeNil())
+
+		zone.Spec.ZoneType = ZoneTypeForwarding
+		mz = zone.ConvertToManagedZone()
+		Expect(mz.ForwardingConfig.TargetNameServers).To(Equal([]*dns.ManagedZoneForwardingConfigNameServerTarget{
+			{Ipv4Address: "10.0.0.53", ForwardingPath: "private"},
+			{Ipv4Address: "10.0.1.53", ForwardingPath: "default"},
+		}))
+		Expect(mz.PeeringConfig).To(BeNil())
+
+		zone.Spec.ZoneType = ZoneTypePeering
+		mz = zone.ConvertToManagedZone()
+		Expect(mz.ForwardingConfig).To(BeNil())
+		Expect(mz.PeeringConfig.TargetNetwork.NetworkUrl).To(Equal("https://www.googleapis.com/compute/v1/projects/host-project/global/networks/shared"))
+	})
+})
@@ -0,0 +1,183 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/dns/v2"
+)
+
+const (
+	// DnsKeyTypeKeySigning is the Cloud DNS type of key signing keys
+	DnsKeyTypeKeySigning = "keySigning"
+	// DnsKeyTypeZoneSigning is the Cloud DNS type of zone signing keys
+	DnsKeyTypeZoneSigning = "zoneSigning"
+)
+
+var (
+	// DefaultKeySigningKeySpec is the key signing key Cloud DNS uses when no key specs are set
+	DefaultKeySigningKeySpec = DnsKeySpec{Algorithm: "RSASHA256", KeyLength: 2048}
+	// DefaultZoneSigningKeySpec is the zone signing key Cloud DNS uses when no key specs are set
+	DefaultZoneSigningKeySpec = DnsKeySpec{Algorithm: "RSASHA256", KeyLength: 1024}
+)
+
+// dnsKeyAlgorithms maps the algorithms of Cloud DNS to their DNSSEC algorithm numbers
+var dnsKeyAlgorithms = map[string]uint8{
+	"rsasha1":         5,
+	"rsasha256":       8,
+	"rsasha512":       10,
+	"ecdsap256sha256": 13,
+	"ecdsap384sha384": 14,
+}
+
+// dnsKeyTypes maps the key types of Cloud DNS to the values of DnsKeyStatus.Type
+var dnsKeyTypes = map[string]string{
+	DnsKeyTypeKeySigning:  "KeySigning",
+	DnsKeyTypeZoneSigning: "ZoneSigning",
+}
+
+// CloudDnsKeySpec returns the key spec of Cloud DNS for a key of keyType, with the length of
+// the algorithm when no length is set
+func (k DnsKeySpec) CloudDnsKeySpec(keyType string) *dns.DnsKeySpec {
+	algorithm := strings.ToLower(k.Algorithm)
+	length := k.KeyLength
+	if length == 0 {
+		switch {
+		case algorithm == "ecdsap256sha256":
+			length = 256
+		case algorithm == "ecdsap384sha384":
+			length = 384
+		case keyType == DnsKeyTypeKeySigning:
+			length = DefaultKeySigningKeySpec.KeyLength
+		default:
+			length = DefaultZoneSigningKeySpec.KeyLength
+		}
+	}
+	return &dns.DnsKeySpec{Algorithm: algorithm, KeyLength: length, KeyType: keyType}
+}
+
+// CloudDnsKeySpecs returns the default key specs of Cloud DNS for the key signing and zone
+// signing keys, nil when neither is set so Cloud DNS picks them
+func (d DnsSecSpec) CloudDnsKeySpecs() []*dns.DnsKeySpec {
+	if d.KeySigningKeySpec == nil && d.ZoneSigningKeySpec == nil {
+		return nil
+	}
+	ksk, zsk := DefaultKeySigningKeySpec, DefaultZoneSigningKeySpec
+	if d.KeySigningKeySpec != nil {
+		ksk = *d.KeySigningKeySpec
+	}
+	if d.ZoneSigningKeySpec != nil {
+		zsk = *d.ZoneSigningKeySpec
+	}
+	return []*dns.DnsKeySpec{ksk.CloudDnsKeySpec(DnsKeyTypeKeySigning), zsk.CloudDnsKeySpec(DnsKeyTypeZoneSigning)}
+}
+
+// ConvertFromDnsKey returns the status of key in the zone dnsName, with the key tag and the DS
+// record computed from the public key
+func ConvertFromDnsKey(dnsName string, key *dns.DnsKey) (DnsKeyStatus, error) {
+	rdata, err := dnsKeyRdata(key)
+	if err != nil {
+		return DnsKeyStatus{}, err
+	}
+	status := DnsKeyStatus{
+		Id:        key.Id,
+		Type:      dnsKeyTypes[key.Type],
+		Algorithm: strings.ToUpper(key.Algorithm),
+		KeyLength: key.KeyLength,
+		KeyTag:    int64(keyTag(rdata)),
+		IsActive:  key.IsActive,
+	}
+	if key.Type == DnsKeyTypeKeySigning {
+		digest := sha256.Sum256(append(wireName(dnsName), rdata...))
+		status.Digest = strings.ToUpper(hex.EncodeToString(digest[:]))
+		status.DsRecord = fmt.Sprintf("%s IN DS %d %d 2 %s", fqdn(dnsName), status.KeyTag, rdata[3], status.Digest)
+	}
+	return status, nil
+}
+
+// DsRdata returns the data of the DS record of the key as the parent zone holds it, empty when the
+// key has no DS record
+func (s DnsKeyStatus) DsRdata() string {
+	_, rdata, _ := strings.Cut(s.DsRecord, " IN DS ")
+	return rdata
+}
+
+// DnsKeyRecord returns the DNSKEY record of key in the zone dnsName
+func DnsKeyRecord(dnsName string, key *dns.DnsKey) (string, error) {
+	rdata, err := dnsKeyRdata(key)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s IN DNSKEY %d 3 %d %s", fqdn(dnsName), binary.BigEndian.Uint16(rdata), rdata[3], key.PublicKey), nil
+}
+
+// dnsKeyRdata returns the wire format of the DNSKEY record of key, RFC 4034 section 2.1
+func dnsKeyRdata(key *dns.DnsKey) ([]byte, error) {
+	algorithm, ok := dnsKeyAlgorithms[strings.ToLower(key.Algorithm)]
+	if !ok {
+		return nil, fmt.Errorf("dns key %s has the unknown algorithm %s", key.Id, key.Algorithm)
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("dns key %s: failed to decode public key: %w", key.Id, err)
+	}
+	flags := uint16(256)
+	if key.Type == DnsKeyTypeKeySigning {
+		// the secure entry point flag
+		flags |= 1
+	}
+	rdata := binary.BigEndian.AppendUint16(nil, flags)
+	rdata = append(rdata, 3, algorithm)
+	return append(rdata, publicKey...), nil
+}
+
+// keyTag computes the key tag of the DNSKEY record rdata, RFC 4034 appendix B
+func keyTag(rdata []byte) uint16 {
+	var sum uint32
+	for i, b := range rdata {
+		if i%2 == 0 {
+			sum += uint32(b) << 8
+		} else {
+			sum += uint32(b)
+		}
+	}
+	sum += sum >> 16 & 0xffff
+	return uint16(sum & 0xffff)
+}
+
+// wireName returns the lower case wire format of the domain name
+func wireName(name string) []byte {
+	var wire []byte
+	for _, label := range strings.Split(strings.TrimSuffix(strings.ToLower(name), "."), ".") {
+		if label == "" {
+			continue
+		}
+		wire = append(wire, byte(len(label)))
+		wire = append(wire, label...)
+	}
+	return append(wire, 0)
+}
+
+func fqdn(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/dns/v2"
+)
+
+// rfc4034Key is the DNSKEY of the examples in RFC 4034 section 5.4 and RFC 4509 section 2.3
+const rfc4034Key = "AQOeiiR0GOMYkDshWoSKz9XzfwJr1AYtsmx3TGkJaNXVbfi/2pHm822aJ5iI9BMzNXxeYCmZDRD99WYwYqUSdjMmmAphXdvxegXd/M5+X7OrzKBaMbCVdFLUUh6DhweJBjEVv5f2wwjM9XzcnOf+EPbtG9DMBmADjFDc2w/rljwvFw=="
+
+var _ = Describe("CloudDnsZone DNS keys", func() {
+	It("Should compute the key tag and digest of the RFC examples", func() {
+		key := &dns.DnsKey{Id: "1", Type: DnsKeyTypeZoneSigning, Algorithm: "rsasha1", PublicKey: rfc4034Key}
+		rdata, err := dnsKeyRdata(key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keyTag(rdata)).To(Equal(uint16(60485)))
+		digest := sha256.Sum256(append(wireName("DSKEY.example.com."), rdata...))
+		Expect(strings.ToUpper(hex.EncodeToString(digest[:]))).To(Equal("D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A"))
+
+		status, err := ConvertFromDnsKey("dskey.example.com", key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.KeyTag).To(Equal(int64(60485)))
+		Expect(status.Type).To(Equal("ZoneSigning"))
+		Expect(status.DsRecord).To(BeEmpty())
+	})
+
+	It("Should only build DS records for key signing keys", func() {
+		key := &dns.DnsKey{Id: "2", Type: DnsKeyTypeKeySigning, Algorithm: "rsasha1", KeyLength: 1024, PublicKey: rfc4034Key, IsActive: true}
+		status, err := ConvertFromDnsKey("dskey.example.com", key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Type).To(Equal("KeySigning"))
+		Expect(status.Algorithm).To(Equal("RSASHA1"))
+		Expect(status.IsActive).To(BeTrue())
+		Expect(status.DsRecord).To(Equal("dskey.example.com. IN DS " + fmt.Sprint(status.KeyTag) + " 5 2 " + status.Digest))
+		Expect(status.Digest).To(HaveLen(64))
+		Expect(status.DsRdata()).To(Equal(fmt.Sprint(status.KeyTag) + " 5 2 " + status.Digest))
+
+		record, err := DnsKeyRecord("dskey.example.com", key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(record).To(Equal("dskey.example.com. IN DNSKEY 257 3 5 " + rfc4034Key))
+
+		_, err = ConvertFromDnsKey("dskey.example.com", &dns.DnsKey{Id: "3", Algorithm: "gost", PublicKey: rfc4034Key})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should only set key specs when one of them is configured", func() {
+		Expect(DnsSecSpec{State: "On"}.CloudDnsKeySpecs()).To(BeNil())
+		specs := DnsSecSpec{State: "On", ZoneSigningKeySpec: &DnsKeySpec{Algorithm: "ECDSAP256SHA256"}}.CloudDnsKeySpecs()
+		Expect(specs).To(Equal([]*dns.DnsKeySpec{
+			{Algorithm: "rsasha256", KeyLength: 2048, KeyType: DnsKeyTypeKeySigning},
+			{Algorithm: "ecdsap256sha256", KeyLength: 256, KeyType: DnsKeyTypeZoneSigning},
+		}))
+	})
+})
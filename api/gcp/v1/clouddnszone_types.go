@@ -38,23 +38,169 @@ type CloudDnsZoneSpec struct {
 	//PrivateZone defines if the zone is private or public
 	// +kubebuilder:default=false
 	PrivateZone bool `json:"privateZone"`
+	//ZoneType defines if the zone holds its own records, forwards queries to other name servers or
+	//peers with a zone of another network. Forwarding and peering zones must be private.
+	// +kubebuilder:default=Standard
+	// +kubebuilder:validation:Enum=Standard;Forwarding;Peering
+	// +kubebuilder:validation:Optional
+	ZoneType ZoneType `json:"zoneType,omitempty"`
+	//ForwardingSpec defines where a Forwarding zone forwards queries to, only valid on Forwarding zones
+	// +kubebuilder:validation:Optional
+	ForwardingSpec *ForwardingSpec `json:"forwardingSpec,omitempty"`
+	//PeeringSpec defines the network a Peering zone peers with, only valid on Peering zones
+	// +kubebuilder:validation:Optional
+	PeeringSpec *PeeringSpec `json:"peeringSpec,omitempty"`
+	//PrivateNetworks are the VPC networks and GKE clusters that can resolve the zone, only valid on private zones
+	// +kubebuilder:validation:Optional
+	PrivateNetworks []PrivateNetworkReference `json:"privateNetworks,omitempty"`
+	//Description of the zone
+	// +kubebuilder:default="DnsZone created by Stilas"
+	// +kubebuilder:validation:MaxLength=1024
+	// +kubebuilder:validation:Optional
+	Description string `json:"description,omitempty"`
+	//Labels are set on the zone in GCP
+	// +kubebuilder:validation:Optional
+	Labels map[string]string `json:"labels,omitempty"`
 	//DnsSecSpec defines the DNSSEC configuration for the zone
 	// +kubebuilder:validation:Optional
 	DnsSecSpec DnsSecSpec `json:"dnsSecSpec,omitempty"`
+	//CloudLoggingSpec defines if the queries answered by the zone are logged to Cloud Logging
+	// +kubebuilder:validation:Optional
+	CloudLoggingSpec CloudLoggingSpec `json:"cloudLoggingSpec,omitempty"`
+	//ParentZoneRef refers to the CloudDnsZone in the same namespace the zone is delegated from. The NS
+	//records of the zone, and its DS records while DNSSEC is On, are kept in the parent zone and
+	//removed when the zone is deleted. Only valid on public zones.
+	// +kubebuilder:validation:Optional
+	ParentZoneRef *CloudDnsZoneReference `json:"parentZoneRef,omitempty"`
 	//CleanupOnDelete defines if the zone should be deleted when the resource is deleted
 	// +kubebuilder:default=false
 	// +kubebuilder:validation:Optional
 	CleanupOnDelete bool `json:"cleanupOnDelete,omitempty"`
+	//ProviderConfigRef refers to the provider config holding the credentials used to manage the zone,
+	//the GcpProviderConfig named default in the namespace is used when not set
+	// +kubebuilder:validation:Optional
+	ProviderConfigRef *ProviderConfigReference `json:"providerConfigRef,omitempty"`
 }
 
+// DnsSecSpec configures the DNSSEC signing of a zone. The managed rollover of the zone signing key,
+// adding the key with the new spec and keeping the previous one active until the TTL of the signed
+// records has passed, is not supported: Cloud DNS only takes the key specs of a zone in its
+// defaultKeySpecs, which can not change while DNSSEC is On, and has no call adding or removing a
+// single key. A key spec change therefore needs DNSSEC turned Off, after removing the DS record of
+// the zone from its parent, and On again with the new spec.
 type DnsSecSpec struct {
 	// +kubebuilder:default=On
 	// +kubebuilder:validation:Enum=On;Off;Transfer
 	//State specifies whether DNSSEC is enabled, and what mode it is in
 	State string `json:"state,omitempty"`
 	// +kubebuilder:default=true
-	//NonExistence defines if the NSEC3 record should be included in the response
-	NonExistence bool `json:"nonExistence,omitempty"`
+	//NonExistence selects NSEC3 records when true and NSEC records when false to prove that a name
+	//does not exist. Cloud DNS only lets it change while the state is Off.
+	NonExistence bool `json:"nonExistence"`
+	// +kubebuilder:validation:Optional
+	//KeySigningKeySpec defines the algorithm and length of the key signing key, the Cloud DNS default
+	//when not set. It can not change while DNSSEC is On, the DS record at the registrar would break.
+	KeySigningKeySpec *DnsKeySpec `json:"keySigningKeySpec,omitempty"`
+	// +kubebuilder:validation:Optional
+	//ZoneSigningKeySpec defines the algorithm and length of the zone signing key, the Cloud DNS default
+	//when not set. Cloud DNS only lets the key specs change while DNSSEC is Off, there is no managed
+	//rollover keeping the previous zone signing key active.
+	ZoneSigningKeySpec *DnsKeySpec `json:"zoneSigningKeySpec,omitempty"`
+	// +kubebuilder:validation:Optional
+	//PublishKeys defines the ConfigMap or Secret the DS and DNSKEY records of the zone are written to
+	PublishKeys *DnsKeyPublishSpec `json:"publishKeys,omitempty"`
+}
+
+type DnsKeySpec struct {
+	// +kubebuilder:validation:Enum=RSASHA1;RSASHA256;RSASHA512;ECDSAP256SHA256;ECDSAP384SHA384
+	// +kubebuilder:validation:Required
+	//Algorithm of the key
+	Algorithm string `json:"algorithm"`
+	// +kubebuilder:validation:Optional
+	//KeyLength in bits, 2048 for key signing and 1024 for zone signing RSA keys when not set.
+	//ECDSA keys have the length of their curve.
+	KeyLength int64 `json:"keyLength,omitempty"`
+}
+
+type DnsKeyPublishSpec struct {
+	// +kubebuilder:default=ConfigMap
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	//Kind of the object the records are written to
+	Kind string `json:"kind,omitempty"`
+	// +kubebuilder:validation:Required
+	//Name of the ConfigMap or Secret in the namespace of the zone, it is owned by the zone
+	Name string `json:"name"`
+}
+
+type ZoneType string
+
+const (
+	ZoneTypeStandard   ZoneType = "Standard"
+	ZoneTypeForwarding ZoneType = "Forwarding"
+	ZoneTypePeering    ZoneType = "Peering"
+)
+
+type ForwardingSpec struct {
+	//TargetNameServers are the name servers queries are forwarded to
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	TargetNameServers []ForwardingTarget `json:"targetNameServers"`
+}
+
+type ForwardingTarget struct {
+	//IPv4Address of the name server
+	// +kubebuilder:validation:Required
+	IPv4Address string `json:"ipv4Address"`
+	//ForwardingPath defines if queries reach the name server through the VPC network only (Private),
+	//or through the VPC network for RFC1918 addresses and the internet otherwise (Default)
+	// +kubebuilder:default=Default
+	// +kubebuilder:validation:Enum=Default;Private
+	// +kubebuilder:validation:Optional
+	ForwardingPath string `json:"forwardingPath,omitempty"`
+}
+
+type PeeringSpec struct {
+	//TargetNetwork is the VPC network the zone peers with, its DNS resolution order answers the queries
+	// +kubebuilder:validation:Required
+	TargetNetwork NetworkReference `json:"targetNetwork"`
+}
+
+// NetworkReference refers to a VPC network
+type NetworkReference struct {
+	//ProjectID of the network, the project of the zone when not set
+	// +kubebuilder:validation:Optional
+	ProjectID string `json:"projectID,omitempty"`
+	//Network is the name of the VPC network
+	// +kubebuilder:validation:Required
+	Network string `json:"network"`
+}
+
+// PrivateNetworkReference refers to a VPC network or a GKE cluster, exactly one of them must be set
+type PrivateNetworkReference struct {
+	//ProjectID of the network or the cluster, the project of the zone when not set
+	// +kubebuilder:validation:Optional
+	ProjectID string `json:"projectID,omitempty"`
+	//Network is the name of the VPC network
+	// +kubebuilder:validation:Optional
+	Network string `json:"network,omitempty"`
+	//GkeCluster refers to the GKE cluster
+	// +kubebuilder:validation:Optional
+	GkeCluster *GkeClusterReference `json:"gkeCluster,omitempty"`
+}
+
+type GkeClusterReference struct {
+	//Location of the cluster, a region or a zone
+	// +kubebuilder:validation:Required
+	Location string `json:"location"`
+	//Name of the cluster
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+type CloudLoggingSpec struct {
+	// +kubebuilder:default=false
+	//Enabled defines if the queries answered by the zone are logged
+	Enabled bool `json:"enabled,omitempty"`
 }
 
 // CloudDnsZoneStatus defines the observed state of CloudDnsZone
@@ -66,6 +212,61 @@ type CloudDnsZoneStatus struct {
 	// +kubebuilder:validation:Optional
 	// OperationId is the id of the operation that is currently running, empty if no ongioing operation
 	Operation string `json:"operation,omitempty"`
+	// +kubebuilder:validation:Optional
+	// OperationStartTime is when the ongoing operation was started
+	OperationStartTime *metav1.Time `json:"operationStartTime,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	// Conditions report the state of the last reconcile. The Synced condition is False with a
+	// reason of TransientError or PermanentError when GCP rejected a call. The Delegated condition
+	// of a zone with a parentZoneRef tells if its delegation records exist in the parent zone.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Plan lists the changes stilas would make in GCP, one per line, only set in plan-only mode
+	Plan string `json:"plan,omitempty"`
+	// +kubebuilder:validation:Optional
+	// DnsKeys are the DNSSEC keys of the zone, the DS records of the active key signing keys go to
+	// the registrar
+	DnsKeys []DnsKeyStatus `json:"dnsKeys,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Delegation is the delegation of the zone in its parent zone, set while the records exist
+	Delegation *ZoneDelegation `json:"delegation,omitempty"`
+}
+
+type DnsKeyStatus struct {
+	// Id of the key in Cloud DNS
+	Id string `json:"id"`
+	// Type is KeySigning or ZoneSigning
+	Type string `json:"type"`
+	// Algorithm of the key
+	Algorithm string `json:"algorithm"`
+	// KeyLength in bits
+	KeyLength int64 `json:"keyLength,omitempty"`
+	// KeyTag identifies the key in DS and RRSIG records
+	KeyTag int64 `json:"keyTag"`
+	// IsActive is true while the key signs the zone
+	IsActive bool `json:"isActive"`
+	// +kubebuilder:validation:Optional
+	// Digest is the SHA-256 digest of the key, only set for key signing keys
+	Digest string `json:"digest,omitempty"`
+	// +kubebuilder:validation:Optional
+	// DsRecord is the DS record of the key to add to the parent zone, only set for key signing keys
+	DsRecord string `json:"dsRecord,omitempty"`
+}
+
+type ZoneDelegation struct {
+	// ParentZone is the name of the parent CloudDnsZone
+	ParentZone string `json:"parentZone"`
+	// ProjectID of the managed zone of the parent
+	ProjectID string `json:"projectID"`
+	// ManagedZone is the name of the managed zone of the parent
+	ManagedZone string `json:"managedZone"`
+	// Fqdn of the delegation records in the parent zone
+	Fqdn string `json:"fqdn"`
+	// +kubebuilder:validation:Optional
+	// DsRecords is true when the parent zone holds DS records of the zone
+	DsRecords bool `json:"dsRecords,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -89,7 +290,12 @@ type CloudDnsZoneList struct {
 	Items           []CloudDnsZone `json:"items"`
 }
 
+// GetCloudDnsZoneFullName returns the name of the managed zone, the external name annotation when
+// set and <namespace>-<name> otherwise
 func (c *CloudDnsZone) GetCloudDnsZoneFullName() string {
+	if name := c.Annotations[ExternalNameAnnotation]; name != "" {
+		return name
+	}
 	return fmt.Sprintf("%s-%s", c.Namespace, c.Name)
 }
 
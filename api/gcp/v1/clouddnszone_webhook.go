@@ -0,0 +1,194 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/api/dns/v2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var clouddnszonelog = logf.Log.WithName("clouddnszone-resource")
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (z *CloudDnsZone) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(z).
+		WithValidator(&CloudDnsZoneCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-gcp-stilas-418-cloud-v1-clouddnszone,mutating=false,failurePolicy=fail,sideEffects=None,groups=gcp.stilas.418.cloud,resources=clouddnszones,verbs=create;update,versions=v1,name=vclouddnszone.kb.io,admissionReviewVersions=v1
+
+// CloudDnsZoneCustomValidator rejects CloudDnsZones Cloud DNS would refuse and changes to the fields
+// Cloud DNS can not change on an existing zone
+type CloudDnsZoneCustomValidator struct{}
+
+var _ webhook.CustomValidator = &CloudDnsZoneCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator, validating the spec of the zone
+func (v *CloudDnsZoneCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	zone, ok := obj.(*CloudDnsZone)
+	if !ok {
+		return nil, fmt.Errorf("expected a CloudDnsZone object but got %T", obj)
+	}
+	if errs := validateCloudDnsZoneSpec(zone, field.NewPath("spec")); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(GroupVersion.WithKind("CloudDnsZone").GroupKind(), zone.Name, errs)
+	}
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator, validating the spec of the zone and rejecting
+// changes of the dns name, the visibility, the zone type, the non-existence proof and the key specs
+// of a signed zone
+func (v *CloudDnsZoneCustomValidator) ValidateUpdate(_ context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	oldZone, ok := oldObj.(*CloudDnsZone)
+	if !ok {
+		return nil, fmt.Errorf("expected a CloudDnsZone object but got %T", oldObj)
+	}
+	newZone, ok := newObj.(*CloudDnsZone)
+	if !ok {
+		return nil, fmt.Errorf("expected a CloudDnsZone object but got %T", newObj)
+	}
+	spec := field.NewPath("spec")
+	errs := validateCloudDnsZoneSpec(newZone, spec)
+	errs = append(errs, apivalidation.ValidateImmutableField(newZone.Spec.DnsName, oldZone.Spec.DnsName, spec.Child("dnsName"))...)
+	errs = append(errs, apivalidation.ValidateImmutableField(newZone.Spec.PrivateZone, oldZone.Spec.PrivateZone, spec.Child("privateZone"))...)
+	errs = append(errs, apivalidation.ValidateImmutableField(zoneType(&newZone.Spec), zoneType(&oldZone.Spec), spec.Child("zoneType"))...)
+	if strings.EqualFold(oldZone.Spec.DnsSecSpec.State, "on") && strings.EqualFold(newZone.Spec.DnsSecSpec.State, "on") {
+		dnssecPath := spec.Child("dnsSecSpec")
+		if oldZone.Spec.DnsSecSpec.NonExistence != newZone.Spec.DnsSecSpec.NonExistence {
+			errs = append(errs, field.Forbidden(dnssecPath.Child("nonExistence"), "the non-existence proof can not change while DNSSEC is On, turn it Off first"))
+		}
+		if !equality.Semantic.DeepEqual(keySpec(oldZone.Spec.DnsSecSpec.KeySigningKeySpec, DnsKeyTypeKeySigning), keySpec(newZone.Spec.DnsSecSpec.KeySigningKeySpec, DnsKeyTypeKeySigning)) {
+			errs = append(errs, field.Forbidden(dnssecPath.Child("keySigningKeySpec"), "the key signing key can not change while DNSSEC is On"))
+		}
+		if !equality.Semantic.DeepEqual(keySpec(oldZone.Spec.DnsSecSpec.ZoneSigningKeySpec, DnsKeyTypeZoneSigning), keySpec(newZone.Spec.DnsSecSpec.ZoneSigningKeySpec, DnsKeyTypeZoneSigning)) {
+			errs = append(errs, field.Forbidden(dnssecPath.Child("zoneSigningKeySpec"), "the zone signing key can not change while DNSSEC is On, turn it Off first"))
+		}
+	}
+	if len(errs) > 0 {
+		clouddnszonelog.Info("rejected invalid update", "namespace", newZone.Namespace, "name", newZone.Name)
+		return nil, apierrors.NewInvalid(GroupVersion.WithKind("CloudDnsZone").GroupKind(), newZone.Name, errs)
+	}
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.CustomValidator, deletes are always allowed
+func (v *CloudDnsZoneCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// keySpec returns the key spec of Cloud DNS for the key of keyType with the spec, the default key
+// when it is not set
+func keySpec(spec *DnsKeySpec, keyType string) *dns.DnsKeySpec {
+	if spec != nil {
+		return spec.CloudDnsKeySpec(keyType)
+	}
+	if keyType == DnsKeyTypeKeySigning {
+		return DefaultKeySigningKeySpec.CloudDnsKeySpec(keyType)
+	}
+	return DefaultZoneSigningKeySpec.CloudDnsKeySpec(keyType)
+}
+
+// zoneType returns the type of the zone, Standard when it is not set
+func zoneType(spec *CloudDnsZoneSpec) ZoneType {
+	if spec.ZoneType == "" {
+		return ZoneTypeStandard
+	}
+	return spec.ZoneType
+}
+
+// validateCloudDnsZoneSpec checks that each zone type only carries its own fields, that the private
+// networks are only set on private zones and that each of them refers to either a network or a GKE
+// cluster, and that only public zones are delegated from another zone
+func validateCloudDnsZoneSpec(zone *CloudDnsZone, path *field.Path) field.ErrorList {
+	spec := &zone.Spec
+	var errs field.ErrorList
+	errs = append(errs, validateZoneType(spec, path)...)
+	if ref := spec.ParentZoneRef; ref != nil {
+		switch {
+		case spec.PrivateZone:
+			errs = append(errs, field.Forbidden(path.Child("parentZoneRef"), "only public zones are delegated"))
+		case ref.Name == zone.Name:
+			errs = append(errs, field.Invalid(path.Child("parentZoneRef", "name"), ref.Name, "a zone can not be its own parent"))
+		}
+	}
+	networksPath := path.Child("privateNetworks")
+	if len(spec.PrivateNetworks) > 0 && !spec.PrivateZone {
+		errs = append(errs, field.Forbidden(networksPath, "only private zones are visible to networks"))
+	}
+	for i, ref := range spec.PrivateNetworks {
+		refPath := networksPath.Index(i)
+		switch {
+		case ref.Network == "" && ref.GkeCluster == nil:
+			errs = append(errs, field.Required(refPath, "either network or gkeCluster must be set"))
+		case ref.Network != "" && ref.GkeCluster != nil:
+			errs = append(errs, field.Invalid(refPath, ref.Network, "network and gkeCluster can not both be set"))
+		}
+	}
+	return errs
+}
+
+// validateZoneType checks that forwarding and peering zones are private and only carry the spec of
+// their own type
+func validateZoneType(spec *CloudDnsZoneSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	zoneType := zoneType(spec)
+	if zoneType != ZoneTypeStandard && !spec.PrivateZone {
+		errs = append(errs, field.Invalid(path.Child("privateZone"), spec.PrivateZone, fmt.Sprintf("%s zones must be private", zoneType)))
+	}
+	forwardingPath := path.Child("forwardingSpec")
+	switch {
+	case zoneType == ZoneTypeForwarding && spec.ForwardingSpec == nil:
+		errs = append(errs, field.Required(forwardingPath, "Forwarding zones must have target name servers"))
+	case zoneType != ZoneTypeForwarding && spec.ForwardingSpec != nil:
+		errs = append(errs, field.Forbidden(forwardingPath, "only Forwarding zones forward queries"))
+	case spec.ForwardingSpec != nil:
+		targetsPath := forwardingPath.Child("targetNameServers")
+		if len(spec.ForwardingSpec.TargetNameServers) == 0 {
+			errs = append(errs, field.Required(targetsPath, "Forwarding zones must have target name servers"))
+		}
+		for i, target := range spec.ForwardingSpec.TargetNameServers {
+			if ip := net.ParseIP(target.IPv4Address); ip == nil || ip.To4() == nil {
+				errs = append(errs, field.Invalid(targetsPath.Index(i).Child("ipv4Address"), target.IPv4Address, "must be an IPv4 address"))
+			}
+		}
+	}
+	peeringPath := path.Child("peeringSpec")
+	switch {
+	case zoneType == ZoneTypePeering && spec.PeeringSpec == nil:
+		errs = append(errs, field.Required(peeringPath, "Peering zones must have a target network"))
+	case zoneType != ZoneTypePeering && spec.PeeringSpec != nil:
+		errs = append(errs, field.Forbidden(peeringPath, "only Peering zones peer with a network"))
+	case spec.PeeringSpec != nil && spec.PeeringSpec.TargetNetwork.Network == "":
+		errs = append(errs, field.Required(peeringPath.Child("targetNetwork", "network"), "Peering zones must have a target network"))
+	}
+	return errs
+}
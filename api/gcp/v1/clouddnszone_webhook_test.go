@@ -0,0 +1,189 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("CloudDnsZone Webhook", func() {
+	var zone *CloudDnsZone
+	validator := &CloudDnsZoneCustomValidator{}
+
+	BeforeEach(func() {
+		zone = &CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec: CloudDnsZoneSpec{
+				ProjectID:   "test-project",
+				DnsName:     "example.com",
+				Description: "example zone",
+			},
+		}
+	})
+
+	It("Should allow changes of the mutable fields", func() {
+		updated := zone.DeepCopy()
+		updated.Spec.Description = "changed"
+		updated.Spec.Labels = map[string]string{"team": "dns"}
+		updated.Spec.DnsSecSpec.State = "On"
+		updated.Spec.CloudLoggingSpec.Enabled = true
+		_, err := validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should reject changes of the dns name and the visibility", func() {
+		updated := zone.DeepCopy()
+		updated.Spec.DnsName = "example.org"
+		updated.Spec.PrivateZone = true
+		_, err := validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.dnsName"))
+		Expect(err.Error()).To(ContainSubstring("spec.privateZone"))
+	})
+
+	It("Should only allow private networks on private zones", func() {
+		zone.Spec.PrivateNetworks = []PrivateNetworkReference{{Network: "default"}}
+		_, err := validator.ValidateCreate(context.Background(), zone)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.privateNetworks"))
+
+		zone.Spec.PrivateZone = true
+		zone.Spec.DnsSecSpec.State = "Off"
+		_, err = validator.ValidateCreate(context.Background(), zone)
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := zone.DeepCopy()
+		updated.Spec.PrivateNetworks = append(updated.Spec.PrivateNetworks,
+			PrivateNetworkReference{GkeCluster: &GkeClusterReference{Location: "europe-north1", Name: "apps"}})
+		_, err = validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should only allow the spec of the zone type", func() {
+		zone.Spec.PrivateZone = true
+		zone.Spec.DnsSecSpec.State = "Off"
+		zone.Spec.ZoneType = ZoneTypeForwarding
+		_, err := validator.ValidateCreate(context.Background(), zone)
+		Expect(err).To(MatchError(ContainSubstring("spec.forwardingSpec")))
+
+		zone.Spec.ForwardingSpec = &ForwardingSpec{TargetNameServers: []ForwardingTarget{{IPv4Address: "10.0.0.53"}}}
+		_, err = validator.ValidateCreate(context.Background(), zone)
+		Expect(err).NotTo(HaveOccurred())
+
+		zone.Spec.PeeringSpec = &PeeringSpec{TargetNetwork: NetworkReference{Network: "shared"}}
+		zone.Spec.ForwardingSpec.TargetNameServers[0].IPv4Address = "fd00::53"
+		_, err = validator.ValidateCreate(context.Background(), zone)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.peeringSpec"))
+		Expect(err.Error()).To(ContainSubstring("spec.forwardingSpec.targetNameServers[0].ipv4Address"))
+
+		zone.Spec.ZoneType = ZoneTypePeering
+		zone.Spec.ForwardingSpec = nil
+		_, err = validator.ValidateCreate(context.Background(), zone)
+		Expect(err).NotTo(HaveOccurred())
+
+		zone.Spec.PrivateZone = false
+		_, err = validator.ValidateCreate(context.Background(), zone)
+		Expect(err).To(MatchError(ContainSubstring("Peering zones must be private")))
+	})
+
+	It("Should reject changes of the zone type", func() {
+		updated := zone.DeepCopy()
+		updated.Spec.ZoneType = ZoneTypeStandard
+		_, err := validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).NotTo(HaveOccurred())
+
+		updated.Spec.PrivateZone = true
+		zone.Spec.PrivateZone = true
+		updated.Spec.ZoneType = ZoneTypePeering
+		updated.Spec.PeeringSpec = &PeeringSpec{TargetNetwork: NetworkReference{Network: "shared"}}
+		_, err = validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).To(MatchError(ContainSubstring("spec.zoneType")))
+	})
+
+	It("Should reject changes of the key specs while DNSSEC is on", func() {
+		zone.Spec.DnsSecSpec.State = "On"
+		updated := zone.DeepCopy()
+		updated.Spec.DnsSecSpec.KeySigningKeySpec = &DnsKeySpec{Algorithm: "RSASHA256", KeyLength: 2048}
+		updated.Spec.DnsSecSpec.ZoneSigningKeySpec = &DnsKeySpec{Algorithm: "RSASHA256"}
+		_, err := validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).NotTo(HaveOccurred())
+
+		updated.Spec.DnsSecSpec.ZoneSigningKeySpec = &DnsKeySpec{Algorithm: "ECDSAP256SHA256"}
+		_, err = validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).To(MatchError(ContainSubstring("spec.dnsSecSpec.zoneSigningKeySpec")))
+
+		updated.Spec.DnsSecSpec.KeySigningKeySpec = &DnsKeySpec{Algorithm: "ECDSAP256SHA256"}
+		_, err = validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).To(MatchError(ContainSubstring("spec.dnsSecSpec.keySigningKeySpec")))
+
+		updated.Spec.DnsSecSpec.State = "Off"
+		_, err = validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should reject changes of the non-existence proof while DNSSEC is on", func() {
+		zone.Spec.DnsSecSpec = DnsSecSpec{State: "On", NonExistence: true}
+		updated := zone.DeepCopy()
+		updated.Spec.DnsSecSpec.NonExistence = false
+		_, err := validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).To(MatchError(ContainSubstring("spec.dnsSecSpec.nonExistence")))
+
+		updated.Spec.DnsSecSpec.State = "Off"
+		_, err = validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).NotTo(HaveOccurred())
+
+		zone.Spec.DnsSecSpec.State = "Off"
+		updated.Spec.DnsSecSpec.State = "On"
+		_, err = validator.ValidateUpdate(context.Background(), zone, updated)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should only delegate public zones from another zone", func() {
+		zone.Spec.ParentZoneRef = &CloudDnsZoneReference{Name: "parent"}
+		_, err := validator.ValidateCreate(context.Background(), zone)
+		Expect(err).NotTo(HaveOccurred())
+
+		zone.Spec.ParentZoneRef.Name = zone.Name
+		_, err = validator.ValidateCreate(context.Background(), zone)
+		Expect(err).To(MatchError(ContainSubstring("spec.parentZoneRef.name")))
+
+		zone.Spec.ParentZoneRef.Name = "parent"
+		zone.Spec.PrivateZone = true
+		zone.Spec.DnsSecSpec.State = "Off"
+		_, err = validator.ValidateCreate(context.Background(), zone)
+		Expect(err).To(MatchError(ContainSubstring("spec.parentZoneRef")))
+	})
+
+	It("Should require a private network to refer to either a network or a GKE cluster", func() {
+		zone.Spec.PrivateZone = true
+		zone.Spec.PrivateNetworks = []PrivateNetworkReference{
+			{ProjectID: "shared-vpc"},
+			{Network: "default", GkeCluster: &GkeClusterReference{Location: "europe-north1", Name: "apps"}},
+		}
+		_, err := validator.ValidateCreate(context.Background(), zone)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.privateNetworks[0]"))
+		Expect(err.Error()).To(ContainSubstring("spec.privateNetworks[1]"))
+	})
+
+})
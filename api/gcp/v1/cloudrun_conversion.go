@@ -0,0 +1,303 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+// CloudRunHubSpecAnnotation holds the v2 spec of a CloudRun served as v1 when it uses fields
+// v1 can not represent, so they survive a round-trip through v1
+const CloudRunHubSpecAnnotation = "cloudrun.gcp.stilas.418.cloud/v2-spec"
+
+// CloudRunHubStatusAnnotation holds the v2 status fields of a CloudRun served as v1 that v1 can not
+// represent, so the synced secret versions and the traffic survive a round-trip through v1
+const CloudRunHubStatusAnnotation = "cloudrun.gcp.stilas.418.cloud/v2-status"
+
+var _ conversion.Convertible = &CloudRun{}
+
+// ConvertTo converts this CloudRun to the Hub version (v2)
+func (c *CloudRun) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*gcpv2.CloudRun)
+	if !ok {
+		return fmt.Errorf("expected a v2 CloudRun but got %T", dstRaw)
+	}
+	spec, err := c.Spec.convertToHub()
+	if err != nil {
+		return err
+	}
+	dst.ObjectMeta = *c.ObjectMeta.DeepCopy()
+	dst.Spec = spec
+	dst.Status = c.Status.convertToHub()
+
+	var savedSpec gcpv2.CloudRunSpec
+	found, err := popHubAnnotation(dst, CloudRunHubSpecAnnotation, &savedSpec)
+	if err != nil {
+		return err
+	}
+	if found {
+		restoreHubOnlyFields(&dst.Spec, &savedSpec)
+	}
+	var savedStatus gcpv2.CloudRunStatus
+	found, err = popHubAnnotation(dst, CloudRunHubStatusAnnotation, &savedStatus)
+	if err != nil {
+		return err
+	}
+	if found {
+		restoreHubOnlyStatusFields(&dst.Status, &savedStatus)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v2) to this version
+func (c *CloudRun) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*gcpv2.CloudRun)
+	if !ok {
+		return fmt.Errorf("expected a v2 CloudRun but got %T", srcRaw)
+	}
+	c.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	c.Spec = convertSpecFromHub(&src.Spec)
+	c.Status = convertStatusFromHub(&src.Status)
+
+	spec, err := c.Spec.convertToHub()
+	if err != nil {
+		return err
+	}
+	if !equality.Semantic.DeepEqual(spec, src.Spec) {
+		if err := c.setHubAnnotation(CloudRunHubSpecAnnotation, src.Spec); err != nil {
+			return err
+		}
+	}
+	var hubOnly gcpv2.CloudRunStatus
+	restoreHubOnlyStatusFields(&hubOnly, &src.Status)
+	if !equality.Semantic.DeepEqual(hubOnly, gcpv2.CloudRunStatus{}) {
+		if err := c.setHubAnnotation(CloudRunHubStatusAnnotation, hubOnly); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setHubAnnotation saves the JSON of v in the annotation name
+func (c *CloudRun) setHubAnnotation(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to write annotation %s: %w", name, err)
+	}
+	if c.Annotations == nil {
+		c.Annotations = map[string]string{}
+	}
+	c.Annotations[name] = string(data)
+	return nil
+}
+
+// popHubAnnotation reads the JSON saved in the annotation name into v and removes the annotation,
+// returning false when it is not set
+func popHubAnnotation(dst *gcpv2.CloudRun, name string, v any) (bool, error) {
+	data, ok := dst.Annotations[name]
+	if !ok {
+		return false, nil
+	}
+	delete(dst.Annotations, name)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return false, fmt.Errorf("failed to read annotation %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// restoreHubOnlyStatusFields copies the status fields v1 has no representation for from a saved v2 status
+func restoreHubOnlyStatusFields(dst *gcpv2.CloudRunStatus, saved *gcpv2.CloudRunStatus) {
+	dst.SecretVersions = saved.SecretVersions
+	dst.RevisionSecretVersions = saved.RevisionSecretVersions
+	dst.Traffic = saved.Traffic
+}
+
+// restoreHubOnlyFields copies the fields v1 has no representation for from a saved v2 spec
+func restoreHubOnlyFields(dst *gcpv2.CloudRunSpec, saved *gcpv2.CloudRunSpec) {
+	dst.Scaling = saved.Scaling
+	dst.Security.ServiceAccount = saved.Security.ServiceAccount
+	dst.Volumes = saved.Volumes
+	dst.ProviderConfigRef = saved.ProviderConfigRef
+	for i := range dst.Traffic {
+		if i < len(saved.Traffic) && saved.Traffic[i].Revision == dst.Traffic[i].Revision &&
+			saved.Traffic[i].LatestRevision == dst.Traffic[i].LatestRevision {
+			dst.Traffic[i].Tag = saved.Traffic[i].Tag
+		}
+	}
+	for i := range dst.Containers {
+		for _, container := range saved.Containers {
+			if container.Name == dst.Containers[i].Name {
+				dst.Containers[i].Env = container.Env
+				dst.Containers[i].EnvFrom = container.EnvFrom
+				dst.Containers[i].VolumeMounts = container.VolumeMounts
+				dst.Containers[i].Resources = container.Resources
+			}
+		}
+	}
+}
+
+// convertToHub converts the spec to v2, an empty TrafficMode is left for the v2 defaulter and an
+// unknown one is an error so it never falls back to the default ingress
+func (s CloudRunSpec) convertToHub() (gcpv2.CloudRunSpec, error) {
+	dst := gcpv2.CloudRunSpec{
+		Location:  s.Location,
+		ProjectID: s.ProjectID,
+		Security: gcpv2.CloudRunSecurity{
+			InvokeMembers: s.InvokeMembers,
+		},
+	}
+	if trafficModeSet(s.TrafficMode) {
+		ingress, err := ParseIngressTraffic(s.TrafficMode)
+		if err != nil {
+			return gcpv2.CloudRunSpec{}, fmt.Errorf("failed to convert spec.trafficMode: %w", err)
+		}
+		dst.Networking.Ingress, _ = gcpv2.IngressFromTraffic(ingress)
+	}
+	for _, container := range s.Containers {
+		dst.Containers = append(dst.Containers, gcpv2.CloudRunContainer{
+			Name:          container.Name,
+			Image:         container.Image,
+			Port:          container.Port,
+			LivenessProbe: container.LivenessProbe.convertToHub(),
+			StartupProbe:  container.StartupProbe.convertToHub(),
+		})
+	}
+	for _, traffic := range s.Traffic {
+		dst.Traffic = append(dst.Traffic, gcpv2.CloudRunTraffic{
+			Revision:       traffic.Revision,
+			Percent:        traffic.Percent,
+			LatestRevision: traffic.LatestRevision,
+		})
+	}
+	return dst, nil
+}
+
+func convertSpecFromHub(src *gcpv2.CloudRunSpec) CloudRunSpec {
+	dst := CloudRunSpec{
+		Location:      src.Location,
+		ProjectID:     src.ProjectID,
+		InvokeMembers: src.Security.InvokeMembers,
+	}
+	if src.Networking.Ingress != "" {
+		dst.TrafficMode = intstr.FromString(string(src.Networking.Ingress))
+	}
+	for _, container := range src.Containers {
+		dst.Containers = append(dst.Containers, CloudRunContainer{
+			Name:          container.Name,
+			Image:         container.Image,
+			Port:          container.Port,
+			LivenessProbe: convertProbeFromHub(container.LivenessProbe),
+			StartupProbe:  convertProbeFromHub(container.StartupProbe),
+		})
+	}
+	for _, traffic := range src.Traffic {
+		dst.Traffic = append(dst.Traffic, CloudRunTraffic{
+			Revision:       traffic.Revision,
+			Percent:        traffic.Percent,
+			LatestRevision: traffic.LatestRevision,
+		})
+	}
+	return dst
+}
+
+func (p *CloudRunProbe) convertToHub() *gcpv2.CloudRunProbe {
+	if p == nil {
+		return nil
+	}
+	return &gcpv2.CloudRunProbe{
+		ProbeSpec: gcpv2.CloudRunProbeSpec{
+			ProbeType: gcpv2.CloudRunProbeType(p.ProbeSpec.ProbeType),
+			Port:      p.ProbeSpec.Port,
+			Service:   p.ProbeSpec.Service,
+			Path:      p.ProbeSpec.Path,
+		},
+		InitialDelaySeconds: p.InitialDelaySeconds,
+		TimeoutSeconds:      p.TimeoutSeconds,
+		PeriodSeconds:       p.PeriodSeconds,
+		FailureThreshold:    p.FailureThreshold,
+	}
+}
+
+func convertProbeFromHub(p *gcpv2.CloudRunProbe) *CloudRunProbe {
+	if p == nil {
+		return nil
+	}
+	return &CloudRunProbe{
+		ProbeSpec: CloudRunProbeSpec{
+			ProbeType: CloudRunProbeType(p.ProbeSpec.ProbeType),
+			Port:      p.ProbeSpec.Port,
+			Service:   p.ProbeSpec.Service,
+			Path:      p.ProbeSpec.Path,
+		},
+		InitialDelaySeconds: p.InitialDelaySeconds,
+		TimeoutSeconds:      p.TimeoutSeconds,
+		PeriodSeconds:       p.PeriodSeconds,
+		FailureThreshold:    p.FailureThreshold,
+	}
+}
+
+func (s CloudRunStatus) convertToHub() gcpv2.CloudRunStatus {
+	dst := gcpv2.CloudRunStatus{
+		Ready:               s.Ready,
+		Reconciling:         s.Reconciling,
+		Uri:                 s.Uri,
+		LatestReadyRevision: s.LatestReadyRevision,
+		Revisions:           s.Revisions,
+		Conditions:          s.Conditions,
+		Plan:                s.Plan,
+	}
+	for _, operation := range s.Operations {
+		dst.Operations = append(dst.Operations, &gcpv2.CloudRunOperation{
+			Name:          operation.Name,
+			Done:          operation.Done,
+			OperationType: gcpv2.CloudRunOperationType(operation.OperationType),
+			StartTime:     operation.StartTime,
+		})
+	}
+	return dst
+}
+
+func convertStatusFromHub(src *gcpv2.CloudRunStatus) CloudRunStatus {
+	dst := CloudRunStatus{
+		Ready:               src.Ready,
+		Reconciling:         src.Reconciling,
+		Uri:                 src.Uri,
+		LatestReadyRevision: src.LatestReadyRevision,
+		Revisions:           src.Revisions,
+		Conditions:          src.Conditions,
+		Plan:                src.Plan,
+	}
+	for _, operation := range src.Operations {
+		dst.Operations = append(dst.Operations, &CloudRunOperation{
+			Name:          operation.Name,
+			Done:          operation.Done,
+			OperationType: CloudRunOperationType(operation.OperationType),
+			StartTime:     operation.StartTime,
+		})
+	}
+	return dst
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+var _ = Describe("CloudRun Conversion", func() {
+	path := "/healthz"
+	minInstances := int32(1)
+
+	newV1 := func() *CloudRun {
+		return &CloudRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-resource",
+				Namespace:   "default",
+				Annotations: map[string]string{"team": "test"},
+			},
+			Spec: CloudRunSpec{
+				Location:  "us-central1",
+				ProjectID: "test-project",
+				Containers: []CloudRunContainer{
+					{
+						Image: "gcr.io/test-project/test-image",
+						Name:  "test-container",
+						Port:  8080,
+						LivenessProbe: &CloudRunProbe{
+							ProbeSpec: CloudRunProbeSpec{
+								ProbeType: CloudRunProbeType_HTTPGet,
+								Port:      8080,
+								Path:      &path,
+							},
+							TimeoutSeconds:   5,
+							PeriodSeconds:    10,
+							FailureThreshold: 3,
+						},
+						StartupProbe: &CloudRunProbe{
+							ProbeSpec: CloudRunProbeSpec{
+								ProbeType: CloudRunProbeType_TCPSocket,
+								Port:      8080,
+							},
+						},
+					},
+				},
+				Traffic: []CloudRunTraffic{
+					{Percent: 80, LatestRevision: true},
+					{Percent: 20, Revision: "test-revision"},
+				},
+				TrafficMode:   intstr.FromString("Internal"),
+				InvokeMembers: []string{"allUsers"},
+			},
+			Status: CloudRunStatus{
+				Ready: true,
+				Uri:   "https://test.run.app",
+				Operations: []*CloudRunOperation{
+					{Name: "test-operation", Done: true, OperationType: CloudRunOperationType_Create},
+				},
+			},
+		}
+	}
+
+	newV2 := func() *gcpv2.CloudRun {
+		hub := &gcpv2.CloudRun{}
+		Expect(newV1().ConvertTo(hub)).To(Succeed())
+		hub.Spec.Scaling = gcpv2.CloudRunScaling{MinInstances: &minInstances, MaxConcurrency: 10}
+		hub.Spec.Security.ServiceAccount = "runner@test-project.iam.gserviceaccount.com"
+		hub.Spec.Containers[0].Env = []gcpv2.CloudRunEnvVar{
+			{Name: "LOG_LEVEL", Value: "info"},
+			{Name: "PASSWORD", ValueFrom: &gcpv2.CloudRunEnvVarSource{
+				SecretKeyRef: &gcpv2.CloudRunSecretKeySelector{Name: "test-secret", Key: "password"},
+			}},
+		}
+		hub.Spec.Traffic[1].Tag = "previous"
+		hub.Spec.Containers[0].EnvFrom = []gcpv2.CloudRunEnvFromSource{
+			{Prefix: "CONFIG_", ConfigMapRef: &gcpv2.CloudRunConfigMapEnvSource{Name: "test-config"}},
+		}
+		hub.Spec.Containers[0].VolumeMounts = []gcpv2.CloudRunVolumeMount{{Name: "config", MountPath: "/etc/config"}}
+		hub.Spec.Volumes = []gcpv2.CloudRunVolume{
+			{Name: "config", Secret: &gcpv2.CloudRunSecretVolumeSource{SecretName: "test-secret", Key: "config.json"}},
+		}
+		hub.Spec.ProviderConfigRef = &gcpv2.ProviderConfigReference{Kind: gcpv2.ProviderConfigKind_Cluster, Name: "shared"}
+		hub.Status.SecretVersions = []gcpv2.CloudRunSecretVersion{
+			{SecretName: "test-secret", Key: "password", SecretManagerSecret: "stilas-default-test-resource-test-secret-password", Version: "2", Checksum: "abc"},
+		}
+		hub.Status.RevisionSecretVersions = []gcpv2.CloudRunRevisionSecretVersions{
+			{Revision: "test-revision", Versions: []string{"stilas-default-test-resource-test-secret-password/versions/1"}},
+		}
+		hub.Status.Traffic = []gcpv2.CloudRunTrafficStatus{
+			{Percent: 80, LatestRevision: true},
+			{Percent: 20, Revision: "test-revision", Tag: "previous", Uri: "https://previous---test.run.app"},
+		}
+		return hub
+	}
+
+	It("Should map the v1 fields to their v2 locations", func() {
+		hub := &gcpv2.CloudRun{}
+		Expect(newV1().ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Networking.Ingress).To(Equal(gcpv2.CloudRunIngress_Internal))
+		Expect(hub.Spec.Security.InvokeMembers).To(Equal([]string{"allUsers"}))
+		Expect(hub.Spec.Containers[0].StartupProbe.ProbeSpec.ProbeType).To(Equal(gcpv2.CloudRunProbeType_TCPSocket))
+		Expect(hub.Status.Operations[0].Name).To(Equal("test-operation"))
+	})
+
+	It("Should convert numeric traffic modes", func() {
+		run := newV1()
+		run.Spec.TrafficMode = intstr.FromInt32(3)
+		hub := &gcpv2.CloudRun{}
+		Expect(run.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Networking.Ingress).To(Equal(gcpv2.CloudRunIngress_InternalLoadBalancer))
+	})
+
+	It("Should round-trip v1 through v2 without changes", func() {
+		original := newV1()
+		hub := &gcpv2.CloudRun{}
+		Expect(original.DeepCopy().ConvertTo(hub)).To(Succeed())
+		converted := &CloudRun{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted).To(Equal(original))
+	})
+
+	It("Should round-trip v2 through v1 without losing v2 only fields", func() {
+		original := newV2()
+		spoke := &CloudRun{}
+		Expect(spoke.ConvertFrom(original.DeepCopy())).To(Succeed())
+		Expect(spoke.Annotations).To(HaveKey(CloudRunHubSpecAnnotation))
+		Expect(spoke.Annotations).To(HaveKey(CloudRunHubStatusAnnotation))
+		converted := &gcpv2.CloudRun{}
+		Expect(spoke.ConvertTo(converted)).To(Succeed())
+		Expect(converted).To(Equal(original))
+	})
+
+	It("Should keep v2 only fields when the v1 object is edited", func() {
+		spoke := &CloudRun{}
+		Expect(spoke.ConvertFrom(newV2())).To(Succeed())
+		spoke.Spec.Containers[0].Image = "gcr.io/test-project/test-image:v2"
+		converted := &gcpv2.CloudRun{}
+		Expect(spoke.ConvertTo(converted)).To(Succeed())
+		Expect(converted.Spec.Containers[0].Image).To(Equal("gcr.io/test-project/test-image:v2"))
+		Expect(converted.Spec.Scaling.MinInstances).To(HaveValue(Equal(minInstances)))
+		Expect(converted.Spec.Security.ServiceAccount).To(Equal("runner@test-project.iam.gserviceaccount.com"))
+		Expect(converted.Spec.Containers[0].Env).To(HaveLen(2))
+		Expect(converted.Spec.Containers[0].EnvFrom).To(HaveLen(1))
+		Expect(converted.Spec.Volumes).To(HaveLen(1))
+		Expect(converted.Spec.Traffic[1].Tag).To(Equal("previous"))
+		Expect(converted.Spec.ProviderConfigRef).To(HaveValue(HaveField("Name", "shared")))
+		Expect(converted.Status.SecretVersions).To(HaveLen(1))
+		Expect(converted.Status.RevisionSecretVersions).To(HaveLen(1))
+		Expect(converted.Status.Traffic[1].Uri).To(Equal("https://previous---test.run.app"))
+		Expect(converted.Annotations).NotTo(HaveKey(CloudRunHubSpecAnnotation))
+		Expect(converted.Annotations).NotTo(HaveKey(CloudRunHubStatusAnnotation))
+	})
+})
@@ -4,99 +4,63 @@ import (
 	"fmt"
 
 	"cloud.google.com/go/run/apiv2/runpb"
-)
+	"k8s.io/apimachinery/pkg/util/intstr"
 
-func (c *CloudRun) ConvertToCreateServiceRequest() *runpb.CreateServiceRequest {
-	return &runpb.CreateServiceRequest{
-		Parent:    fmt.Sprintf("projects/%s/locations/%s", c.Spec.ProjectID, c.Spec.Location),
-		ServiceId: fmt.Sprintf("%s-%s", c.Namespace, c.Name),
-		Service:   c.convertToService(),
-	}
-}
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
 
-func (c *CloudRun) GetGcpCloudRunServiceFullName() string {
-	return fmt.Sprintf("projects/%s/locations/%s/services/%s-%s", c.Spec.ProjectID, c.Spec.Location, c.Namespace, c.Name)
+var ingressTraffic = map[CloudRunIngress]runpb.IngressTraffic{
+	CloudRunIngress_All:                  runpb.IngressTraffic_INGRESS_TRAFFIC_ALL,
+	CloudRunIngress_Internal:             runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_ONLY,
+	CloudRunIngress_InternalLoadBalancer: runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER,
 }
 
-func (c *CloudRun) convertToService() *runpb.Service {
-	return &runpb.Service{
-		Ingress: c.Spec.TrafficMode,
-		Traffic: c.convertToTrafficTarget(),
-		Template: &runpb.RevisionTemplate{
-			Containers: c.convertToContainers(),
-		},
+// ParseIngressTraffic converts a TrafficMode, given either as a CloudRunIngress name or
+// as a numeric runpb.IngressTraffic value, to the runpb representation
+func ParseIngressTraffic(mode intstr.IntOrString) (runpb.IngressTraffic, error) {
+	if mode.Type == intstr.Int {
+		ingress := runpb.IngressTraffic(mode.IntVal)
+		if _, ok := IngressTrafficName(ingress); !ok {
+			return runpb.IngressTraffic_INGRESS_TRAFFIC_UNSPECIFIED, fmt.Errorf("unknown ingress traffic value %d", mode.IntVal)
+		}
+		return ingress, nil
 	}
+	ingress, ok := ingressTraffic[CloudRunIngress(mode.StrVal)]
+	if !ok {
+		return runpb.IngressTraffic_INGRESS_TRAFFIC_UNSPECIFIED, fmt.Errorf("unknown ingress traffic %q, must be one of All, Internal or InternalLoadBalancer", mode.StrVal)
+	}
+	return ingress, nil
 }
 
-func (c *CloudRun) convertToTrafficTarget() []*runpb.TrafficTarget {
-	var trafficTargets []*runpb.TrafficTarget
-	for _, traffic := range c.Spec.Traffic {
-		if traffic.LatestRevision {
-			trafficTargets = append(trafficTargets, &runpb.TrafficTarget{
-				Percent: traffic.Percent,
-				Type:    runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST,
-			})
-		} else {
-			trafficTargets = append(trafficTargets, &runpb.TrafficTarget{
-				Percent:  traffic.Percent,
-				Revision: traffic.Revision,
-				Type:     runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_REVISION,
-			})
+// IngressTrafficName returns the CloudRunIngress name of a runpb.IngressTraffic value
+func IngressTrafficName(ingress runpb.IngressTraffic) (CloudRunIngress, bool) {
+	for name, value := range ingressTraffic {
+		if value == ingress {
+			return name, true
 		}
 	}
-	return trafficTargets
+	return "", false
 }
 
-func (c *CloudRun) convertToContainers() []*runpb.Container {
-	containers := make([]*runpb.Container, 0, len(c.Spec.Containers))
-	for _, container := range c.Spec.Containers {
-		containers = append(containers, &runpb.Container{
-			Image: container.Image,
-			Name:  container.Name,
-			Ports: []*runpb.ContainerPort{
-				{
-					ContainerPort: container.Port,
-				},
-			},
-			LivenessProbe: container.LivenessProbe.convertToProbes(),
-			StartupProbe:  container.StartupProbe.convertToProbes(),
-		})
-	}
-	return containers
+// trafficModeSet returns false for a TrafficMode left empty, which is defaulted to All
+func trafficModeSet(mode intstr.IntOrString) bool {
+	return mode != intstr.IntOrString{} && mode != intstr.FromString("")
 }
 
-func (p *CloudRunProbe) convertToProbes() *runpb.Probe {
-	if p == nil {
-		return nil
+// ConvertToCreateServiceRequest builds the request through the hub version, v2 owns the
+// mapping to runpb. It fails when the TrafficMode can not be parsed.
+func (c *CloudRun) ConvertToCreateServiceRequest() (*runpb.CreateServiceRequest, error) {
+	hub := &gcpv2.CloudRun{}
+	if err := c.ConvertTo(hub); err != nil {
+		return nil, err
 	}
-	probe := &runpb.Probe{
-		InitialDelaySeconds: p.InitialDelaySeconds,
-		PeriodSeconds:       p.PeriodSeconds,
-		TimeoutSeconds:      p.TimeoutSeconds,
-		FailureThreshold:    p.FailureThreshold,
-	}
-	switch p.ProbeSpec.ProbeType {
-	case "HTTPGet":
-		probe.ProbeType = &runpb.Probe_HttpGet{
-			HttpGet: &runpb.HTTPGetAction{
-				Path:        *p.ProbeSpec.Path,
-				HttpHeaders: nil,
-				Port:        p.ProbeSpec.Port,
-			},
-		}
-	case "TCPSocket":
-		probe.ProbeType = &runpb.Probe_TcpSocket{
-			TcpSocket: &runpb.TCPSocketAction{
-				Port: p.ProbeSpec.Port,
-			},
-		}
-	case "Grpc":
-		probe.ProbeType = &runpb.Probe_Grpc{
-			Grpc: &runpb.GRPCAction{
-				Port:    p.ProbeSpec.Port,
-				Service: *p.ProbeSpec.Service,
-			},
-		}
+	return hub.ConvertToCreateServiceRequest(nil), nil
+}
+
+func (c *CloudRun) GetGcpCloudRunServiceFullName() string {
+	hub := &gcpv2.CloudRun{
+		ObjectMeta: c.ObjectMeta,
+		Spec:       gcpv2.CloudRunSpec{ProjectID: c.Spec.ProjectID, Location: c.Spec.Location},
 	}
-	return probe
+	return hub.GetGcpCloudRunServiceFullName()
 }
@@ -17,8 +17,8 @@ limitations under the License.
 package v1
 
 import (
-	"cloud.google.com/go/run/apiv2/runpb"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -48,9 +48,14 @@ type CloudRunSpec struct {
 	//+kubebuilder:validation:Optional
 	Traffic []CloudRunTraffic `json:"traffic"`
 
+	//TrafficMode controls which sources are allowed to reach the service.
+	//One of All, Internal or InternalLoadBalancer. The numeric runpb.IngressTraffic
+	//values used by earlier versions are still accepted and normalized by the defaulting webhook,
+	//unknown values are rejected by the validating webhook
+	//+kubebuilder:example:=All
 	//+kubebuilder:validation:Optional
-	//+kubebuilder:default:=1
-	TrafficMode runpb.IngressTraffic `json:"trafficMode"`
+	//+kubebuilder:default:=All
+	TrafficMode intstr.IntOrString `json:"trafficMode"`
 
 	//+kubebuilder:validation:Required
 	//+kubebuilder:default:={allUsers}
@@ -136,6 +141,15 @@ const (
 	CloudRunProbeType_Grpc      CloudRunProbeType = "Grpc"
 )
 
+// CloudRunIngress is the human-friendly name of a runpb.IngressTraffic value
+type CloudRunIngress string
+
+const (
+	CloudRunIngress_All                  CloudRunIngress = "All"
+	CloudRunIngress_Internal             CloudRunIngress = "Internal"
+	CloudRunIngress_InternalLoadBalancer CloudRunIngress = "InternalLoadBalancer"
+)
+
 // CloudRunStatus defines the observed state of CloudRun
 type CloudRunStatus struct {
 	Ready bool `json:"ready"`
@@ -149,6 +163,15 @@ type CloudRunStatus struct {
 	LatestReadyRevision string `json:"latestReadyRevision,omitempty"`
 	//+kubebuilder:validation:Optional
 	Revisions []string `json:"revisions,omitempty"`
+	//Conditions report the state of the last reconcile. The Synced condition is False with a
+	//reason of TransientError or PermanentError when GCP rejected a call.
+	//+kubebuilder:validation:Optional
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	//Plan lists the changes stilas would make in GCP, one per line, only set in plan-only mode
+	//+kubebuilder:validation:Optional
+	Plan string `json:"plan,omitempty"`
 }
 
 type CloudRunOperation struct {
@@ -158,6 +181,9 @@ type CloudRunOperation struct {
 	Done bool `json:"done"`
 	//+kubebuilder:validation:Optional
 	OperationType CloudRunOperationType `json:"operationType"`
+	//StartTime is when the operation was started
+	//+kubebuilder:validation:Optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
 }
 
 type CloudRunOperationType string
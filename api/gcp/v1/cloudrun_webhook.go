@@ -0,0 +1,123 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+// The defaults are shared with v2, a v1 CloudRun is defaulted as its v2 conversion
+const (
+	// DefaultContainerPort is the port Cloud Run sends requests to when none is given
+	DefaultContainerPort = gcpv2.DefaultContainerPort
+	// DefaultProbeTimeoutSeconds is the probe timeout used when none is given
+	DefaultProbeTimeoutSeconds = gcpv2.DefaultProbeTimeoutSeconds
+	// DefaultProbePeriodSeconds is the probe period used when none is given
+	DefaultProbePeriodSeconds = gcpv2.DefaultProbePeriodSeconds
+	// DefaultProbeFailureThreshold is the probe failure threshold used when none is given
+	DefaultProbeFailureThreshold = gcpv2.DefaultProbeFailureThreshold
+	// DefaultProbePath is the path used by HTTPGet probes when none is given
+	DefaultProbePath = gcpv2.DefaultProbePath
+)
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (c *CloudRun) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(c).
+		WithDefaulter(&CloudRunCustomDefaulter{}).
+		WithValidator(&CloudRunCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-gcp-stilas-418-cloud-v1-cloudrun,mutating=true,failurePolicy=fail,sideEffects=None,groups=gcp.stilas.418.cloud,resources=cloudruns,verbs=create;update,versions=v1,name=mcloudrun.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-gcp-stilas-418-cloud-v1-cloudrun,mutating=false,failurePolicy=fail,sideEffects=None,groups=gcp.stilas.418.cloud,resources=cloudruns,verbs=create;update,versions=v1,name=vcloudrun.kb.io,admissionReviewVersions=v1
+
+// CloudRunCustomDefaulter fills in the defaults of a CloudRun that can not be expressed as
+// kubebuilder markers
+type CloudRunCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &CloudRunCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type. The
+// CloudRun is defaulted by the v2 defaulter through its conversion to v2, a CloudRun with an
+// unknown TrafficMode can not be converted and is left to CloudRunCustomValidator to reject.
+func (d *CloudRunCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	run, ok := obj.(*CloudRun)
+	if !ok {
+		return fmt.Errorf("expected a CloudRun object but got %T", obj)
+	}
+	if trafficModeSet(run.Spec.TrafficMode) {
+		if _, err := ParseIngressTraffic(run.Spec.TrafficMode); err != nil {
+			return nil
+		}
+	}
+	hub := &gcpv2.CloudRun{}
+	if err := run.ConvertTo(hub); err != nil {
+		return err
+	}
+	if err := (&gcpv2.CloudRunCustomDefaulter{}).Default(ctx, hub); err != nil {
+		return err
+	}
+	return run.ConvertFrom(hub)
+}
+
+// CloudRunCustomValidator rejects v1 CloudRuns that can not be converted to v2
+type CloudRunCustomValidator struct{}
+
+var _ webhook.CustomValidator = &CloudRunCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator, validating the spec of the CloudRun
+func (v *CloudRunCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(obj)
+}
+
+// ValidateUpdate implements webhook.CustomValidator, validating the spec of the CloudRun
+func (v *CloudRunCustomValidator) ValidateUpdate(_ context.Context, _ runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(newObj)
+}
+
+// ValidateDelete implements webhook.CustomValidator, deletes are always allowed
+func (v *CloudRunCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *CloudRunCustomValidator) validate(obj runtime.Object) error {
+	run, ok := obj.(*CloudRun)
+	if !ok {
+		return fmt.Errorf("expected a CloudRun object but got %T", obj)
+	}
+	var errs field.ErrorList
+	if trafficModeSet(run.Spec.TrafficMode) {
+		if _, err := ParseIngressTraffic(run.Spec.TrafficMode); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "trafficMode"), run.Spec.TrafficMode.String(), err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("CloudRun").GroupKind(), run.Name, errs)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	"cloud.google.com/go/run/apiv2/runpb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+var _ = Describe("CloudRun Webhook", func() {
+	var run *CloudRun
+	defaulter := &CloudRunCustomDefaulter{}
+	validator := &CloudRunCustomValidator{}
+
+	BeforeEach(func() {
+		run = &CloudRun{
+			Spec: CloudRunSpec{
+				Location:  "us-central1",
+				ProjectID: "test-project",
+				Containers: []CloudRunContainer{
+					{
+						Image: "gcr.io/test-project/test-image",
+						Name:  "test-container",
+					},
+				},
+			},
+		}
+	})
+
+	Context("When creating CloudRun under Defaulting Webhook", func() {
+		It("Should fill in the container port and route all traffic to the latest revision", func() {
+			Expect(defaulter.Default(context.Background(), run)).To(Succeed())
+			Expect(run.Spec.Containers[0].Port).To(Equal(DefaultContainerPort))
+			Expect(run.Spec.Traffic).To(Equal([]CloudRunTraffic{{Percent: 100, LatestRevision: true}}))
+			Expect(run.Spec.TrafficMode).To(Equal(intstr.FromString("All")))
+		})
+
+		It("Should keep explicitly configured values", func() {
+			run.Spec.Containers[0].Port = 9090
+			run.Spec.Traffic = []CloudRunTraffic{{Revision: "test-revision", Percent: 100}}
+			run.Spec.TrafficMode = intstr.FromString("Internal")
+			Expect(defaulter.Default(context.Background(), run)).To(Succeed())
+			Expect(run.Spec.Containers[0].Port).To(Equal(int32(9090)))
+			Expect(run.Spec.Traffic).To(Equal([]CloudRunTraffic{{Revision: "test-revision", Percent: 100}}))
+			Expect(run.Spec.TrafficMode).To(Equal(intstr.FromString("Internal")))
+		})
+
+		It("Should rewrite numeric traffic modes to their name", func() {
+			run.Spec.TrafficMode = intstr.FromInt32(int32(runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER))
+			Expect(defaulter.Default(context.Background(), run)).To(Succeed())
+			Expect(run.Spec.TrafficMode).To(Equal(intstr.FromString("InternalLoadBalancer")))
+		})
+
+		It("Should leave unknown traffic modes to the validator", func() {
+			run.Spec.TrafficMode = intstr.FromString("Everyone")
+			Expect(defaulter.Default(context.Background(), run)).To(Succeed())
+			Expect(run.Spec.TrafficMode).To(Equal(intstr.FromString("Everyone")))
+		})
+
+		It("Should default probe timings, port and path", func() {
+			run.Spec.Containers[0].LivenessProbe = &CloudRunProbe{
+				ProbeSpec: CloudRunProbeSpec{ProbeType: CloudRunProbeType_HTTPGet},
+			}
+			Expect(defaulter.Default(context.Background(), run)).To(Succeed())
+			probe := run.Spec.Containers[0].LivenessProbe
+			Expect(probe.TimeoutSeconds).To(Equal(DefaultProbeTimeoutSeconds))
+			Expect(probe.PeriodSeconds).To(Equal(DefaultProbePeriodSeconds))
+			Expect(probe.FailureThreshold).To(Equal(DefaultProbeFailureThreshold))
+			Expect(probe.ProbeSpec.Port).To(Equal(DefaultContainerPort))
+			Expect(probe.ProbeSpec.Path).To(HaveValue(Equal(DefaultProbePath)))
+			Expect(run.Spec.Containers[0].StartupProbe).To(BeNil())
+		})
+	})
+
+	Context("When creating CloudRun under Validating Webhook", func() {
+		It("Should accept known and empty traffic modes", func() {
+			for _, mode := range []intstr.IntOrString{{}, intstr.FromString("Internal"), intstr.FromInt32(int32(runpb.IngressTraffic_INGRESS_TRAFFIC_ALL))} {
+				run.Spec.TrafficMode = mode
+				_, err := validator.ValidateCreate(context.Background(), run)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("Should reject unknown traffic modes", func() {
+			for _, mode := range []intstr.IntOrString{intstr.FromString("Everyone"), intstr.FromInt32(42)} {
+				run.Spec.TrafficMode = mode
+				_, err := validator.ValidateCreate(context.Background(), run)
+				Expect(apierrors.IsInvalid(err)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("spec.trafficMode")))
+				_, err = validator.ValidateUpdate(context.Background(), run, run)
+				Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("When defaulting a CloudRun created as v2", func() {
+		It("Should keep the v2 only fields saved in the conversion annotation", func() {
+			hub := &gcpv2.CloudRun{}
+			Expect(run.ConvertTo(hub)).To(Succeed())
+			hub.Spec.Security.ServiceAccount = "runner@test-project.iam.gserviceaccount.com"
+			Expect(run.ConvertFrom(hub)).To(Succeed())
+			Expect(run.Annotations).To(HaveKey(CloudRunHubSpecAnnotation))
+
+			Expect(defaulter.Default(context.Background(), run)).To(Succeed())
+			Expect(run.Spec.Containers[0].Port).To(Equal(DefaultContainerPort))
+			converted := &gcpv2.CloudRun{}
+			Expect(run.ConvertTo(converted)).To(Succeed())
+			Expect(converted.Spec.Security.ServiceAccount).To(Equal("runner@test-project.iam.gserviceaccount.com"))
+		})
+	})
+
+	Context("When converting the traffic mode", func() {
+		It("Should map names to runpb values", func() {
+			Expect(ParseIngressTraffic(intstr.FromString("All"))).To(Equal(runpb.IngressTraffic_INGRESS_TRAFFIC_ALL))
+			Expect(ParseIngressTraffic(intstr.FromString("Internal"))).To(Equal(runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_ONLY))
+			Expect(ParseIngressTraffic(intstr.FromString("InternalLoadBalancer"))).To(Equal(runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER))
+		})
+
+		It("Should set the ingress on the service request", func() {
+			run.Spec.TrafficMode = intstr.FromString("Internal")
+			req, err := run.ConvertToCreateServiceRequest()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(req.Service.Ingress).To(Equal(runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_ONLY))
+		})
+
+		It("Should fail on unknown traffic modes instead of using the default ingress", func() {
+			run.Spec.TrafficMode = intstr.FromString("Everyone")
+			_, err := run.ConvertToCreateServiceRequest()
+			Expect(err).To(MatchError(ContainSubstring(`unknown ingress traffic "Everyone"`)))
+			Expect(run.ConvertTo(&gcpv2.CloudRun{})).To(MatchError(ContainSubstring("spec.trafficMode")))
+		})
+	})
+})
@@ -0,0 +1,68 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GcpProjectBindingSpec allows the resources in a set of namespaces to target a set of GCP projects
+// +kubebuilder:validation:XValidation:rule="(has(self.namespaces) && size(self.namespaces) > 0) || has(self.namespaceSelector)",message="one of namespaces and namespaceSelector must be set"
+type GcpProjectBindingSpec struct {
+	//Namespaces are the names of the namespaces the binding applies to
+	//+kubebuilder:validation:Optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	//NamespaceSelector selects the namespaces the binding applies to by their labels
+	//+kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	//Projects are the ids of the GCP projects the namespaces can target, shell patterns like team-a-* are allowed
+	//+kubebuilder:example:={"team-a-*"}
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:MinItems:=1
+	Projects []string `json:"projects"`
+	//Locations are the locations the namespaces can create regional resources in, shell patterns like
+	//europe-* are allowed. All locations are allowed when empty.
+	//+kubebuilder:example:={"europe-north1"}
+	//+kubebuilder:validation:Optional
+	Locations []string `json:"locations,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Projects",type=string,JSONPath=`.spec.projects`
+
+// GcpProjectBinding allows namespaces to manage resources in GCP projects. When project bindings
+// are enforced a resource is only reconciled if a binding covers its namespace, project and location.
+type GcpProjectBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GcpProjectBindingSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GcpProjectBindingList contains a list of GcpProjectBinding
+type GcpProjectBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GcpProjectBinding `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GcpProjectBinding{}, &GcpProjectBindingList{})
+}
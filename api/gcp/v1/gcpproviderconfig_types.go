@@ -0,0 +1,169 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultProviderConfigName is the name of the GcpProviderConfig used by the resources in its
+	// namespace that do not refer to a provider config
+	DefaultProviderConfigName = "default"
+	// DefaultCredentialsKey is the key of the service account key in a credentials Secret
+	DefaultCredentialsKey = "credentials.json"
+	// DefaultWorkloadIdentityTokenPath is the token of the manager exchanged by workload identity
+	// federation, the only token a GcpProviderConfig can use
+	DefaultWorkloadIdentityTokenPath = "/var/run/secrets/stilas/serviceaccount/token"
+)
+
+// ProviderConfigKind is the kind of provider config a resource refers to
+// +kubebuilder:validation:Enum=GcpProviderConfig;ClusterGcpProviderConfig
+type ProviderConfigKind string
+
+const (
+	ProviderConfigKind_Namespaced ProviderConfigKind = "GcpProviderConfig"
+	ProviderConfigKind_Cluster    ProviderConfigKind = "ClusterGcpProviderConfig"
+)
+
+// ProviderConfigReference refers to the provider config holding the credentials used for a resource
+type ProviderConfigReference struct {
+	//Kind is GcpProviderConfig for a config in the namespace of the resource, or ClusterGcpProviderConfig
+	//+kubebuilder:default=GcpProviderConfig
+	//+kubebuilder:validation:Optional
+	Kind ProviderConfigKind `json:"kind,omitempty"`
+	//Name of the provider config
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// GcpProviderConfigSpec defines the credentials used to call the Google APIs. Without
+// serviceAccountKeySecretRef or workloadIdentityFederation the ambient credentials of the manager are
+// used, optionally impersonating another service account.
+// +kubebuilder:validation:XValidation:rule="!(has(self.serviceAccountKeySecretRef) && has(self.workloadIdentityFederation))",message="only one of serviceAccountKeySecretRef and workloadIdentityFederation can be set"
+type GcpProviderConfigSpec struct {
+	//ServiceAccountKeySecretRef refers to a Secret holding a service account key in JSON format
+	//+kubebuilder:validation:Optional
+	ServiceAccountKeySecretRef *CredentialsSecretSelector `json:"serviceAccountKeySecretRef,omitempty"`
+	//WorkloadIdentityFederation exchanges a token of the manager for Google credentials
+	//+kubebuilder:validation:Optional
+	WorkloadIdentityFederation *WorkloadIdentityFederation `json:"workloadIdentityFederation,omitempty"`
+	//Impersonation makes the calls as another service account, using the credentials above to
+	//get its tokens. Only allowed in a ClusterGcpProviderConfig, the manager impersonates with its
+	//own identity.
+	//+kubebuilder:validation:Optional
+	Impersonation *Impersonation `json:"impersonation,omitempty"`
+}
+
+// ClusterGcpProviderConfigSpec defines the credentials of a ClusterGcpProviderConfig and the
+// namespaces allowed to use them
+type ClusterGcpProviderConfigSpec struct {
+	GcpProviderConfigSpec `json:",inline"`
+	//AllowedNamespaces selects the namespaces whose resources can use the config by their labels,
+	//an empty selector allows every namespace. No namespace can use the config when not set.
+	//+kubebuilder:validation:Optional
+	AllowedNamespaces *metav1.LabelSelector `json:"allowedNamespaces,omitempty"`
+}
+
+// CredentialsSecretSelector selects a key of a Secret
+type CredentialsSecretSelector struct {
+	//Namespace of the Secret, only used by ClusterGcpProviderConfig. A GcpProviderConfig always
+	//reads Secrets in its own namespace.
+	//+kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+	//Name of the Secret
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+	//Key in the Secret holding the service account key
+	//+kubebuilder:default=credentials.json
+	//+kubebuilder:validation:Optional
+	Key string `json:"key,omitempty"`
+}
+
+// WorkloadIdentityFederation configures a workload identity pool provider trusting the tokens of the manager
+type WorkloadIdentityFederation struct {
+	//Audience is the full resource name of the workload identity pool provider
+	//+kubebuilder:example:=//iam.googleapis.com/projects/123456789/locations/global/workloadIdentityPools/my-pool/providers/my-provider
+	//+kubebuilder:validation:Required
+	Audience string `json:"audience"`
+	//ServiceAccount is the email of the service account to impersonate with the federated token,
+	//the federated principal is used directly when empty
+	//+kubebuilder:validation:Optional
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	//TokenPath is the path of the token file exchanged for Google credentials, usually a projected
+	//service account token mounted in the manager. Only a ClusterGcpProviderConfig can change it.
+	//+kubebuilder:default=/var/run/secrets/stilas/serviceaccount/token
+	//+kubebuilder:validation:Optional
+	TokenPath string `json:"tokenPath,omitempty"`
+}
+
+// Impersonation configures service account impersonation
+type Impersonation struct {
+	//ServiceAccount is the email of the service account to impersonate
+	//+kubebuilder:validation:Required
+	ServiceAccount string `json:"serviceAccount"`
+	//Delegates are the service accounts in the delegation chain, each having the Service Account
+	//Token Creator role on the next, the last one on ServiceAccount
+	//+kubebuilder:validation:Optional
+	Delegates []string `json:"delegates,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GcpProviderConfig holds the credentials used for the GCP resources in its namespace
+// +kubebuilder:validation:XValidation:rule="!has(self.spec) || !has(self.spec.impersonation)",message="impersonation is only allowed in a ClusterGcpProviderConfig"
+// +kubebuilder:validation:XValidation:rule="!has(self.spec) || !has(self.spec.workloadIdentityFederation) || !has(self.spec.workloadIdentityFederation.tokenPath) || self.spec.workloadIdentityFederation.tokenPath == '/var/run/secrets/stilas/serviceaccount/token'",message="workloadIdentityFederation.tokenPath can only be changed in a ClusterGcpProviderConfig"
+type GcpProviderConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GcpProviderConfigSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GcpProviderConfigList contains a list of GcpProviderConfig
+type GcpProviderConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GcpProviderConfig `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// ClusterGcpProviderConfig holds credentials that can be used for GCP resources in the namespaces
+// it allows
+type ClusterGcpProviderConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterGcpProviderConfigSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterGcpProviderConfigList contains a list of ClusterGcpProviderConfig
+type ClusterGcpProviderConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterGcpProviderConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GcpProviderConfig{}, &GcpProviderConfigList{}, &ClusterGcpProviderConfig{}, &ClusterGcpProviderConfigList{})
+}
@@ -21,7 +21,8 @@ limitations under the License.
 package v1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -29,8 +30,8 @@ func (in *CloudDnsRecord) DeepCopyInto(out *CloudDnsRecord) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudDnsRecord.
@@ -86,6 +87,12 @@ func (in *CloudDnsRecordList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudDnsRecordSpec) DeepCopyInto(out *CloudDnsRecordSpec) {
 	*out = *in
+	out.ZoneRef = in.ZoneRef
+	if in.Rrdatas != nil {
+		in, out := &in.Rrdatas, &out.Rrdatas
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudDnsRecordSpec.
@@ -101,6 +108,13 @@ func (in *CloudDnsRecordSpec) DeepCopy() *CloudDnsRecordSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudDnsRecordStatus) DeepCopyInto(out *CloudDnsRecordStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudDnsRecordStatus.
@@ -118,7 +132,7 @@ func (in *CloudDnsZone) DeepCopyInto(out *CloudDnsZone) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -140,6 +154,21 @@ func (in *CloudDnsZone) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudDnsZoneCustomValidator) DeepCopyInto(out *CloudDnsZoneCustomValidator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudDnsZoneCustomValidator.
+func (in *CloudDnsZoneCustomValidator) DeepCopy() *CloudDnsZoneCustomValidator {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudDnsZoneCustomValidator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudDnsZoneList) DeepCopyInto(out *CloudDnsZoneList) {
 	*out = *in
@@ -172,10 +201,60 @@ func (in *CloudDnsZoneList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudDnsZoneReference) DeepCopyInto(out *CloudDnsZoneReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudDnsZoneReference.
+func (in *CloudDnsZoneReference) DeepCopy() *CloudDnsZoneReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudDnsZoneReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudDnsZoneSpec) DeepCopyInto(out *CloudDnsZoneSpec) {
 	*out = *in
-	out.DnsSecSpec = in.DnsSecSpec
+	if in.ForwardingSpec != nil {
+		in, out := &in.ForwardingSpec, &out.ForwardingSpec
+		*out = new(ForwardingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PeeringSpec != nil {
+		in, out := &in.PeeringSpec, &out.PeeringSpec
+		*out = new(PeeringSpec)
+		**out = **in
+	}
+	if in.PrivateNetworks != nil {
+		in, out := &in.PrivateNetworks, &out.PrivateNetworks
+		*out = make([]PrivateNetworkReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.DnsSecSpec.DeepCopyInto(&out.DnsSecSpec)
+	out.CloudLoggingSpec = in.CloudLoggingSpec
+	if in.ParentZoneRef != nil {
+		in, out := &in.ParentZoneRef, &out.ParentZoneRef
+		*out = new(CloudDnsZoneReference)
+		**out = **in
+	}
+	if in.ProviderConfigRef != nil {
+		in, out := &in.ProviderConfigRef, &out.ProviderConfigRef
+		*out = new(ProviderConfigReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudDnsZoneSpec.
@@ -196,6 +275,27 @@ func (in *CloudDnsZoneStatus) DeepCopyInto(out *CloudDnsZoneStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.OperationStartTime != nil {
+		in, out := &in.OperationStartTime, &out.OperationStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DnsKeys != nil {
+		in, out := &in.DnsKeys, &out.DnsKeys
+		*out = make([]DnsKeyStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Delegation != nil {
+		in, out := &in.Delegation, &out.Delegation
+		*out = new(ZoneDelegation)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudDnsZoneStatus.
@@ -208,6 +308,21 @@ func (in *CloudDnsZoneStatus) DeepCopy() *CloudDnsZoneStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudLoggingSpec) DeepCopyInto(out *CloudLoggingSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudLoggingSpec.
+func (in *CloudLoggingSpec) DeepCopy() *CloudLoggingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudLoggingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudRun) DeepCopyInto(out *CloudRun) {
 	*out = *in
@@ -260,6 +375,36 @@ func (in *CloudRunContainer) DeepCopy() *CloudRunContainer {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunCustomDefaulter) DeepCopyInto(out *CloudRunCustomDefaulter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunCustomDefaulter.
+func (in *CloudRunCustomDefaulter) DeepCopy() *CloudRunCustomDefaulter {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunCustomDefaulter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunCustomValidator) DeepCopyInto(out *CloudRunCustomValidator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunCustomValidator.
+func (in *CloudRunCustomValidator) DeepCopy() *CloudRunCustomValidator {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunCustomValidator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudRunList) DeepCopyInto(out *CloudRunList) {
 	*out = *in
@@ -295,6 +440,10 @@ func (in *CloudRunList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudRunOperation) DeepCopyInto(out *CloudRunOperation) {
 	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunOperation.
@@ -363,6 +512,7 @@ func (in *CloudRunSpec) DeepCopyInto(out *CloudRunSpec) {
 		*out = make([]CloudRunTraffic, len(*in))
 		copy(*out, *in)
 	}
+	out.TrafficMode = in.TrafficMode
 	if in.InvokeMembers != nil {
 		in, out := &in.InvokeMembers, &out.InvokeMembers
 		*out = make([]string, len(*in))
@@ -390,7 +540,7 @@ func (in *CloudRunStatus) DeepCopyInto(out *CloudRunStatus) {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(CloudRunOperation)
-				**out = **in
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
@@ -399,6 +549,13 @@ func (in *CloudRunStatus) DeepCopyInto(out *CloudRunStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunStatus.
@@ -426,9 +583,163 @@ func (in *CloudRunTraffic) DeepCopy() *CloudRunTraffic {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGcpProviderConfig) DeepCopyInto(out *ClusterGcpProviderConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGcpProviderConfig.
+func (in *ClusterGcpProviderConfig) DeepCopy() *ClusterGcpProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGcpProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterGcpProviderConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGcpProviderConfigList) DeepCopyInto(out *ClusterGcpProviderConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterGcpProviderConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGcpProviderConfigList.
+func (in *ClusterGcpProviderConfigList) DeepCopy() *ClusterGcpProviderConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGcpProviderConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterGcpProviderConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGcpProviderConfigSpec) DeepCopyInto(out *ClusterGcpProviderConfigSpec) {
+	*out = *in
+	in.GcpProviderConfigSpec.DeepCopyInto(&out.GcpProviderConfigSpec)
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGcpProviderConfigSpec.
+func (in *ClusterGcpProviderConfigSpec) DeepCopy() *ClusterGcpProviderConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGcpProviderConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsSecretSelector) DeepCopyInto(out *CredentialsSecretSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsSecretSelector.
+func (in *CredentialsSecretSelector) DeepCopy() *CredentialsSecretSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsSecretSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DnsKeyPublishSpec) DeepCopyInto(out *DnsKeyPublishSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DnsKeyPublishSpec.
+func (in *DnsKeyPublishSpec) DeepCopy() *DnsKeyPublishSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DnsKeyPublishSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DnsKeySpec) DeepCopyInto(out *DnsKeySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DnsKeySpec.
+func (in *DnsKeySpec) DeepCopy() *DnsKeySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DnsKeySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DnsKeyStatus) DeepCopyInto(out *DnsKeyStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DnsKeyStatus.
+func (in *DnsKeyStatus) DeepCopy() *DnsKeyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DnsKeyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DnsSecSpec) DeepCopyInto(out *DnsSecSpec) {
 	*out = *in
+	if in.KeySigningKeySpec != nil {
+		in, out := &in.KeySigningKeySpec, &out.KeySigningKeySpec
+		*out = new(DnsKeySpec)
+		**out = **in
+	}
+	if in.ZoneSigningKeySpec != nil {
+		in, out := &in.ZoneSigningKeySpec, &out.ZoneSigningKeySpec
+		*out = new(DnsKeySpec)
+		**out = **in
+	}
+	if in.PublishKeys != nil {
+		in, out := &in.PublishKeys, &out.PublishKeys
+		*out = new(DnsKeyPublishSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DnsSecSpec.
@@ -440,3 +751,350 @@ func (in *DnsSecSpec) DeepCopy() *DnsSecSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForwardingSpec) DeepCopyInto(out *ForwardingSpec) {
+	*out = *in
+	if in.TargetNameServers != nil {
+		in, out := &in.TargetNameServers, &out.TargetNameServers
+		*out = make([]ForwardingTarget, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForwardingSpec.
+func (in *ForwardingSpec) DeepCopy() *ForwardingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ForwardingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForwardingTarget) DeepCopyInto(out *ForwardingTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForwardingTarget.
+func (in *ForwardingTarget) DeepCopy() *ForwardingTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ForwardingTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GcpProjectBinding) DeepCopyInto(out *GcpProjectBinding) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GcpProjectBinding.
+func (in *GcpProjectBinding) DeepCopy() *GcpProjectBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(GcpProjectBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GcpProjectBinding) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GcpProjectBindingList) DeepCopyInto(out *GcpProjectBindingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GcpProjectBinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GcpProjectBindingList.
+func (in *GcpProjectBindingList) DeepCopy() *GcpProjectBindingList {
+	if in == nil {
+		return nil
+	}
+	out := new(GcpProjectBindingList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GcpProjectBindingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GcpProjectBindingSpec) DeepCopyInto(out *GcpProjectBindingSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Projects != nil {
+		in, out := &in.Projects, &out.Projects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Locations != nil {
+		in, out := &in.Locations, &out.Locations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GcpProjectBindingSpec.
+func (in *GcpProjectBindingSpec) DeepCopy() *GcpProjectBindingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GcpProjectBindingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GcpProviderConfig) DeepCopyInto(out *GcpProviderConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GcpProviderConfig.
+func (in *GcpProviderConfig) DeepCopy() *GcpProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GcpProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GcpProviderConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GcpProviderConfigList) DeepCopyInto(out *GcpProviderConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GcpProviderConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GcpProviderConfigList.
+func (in *GcpProviderConfigList) DeepCopy() *GcpProviderConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(GcpProviderConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GcpProviderConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GcpProviderConfigSpec) DeepCopyInto(out *GcpProviderConfigSpec) {
+	*out = *in
+	if in.ServiceAccountKeySecretRef != nil {
+		in, out := &in.ServiceAccountKeySecretRef, &out.ServiceAccountKeySecretRef
+		*out = new(CredentialsSecretSelector)
+		**out = **in
+	}
+	if in.WorkloadIdentityFederation != nil {
+		in, out := &in.WorkloadIdentityFederation, &out.WorkloadIdentityFederation
+		*out = new(WorkloadIdentityFederation)
+		**out = **in
+	}
+	if in.Impersonation != nil {
+		in, out := &in.Impersonation, &out.Impersonation
+		*out = new(Impersonation)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GcpProviderConfigSpec.
+func (in *GcpProviderConfigSpec) DeepCopy() *GcpProviderConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GcpProviderConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GkeClusterReference) DeepCopyInto(out *GkeClusterReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GkeClusterReference.
+func (in *GkeClusterReference) DeepCopy() *GkeClusterReference {
+	if in == nil {
+		return nil
+	}
+	out := new(GkeClusterReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Impersonation) DeepCopyInto(out *Impersonation) {
+	*out = *in
+	if in.Delegates != nil {
+		in, out := &in.Delegates, &out.Delegates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Impersonation.
+func (in *Impersonation) DeepCopy() *Impersonation {
+	if in == nil {
+		return nil
+	}
+	out := new(Impersonation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkReference) DeepCopyInto(out *NetworkReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkReference.
+func (in *NetworkReference) DeepCopy() *NetworkReference {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringSpec) DeepCopyInto(out *PeeringSpec) {
+	*out = *in
+	out.TargetNetwork = in.TargetNetwork
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringSpec.
+func (in *PeeringSpec) DeepCopy() *PeeringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrivateNetworkReference) DeepCopyInto(out *PrivateNetworkReference) {
+	*out = *in
+	if in.GkeCluster != nil {
+		in, out := &in.GkeCluster, &out.GkeCluster
+		*out = new(GkeClusterReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivateNetworkReference.
+func (in *PrivateNetworkReference) DeepCopy() *PrivateNetworkReference {
+	if in == nil {
+		return nil
+	}
+	out := new(PrivateNetworkReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfigReference) DeepCopyInto(out *ProviderConfigReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigReference.
+func (in *ProviderConfigReference) DeepCopy() *ProviderConfigReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfigReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentityFederation) DeepCopyInto(out *WorkloadIdentityFederation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentityFederation.
+func (in *WorkloadIdentityFederation) DeepCopy() *WorkloadIdentityFederation {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentityFederation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneDelegation) DeepCopyInto(out *ZoneDelegation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneDelegation.
+func (in *ZoneDelegation) DeepCopy() *ZoneDelegation {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneDelegation)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,23 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+// ExternalNameAnnotation is the name of the GCP resource managed by a resource, used instead of the
+// <namespace>-<name> stilas gives the resources it creates. It lets a resource manage a GCP resource
+// created outside of stilas, like the ones written by stilasctl import. Changing it makes the
+// resource manage another GCP resource, the previous one is left in place.
+const ExternalNameAnnotation = "stilas.418.cloud/external-name"
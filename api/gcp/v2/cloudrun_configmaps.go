@@ -0,0 +1,81 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// ConfigHashAnnotation is set on the revision template to the hash of the referenced ConfigMaps,
+// so a change to one of them rolls out a new revision
+const ConfigHashAnnotation = "stilas.418.cloud/config-hash"
+
+// ConfigMapData holds the data of the ConfigMaps referenced by a CloudRun, by ConfigMap name
+type ConfigMapData map[string]map[string]string
+
+// Hash returns a sha256 of the data, independent of map ordering
+func (d ConfigMapData) Hash() string {
+	h := sha256.New()
+	for _, name := range sortedKeys(d) {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		for _, key := range sortedKeys(d[name]) {
+			h.Write([]byte(key))
+			h.Write([]byte{0})
+			h.Write([]byte(d[name][key]))
+			h.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ConfigMapReferences returns the distinct names of the ConfigMaps referenced by the env
+// variables of the service
+func (c *CloudRun) ConfigMapReferences() []string {
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, container := range c.Spec.Containers {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				add(env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				add(envFrom.ConfigMapRef.Name)
+			}
+		}
+	}
+	return names
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("CloudRun ConfigMaps", func() {
+	var run *CloudRun
+	var config ConfigMapData
+
+	BeforeEach(func() {
+		run = &CloudRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-resource",
+				Namespace: "default",
+			},
+			Spec: CloudRunSpec{
+				Location:  "us-central1",
+				ProjectID: "test-project",
+				Containers: []CloudRunContainer{
+					{
+						Image: "gcr.io/test-project/test-image",
+						Name:  "test-container",
+						Env: []CloudRunEnvVar{
+							{Name: "CONFIG_MODE", Value: "explicit"},
+							{Name: "LOG_LEVEL", ValueFrom: &CloudRunEnvVarSource{
+								ConfigMapKeyRef: &CloudRunConfigMapKeySelector{Name: "logging", Key: "level"},
+							}},
+						},
+						EnvFrom: []CloudRunEnvFromSource{
+							{Prefix: "CONFIG_", ConfigMapRef: &CloudRunConfigMapEnvSource{Name: "app"}},
+						},
+					},
+				},
+			},
+		}
+		config = ConfigMapData{
+			"app":     {"MODE": "from-config-map", "REGION": "eu"},
+			"logging": {"level": "debug"},
+		}
+	})
+
+	It("Should return each referenced config map once", func() {
+		run.Spec.Containers[0].EnvFrom = append(run.Spec.Containers[0].EnvFrom,
+			CloudRunEnvFromSource{ConfigMapRef: &CloudRunConfigMapEnvSource{Name: "logging"}})
+		Expect(run.ConfigMapReferences()).To(Equal([]string{"logging", "app"}))
+	})
+
+	It("Should resolve env variables, letting env override envFrom", func() {
+		env := run.ConvertToCreateServiceRequest(config).Service.Template.Containers[0].Env
+		values := map[string]string{}
+		for _, e := range env {
+			values[e.Name] = e.GetValue()
+		}
+		Expect(env).To(HaveLen(3))
+		Expect(values).To(Equal(map[string]string{
+			"CONFIG_MODE":   "explicit",
+			"CONFIG_REGION": "eu",
+			"LOG_LEVEL":     "debug",
+		}))
+	})
+
+	It("Should change the revision config hash when a config map changes", func() {
+		annotations := run.ConvertToCreateServiceRequest(config).Service.Template.Annotations
+		Expect(annotations).To(HaveKeyWithValue(ConfigHashAnnotation, config.Hash()))
+		before := config.Hash()
+		config["app"]["REGION"] = "us"
+		Expect(config.Hash()).NotTo(Equal(before))
+	})
+
+	It("Should not set a config hash without config map references", func() {
+		run.Spec.Containers[0].Env = nil
+		run.Spec.Containers[0].EnvFrom = nil
+		Expect(run.ConvertToCreateServiceRequest(nil).Service.Template.Annotations).To(BeNil())
+	})
+})
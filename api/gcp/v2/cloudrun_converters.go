@@ -0,0 +1,314 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/run/apiv2/runpb"
+)
+
+var ingressTraffic = map[CloudRunIngress]runpb.IngressTraffic{
+	CloudRunIngress_All:                  runpb.IngressTraffic_INGRESS_TRAFFIC_ALL,
+	CloudRunIngress_Internal:             runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_ONLY,
+	CloudRunIngress_InternalLoadBalancer: runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER,
+}
+
+// IngressTraffic returns the runpb value of the ingress, INGRESS_TRAFFIC_UNSPECIFIED if unknown
+func (i CloudRunIngress) IngressTraffic() runpb.IngressTraffic {
+	return ingressTraffic[i]
+}
+
+// IngressFromTraffic returns the CloudRunIngress name of a runpb.IngressTraffic value
+func IngressFromTraffic(ingress runpb.IngressTraffic) (CloudRunIngress, bool) {
+	for name, value := range ingressTraffic {
+		if value == ingress {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ConvertToCreateServiceRequest builds the request creating the service, config holds the
+// ConfigMaps referenced by the containers and may be nil when there are none
+func (c *CloudRun) ConvertToCreateServiceRequest(config ConfigMapData) *runpb.CreateServiceRequest {
+	return &runpb.CreateServiceRequest{
+		Parent:    fmt.Sprintf("projects/%s/locations/%s", c.Spec.ProjectID, c.Spec.Location),
+		ServiceId: c.ServiceId(),
+		Service:   c.convertToService(config),
+	}
+}
+
+func (c *CloudRun) GetGcpCloudRunServiceFullName() string {
+	return fmt.Sprintf("projects/%s/locations/%s/services/%s", c.Spec.ProjectID, c.Spec.Location, c.ServiceId())
+}
+
+// ServiceId returns the id of the Cloud Run service, the external name annotation when set and
+// <namespace>-<name> otherwise
+func (c *CloudRun) ServiceId() string {
+	if name := c.Annotations[ExternalNameAnnotation]; name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s-%s", c.Namespace, c.Name)
+}
+
+func (c *CloudRun) convertToService(config ConfigMapData) *runpb.Service {
+	return &runpb.Service{
+		Ingress: c.Spec.Networking.Ingress.IngressTraffic(),
+		Traffic: c.convertToTrafficTarget(),
+		Template: &runpb.RevisionTemplate{
+			Annotations:                   c.convertToRevisionAnnotations(config),
+			Containers:                    c.convertToContainers(config),
+			Volumes:                       c.convertToVolumes(),
+			Scaling:                       c.Spec.Scaling.convertToRevisionScaling(),
+			MaxInstanceRequestConcurrency: c.Spec.Scaling.MaxConcurrency,
+			ServiceAccount:                c.Spec.Security.ServiceAccount,
+		},
+	}
+}
+
+func (c *CloudRun) convertToTrafficTarget() []*runpb.TrafficTarget {
+	var trafficTargets []*runpb.TrafficTarget
+	for _, traffic := range c.Spec.Traffic {
+		if traffic.LatestRevision {
+			trafficTargets = append(trafficTargets, &runpb.TrafficTarget{
+				Percent: traffic.Percent,
+				Type:    runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST,
+				Tag:     traffic.Tag,
+			})
+		} else {
+			trafficTargets = append(trafficTargets, &runpb.TrafficTarget{
+				Percent:  traffic.Percent,
+				Revision: traffic.Revision,
+				Type:     runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_REVISION,
+				Tag:      traffic.Tag,
+			})
+		}
+	}
+	return trafficTargets
+}
+
+func (c *CloudRun) convertToRevisionAnnotations(config ConfigMapData) map[string]string {
+	if len(c.ConfigMapReferences()) == 0 {
+		return nil
+	}
+	return map[string]string{ConfigHashAnnotation: config.Hash()}
+}
+
+// ConvertFromTrafficStatuses returns the status of the traffic targets served by a service
+func ConvertFromTrafficStatuses(statuses []*runpb.TrafficTargetStatus) []CloudRunTrafficStatus {
+	var traffic []CloudRunTrafficStatus
+	for _, status := range statuses {
+		traffic = append(traffic, CloudRunTrafficStatus{
+			Revision:       status.Revision,
+			Percent:        status.Percent,
+			LatestRevision: status.Type == runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST,
+			Tag:            status.Tag,
+			Uri:            status.Uri,
+		})
+	}
+	return traffic
+}
+
+func (c *CloudRun) convertToContainers(config ConfigMapData) []*runpb.Container {
+	containers := make([]*runpb.Container, 0, len(c.Spec.Containers))
+	for _, container := range c.Spec.Containers {
+		containers = append(containers, &runpb.Container{
+			Image: container.Image,
+			Name:  container.Name,
+			Ports: []*runpb.ContainerPort{
+				{
+					ContainerPort: container.Port,
+				},
+			},
+			LivenessProbe: container.LivenessProbe.convertToProbes(),
+			StartupProbe:  container.StartupProbe.convertToProbes(),
+			Env:           c.convertToEnv(container, config),
+			VolumeMounts:  convertToVolumeMounts(container.VolumeMounts),
+			Resources:     container.Resources.convertToResourceRequirements(),
+		})
+	}
+	return containers
+}
+
+func (c *CloudRun) convertToEnv(container CloudRunContainer, config ConfigMapData) []*runpb.EnvVar {
+	var envVars []*runpb.EnvVar
+	explicit := map[string]bool{}
+	for _, e := range container.Env {
+		explicit[e.Name] = true
+	}
+	for _, envFrom := range container.EnvFrom {
+		if envFrom.ConfigMapRef == nil {
+			continue
+		}
+		data := config[envFrom.ConfigMapRef.Name]
+		for _, key := range sortedKeys(data) {
+			name := envFrom.Prefix + key
+			if explicit[name] {
+				continue
+			}
+			explicit[name] = true
+			envVars = append(envVars, &runpb.EnvVar{
+				Name:   name,
+				Values: &runpb.EnvVar_Value{Value: data[key]},
+			})
+		}
+	}
+	for _, e := range container.Env {
+		if e.ValueFrom != nil && e.ValueFrom.ConfigMapKeyRef != nil {
+			ref := e.ValueFrom.ConfigMapKeyRef
+			envVars = append(envVars, &runpb.EnvVar{
+				Name:   e.Name,
+				Values: &runpb.EnvVar_Value{Value: config[ref.Name][ref.Key]},
+			})
+			continue
+		}
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+			envVars = append(envVars, &runpb.EnvVar{
+				Name: e.Name,
+				Values: &runpb.EnvVar_ValueSource{
+					ValueSource: &runpb.EnvVarSource{
+						SecretKeyRef: c.convertToSecretKeySelector(*e.ValueFrom.SecretKeyRef),
+					},
+				},
+			})
+			continue
+		}
+		envVars = append(envVars, &runpb.EnvVar{
+			Name:   e.Name,
+			Values: &runpb.EnvVar_Value{Value: e.Value},
+		})
+	}
+	return envVars
+}
+
+// convertToSecretKeySelector points at the Secret Manager version the key was synced to, the
+// reconciler syncs the Kubernetes Secrets before converting the service
+func (c *CloudRun) convertToSecretKeySelector(ref CloudRunSecretKeySelector) *runpb.SecretKeySelector {
+	selector := &runpb.SecretKeySelector{
+		Secret:  c.SecretManagerSecretId(ref),
+		Version: "latest",
+	}
+	if version, ok := c.SecretVersion(ref); ok {
+		selector.Version = version.Version
+	}
+	return selector
+}
+
+func (c *CloudRun) convertToVolumes() []*runpb.Volume {
+	var volumes []*runpb.Volume
+	for _, volume := range c.Spec.Volumes {
+		if volume.Secret == nil {
+			continue
+		}
+		selector := c.convertToSecretKeySelector(CloudRunSecretKeySelector{Name: volume.Secret.SecretName, Key: volume.Secret.Key})
+		path := volume.Secret.Path
+		if path == "" {
+			path = volume.Secret.Key
+		}
+		volumes = append(volumes, &runpb.Volume{
+			Name: volume.Name,
+			VolumeType: &runpb.Volume_Secret{
+				Secret: &runpb.SecretVolumeSource{
+					Secret: selector.Secret,
+					Items: []*runpb.VersionToPath{
+						{
+							Path:    path,
+							Version: selector.Version,
+						},
+					},
+				},
+			},
+		})
+	}
+	return volumes
+}
+
+func convertToVolumeMounts(mounts []CloudRunVolumeMount) []*runpb.VolumeMount {
+	var volumeMounts []*runpb.VolumeMount
+	for _, mount := range mounts {
+		volumeMounts = append(volumeMounts, &runpb.VolumeMount{
+			Name:      mount.Name,
+			MountPath: mount.MountPath,
+		})
+	}
+	return volumeMounts
+}
+
+func (r *CloudRunResources) convertToResourceRequirements() *runpb.ResourceRequirements {
+	if r == nil {
+		return nil
+	}
+	return &runpb.ResourceRequirements{Limits: r.Limits}
+}
+
+func (s CloudRunScaling) convertToRevisionScaling() *runpb.RevisionScaling {
+	if s.MinInstances == nil && s.MaxInstances == nil {
+		return nil
+	}
+	scaling := &runpb.RevisionScaling{}
+	if s.MinInstances != nil {
+		scaling.MinInstanceCount = *s.MinInstances
+	}
+	if s.MaxInstances != nil {
+		scaling.MaxInstanceCount = *s.MaxInstances
+	}
+	return scaling
+}
+
+func (p *CloudRunProbe) convertToProbes() *runpb.Probe {
+	if p == nil {
+		return nil
+	}
+	probe := &runpb.Probe{
+		InitialDelaySeconds: p.InitialDelaySeconds,
+		PeriodSeconds:       p.PeriodSeconds,
+		TimeoutSeconds:      p.TimeoutSeconds,
+		FailureThreshold:    p.FailureThreshold,
+	}
+	switch p.ProbeSpec.ProbeType {
+	case CloudRunProbeType_HTTPGet:
+		probe.ProbeType = &runpb.Probe_HttpGet{
+			HttpGet: &runpb.HTTPGetAction{
+				Path:        stringValue(p.ProbeSpec.Path),
+				HttpHeaders: nil,
+				Port:        p.ProbeSpec.Port,
+			},
+		}
+	case CloudRunProbeType_TCPSocket:
+		probe.ProbeType = &runpb.Probe_TcpSocket{
+			TcpSocket: &runpb.TCPSocketAction{
+				Port: p.ProbeSpec.Port,
+			},
+		}
+	case CloudRunProbeType_Grpc:
+		probe.ProbeType = &runpb.Probe_Grpc{
+			Grpc: &runpb.GRPCAction{
+				Port:    p.ProbeSpec.Port,
+				Service: stringValue(p.ProbeSpec.Service),
+			},
+		}
+	}
+	return probe
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
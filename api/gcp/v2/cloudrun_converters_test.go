@@ -0,0 +1,66 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"cloud.google.com/go/run/apiv2/runpb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("CloudRun Converters", func() {
+	run := &CloudRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-resource",
+			Namespace: "default",
+		},
+		Spec: CloudRunSpec{
+			Location:  "us-central1",
+			ProjectID: "test-project",
+			Containers: []CloudRunContainer{
+				{
+					Image: "gcr.io/test-project/test-image",
+					Name:  "test-container",
+				},
+			},
+			Traffic: []CloudRunTraffic{
+				{Percent: 100, Revision: "test-resource-00001"},
+				{Percent: 0, LatestRevision: true, Tag: "preview"},
+			},
+		},
+	}
+
+	It("Should set the tag of traffic targets", func() {
+		traffic := run.ConvertToCreateServiceRequest(nil).Service.Traffic
+		Expect(traffic).To(HaveLen(2))
+		Expect(traffic[0].Revision).To(Equal("test-resource-00001"))
+		Expect(traffic[0].Tag).To(BeEmpty())
+		Expect(traffic[1].Type).To(Equal(runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST))
+		Expect(traffic[1].Tag).To(Equal("preview"))
+	})
+
+	It("Should convert traffic statuses", func() {
+		Expect(ConvertFromTrafficStatuses([]*runpb.TrafficTargetStatus{
+			{Type: runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_REVISION, Revision: "test-resource-00001", Percent: 100},
+			{Type: runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST, Tag: "preview", Uri: "https://preview---test.run.app"},
+		})).To(Equal([]CloudRunTrafficStatus{
+			{Revision: "test-resource-00001", Percent: 100},
+			{LatestRevision: true, Tag: "preview", Uri: "https://preview---test.run.app"},
+		}))
+	})
+})
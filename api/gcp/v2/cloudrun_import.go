@@ -0,0 +1,204 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/run/apiv2/runpb"
+)
+
+// defaultRequestTimeout is the request timeout Cloud Run gives a revision without one
+const defaultRequestTimeout = 300 * time.Second
+
+// ConvertFromService returns the spec of a CloudRun managing srv, the reverse of
+// ConvertToCreateServiceRequest. The fields of srv a CloudRun can not express are left out and
+// listed in the warnings, a reconcile would remove them from the service. The invoke members are
+// part of the IAM policy of the service and are left empty.
+func ConvertFromService(srv *runpb.Service) (CloudRunSpec, []string) {
+	var warnings []string
+	warn := func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+	project, location, _ := parseServiceName(srv.GetName())
+	spec := CloudRunSpec{
+		ProjectID: project,
+		Location:  location,
+		Traffic:   convertFromTrafficTargets(srv.GetTraffic()),
+	}
+	if ingress, ok := IngressFromTraffic(srv.GetIngress()); ok {
+		spec.Networking.Ingress = ingress
+	}
+	if srv.GetBinaryAuthorization() != nil {
+		warn("binary authorization is not supported")
+	}
+	template := srv.GetTemplate()
+	convertFromTemplateSettings(template, warn)
+	spec.Security.ServiceAccount = template.GetServiceAccount()
+	spec.Scaling.MaxConcurrency = template.GetMaxInstanceRequestConcurrency()
+	if minInstances := template.GetScaling().GetMinInstanceCount(); minInstances != 0 {
+		spec.Scaling.MinInstances = &minInstances
+	}
+	if maxInstances := template.GetScaling().GetMaxInstanceCount(); maxInstances != 0 {
+		spec.Scaling.MaxInstances = &maxInstances
+	}
+	skippedVolumes := map[string]bool{}
+	for _, volume := range template.GetVolumes() {
+		skippedVolumes[volume.GetName()] = true
+		if volume.GetSecret() != nil {
+			warn("volume %s mounts Secret Manager secret %s, add the value to a Kubernetes Secret and mount it with a secret volume", volume.GetName(), volume.GetSecret().GetSecret())
+		} else {
+			warn("volume %s is not a secret volume, only secret volumes are supported", volume.GetName())
+		}
+	}
+	for _, container := range template.GetContainers() {
+		spec.Containers = append(spec.Containers, convertFromContainer(container, skippedVolumes, warn))
+	}
+	return spec, warnings
+}
+
+// convertFromTemplateSettings warns about the settings of the revision template a CloudRun leaves
+// to Cloud Run's defaults
+func convertFromTemplateSettings(template *runpb.RevisionTemplate, warn func(string, ...any)) {
+	for _, name := range sortedKeys(template.GetLabels()) {
+		warn("revision label %s is not supported", name)
+	}
+	for _, name := range sortedKeys(template.GetAnnotations()) {
+		if name != ConfigHashAnnotation {
+			warn("revision annotation %s is not supported", name)
+		}
+	}
+	if vpc := template.GetVpcAccess(); vpc.GetConnector() != "" || len(vpc.GetNetworkInterfaces()) > 0 {
+		warn("VPC access is not supported, the service gets no access to the VPC network")
+	}
+	if timeout := template.GetTimeout(); timeout != nil && timeout.AsDuration() != defaultRequestTimeout {
+		warn("the request timeout of %s is not supported, Cloud Run's default is used", timeout.AsDuration())
+	}
+	if env := template.GetExecutionEnvironment(); env != runpb.ExecutionEnvironment_EXECUTION_ENVIRONMENT_UNSPECIFIED {
+		warn("execution environment %s is not supported, Cloud Run's default is used", env)
+	}
+	if template.GetSessionAffinity() {
+		warn("session affinity is not supported")
+	}
+	if key := template.GetEncryptionKey(); key != "" {
+		warn("encryption key %s is not supported, the image is encrypted with a Google-managed key", key)
+	}
+}
+
+// parseServiceName splits projects/{project}/locations/{location}/services/{id}
+func parseServiceName(name string) (project string, location string, id string) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "locations" || parts[4] != "services" {
+		return "", "", ""
+	}
+	return parts[1], parts[3], parts[5]
+}
+
+// ServiceIdOf returns the id of a service from its full name
+func ServiceIdOf(name string) string {
+	_, _, id := parseServiceName(name)
+	return id
+}
+
+func convertFromTrafficTargets(targets []*runpb.TrafficTarget) []CloudRunTraffic {
+	var traffic []CloudRunTraffic
+	for _, target := range targets {
+		t := CloudRunTraffic{Percent: target.GetPercent(), Tag: target.GetTag()}
+		if target.GetType() == runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST {
+			t.LatestRevision = true
+		} else {
+			t.Revision = target.GetRevision()
+		}
+		traffic = append(traffic, t)
+	}
+	return traffic
+}
+
+func convertFromContainer(container *runpb.Container, skippedVolumes map[string]bool, warn func(string, ...any)) CloudRunContainer {
+	c := CloudRunContainer{
+		Name:          container.GetName(),
+		Image:         container.GetImage(),
+		LivenessProbe: convertFromProbe(container.GetLivenessProbe(), container.GetName(), warn),
+		StartupProbe:  convertFromProbe(container.GetStartupProbe(), container.GetName(), warn),
+	}
+	if len(container.GetCommand()) > 0 || len(container.GetArgs()) > 0 {
+		warn("the command and args of container %s are not supported, the entrypoint of the image is used", container.GetName())
+	}
+	if dir := container.GetWorkingDir(); dir != "" {
+		warn("the working directory %s of container %s is not supported", dir, container.GetName())
+	}
+	if limits := container.GetResources().GetLimits(); len(limits) > 0 {
+		c.Resources = &CloudRunResources{Limits: limits}
+	}
+	if ports := container.GetPorts(); len(ports) > 0 {
+		c.Port = ports[0].GetContainerPort()
+		if len(ports) > 1 {
+			warn("container %s has %d ports, only the first is kept", container.GetName(), len(ports))
+		}
+	}
+	for _, env := range container.GetEnv() {
+		if env.GetValueSource() != nil {
+			warn("env %s of container %s reads Secret Manager secret %s, add the value to a Kubernetes Secret and read it with secretKeyRef",
+				env.GetName(), container.GetName(), env.GetValueSource().GetSecretKeyRef().GetSecret())
+			continue
+		}
+		c.Env = append(c.Env, CloudRunEnvVar{Name: env.GetName(), Value: env.GetValue()})
+	}
+	for _, mount := range container.GetVolumeMounts() {
+		if skippedVolumes[mount.GetName()] {
+			continue
+		}
+		c.VolumeMounts = append(c.VolumeMounts, CloudRunVolumeMount{Name: mount.GetName(), MountPath: mount.GetMountPath()})
+	}
+	return c
+}
+
+func convertFromProbe(probe *runpb.Probe, container string, warn func(string, ...any)) *CloudRunProbe {
+	if probe == nil {
+		return nil
+	}
+	p := &CloudRunProbe{
+		InitialDelaySeconds: probe.GetInitialDelaySeconds(),
+		TimeoutSeconds:      probe.GetTimeoutSeconds(),
+		PeriodSeconds:       probe.GetPeriodSeconds(),
+		FailureThreshold:    probe.GetFailureThreshold(),
+	}
+	switch t := probe.GetProbeType().(type) {
+	case *runpb.Probe_HttpGet:
+		p.ProbeSpec = CloudRunProbeSpec{ProbeType: CloudRunProbeType_HTTPGet, Port: t.HttpGet.GetPort(), Path: stringPointer(t.HttpGet.GetPath())}
+		if len(t.HttpGet.GetHttpHeaders()) > 0 {
+			warn("the HTTP probe of container %s sends headers, they are not supported", container)
+		}
+	case *runpb.Probe_TcpSocket:
+		p.ProbeSpec = CloudRunProbeSpec{ProbeType: CloudRunProbeType_TCPSocket, Port: t.TcpSocket.GetPort()}
+	case *runpb.Probe_Grpc:
+		p.ProbeSpec = CloudRunProbeSpec{ProbeType: CloudRunProbeType_Grpc, Port: t.Grpc.GetPort(), Service: stringPointer(t.Grpc.GetService())}
+	default:
+		warn("a probe of container %s has no supported type", container)
+		return nil
+	}
+	return p
+}
+
+func stringPointer(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
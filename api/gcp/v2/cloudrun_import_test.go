@@ -0,0 +1,155 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"time"
+
+	"cloud.google.com/go/run/apiv2/runpb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("CloudRun import", func() {
+	original := &CloudRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+		Spec: CloudRunSpec{
+			Location:  "europe-north1",
+			ProjectID: "test-project",
+			Containers: []CloudRunContainer{{
+				Name:      "app",
+				Image:     "europe-docker.pkg.dev/test-project/app/hello:1.0",
+				Port:      8080,
+				Env:       []CloudRunEnvVar{{Name: "LOG_LEVEL", Value: "info"}},
+				Resources: &CloudRunResources{Limits: map[string]string{"cpu": "1", "memory": "512Mi"}},
+				LivenessProbe: &CloudRunProbe{
+					ProbeSpec:      CloudRunProbeSpec{ProbeType: CloudRunProbeType_HTTPGet, Port: 8080, Path: ptr.To("/healthz")},
+					TimeoutSeconds: 5, PeriodSeconds: 10, FailureThreshold: 3,
+				},
+				StartupProbe: &CloudRunProbe{
+					ProbeSpec:      CloudRunProbeSpec{ProbeType: CloudRunProbeType_TCPSocket, Port: 8080},
+					TimeoutSeconds: 5, PeriodSeconds: 10, FailureThreshold: 3,
+				},
+			}},
+			Traffic: []CloudRunTraffic{
+				{Percent: 90, Revision: "hello-00001"},
+				{Percent: 10, LatestRevision: true, Tag: "canary"},
+			},
+			Scaling:    CloudRunScaling{MinInstances: ptr.To[int32](1), MaxInstances: ptr.To[int32](5), MaxConcurrency: 80},
+			Networking: CloudRunNetworking{Ingress: CloudRunIngress_Internal},
+			Security:   CloudRunSecurity{ServiceAccount: "hello@test-project.iam.gserviceaccount.com"},
+		},
+	}
+
+	// live returns the service Cloud Run serves for a CloudRun, with the output only fields set
+	live := func(run *CloudRun) *runpb.Service {
+		srv := run.ConvertToCreateServiceRequest(nil).Service
+		srv.Name = run.GetGcpCloudRunServiceFullName()
+		srv.Uri = "https://hello-abc-lz.a.run.app"
+		srv.LatestReadyRevision = run.GetGcpCloudRunServiceFullName() + "/revisions/hello-00002"
+		return srv
+	}
+
+	It("Should give back an equivalent request when the imported service is rendered again", func() {
+		srv := live(original)
+		spec, warnings := ConvertFromService(srv)
+		Expect(warnings).To(BeEmpty())
+		imported := &CloudRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "imported",
+				Namespace:   "other",
+				Annotations: map[string]string{ExternalNameAnnotation: ServiceIdOf(srv.Name)},
+			},
+			Spec: spec,
+		}
+		Expect(imported.GetGcpCloudRunServiceFullName()).To(Equal(original.GetGcpCloudRunServiceFullName()))
+		Expect(proto.Equal(imported.ConvertToCreateServiceRequest(nil), original.ConvertToCreateServiceRequest(nil))).To(BeTrue())
+	})
+
+	It("Should warn about the Secret Manager secrets it can not import", func() {
+		srv := live(original)
+		srv.Template.Containers[0].Env = append(srv.Template.Containers[0].Env, &runpb.EnvVar{
+			Name: "PASSWORD",
+			Values: &runpb.EnvVar_ValueSource{ValueSource: &runpb.EnvVarSource{
+				SecretKeyRef: &runpb.SecretKeySelector{Secret: "db-password", Version: "latest"},
+			}},
+		})
+		srv.Template.Volumes = []*runpb.Volume{{
+			Name:       "credentials",
+			VolumeType: &runpb.Volume_Secret{Secret: &runpb.SecretVolumeSource{Secret: "credentials"}},
+		}}
+		srv.Template.Containers[0].VolumeMounts = []*runpb.VolumeMount{{Name: "credentials", MountPath: "/etc/credentials"}}
+
+		spec, warnings := ConvertFromService(srv)
+		Expect(warnings).To(HaveLen(2))
+		Expect(warnings[0]).To(ContainSubstring("volume credentials mounts Secret Manager secret credentials"))
+		Expect(warnings[1]).To(ContainSubstring("env PASSWORD of container app reads Secret Manager secret db-password"))
+		Expect(spec.Containers[0].Env).To(Equal([]CloudRunEnvVar{{Name: "LOG_LEVEL", Value: "info"}}))
+		Expect(spec.Containers[0].VolumeMounts).To(BeEmpty())
+		Expect(spec.Volumes).To(BeEmpty())
+	})
+
+	It("Should warn about the settings it leaves to Cloud Run's defaults", func() {
+		srv := live(original)
+		srv.BinaryAuthorization = &runpb.BinaryAuthorization{BinauthzMethod: &runpb.BinaryAuthorization_UseDefault{UseDefault: true}}
+		srv.Template.Labels = map[string]string{"team": "web"}
+		srv.Template.Annotations = map[string]string{ConfigHashAnnotation: "abc", "run.googleapis.com/cpu-throttling": "false"}
+		srv.Template.VpcAccess = &runpb.VpcAccess{Connector: "projects/test-project/locations/europe-north1/connectors/vpc"}
+		srv.Template.Timeout = durationpb.New(5 * time.Minute)
+		srv.Template.ExecutionEnvironment = runpb.ExecutionEnvironment_EXECUTION_ENVIRONMENT_GEN2
+		srv.Template.SessionAffinity = true
+		srv.Template.EncryptionKey = "projects/test-project/locations/europe-north1/keyRings/run/cryptoKeys/images"
+		srv.Template.Containers[0].Command = []string{"/hello"}
+		srv.Template.Containers[0].Args = []string{"--verbose"}
+		srv.Template.Containers[0].WorkingDir = "/srv"
+
+		spec, warnings := ConvertFromService(srv)
+		Expect(warnings).To(Equal([]string{
+			"binary authorization is not supported",
+			"revision label team is not supported",
+			"revision annotation run.googleapis.com/cpu-throttling is not supported",
+			"VPC access is not supported, the service gets no access to the VPC network",
+			"execution environment EXECUTION_ENVIRONMENT_GEN2 is not supported, Cloud Run's default is used",
+			"session affinity is not supported",
+			"encryption key projects/test-project/locations/europe-north1/keyRings/run/cryptoKeys/images is not supported, the image is encrypted with a Google-managed key",
+			"the command and args of container app are not supported, the entrypoint of the image is used",
+			"the working directory /srv of container app is not supported",
+		}))
+
+		srv.Template.Timeout = durationpb.New(time.Minute)
+		_, warnings = ConvertFromService(srv)
+		Expect(warnings).To(ContainElement("the request timeout of 1m0s is not supported, Cloud Run's default is used"))
+
+		imported := &CloudRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+			Spec:       spec,
+		}
+		Expect(proto.Equal(imported.ConvertToCreateServiceRequest(nil), original.ConvertToCreateServiceRequest(nil))).To(BeTrue())
+	})
+
+	It("Should name the service after the external name annotation", func() {
+		run := original.DeepCopy()
+		Expect(run.ServiceId()).To(Equal("default-hello"))
+		run.Annotations = map[string]string{ExternalNameAnnotation: "legacy-hello"}
+		Expect(run.GetGcpCloudRunServiceFullName()).To(Equal("projects/test-project/locations/europe-north1/services/legacy-hello"))
+		Expect(run.ConvertToCreateServiceRequest(nil).ServiceId).To(Equal("legacy-hello"))
+	})
+})
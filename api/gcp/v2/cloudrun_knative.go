@@ -0,0 +1,340 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// KnativeServingAPIVersion is the apiVersion of the Knative Services ConvertFromKnativeService reads
+	KnativeServingAPIVersion = "serving.knative.dev/v1"
+	// KnativeServiceKind is the kind of a Knative Service
+	KnativeServiceKind = "Service"
+
+	knativeAutoscalingPrefix = "autoscaling.knative.dev/"
+	knativeVisibilityLabel   = "networking.knative.dev/visibility"
+	cloudRunIngressKey       = "run.googleapis.com/ingress"
+)
+
+// knativeScaleAnnotations are the names of the min and max scale annotations, the old camel case
+// names are still accepted by Knative
+var (
+	knativeMinScaleAnnotations = []string{knativeAutoscalingPrefix + "min-scale", knativeAutoscalingPrefix + "minScale"}
+	knativeMaxScaleAnnotations = []string{knativeAutoscalingPrefix + "max-scale", knativeAutoscalingPrefix + "maxScale"}
+)
+
+// knativeIngress maps the values of the run.googleapis.com/ingress annotation of the Knative shaped
+// Cloud Run v1 API to CloudRunIngress
+var knativeIngress = map[string]CloudRunIngress{
+	"all":                               CloudRunIngress_All,
+	"internal":                          CloudRunIngress_Internal,
+	"internal-and-cloud-load-balancing": CloudRunIngress_InternalLoadBalancer,
+}
+
+// KnativeService is the part of a serving.knative.dev/v1 Service read by ConvertFromKnativeService.
+// The metadata is a named field so the type is not taken for a kind of this API group.
+// +kubebuilder:object:generate=false
+type KnativeService struct {
+	metav1.TypeMeta `json:",inline"`
+	Metadata        metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec KnativeServiceSpec `json:"spec,omitempty"`
+}
+
+// KnativeServiceSpec is the spec of a Knative Service
+// +kubebuilder:object:generate=false
+type KnativeServiceSpec struct {
+	Template KnativeRevisionTemplate `json:"template,omitempty"`
+	Traffic  []KnativeTrafficTarget  `json:"traffic,omitempty"`
+}
+
+// KnativeRevisionTemplate is the template of the revisions of a Knative Service
+// +kubebuilder:object:generate=false
+type KnativeRevisionTemplate struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec KnativeRevisionSpec `json:"spec,omitempty"`
+}
+
+// KnativeRevisionSpec is the pod spec of a revision with the Knative fields
+// +kubebuilder:object:generate=false
+type KnativeRevisionSpec struct {
+	corev1.PodSpec `json:",inline"`
+
+	ContainerConcurrency *int64 `json:"containerConcurrency,omitempty"`
+	TimeoutSeconds       *int64 `json:"timeoutSeconds,omitempty"`
+}
+
+// KnativeTrafficTarget is a traffic target of a Knative Service
+// +kubebuilder:object:generate=false
+type KnativeTrafficTarget struct {
+	Tag               string `json:"tag,omitempty"`
+	RevisionName      string `json:"revisionName,omitempty"`
+	ConfigurationName string `json:"configurationName,omitempty"`
+	LatestRevision    *bool  `json:"latestRevision,omitempty"`
+	Percent           *int64 `json:"percent,omitempty"`
+}
+
+// ConvertFromKnativeService returns the spec of a CloudRun in project and location running the
+// revisions of ksvc. The containers, env, resources, traffic and the autoscaling annotations are
+// converted, the fields a CloudRun can not express are left out and listed in the warnings.
+func ConvertFromKnativeService(ksvc *KnativeService, project string, location string) (CloudRunSpec, []string) {
+	var warnings []string
+	warn := func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+	template := ksvc.Spec.Template
+	spec := CloudRunSpec{
+		ProjectID: project,
+		Location:  location,
+		Traffic:   convertFromKnativeTraffic(ksvc.Spec.Traffic, warn),
+		Scaling:   convertFromKnativeScaling(template.Annotations, template.Spec.ContainerConcurrency, warn),
+		Security:  CloudRunSecurity{ServiceAccount: template.Spec.ServiceAccountName},
+	}
+	if ingress, ok := ksvc.Metadata.Annotations[cloudRunIngressKey]; ok {
+		if spec.Networking.Ingress, ok = knativeIngress[ingress]; !ok {
+			warn("ingress %s is not supported", ingress)
+		}
+	}
+	if ksvc.Metadata.Labels[knativeVisibilityLabel] == "cluster-local" {
+		spec.Networking.Ingress = CloudRunIngress_Internal
+	}
+	if template.Spec.TimeoutSeconds != nil {
+		warn("the request timeout of %d seconds is not supported, Cloud Run's default is used", *template.Spec.TimeoutSeconds)
+	}
+	if len(template.Spec.InitContainers) > 0 {
+		warn("init containers are not supported")
+	}
+	if len(template.Spec.ImagePullSecrets) > 0 {
+		warn("image pull secrets are not supported, grant the service account access to the registry")
+	}
+	if template.Spec.NodeSelector != nil || template.Spec.Affinity != nil || len(template.Spec.Tolerations) > 0 {
+		warn("scheduling constraints are not supported")
+	}
+	volumes := map[string]bool{}
+	for _, volume := range template.Spec.Volumes {
+		if v, ok := convertFromKnativeVolume(volume, warn); ok {
+			spec.Volumes = append(spec.Volumes, v)
+			volumes[volume.Name] = true
+		}
+	}
+	for i, container := range template.Spec.Containers {
+		name := container.Name
+		if name == "" {
+			name = ksvc.Metadata.Name
+			if i > 0 {
+				name = fmt.Sprintf("%s-%d", ksvc.Metadata.Name, i)
+			}
+		}
+		spec.Containers = append(spec.Containers, convertFromKnativeContainer(container, name, volumes, warn))
+	}
+	return spec, warnings
+}
+
+func convertFromKnativeTraffic(targets []KnativeTrafficTarget, warn func(string, ...any)) []CloudRunTraffic {
+	var traffic []CloudRunTraffic
+	for _, target := range targets {
+		t := CloudRunTraffic{Tag: target.Tag}
+		if target.Percent != nil {
+			t.Percent = int32(*target.Percent)
+		}
+		switch {
+		case target.RevisionName != "":
+			t.Revision = target.RevisionName
+		case target.LatestRevision == nil || *target.LatestRevision:
+			t.LatestRevision = true
+		default:
+			warn("the traffic target of configuration %s is not supported", target.ConfigurationName)
+			continue
+		}
+		traffic = append(traffic, t)
+	}
+	return traffic
+}
+
+func convertFromKnativeScaling(annotations map[string]string, concurrency *int64, warn func(string, ...any)) CloudRunScaling {
+	scaling := CloudRunScaling{}
+	if concurrency != nil {
+		scaling.MaxConcurrency = int32(*concurrency)
+	}
+	scale := func(names []string) *int32 {
+		for _, name := range names {
+			value, ok := annotations[name]
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				warn("annotation %s is not a number: %s", name, value)
+				return nil
+			}
+			instances := int32(n)
+			return &instances
+		}
+		return nil
+	}
+	scaling.MinInstances = scale(knativeMinScaleAnnotations)
+	scaling.MaxInstances = scale(knativeMaxScaleAnnotations)
+	var unsupported []string
+	for name := range annotations {
+		if strings.HasPrefix(name, knativeAutoscalingPrefix) && !slices.Contains(knativeMinScaleAnnotations, name) && !slices.Contains(knativeMaxScaleAnnotations, name) {
+			unsupported = append(unsupported, name)
+		}
+	}
+	slices.Sort(unsupported)
+	for _, name := range unsupported {
+		warn("annotation %s is not supported, Cloud Run scales on concurrency", name)
+	}
+	return scaling
+}
+
+// convertFromKnativeVolume converts a secret volume with a single key, Cloud Run mounts one secret
+// version per volume
+func convertFromKnativeVolume(volume corev1.Volume, warn func(string, ...any)) (CloudRunVolume, bool) {
+	secret := volume.Secret
+	if secret == nil {
+		warn("volume %s is not a secret volume, only secret volumes are supported", volume.Name)
+		return CloudRunVolume{}, false
+	}
+	if len(secret.Items) != 1 {
+		warn("volume %s mounts all keys of Secret %s, list the one key to mount in items", volume.Name, secret.SecretName)
+		return CloudRunVolume{}, false
+	}
+	item := secret.Items[0]
+	source := &CloudRunSecretVolumeSource{SecretName: secret.SecretName, Key: item.Key}
+	if item.Path != item.Key {
+		source.Path = item.Path
+	}
+	return CloudRunVolume{Name: volume.Name, Secret: source}, true
+}
+
+func convertFromKnativeContainer(container corev1.Container, name string, volumes map[string]bool, warn func(string, ...any)) CloudRunContainer {
+	c := CloudRunContainer{
+		Name:          name,
+		Image:         container.Image,
+		LivenessProbe: convertFromKnativeProbe(container.LivenessProbe, "liveness", name, warn),
+		StartupProbe:  convertFromKnativeProbe(container.StartupProbe, "startup", name, warn),
+	}
+	if len(container.Command) > 0 || len(container.Args) > 0 {
+		warn("the command and args of container %s are not supported, the entrypoint of the image is used", name)
+	}
+	if container.ReadinessProbe != nil {
+		warn("the readiness probe of container %s is not supported", name)
+	}
+	if ports := container.Ports; len(ports) > 0 {
+		c.Port = ports[0].ContainerPort
+		if ports[0].Name == "h2c" {
+			warn("container %s serves HTTP/2 without TLS, which is not supported", name)
+		}
+		if len(ports) > 1 {
+			warn("container %s has %d ports, only the first is kept", name, len(ports))
+		}
+	}
+	if len(container.Resources.Limits) > 0 {
+		c.Resources = &CloudRunResources{Limits: map[string]string{}}
+		for resource, quantity := range container.Resources.Limits {
+			c.Resources.Limits[string(resource)] = quantity.String()
+		}
+	}
+	if len(container.Resources.Requests) > 0 {
+		warn("the resource requests of container %s are not supported, Cloud Run only has limits", name)
+	}
+	for _, env := range container.Env {
+		if e, ok := convertFromKnativeEnv(env, name, warn); ok {
+			c.Env = append(c.Env, e)
+		}
+	}
+	for _, envFrom := range container.EnvFrom {
+		if envFrom.ConfigMapRef == nil {
+			warn("container %s reads env from a Secret, only ConfigMaps are supported, use secretKeyRef per key", name)
+			continue
+		}
+		c.EnvFrom = append(c.EnvFrom, CloudRunEnvFromSource{
+			Prefix:       envFrom.Prefix,
+			ConfigMapRef: &CloudRunConfigMapEnvSource{Name: envFrom.ConfigMapRef.Name},
+		})
+	}
+	for _, mount := range container.VolumeMounts {
+		if !volumes[mount.Name] {
+			continue
+		}
+		c.VolumeMounts = append(c.VolumeMounts, CloudRunVolumeMount{Name: mount.Name, MountPath: mount.MountPath})
+	}
+	return c
+}
+
+func convertFromKnativeEnv(env corev1.EnvVar, container string, warn func(string, ...any)) (CloudRunEnvVar, bool) {
+	e := CloudRunEnvVar{Name: env.Name, Value: env.Value}
+	switch {
+	case env.ValueFrom == nil:
+	case env.ValueFrom.SecretKeyRef != nil:
+		ref := env.ValueFrom.SecretKeyRef
+		e.ValueFrom = &CloudRunEnvVarSource{SecretKeyRef: &CloudRunSecretKeySelector{Name: ref.Name, Key: ref.Key}}
+	case env.ValueFrom.ConfigMapKeyRef != nil:
+		ref := env.ValueFrom.ConfigMapKeyRef
+		e.ValueFrom = &CloudRunEnvVarSource{ConfigMapKeyRef: &CloudRunConfigMapKeySelector{Name: ref.Name, Key: ref.Key}}
+	default:
+		warn("env %s of container %s reads a field of the pod, which is not supported", env.Name, container)
+		return CloudRunEnvVar{}, false
+	}
+	return e, true
+}
+
+func convertFromKnativeProbe(probe *corev1.Probe, kind string, container string, warn func(string, ...any)) *CloudRunProbe {
+	if probe == nil {
+		return nil
+	}
+	p := &CloudRunProbe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+	port := func(port intstr.IntOrString) int32 {
+		if port.Type == intstr.String {
+			warn("the %s probe of container %s uses the named port %s, the container port is used", kind, container, port.StrVal)
+			return 0
+		}
+		return port.IntVal
+	}
+	switch {
+	case probe.HTTPGet != nil:
+		p.ProbeSpec = CloudRunProbeSpec{ProbeType: CloudRunProbeType_HTTPGet, Port: port(probe.HTTPGet.Port), Path: stringPointer(probe.HTTPGet.Path)}
+		if len(probe.HTTPGet.HTTPHeaders) > 0 {
+			warn("the %s probe of container %s sends headers, they are not supported", kind, container)
+		}
+	case probe.TCPSocket != nil:
+		p.ProbeSpec = CloudRunProbeSpec{ProbeType: CloudRunProbeType_TCPSocket, Port: port(probe.TCPSocket.Port)}
+	case probe.GRPC != nil:
+		p.ProbeSpec = CloudRunProbeSpec{ProbeType: CloudRunProbeType_Grpc, Port: probe.GRPC.Port, Service: probe.GRPC.Service}
+	default:
+		warn("the %s probe of container %s runs a command, which is not supported", kind, container)
+		return nil
+	}
+	if probe.SuccessThreshold > 1 {
+		warn("the success threshold of the %s probe of container %s is not supported", kind, container)
+	}
+	return p
+}
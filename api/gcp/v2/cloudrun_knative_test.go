@@ -0,0 +1,148 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("Knative Service conversion", func() {
+	var ksvc *KnativeService
+
+	BeforeEach(func() {
+		ksvc = &KnativeService{
+			Metadata: metav1.ObjectMeta{
+				Name:        "hello",
+				Annotations: map[string]string{"run.googleapis.com/ingress": "internal-and-cloud-load-balancing"},
+			},
+			Spec: KnativeServiceSpec{
+				Template: KnativeRevisionTemplate{
+					ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+						"autoscaling.knative.dev/min-scale": "1",
+						"autoscaling.knative.dev/maxScale":  "10",
+					}},
+					Spec: KnativeRevisionSpec{
+						ContainerConcurrency: ptr.To[int64](80),
+						PodSpec: corev1.PodSpec{
+							ServiceAccountName: "hello@test-project.iam.gserviceaccount.com",
+							Containers: []corev1.Container{{
+								Image: "europe-docker.pkg.dev/test-project/app/hello:1.0",
+								Ports: []corev1.ContainerPort{{ContainerPort: 8080}},
+								Env: []corev1.EnvVar{
+									{Name: "LOG_LEVEL", Value: "info"},
+									{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+										LocalObjectReference: corev1.LocalObjectReference{Name: "hello"}, Key: "password",
+									}}},
+								},
+								Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("1"),
+									corev1.ResourceMemory: resource.MustParse("512Mi"),
+								}},
+								StartupProbe: &corev1.Probe{
+									ProbeHandler:  corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromInt32(8080)}},
+									PeriodSeconds: 5,
+								},
+							}},
+						},
+					},
+				},
+				Traffic: []KnativeTrafficTarget{
+					{RevisionName: "hello-00001", Percent: ptr.To[int64](90)},
+					{LatestRevision: ptr.To(true), Percent: ptr.To[int64](10), Tag: "next"},
+				},
+			},
+		}
+	})
+
+	It("Should convert the containers, traffic and autoscaling", func() {
+		spec, warnings := ConvertFromKnativeService(ksvc, "test-project", "europe-north1")
+		Expect(warnings).To(BeEmpty())
+		Expect(spec).To(Equal(CloudRunSpec{
+			ProjectID: "test-project",
+			Location:  "europe-north1",
+			Containers: []CloudRunContainer{{
+				Name:  "hello",
+				Image: "europe-docker.pkg.dev/test-project/app/hello:1.0",
+				Port:  8080,
+				Env: []CloudRunEnvVar{
+					{Name: "LOG_LEVEL", Value: "info"},
+					{Name: "PASSWORD", ValueFrom: &CloudRunEnvVarSource{SecretKeyRef: &CloudRunSecretKeySelector{Name: "hello", Key: "password"}}},
+				},
+				Resources: &CloudRunResources{Limits: map[string]string{"cpu": "1", "memory": "512Mi"}},
+				StartupProbe: &CloudRunProbe{
+					ProbeSpec:     CloudRunProbeSpec{ProbeType: CloudRunProbeType_HTTPGet, Port: 8080, Path: ptr.To("/ready")},
+					PeriodSeconds: 5,
+				},
+			}},
+			Traffic: []CloudRunTraffic{
+				{Revision: "hello-00001", Percent: 90},
+				{LatestRevision: true, Percent: 10, Tag: "next"},
+			},
+			Scaling:    CloudRunScaling{MinInstances: ptr.To[int32](1), MaxInstances: ptr.To[int32](10), MaxConcurrency: 80},
+			Networking: CloudRunNetworking{Ingress: CloudRunIngress_InternalLoadBalancer},
+			Security:   CloudRunSecurity{ServiceAccount: "hello@test-project.iam.gserviceaccount.com"},
+		}))
+	})
+
+	It("Should warn about the fields it can not convert", func() {
+		ksvc.Spec.Template.Annotations["autoscaling.knative.dev/target"] = "70"
+		ksvc.Spec.Template.Spec.TimeoutSeconds = ptr.To[int64](300)
+		container := &ksvc.Spec.Template.Spec.Containers[0]
+		container.Args = []string{"--verbose"}
+		container.ReadinessProbe = &corev1.Probe{}
+		container.Env = append(container.Env, corev1.EnvVar{Name: "POD", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}}})
+		ksvc.Spec.Template.Spec.Volumes = []corev1.Volume{{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}}}
+
+		spec, warnings := ConvertFromKnativeService(ksvc, "test-project", "europe-north1")
+		Expect(warnings).To(ConsistOf(
+			ContainSubstring("autoscaling.knative.dev/target"),
+			ContainSubstring("request timeout of 300 seconds"),
+			ContainSubstring("command and args of container hello"),
+			ContainSubstring("readiness probe of container hello"),
+			ContainSubstring("env POD of container hello"),
+			ContainSubstring("volume config is not a secret volume"),
+		))
+		Expect(spec.Containers[0].Env).To(HaveLen(2))
+		Expect(spec.Volumes).To(BeEmpty())
+	})
+
+	It("Should convert a secret volume with a single key", func() {
+		ksvc.Spec.Template.Spec.Volumes = []corev1.Volume{{Name: "credentials", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+			SecretName: "hello",
+			Items:      []corev1.KeyToPath{{Key: "credentials.json", Path: "credentials.json"}},
+		}}}}
+		ksvc.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "credentials", MountPath: "/secrets"}}
+
+		spec, warnings := ConvertFromKnativeService(ksvc, "test-project", "europe-north1")
+		Expect(warnings).To(BeEmpty())
+		Expect(spec.Volumes).To(Equal([]CloudRunVolume{{Name: "credentials", Secret: &CloudRunSecretVolumeSource{SecretName: "hello", Key: "credentials.json"}}}))
+		Expect(spec.Containers[0].VolumeMounts).To(Equal([]CloudRunVolumeMount{{Name: "credentials", MountPath: "/secrets"}}))
+	})
+
+	It("Should make a cluster-local service internal", func() {
+		ksvc.Metadata.Annotations = nil
+		ksvc.Metadata.Labels = map[string]string{"networking.knative.dev/visibility": "cluster-local"}
+		spec, _ := ConvertFromKnativeService(ksvc, "test-project", "europe-north1")
+		Expect(spec.Networking.Ingress).To(Equal(CloudRunIngress_Internal))
+	})
+})
@@ -0,0 +1,77 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+)
+
+// maxSecretIdLength is the maximum length of a Secret Manager secret id
+const maxSecretIdLength = 255
+
+var invalidSecretIdChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// SecretReferences returns the distinct keys of Kubernetes Secrets referenced by the env
+// variables and volumes of the service
+func (c *CloudRun) SecretReferences() []CloudRunSecretKeySelector {
+	var refs []CloudRunSecretKeySelector
+	seen := map[CloudRunSecretKeySelector]bool{}
+	add := func(ref CloudRunSecretKeySelector) {
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	for _, container := range c.Spec.Containers {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				add(*env.ValueFrom.SecretKeyRef)
+			}
+		}
+	}
+	for _, volume := range c.Spec.Volumes {
+		if volume.Secret != nil {
+			add(CloudRunSecretKeySelector{Name: volume.Secret.SecretName, Key: volume.Secret.Key})
+		}
+	}
+	return refs
+}
+
+// SecretManagerSecretId returns the id of the Secret Manager secret a key of a Kubernetes
+// Secret is synced to. The id is unique per CloudRun, Kubernetes Secret and key.
+func (c *CloudRun) SecretManagerSecretId(ref CloudRunSecretKeySelector) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s/%s", c.Namespace, c.Name, ref.Name, ref.Key)))
+	suffix := hex.EncodeToString(hash[:])[:8]
+	id := invalidSecretIdChars.ReplaceAllString(fmt.Sprintf("stilas-%s-%s-%s-%s", c.Namespace, c.Name, ref.Name, ref.Key), "_")
+	if len(id) > maxSecretIdLength-len(suffix)-1 {
+		id = id[:maxSecretIdLength-len(suffix)-1]
+	}
+	return fmt.Sprintf("%s-%s", id, suffix)
+}
+
+// SecretVersion returns the Secret Manager version a key of a Kubernetes Secret was last synced to
+func (c *CloudRun) SecretVersion(ref CloudRunSecretKeySelector) (CloudRunSecretVersion, bool) {
+	for _, version := range c.Status.SecretVersions {
+		if version.SecretName == ref.Name && version.Key == ref.Key {
+			return version, true
+		}
+	}
+	return CloudRunSecretVersion{}, false
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("CloudRun Secrets", func() {
+	var run *CloudRun
+	passwordRef := CloudRunSecretKeySelector{Name: "test-secret", Key: "password"}
+
+	BeforeEach(func() {
+		run = &CloudRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-resource",
+				Namespace: "default",
+			},
+			Spec: CloudRunSpec{
+				Location:  "us-central1",
+				ProjectID: "test-project",
+				Containers: []CloudRunContainer{
+					{
+						Image: "gcr.io/test-project/test-image",
+						Name:  "test-container",
+						Env: []CloudRunEnvVar{
+							{Name: "LOG_LEVEL", Value: "info"},
+							{Name: "PASSWORD", ValueFrom: &CloudRunEnvVarSource{SecretKeyRef: &passwordRef}},
+						},
+						VolumeMounts: []CloudRunVolumeMount{{Name: "config", MountPath: "/etc/config"}},
+					},
+				},
+				Volumes: []CloudRunVolume{
+					{Name: "config", Secret: &CloudRunSecretVolumeSource{SecretName: "test-secret", Key: "config.json"}},
+					{Name: "password", Secret: &CloudRunSecretVolumeSource{SecretName: "test-secret", Key: "password", Path: "pw"}},
+				},
+			},
+		}
+	})
+
+	It("Should return each referenced key once", func() {
+		Expect(run.SecretReferences()).To(Equal([]CloudRunSecretKeySelector{
+			passwordRef,
+			{Name: "test-secret", Key: "config.json"},
+		}))
+	})
+
+	It("Should generate valid and unique Secret Manager ids", func() {
+		id := run.SecretManagerSecretId(CloudRunSecretKeySelector{Name: "test-secret", Key: "config.json"})
+		Expect(id).To(MatchRegexp(`^stilas-default-test-resource-test-secret-config_json-[0-9a-f]{8}$`))
+		Expect(id).NotTo(Equal(run.SecretManagerSecretId(CloudRunSecretKeySelector{Name: "test-secret", Key: "config_json"})))
+		long := run.SecretManagerSecretId(CloudRunSecretKeySelector{Name: strings.Repeat("a", 253), Key: "password"})
+		Expect(len(long)).To(BeNumerically("<=", 255))
+	})
+
+	It("Should point env variables and volumes at the synced versions", func() {
+		run.Status.SecretVersions = []CloudRunSecretVersion{
+			{SecretName: "test-secret", Key: "password", SecretManagerSecret: run.SecretManagerSecretId(passwordRef), Version: "3"},
+		}
+		template := run.ConvertToCreateServiceRequest(nil).Service.Template
+		env := template.Containers[0].Env
+		Expect(env).To(HaveLen(2))
+		Expect(env[0].GetValue()).To(Equal("info"))
+		Expect(env[1].GetValueSource().SecretKeyRef.Secret).To(Equal(run.SecretManagerSecretId(passwordRef)))
+		Expect(env[1].GetValueSource().SecretKeyRef.Version).To(Equal("3"))
+		Expect(template.Containers[0].VolumeMounts[0].MountPath).To(Equal("/etc/config"))
+		Expect(template.Volumes).To(HaveLen(2))
+		Expect(template.Volumes[0].GetSecret().Items[0].Path).To(Equal("config.json"))
+		Expect(template.Volumes[0].GetSecret().Items[0].Version).To(Equal("latest"))
+		Expect(template.Volumes[1].GetSecret().Items[0].Path).To(Equal("pw"))
+		Expect(template.Volumes[1].GetSecret().Items[0].Version).To(Equal("3"))
+	})
+})
@@ -0,0 +1,494 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CloudRunSpec defines the desired state of CloudRun
+type CloudRunSpec struct {
+	//Location is the location of the Cloud Run service
+	//+kubebuilder:example:=us-central1
+	//+kubebuilder:validation:Required
+	Location string `json:"location"`
+
+	//ProjectID id of the gcp project
+	//+kubebuilder:example:=my-project
+	//+kubebuilder:validation:Required
+	ProjectID string `json:"projectID"`
+
+	//Containers are the containers that make up each revision of the service
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:MinItems:=1
+	Containers []CloudRunContainer `json:"containers"`
+
+	//Traffic splits the traffic of the service between its revisions,
+	//defaults to sending all traffic to the latest revision
+	//+kubebuilder:validation:Optional
+	Traffic []CloudRunTraffic `json:"traffic,omitempty"`
+
+	//Scaling configures how many instances a revision may run
+	//+kubebuilder:validation:Optional
+	Scaling CloudRunScaling `json:"scaling,omitempty"`
+
+	//Networking configures how the service can be reached
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default:={}
+	Networking CloudRunNetworking `json:"networking,omitempty"`
+
+	//Security configures who can invoke the service and what identity it runs as
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default:={}
+	Security CloudRunSecurity `json:"security,omitempty"`
+
+	//Volumes can be mounted by the containers of the service
+	//+kubebuilder:validation:Optional
+	Volumes []CloudRunVolume `json:"volumes,omitempty"`
+
+	//ProviderConfigRef refers to the provider config holding the credentials used to manage the
+	//service, the GcpProviderConfig named default in the namespace is used when not set
+	//+kubebuilder:validation:Optional
+	ProviderConfigRef *ProviderConfigReference `json:"providerConfigRef,omitempty"`
+}
+
+// ProviderConfigKind is the kind of provider config a resource refers to
+// +kubebuilder:validation:Enum=GcpProviderConfig;ClusterGcpProviderConfig
+type ProviderConfigKind string
+
+const (
+	ProviderConfigKind_Namespaced ProviderConfigKind = "GcpProviderConfig"
+	ProviderConfigKind_Cluster    ProviderConfigKind = "ClusterGcpProviderConfig"
+)
+
+// ProviderConfigReference refers to the provider config holding the credentials used for a resource
+type ProviderConfigReference struct {
+	//Kind is GcpProviderConfig for a config in the namespace of the resource, or ClusterGcpProviderConfig
+	//+kubebuilder:default=GcpProviderConfig
+	//+kubebuilder:validation:Optional
+	Kind ProviderConfigKind `json:"kind,omitempty"`
+	//Name of the provider config
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// CloudRunContainer defines the container configuration for a Cloud Run service
+type CloudRunContainer struct {
+	//Name is the name of the container
+	//+kubebuilder:example:=my-container
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	//Image is the container image to deploy
+	//+kubebuilder:example:=gcr.io/my-project/my-image
+	//+kubebuilder:validation:Required
+	Image string `json:"image"`
+
+	//Port is the port the container listens on, defaults to 8080
+	//+kubebuilder:example:=8080
+	//+kubebuilder:validation:Optional
+	Port int32 `json:"port,omitempty"`
+
+	//+kubebuilder:validation:Optional
+	LivenessProbe *CloudRunProbe `json:"livenessProbe,omitempty"`
+
+	//+kubebuilder:validation:Optional
+	StartupProbe *CloudRunProbe `json:"startupProbe,omitempty"`
+
+	//Env is the list of environment variables to set in the container
+	//+kubebuilder:validation:Optional
+	Env []CloudRunEnvVar `json:"env,omitempty"`
+
+	//EnvFrom sets an environment variable for every key of a source, keys also set in Env are
+	//taken from Env
+	//+kubebuilder:validation:Optional
+	EnvFrom []CloudRunEnvFromSource `json:"envFrom,omitempty"`
+
+	//VolumeMounts mounts volumes of the service into the container
+	//+kubebuilder:validation:Optional
+	VolumeMounts []CloudRunVolumeMount `json:"volumeMounts,omitempty"`
+
+	//Resources are the compute resources of the container, Cloud Run's defaults are kept when not set
+	//+kubebuilder:validation:Optional
+	Resources *CloudRunResources `json:"resources,omitempty"`
+}
+
+// CloudRunResources defines the compute resources of a container
+type CloudRunResources struct {
+	//Limits are the resources the container may use by name, like cpu and memory
+	//+kubebuilder:example:={cpu: "1", memory: "512Mi"}
+	//+kubebuilder:validation:Optional
+	Limits map[string]string `json:"limits,omitempty"`
+}
+
+// CloudRunEnvVar defines an environment variable of a container
+type CloudRunEnvVar struct {
+	//Name of the environment variable
+	//+kubebuilder:example:=LOG_LEVEL
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	//Value of the environment variable, ignored when ValueFrom is set
+	//+kubebuilder:example:=info
+	//+kubebuilder:validation:Optional
+	Value string `json:"value,omitempty"`
+
+	//ValueFrom reads the value of the environment variable from another resource
+	//+kubebuilder:validation:Optional
+	ValueFrom *CloudRunEnvVarSource `json:"valueFrom,omitempty"`
+}
+
+// CloudRunEnvVarSource defines where the value of an environment variable is read from
+type CloudRunEnvVarSource struct {
+	//SecretKeyRef selects a key of a Kubernetes Secret in the namespace of the CloudRun.
+	//The value is synced to a Secret Manager secret managed by stilas
+	//+kubebuilder:validation:Optional
+	SecretKeyRef *CloudRunSecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	//ConfigMapKeyRef selects a key of a ConfigMap in the namespace of the CloudRun.
+	//The value is resolved when the revision is deployed
+	//+kubebuilder:validation:Optional
+	ConfigMapKeyRef *CloudRunConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// CloudRunConfigMapKeySelector selects a key of a ConfigMap
+type CloudRunConfigMapKeySelector struct {
+	//Name of the ConfigMap
+	//+kubebuilder:example:=my-config
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	//Key of the value in the ConfigMap
+	//+kubebuilder:example:=log-level
+	//+kubebuilder:validation:Required
+	Key string `json:"key"`
+}
+
+// CloudRunEnvFromSource defines a source of a set of environment variables
+type CloudRunEnvFromSource struct {
+	//Prefix is prepended to the name of every environment variable of the source
+	//+kubebuilder:example:=CONFIG_
+	//+kubebuilder:validation:Optional
+	Prefix string `json:"prefix,omitempty"`
+
+	//ConfigMapRef sets an environment variable for every key of a ConfigMap in the
+	//namespace of the CloudRun. The values are resolved when the revision is deployed
+	//+kubebuilder:validation:Optional
+	ConfigMapRef *CloudRunConfigMapEnvSource `json:"configMapRef,omitempty"`
+}
+
+// CloudRunConfigMapEnvSource selects a ConfigMap to read environment variables from
+type CloudRunConfigMapEnvSource struct {
+	//Name of the ConfigMap
+	//+kubebuilder:example:=my-config
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// CloudRunSecretKeySelector selects a key of a Kubernetes Secret
+type CloudRunSecretKeySelector struct {
+	//Name of the Kubernetes Secret
+	//+kubebuilder:example:=my-secret
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	//Key of the value in the Kubernetes Secret
+	//+kubebuilder:example:=password
+	//+kubebuilder:validation:Required
+	Key string `json:"key"`
+}
+
+// CloudRunVolume defines a volume that containers of the service can mount
+type CloudRunVolume struct {
+	//Name of the volume, referenced by the volume mounts of the containers
+	//+kubebuilder:example:=config
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	//Secret mounts a key of a Kubernetes Secret as a file.
+	//The value is synced to a Secret Manager secret managed by stilas
+	//+kubebuilder:validation:Optional
+	Secret *CloudRunSecretVolumeSource `json:"secret,omitempty"`
+}
+
+// CloudRunSecretVolumeSource defines a file backed by a key of a Kubernetes Secret.
+// Cloud Run mounts one Secret Manager secret per volume, so a volume holds a single key
+type CloudRunSecretVolumeSource struct {
+	//SecretName is the name of the Kubernetes Secret
+	//+kubebuilder:example:=my-secret
+	//+kubebuilder:validation:Required
+	SecretName string `json:"secretName"`
+
+	//Key of the value in the Kubernetes Secret
+	//+kubebuilder:example:=credentials.json
+	//+kubebuilder:validation:Required
+	Key string `json:"key"`
+
+	//Path is the name of the file in the mounted directory, defaults to the key
+	//+kubebuilder:example:=credentials.json
+	//+kubebuilder:validation:Optional
+	Path string `json:"path,omitempty"`
+}
+
+// CloudRunVolumeMount defines where a volume is mounted in a container
+type CloudRunVolumeMount struct {
+	//Name of the volume to mount
+	//+kubebuilder:example:=config
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	//MountPath is the directory the volume is mounted at
+	//+kubebuilder:example:=/etc/config
+	//+kubebuilder:validation:Required
+	MountPath string `json:"mountPath"`
+}
+
+// CloudRunTraffic defines the traffic configuration for a Cloud Run service
+type CloudRunTraffic struct {
+	//Revision is the name of the revision, ignored when LatestRevision is set
+	//+kubebuilder:example:=my-revision
+	//+kubebuilder:validation:Optional
+	Revision string `json:"revision,omitempty"`
+
+	//Percent is the percentage of traffic to send to this revision
+	//+kubebuilder:example:=50
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Minimum:=0
+	//+kubebuilder:validation:Maximum:=100
+	Percent int32 `json:"percent"`
+
+	//LatestRevision sends the traffic to the latest ready revision
+	//+kubebuilder:example:=true
+	//+kubebuilder:validation:Optional
+	LatestRevision bool `json:"latestRevision,omitempty"`
+
+	//Tag gives the target a dedicated URL, reachable even when Percent is 0
+	//+kubebuilder:example:=preview
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Pattern:=`^[a-z]([a-z0-9-]{0,44}[a-z0-9])?$`
+	Tag string `json:"tag,omitempty"`
+}
+
+// CloudRunScaling defines the instance scaling of each revision
+type CloudRunScaling struct {
+	//MinInstances is the number of instances kept warm, even when there is no traffic
+	//+kubebuilder:example:=1
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum:=0
+	MinInstances *int32 `json:"minInstances,omitempty"`
+
+	//MaxInstances is the maximum number of instances the revision may scale out to
+	//+kubebuilder:example:=10
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum:=0
+	MaxInstances *int32 `json:"maxInstances,omitempty"`
+
+	//MaxConcurrency is the maximum number of requests an instance handles at once
+	//+kubebuilder:example:=80
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum:=0
+	MaxConcurrency int32 `json:"maxConcurrency,omitempty"`
+}
+
+// CloudRunNetworking defines how a Cloud Run service can be reached
+type CloudRunNetworking struct {
+	//Ingress controls which sources are allowed to reach the service
+	//+kubebuilder:example:=All
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=All;Internal;InternalLoadBalancer
+	//+kubebuilder:default:=All
+	Ingress CloudRunIngress `json:"ingress,omitempty"`
+}
+
+// CloudRunSecurity defines the identity and access control of a Cloud Run service
+type CloudRunSecurity struct {
+	//InvokeMembers are granted roles/run.invoker on the service
+	//+kubebuilder:example:={allUsers}
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default:={allUsers}
+	InvokeMembers []string `json:"invokeMembers,omitempty"`
+
+	//ServiceAccount is the email of the service account the revisions run as,
+	//defaults to the project's default compute service account. Required when the
+	//containers read Kubernetes Secrets, it is granted access to their Secret Manager copies.
+	//+kubebuilder:example:=my-service@my-project.iam.gserviceaccount.com
+	//+kubebuilder:validation:Optional
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+}
+
+// CloudRunIngress is the human-friendly name of a runpb.IngressTraffic value
+type CloudRunIngress string
+
+const (
+	CloudRunIngress_All                  CloudRunIngress = "All"
+	CloudRunIngress_Internal             CloudRunIngress = "Internal"
+	CloudRunIngress_InternalLoadBalancer CloudRunIngress = "InternalLoadBalancer"
+)
+
+type CloudRunProbe struct {
+	//+kubebuilder:validation:Required
+	ProbeSpec CloudRunProbeSpec `json:"probeSpec"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default:=0
+	InitialDelaySeconds int32 `json:"initialDelaySeconds"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default:=5
+	TimeoutSeconds int32 `json:"timeoutSeconds"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default:=10
+	PeriodSeconds int32 `json:"periodSeconds"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default:=3
+	FailureThreshold int32 `json:"failureThreshold"`
+}
+
+type CloudRunProbeSpec struct {
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Enum=HTTPGet;TCPSocket;Grpc
+	ProbeType CloudRunProbeType `json:"probeType"`
+	//+kubebuilder:validation:Optional
+	Port int32 `json:"port,omitempty"`
+	//+kubebuilder:validation:Optional
+	Service *string `json:"service,omitempty"`
+	//+kubebuilder:validation:Optional
+	Path *string `json:"path,omitempty"`
+}
+
+type CloudRunProbeType string
+
+const (
+	CloudRunProbeType_HTTPGet   CloudRunProbeType = "HTTPGet"
+	CloudRunProbeType_TCPSocket CloudRunProbeType = "TCPSocket"
+	CloudRunProbeType_Grpc      CloudRunProbeType = "Grpc"
+)
+
+// CloudRunStatus defines the observed state of CloudRun
+type CloudRunStatus struct {
+	Ready bool `json:"ready"`
+	//+kubebuilder:validation:Optional
+	Reconciling bool `json:"reconciling"`
+	//+kubebuilder:validation:Optional
+	Operations []*CloudRunOperation `json:"operations"`
+	//+kubebuilder:validation:Optional
+	Uri string `json:"uri,omitempty"`
+	//+kubebuilder:validation:Optional
+	LatestReadyRevision string `json:"latestReadyRevision,omitempty"`
+	//+kubebuilder:validation:Optional
+	Revisions []string `json:"revisions,omitempty"`
+	//SecretVersions are the Secret Manager versions the Kubernetes Secrets referenced by the
+	//service are synced to
+	//+kubebuilder:validation:Optional
+	SecretVersions []CloudRunSecretVersion `json:"secretVersions,omitempty"`
+	//RevisionSecretVersions are the Secret Manager versions read by the revisions serving
+	//traffic, they are kept until no revision reading them serves traffic
+	//+kubebuilder:validation:Optional
+	RevisionSecretVersions []CloudRunRevisionSecretVersions `json:"revisionSecretVersions,omitempty"`
+	//Traffic is the traffic split currently served by the service
+	//+kubebuilder:validation:Optional
+	Traffic []CloudRunTrafficStatus `json:"traffic,omitempty"`
+	//Conditions report the state of the last reconcile. The Synced condition is False with a
+	//reason of TransientError or PermanentError when GCP rejected a call.
+	//+kubebuilder:validation:Optional
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	//Plan lists the changes stilas would make in GCP, one per line, only set in plan-only mode
+	//+kubebuilder:validation:Optional
+	Plan string `json:"plan,omitempty"`
+}
+
+// CloudRunTrafficStatus is the observed state of a traffic target
+type CloudRunTrafficStatus struct {
+	//Revision is the name of the revision receiving the traffic, empty for the latest revision
+	Revision string `json:"revision,omitempty"`
+	//Percent is the percentage of traffic sent to the revision
+	Percent int32 `json:"percent"`
+	//LatestRevision is set when the traffic follows the latest ready revision
+	LatestRevision bool `json:"latestRevision,omitempty"`
+	//Tag of the traffic target
+	Tag string `json:"tag,omitempty"`
+	//Uri is the dedicated URL of the tag
+	Uri string `json:"uri,omitempty"`
+}
+
+// CloudRunSecretVersion records the Secret Manager version a key of a Kubernetes Secret was synced to
+type CloudRunSecretVersion struct {
+	//SecretName is the name of the Kubernetes Secret
+	SecretName string `json:"secretName"`
+	//Key of the value in the Kubernetes Secret
+	Key string `json:"key"`
+	//SecretManagerSecret is the id of the Secret Manager secret holding the value
+	SecretManagerSecret string `json:"secretManagerSecret"`
+	//Version is the Secret Manager version holding the current value
+	Version string `json:"version"`
+	//Checksum is the sha256 of the value synced to Version
+	Checksum string `json:"checksum"`
+}
+
+// CloudRunRevisionSecretVersions records the Secret Manager versions a revision reads
+type CloudRunRevisionSecretVersions struct {
+	//Revision is the name of the revision
+	Revision string `json:"revision"`
+	//Versions are the versions read by the revision, as <secret id>/versions/<version>
+	Versions []string `json:"versions,omitempty"`
+}
+
+type CloudRunOperation struct {
+	//+kubebuilder:validation:Optional
+	Name string `json:"name"`
+	//+kubebuilder:validation:Optional
+	Done bool `json:"done"`
+	//+kubebuilder:validation:Optional
+	OperationType CloudRunOperationType `json:"operationType"`
+	//StartTime is when the operation was started
+	//+kubebuilder:validation:Optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+}
+
+type CloudRunOperationType string
+
+const (
+	CloudRunOperationType_Create CloudRunOperationType = "create"
+	CloudRunOperationType_Update CloudRunOperationType = "update"
+	CloudRunOperationType_Delete CloudRunOperationType = "delete"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+
+// CloudRun is the Schema for the cloudruns API
+type CloudRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CloudRunSpec   `json:"spec,omitempty"`
+	Status CloudRunStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CloudRunList contains a list of CloudRun
+type CloudRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CloudRun `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CloudRun{}, &CloudRunList{})
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// DefaultContainerPort is the port Cloud Run sends requests to when none is given
+	DefaultContainerPort int32 = 8080
+	// DefaultProbeTimeoutSeconds is the probe timeout used when none is given
+	DefaultProbeTimeoutSeconds int32 = 5
+	// DefaultProbePeriodSeconds is the probe period used when none is given
+	DefaultProbePeriodSeconds int32 = 10
+	// DefaultProbeFailureThreshold is the probe failure threshold used when none is given
+	DefaultProbeFailureThreshold int32 = 3
+	// DefaultProbePath is the path used by HTTPGet probes when none is given
+	DefaultProbePath = "/"
+)
+
+// log is for logging in this package.
+var cloudrunlog = logf.Log.WithName("cloudrun-resource")
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (c *CloudRun) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(c).
+		WithDefaulter(&CloudRunCustomDefaulter{}).
+		WithValidator(&CloudRunCustomValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-gcp-stilas-418-cloud-v2-cloudrun,mutating=true,failurePolicy=fail,sideEffects=None,groups=gcp.stilas.418.cloud,resources=cloudruns,verbs=create;update,versions=v2,name=mcloudrun-v2.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-gcp-stilas-418-cloud-v2-cloudrun,mutating=false,failurePolicy=fail,sideEffects=None,groups=gcp.stilas.418.cloud,resources=cloudruns,verbs=create;update,versions=v2,name=vcloudrun-v2.kb.io,admissionReviewVersions=v1
+
+// CloudRunCustomDefaulter fills in the defaults of a CloudRun that can not be expressed as
+// kubebuilder markers
+type CloudRunCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &CloudRunCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (d *CloudRunCustomDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	run, ok := obj.(*CloudRun)
+	if !ok {
+		return fmt.Errorf("expected a CloudRun object but got %T", obj)
+	}
+	cloudrunlog.Info("default", "name", run.Name)
+
+	if run.Spec.Networking.Ingress == "" {
+		run.Spec.Networking.Ingress = CloudRunIngress_All
+	}
+
+	if len(run.Spec.Traffic) == 0 {
+		run.Spec.Traffic = []CloudRunTraffic{
+			{
+				Percent:        100,
+				LatestRevision: true,
+			},
+		}
+	}
+
+	for i := range run.Spec.Containers {
+		container := &run.Spec.Containers[i]
+		if container.Port == 0 {
+			container.Port = DefaultContainerPort
+		}
+		container.LivenessProbe.setDefaults(container.Port)
+		container.StartupProbe.setDefaults(container.Port)
+	}
+	return nil
+}
+
+func (p *CloudRunProbe) setDefaults(containerPort int32) {
+	if p == nil {
+		return
+	}
+	if p.TimeoutSeconds == 0 {
+		p.TimeoutSeconds = DefaultProbeTimeoutSeconds
+	}
+	if p.PeriodSeconds == 0 {
+		p.PeriodSeconds = DefaultProbePeriodSeconds
+	}
+	if p.FailureThreshold == 0 {
+		p.FailureThreshold = DefaultProbeFailureThreshold
+	}
+	if p.ProbeSpec.Port == 0 {
+		p.ProbeSpec.Port = containerPort
+	}
+	if p.ProbeSpec.ProbeType == CloudRunProbeType_HTTPGet && p.ProbeSpec.Path == nil {
+		path := DefaultProbePath
+		p.ProbeSpec.Path = &path
+	}
+}
+
+// CloudRunCustomValidator rejects CloudRuns the controller can not run
+type CloudRunCustomValidator struct{}
+
+var _ webhook.CustomValidator = &CloudRunCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator, validating the spec of the CloudRun
+func (v *CloudRunCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(obj)
+}
+
+// ValidateUpdate implements webhook.CustomValidator, validating the spec of the CloudRun
+func (v *CloudRunCustomValidator) ValidateUpdate(_ context.Context, _ runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(newObj)
+}
+
+// ValidateDelete implements webhook.CustomValidator, deletes are always allowed
+func (v *CloudRunCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *CloudRunCustomValidator) validate(obj runtime.Object) error {
+	run, ok := obj.(*CloudRun)
+	if !ok {
+		return fmt.Errorf("expected a CloudRun object but got %T", obj)
+	}
+	var errs field.ErrorList
+	if len(run.SecretReferences()) > 0 && run.Spec.Security.ServiceAccount == "" {
+		// the default compute service account can not be granted access to the Secret Manager copies
+		// without the project number
+		errs = append(errs, field.Required(field.NewPath("spec", "security", "serviceAccount"),
+			"a service account is required to read Kubernetes Secrets, it is granted access to their Secret Manager copies"))
+	}
+	if len(errs) > 0 {
+		cloudrunlog.Info("rejected invalid CloudRun", "namespace", run.Namespace, "name", run.Name)
+		return apierrors.NewInvalid(GroupVersion.WithKind("CloudRun").GroupKind(), run.Name, errs)
+	}
+	return nil
+}
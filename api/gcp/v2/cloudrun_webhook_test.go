@@ -0,0 +1,127 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+
+	"cloud.google.com/go/run/apiv2/runpb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("CloudRun Webhook", func() {
+	var run *CloudRun
+	defaulter := &CloudRunCustomDefaulter{}
+
+	BeforeEach(func() {
+		run = &CloudRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-resource",
+				Namespace: "default",
+			},
+			Spec: CloudRunSpec{
+				Location:  "us-central1",
+				ProjectID: "test-project",
+				Containers: []CloudRunContainer{
+					{
+						Image: "gcr.io/test-project/test-image",
+						Name:  "test-container",
+					},
+				},
+			},
+		}
+	})
+
+	Context("When creating CloudRun under Defaulting Webhook", func() {
+		It("Should fill in the container port, ingress and traffic", func() {
+			Expect(defaulter.Default(context.Background(), run)).To(Succeed())
+			Expect(run.Spec.Containers[0].Port).To(Equal(DefaultContainerPort))
+			Expect(run.Spec.Networking.Ingress).To(Equal(CloudRunIngress_All))
+			Expect(run.Spec.Traffic).To(Equal([]CloudRunTraffic{{Percent: 100, LatestRevision: true}}))
+		})
+
+		It("Should default startup probe timings, port and path", func() {
+			run.Spec.Containers[0].StartupProbe = &CloudRunProbe{
+				ProbeSpec: CloudRunProbeSpec{ProbeType: CloudRunProbeType_HTTPGet},
+			}
+			Expect(defaulter.Default(context.Background(), run)).To(Succeed())
+			probe := run.Spec.Containers[0].StartupProbe
+			Expect(probe.TimeoutSeconds).To(Equal(DefaultProbeTimeoutSeconds))
+			Expect(probe.PeriodSeconds).To(Equal(DefaultProbePeriodSeconds))
+			Expect(probe.FailureThreshold).To(Equal(DefaultProbeFailureThreshold))
+			Expect(probe.ProbeSpec.Port).To(Equal(DefaultContainerPort))
+			Expect(probe.ProbeSpec.Path).To(HaveValue(Equal(DefaultProbePath)))
+		})
+	})
+
+	Context("When creating CloudRun under Validating Webhook", func() {
+		validator := &CloudRunCustomValidator{}
+
+		It("Should require a service account to read Kubernetes Secrets", func() {
+			_, err := validator.ValidateCreate(context.Background(), run)
+			Expect(err).NotTo(HaveOccurred())
+
+			run.Spec.Containers[0].Env = []CloudRunEnvVar{{Name: "PASSWORD", ValueFrom: &CloudRunEnvVarSource{
+				SecretKeyRef: &CloudRunSecretKeySelector{Name: "db", Key: "password"},
+			}}}
+			_, err = validator.ValidateCreate(context.Background(), run)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.security.serviceAccount"))
+
+			updated := run.DeepCopy()
+			updated.Spec.Containers[0].Env = nil
+			updated.Spec.Volumes = []CloudRunVolume{{Name: "credentials", Secret: &CloudRunSecretVolumeSource{SecretName: "db", Key: "credentials.json"}}}
+			_, err = validator.ValidateUpdate(context.Background(), run, updated)
+			Expect(err).To(MatchError(ContainSubstring("spec.security.serviceAccount")))
+
+			updated.Spec.Security.ServiceAccount = "hello@test-project.iam.gserviceaccount.com"
+			_, err = validator.ValidateUpdate(context.Background(), run, updated)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When converting to a Cloud Run service", func() {
+		It("Should map scaling, networking and security", func() {
+			minInstances := int32(1)
+			maxInstances := int32(5)
+			run.Spec.Scaling = CloudRunScaling{
+				MinInstances:   &minInstances,
+				MaxInstances:   &maxInstances,
+				MaxConcurrency: 80,
+			}
+			run.Spec.Networking.Ingress = CloudRunIngress_InternalLoadBalancer
+			run.Spec.Security.ServiceAccount = "runner@test-project.iam.gserviceaccount.com"
+
+			req := run.ConvertToCreateServiceRequest(nil)
+			Expect(req.Parent).To(Equal("projects/test-project/locations/us-central1"))
+			Expect(req.ServiceId).To(Equal("default-test-resource"))
+			Expect(req.Service.Ingress).To(Equal(runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER))
+			Expect(req.Service.Template.Scaling.MinInstanceCount).To(Equal(int32(1)))
+			Expect(req.Service.Template.Scaling.MaxInstanceCount).To(Equal(int32(5)))
+			Expect(req.Service.Template.MaxInstanceRequestConcurrency).To(Equal(int32(80)))
+			Expect(req.Service.Template.ServiceAccount).To(Equal("runner@test-project.iam.gserviceaccount.com"))
+		})
+
+		It("Should leave scaling to Cloud Run when not configured", func() {
+			req := run.ConvertToCreateServiceRequest(nil)
+			Expect(req.Service.Template.Scaling).To(BeNil())
+		})
+	})
+})
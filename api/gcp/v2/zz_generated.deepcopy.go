@@ -0,0 +1,675 @@
+//go:build !ignore_autogenerated
+
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v2
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRun) DeepCopyInto(out *CloudRun) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRun.
+func (in *CloudRun) DeepCopy() *CloudRun {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CloudRun) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunConfigMapEnvSource) DeepCopyInto(out *CloudRunConfigMapEnvSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunConfigMapEnvSource.
+func (in *CloudRunConfigMapEnvSource) DeepCopy() *CloudRunConfigMapEnvSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunConfigMapEnvSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunConfigMapKeySelector) DeepCopyInto(out *CloudRunConfigMapKeySelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunConfigMapKeySelector.
+func (in *CloudRunConfigMapKeySelector) DeepCopy() *CloudRunConfigMapKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunConfigMapKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunContainer) DeepCopyInto(out *CloudRunContainer) {
+	*out = *in
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(CloudRunProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(CloudRunProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]CloudRunEnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]CloudRunEnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]CloudRunVolumeMount, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(CloudRunResources)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunContainer.
+func (in *CloudRunContainer) DeepCopy() *CloudRunContainer {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunContainer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunCustomDefaulter) DeepCopyInto(out *CloudRunCustomDefaulter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunCustomDefaulter.
+func (in *CloudRunCustomDefaulter) DeepCopy() *CloudRunCustomDefaulter {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunCustomDefaulter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunCustomValidator) DeepCopyInto(out *CloudRunCustomValidator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunCustomValidator.
+func (in *CloudRunCustomValidator) DeepCopy() *CloudRunCustomValidator {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunCustomValidator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunEnvFromSource) DeepCopyInto(out *CloudRunEnvFromSource) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(CloudRunConfigMapEnvSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunEnvFromSource.
+func (in *CloudRunEnvFromSource) DeepCopy() *CloudRunEnvFromSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunEnvFromSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunEnvVar) DeepCopyInto(out *CloudRunEnvVar) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(CloudRunEnvVarSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunEnvVar.
+func (in *CloudRunEnvVar) DeepCopy() *CloudRunEnvVar {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunEnvVar)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunEnvVarSource) DeepCopyInto(out *CloudRunEnvVarSource) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(CloudRunSecretKeySelector)
+		**out = **in
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(CloudRunConfigMapKeySelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunEnvVarSource.
+func (in *CloudRunEnvVarSource) DeepCopy() *CloudRunEnvVarSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunEnvVarSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunList) DeepCopyInto(out *CloudRunList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CloudRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunList.
+func (in *CloudRunList) DeepCopy() *CloudRunList {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CloudRunList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunNetworking) DeepCopyInto(out *CloudRunNetworking) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunNetworking.
+func (in *CloudRunNetworking) DeepCopy() *CloudRunNetworking {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunNetworking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunOperation) DeepCopyInto(out *CloudRunOperation) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunOperation.
+func (in *CloudRunOperation) DeepCopy() *CloudRunOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunProbe) DeepCopyInto(out *CloudRunProbe) {
+	*out = *in
+	in.ProbeSpec.DeepCopyInto(&out.ProbeSpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunProbe.
+func (in *CloudRunProbe) DeepCopy() *CloudRunProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunProbeSpec) DeepCopyInto(out *CloudRunProbeSpec) {
+	*out = *in
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(string)
+		**out = **in
+	}
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunProbeSpec.
+func (in *CloudRunProbeSpec) DeepCopy() *CloudRunProbeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunProbeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunResources) DeepCopyInto(out *CloudRunResources) {
+	*out = *in
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunResources.
+func (in *CloudRunResources) DeepCopy() *CloudRunResources {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunRevisionSecretVersions) DeepCopyInto(out *CloudRunRevisionSecretVersions) {
+	*out = *in
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunRevisionSecretVersions.
+func (in *CloudRunRevisionSecretVersions) DeepCopy() *CloudRunRevisionSecretVersions {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunRevisionSecretVersions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunScaling) DeepCopyInto(out *CloudRunScaling) {
+	*out = *in
+	if in.MinInstances != nil {
+		in, out := &in.MinInstances, &out.MinInstances
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxInstances != nil {
+		in, out := &in.MaxInstances, &out.MaxInstances
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunScaling.
+func (in *CloudRunScaling) DeepCopy() *CloudRunScaling {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunScaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunSecretKeySelector) DeepCopyInto(out *CloudRunSecretKeySelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunSecretKeySelector.
+func (in *CloudRunSecretKeySelector) DeepCopy() *CloudRunSecretKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunSecretKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunSecretVersion) DeepCopyInto(out *CloudRunSecretVersion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunSecretVersion.
+func (in *CloudRunSecretVersion) DeepCopy() *CloudRunSecretVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunSecretVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunSecretVolumeSource) DeepCopyInto(out *CloudRunSecretVolumeSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunSecretVolumeSource.
+func (in *CloudRunSecretVolumeSource) DeepCopy() *CloudRunSecretVolumeSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunSecretVolumeSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunSecurity) DeepCopyInto(out *CloudRunSecurity) {
+	*out = *in
+	if in.InvokeMembers != nil {
+		in, out := &in.InvokeMembers, &out.InvokeMembers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunSecurity.
+func (in *CloudRunSecurity) DeepCopy() *CloudRunSecurity {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunSecurity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunSpec) DeepCopyInto(out *CloudRunSpec) {
+	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]CloudRunContainer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = make([]CloudRunTraffic, len(*in))
+		copy(*out, *in)
+	}
+	in.Scaling.DeepCopyInto(&out.Scaling)
+	out.Networking = in.Networking
+	in.Security.DeepCopyInto(&out.Security)
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]CloudRunVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ProviderConfigRef != nil {
+		in, out := &in.ProviderConfigRef, &out.ProviderConfigRef
+		*out = new(ProviderConfigReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunSpec.
+func (in *CloudRunSpec) DeepCopy() *CloudRunSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunStatus) DeepCopyInto(out *CloudRunStatus) {
+	*out = *in
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]*CloudRunOperation, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(CloudRunOperation)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecretVersions != nil {
+		in, out := &in.SecretVersions, &out.SecretVersions
+		*out = make([]CloudRunSecretVersion, len(*in))
+		copy(*out, *in)
+	}
+	if in.RevisionSecretVersions != nil {
+		in, out := &in.RevisionSecretVersions, &out.RevisionSecretVersions
+		*out = make([]CloudRunRevisionSecretVersions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = make([]CloudRunTrafficStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunStatus.
+func (in *CloudRunStatus) DeepCopy() *CloudRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunTraffic) DeepCopyInto(out *CloudRunTraffic) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunTraffic.
+func (in *CloudRunTraffic) DeepCopy() *CloudRunTraffic {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunTraffic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunTrafficStatus) DeepCopyInto(out *CloudRunTrafficStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunTrafficStatus.
+func (in *CloudRunTrafficStatus) DeepCopy() *CloudRunTrafficStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunTrafficStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunVolume) DeepCopyInto(out *CloudRunVolume) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(CloudRunSecretVolumeSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunVolume.
+func (in *CloudRunVolume) DeepCopy() *CloudRunVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudRunVolumeMount) DeepCopyInto(out *CloudRunVolumeMount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudRunVolumeMount.
+func (in *CloudRunVolumeMount) DeepCopy() *CloudRunVolumeMount {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudRunVolumeMount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in ConfigMapData) DeepCopyInto(out *ConfigMapData) {
+	{
+		in := &in
+		*out = make(ConfigMapData, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapData.
+func (in ConfigMapData) DeepCopy() ConfigMapData {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapData)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfigReference) DeepCopyInto(out *ProviderConfigReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigReference.
+func (in *ProviderConfigReference) DeepCopy() *ProviderConfigReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfigReference)
+	in.DeepCopyInto(out)
+	return out
+}
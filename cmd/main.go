@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"os"
@@ -25,6 +26,8 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	gcprun "cloud.google.com/go/run/apiv2"
 	gcpdns "google.golang.org/api/dns/v2"
+	"google.golang.org/api/option"
+	"google.golang.org/api/secretmanager/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -32,13 +35,21 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
 	controllergcp "github.com/tjololo/stilas/internal/controller/gcp"
 	gcpcontroller "github.com/tjololo/stilas/internal/controller/gcp"
+	"github.com/tjololo/stilas/internal/fakegcp"
+	"github.com/tjololo/stilas/internal/metrics"
+	"github.com/tjololo/stilas/internal/policy"
 	"github.com/tjololo/stilas/internal/services/gcp"
+	"github.com/tjololo/stilas/internal/tracing"
+	stilaswebhook "github.com/tjololo/stilas/internal/webhook"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -51,6 +62,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(gcpv1.AddToScheme(scheme))
+	utilruntime.Must(gcpv2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -60,6 +72,13 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var tracingOpts tracing.Options
+	var gcpQPS float64
+	var gcpBurst int
+	var enforceProjectBindings bool
+	var paused bool
+	var planOnly bool
+	var fakeGcp bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -69,14 +88,39 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.Float64Var(&gcpQPS, "gcp-api-qps", gcp.DefaultRateLimitQPS,
+		"Sustained rate of calls per second to each Google API per project, 0 disables rate limiting.")
+	flag.IntVar(&gcpBurst, "gcp-api-burst", gcp.DefaultRateLimitBurst,
+		"Number of calls to each Google API per project allowed in a burst.")
+	flag.BoolVar(&enforceProjectBindings, "enforce-project-bindings", false,
+		"If set, resources are only admitted and reconciled when a GcpProjectBinding allows their namespace to use the project.")
+	flag.BoolVar(&paused, "paused", false,
+		"If set, no changes are made in GCP and resources only get their status refreshed, like with the "+
+			gcpv1.PausedAnnotation+" annotation.")
+	flag.BoolVar(&planOnly, "plan-only", false,
+		"If set, the changes the reconcilers would make in GCP are written to the status and events of the resources "+
+			"instead of being made, like with the "+gcpv1.PlanOnlyAnnotation+" annotation.")
+	flag.BoolVar(&fakeGcp, "fake-gcp", false,
+		"If set, the reconcilers call in-memory fakes of the Cloud Run, Cloud DNS and Secret Manager APIs instead of GCP, "+
+			"for running the manager without access to a cloud.")
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
+	tracingOpts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	gcp.DefaultRateLimiter = gcp.NewRateLimiter(gcpQPS, gcpBurst)
+
+	ctx := ctrl.SetupSignalHandler()
+	shutdownTracing, err := tracing.Setup(ctx, tracingOpts)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -125,31 +169,100 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctrlmetrics.Registry.MustRegister(metrics.NewResourceCollector(mgr.GetClient()))
+
+	var secretManagerService gcp.SecretManagerService
+	var cloudRunClientOptions []option.ClientOption
+	cloudDnsService := &gcp.GcpCloudDnsService{
+		NewService: gcpdns.NewService,
+	}
+	services := []manager.Runnable{cloudDnsService}
+	if fakeGcp {
+		fakeServer, err := fakegcp.NewServer("localhost")
+		if err != nil {
+			setupLog.Error(err, "unable to start fake gcp apis")
+			os.Exit(1)
+		}
+		setupLog.Info("using fake gcp apis", "address", fakeServer.Addr())
+		secretManagerService = fakeServer.SecretManager
+		cloudRunClientOptions = fakeServer.CloudRunClientOptions()
+		cloudDnsService.ClientOptions = fakeServer.CloudDnsClientOptions()
+		services = append(services, fakeServer)
+	} else {
+		gcpSecretManagerService := &gcp.GcpSecretManagerService{
+			NewService: secretmanager.NewService,
+		}
+		secretManagerService = gcpSecretManagerService
+		services = append(services, gcpSecretManagerService)
+	}
+	for _, service := range services {
+		if err := mgr.Add(service); err != nil {
+			setupLog.Error(err, "unable to add google api clients to manager")
+			os.Exit(1)
+		}
+	}
+
+	var projectPolicy *policy.ProjectPolicy
+	if enforceProjectBindings {
+		projectPolicy = &policy.ProjectPolicy{Client: mgr.GetClient()}
+	}
+
 	if err = (&controllergcp.CloudRunReconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		NewClient: gcprun.NewServicesClient,
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		NewClient:     gcprun.NewServicesClient,
+		ClientOptions: cloudRunClientOptions,
+		Recorder:      mgr.GetEventRecorderFor("cloudrun-controller"),
+		SecretManager: secretManagerService,
+		ProjectPolicy: projectPolicy,
+		Paused:        paused,
+		PlanOnly:      planOnly,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CloudRun")
 		os.Exit(1)
 	}
 	if err = (&gcpcontroller.CloudDnsZoneReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		CloudDnsService: &gcp.GcpCloudDnsService{
-			NewService: gcpdns.NewService,
-		},
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("clouddnszone-controller"),
+		CloudDnsService: cloudDnsService,
+		ProjectPolicy:   projectPolicy,
+		Paused:          paused,
+		PlanOnly:        planOnly,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CloudDnsZone")
 		os.Exit(1)
 	}
 	if err = (&gcpcontroller.CloudDnsRecordReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:          mgr.GetClient(),
+		CloudDnsService: cloudDnsService,
+		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("clouddnsrecord-controller"),
+		ProjectPolicy:   projectPolicy,
+		Paused:          paused,
+		PlanOnly:        planOnly,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CloudDnsRecord")
 		os.Exit(1)
 	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&gcpv1.CloudRun{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "CloudRun")
+			os.Exit(1)
+		}
+		if err = (&gcpv2.CloudRun{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "CloudRun")
+			os.Exit(1)
+		}
+		if err = (&gcpv1.CloudDnsZone{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "CloudDnsZone")
+			os.Exit(1)
+		}
+		if err = stilaswebhook.SetupProjectBindingWebhooksWithManager(mgr, projectPolicy); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GcpProjectBinding")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -162,8 +275,11 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "unable to flush traces")
+	}
 }
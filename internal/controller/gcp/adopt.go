@@ -0,0 +1,53 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+)
+
+// adopting returns true if obj takes over an existing GCP resource, which is then never created
+func adopting(obj client.Object) bool {
+	return obj.GetAnnotations()[gcpv1.AdoptAnnotation] == "true"
+}
+
+// adoptedResourceMissing reports in the Synced condition of obj that the GCP resource name it adopts
+// does not exist. It is looked for again after permanentErrorRequeue or when obj changes.
+func adoptedResourceMissing(ctx context.Context, status *statusPatcher, recorder record.EventRecorder, obj client.Object, conditions *[]metav1.Condition, name string) (ctrl.Result, error) {
+	message := fmt.Sprintf("%s does not exist, it is not created as the %s annotation is set", name, gcpv1.AdoptAnnotation)
+	changed := meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionTypeSynced,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonAdoptedResourceNotFound,
+		Message:            message,
+		ObservedGeneration: obj.GetGeneration(),
+	})
+	if changed {
+		recorder.Event(obj, corev1.EventTypeWarning, EventReasonAdoptedResourceNotFound, message)
+	}
+	return ctrl.Result{RequeueAfter: permanentErrorRequeue}, status.patch(ctx, obj)
+}
@@ -18,43 +18,312 @@ package gcp
 
 import (
 	"context"
+	"fmt"
+	"slices"
 
+	"google.golang.org/api/dns/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/plan"
+	"github.com/tjololo/stilas/internal/policy"
+	"github.com/tjololo/stilas/internal/services/gcp"
+	"github.com/tjololo/stilas/internal/tracing"
 )
 
+// zoneRefIndexKey indexes CloudDnsRecords by the name of the CloudDnsZone they reference
+const zoneRefIndexKey = ".spec.zoneRef.name"
+
 // CloudDnsRecordReconciler reconciles a CloudDnsRecord object
 type CloudDnsRecordReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	CloudDnsService gcp.CloudDnsService
+	Scheme          *runtime.Scheme
+	Recorder        record.EventRecorder
+	// ProjectPolicy restricts the projects a namespace can target, not enforced when nil
+	ProjectPolicy *policy.ProjectPolicy
+	// Paused suspends all changes to GCP
+	Paused bool
+	// PlanOnly reports the changes to GCP in the status of the records instead of making them
+	PlanOnly bool
+
+	backoff gcp.Backoff
 }
 
 // +kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=clouddnsrecords,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=clouddnsrecords/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=clouddnsrecords/finalizers,verbs=update
+// +kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=clouddnszones,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the CloudDnsRecord object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.18.2/pkg/reconcile
 func (r *CloudDnsRecordReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := log.FromContext(ctx)
+
+	var dnsRecord gcpv1.CloudDnsRecord
+	if err := r.Client.Get(ctx, req.NamespacedName, &dnsRecord); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "unable to fetch CloudDnsRecord")
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	status := newStatusPatcher(r.Client, &dnsRecord)
+	var dnsZone gcpv1.CloudDnsZone
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: dnsRecord.Namespace, Name: dnsRecord.Spec.ZoneRef.Name}, &dnsZone)
+	if err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "unable to fetch CloudDnsZone")
+		return ctrl.Result{}, err
+	}
+	if errors.IsNotFound(err) && dnsRecord.DeletionTimestamp != nil {
+		// without the zone there is nothing left to remove the record set from
+		return ctrl.Result{}, removeFinalizer(ctx, r.Client, &dnsRecord)
+	}
+	// a deleting record does not wait for its zone, the deletion treats a managed zone that no longer
+	// exists like a record set that is already gone
+	if errors.IsNotFound(err) || (dnsRecord.DeletionTimestamp == nil && len(dnsZone.Status.Nameservers) == 0) {
+		return r.zoneNotReady(ctx, &dnsRecord, status)
+	}
+	tracing.SetProject(ctx, dnsZone.Spec.ProjectID)
+	if dnsRecord.DeletionTimestamp == nil {
+		allowed, err := checkProjectPolicy(ctx, status, r.Recorder, r.ProjectPolicy, &dnsRecord, &dnsRecord.Status.Conditions, dnsZone.Spec.ProjectID, "")
+		if !allowed {
+			return ctrl.Result{}, err
+		}
+	}
+	paused := setPaused(r.Recorder, &dnsRecord, &dnsRecord.Status.Conditions, r.Paused)
+	planned := !paused && planOnly(&dnsRecord, r.PlanOnly)
+	if !planned {
+		dnsRecord.Status.Plan = ""
+	}
+	project, zone := dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName()
+	if dnsRecord.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(&dnsRecord, finalizerName) {
+			return ctrl.Result{}, nil
+		}
+		if paused {
+			logger.Info("CloudDnsRecord is paused, the deletion waits until it is resumed")
+			return ctrl.Result{}, status.patch(ctx, &dnsRecord)
+		}
+		if planned {
+			deletion := &plan.Plan{}
+			if dnsRecord.Status.Fqdn != "" {
+				deletion.Add(plan.ActionDelete, recordSetName(&dnsZone, dnsRecord.Status.Fqdn, dnsRecord.Status.Type))
+			}
+			return ctrl.Result{}, releasePlannedDeletion(ctx, r.Client, r.Recorder, status, &dnsRecord, &dnsRecord.Status.Plan, deletion)
+		}
+	}
+	ctx, err = withCredentials(ctx, r.Client, dnsZone.Namespace, dnsZone.Spec.ProviderConfigRef)
+	if err != nil {
+		return credentialsFailed(ctx, status, r.Recorder, &dnsRecord, &dnsRecord.Status.Conditions, err)
+	}
+	if dnsRecord.DeletionTimestamp != nil {
+		if dnsRecord.Status.Fqdn != "" {
+			err := r.CloudDnsService.DeleteRecord(ctx, project, zone, dnsRecord.Status.Fqdn, dnsRecord.Status.Type)
+			if err != nil && gcp.ErrorCode(err) != "404" {
+				logger.Error(err, "unable to delete ResourceRecordSet")
+				r.Recorder.Eventf(&dnsRecord, corev1.EventTypeWarning, EventReasonDeleteFailed, "Failed to delete record set %s %s: %v", dnsRecord.Status.Fqdn, dnsRecord.Status.Type, err)
+				return handleGcpError(ctx, status, &dnsRecord, &dnsRecord.Status.Conditions, &r.backoff, err)
+			}
+		}
+		if err := removeFinalizer(ctx, r.Client, &dnsRecord); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(&dnsRecord, corev1.EventTypeNormal, EventReasonDeleted, "Deleted record set %s %s", dnsRecord.Status.Fqdn, dnsRecord.Status.Type)
+		return ctrl.Result{}, nil
+	}
+	if paused {
+		return ctrl.Result{RequeueAfter: resyncPeriod}, status.patch(ctx, &dnsRecord)
+	}
+	desired := desiredRecordSet(&dnsRecord, &dnsZone)
+	if planned {
+		p, err := r.planChanges(ctx, &dnsRecord, &dnsZone, desired)
+		if err != nil {
+			logger.Error(err, "unable to plan ResourceRecordSet changes")
+			return handleGcpError(ctx, status, &dnsRecord, &dnsRecord.Status.Conditions, &r.backoff, err)
+		}
+		reportPlan(r.Recorder, &dnsRecord, &dnsRecord.Status.Plan, p)
+		return ctrl.Result{RequeueAfter: resyncPeriod}, status.patch(ctx, &dnsRecord)
+	}
+	if err := addFinalizer(ctx, r.Client, &dnsRecord); err != nil {
+		logger.Error(err, "unable to add finalizer")
+		return ctrl.Result{}, err
+	}
+
+	logger.V(1).Info("Reconciling CloudDnsRecord", "generation", dnsRecord.Generation)
+	if moved := dnsRecord.Status.Fqdn != "" && (dnsRecord.Status.Fqdn != desired.Name || dnsRecord.Status.Type != desired.Type); moved {
+		logger.Info("ResourceRecordSet renamed, deleting the old one.", "fqdn", dnsRecord.Status.Fqdn, "type", dnsRecord.Status.Type)
+		err := r.CloudDnsService.DeleteRecord(ctx, project, zone, dnsRecord.Status.Fqdn, dnsRecord.Status.Type)
+		if err != nil && gcp.ErrorCode(err) != "404" {
+			r.Recorder.Eventf(&dnsRecord, corev1.EventTypeWarning, EventReasonDeleteFailed, "Failed to delete record set %s %s: %v", dnsRecord.Status.Fqdn, dnsRecord.Status.Type, err)
+			return handleGcpError(ctx, status, &dnsRecord, &dnsRecord.Status.Conditions, &r.backoff, err)
+		}
+		r.Recorder.Eventf(&dnsRecord, corev1.EventTypeNormal, EventReasonDeleted, "Deleted record set %s %s", dnsRecord.Status.Fqdn, dnsRecord.Status.Type)
+		dnsRecord.Status.Fqdn, dnsRecord.Status.Type = "", ""
+		if err := status.patch(ctx, &dnsRecord); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	rrs, err := r.CloudDnsService.GetRecord(ctx, project, zone, desired.Name, desired.Type)
+	switch {
+	case gcp.ErrorCode(err) == "404" && adopting(&dnsRecord):
+		return adoptedResourceMissing(ctx, status, r.Recorder, &dnsRecord, &dnsRecord.Status.Conditions, recordSetName(&dnsZone, desired.Name, desired.Type))
+	case gcp.ErrorCode(err) == "404":
+		logger.Info("ResourceRecordSet not found, creating.")
+		if _, err := r.CloudDnsService.CreateRecord(ctx, project, zone, desired); err != nil {
+			r.Recorder.Eventf(&dnsRecord, corev1.EventTypeWarning, EventReasonCreateFailed, "Failed to create record set %s %s: %v", desired.Name, desired.Type, err)
+			return handleGcpError(ctx, status, &dnsRecord, &dnsRecord.Status.Conditions, &r.backoff, err)
+		}
+		r.Recorder.Eventf(&dnsRecord, corev1.EventTypeNormal, EventReasonCreated, "Created record set %s %s", desired.Name, desired.Type)
+	case err != nil:
+		logger.Error(err, "unable to get ResourceRecordSet")
+		return handleGcpError(ctx, status, &dnsRecord, &dnsRecord.Status.Conditions, &r.backoff, err)
+	default:
+		fields, err := recordSetChanges(rrs, desired)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if len(fields) > 0 {
+			drift := &plan.Plan{}
+			drift.Add(plan.ActionUpdate, recordSetName(&dnsZone, desired.Name, desired.Type), fields...)
+			recordDrift("CloudDnsRecord", drift)
+			logger.Info("ResourceRecordSet updated, updating.", "fields", fields)
+			if _, err := r.CloudDnsService.UpdateRecord(ctx, project, zone, desired); err != nil {
+				r.Recorder.Eventf(&dnsRecord, corev1.EventTypeWarning, EventReasonUpdateFailed, "Failed to update record set %s %s: %v", desired.Name, desired.Type, err)
+				return handleGcpError(ctx, status, &dnsRecord, &dnsRecord.Status.Conditions, &r.backoff, err)
+			}
+			r.Recorder.Eventf(&dnsRecord, corev1.EventTypeNormal, EventReasonUpdated, "Updated record set %s %s", desired.Name, desired.Type)
+		}
+	}
+	dnsRecord.Status.Fqdn, dnsRecord.Status.Type = desired.Name, desired.Type
+	setSynced(&dnsRecord, &dnsRecord.Status.Conditions, &r.backoff)
+	return ctrl.Result{RequeueAfter: resyncPeriod}, status.patch(ctx, &dnsRecord)
+}
 
-	return ctrl.Result{}, nil
+// planChanges returns the changes a reconcile would make to the record sets of dnsRecord
+func (r *CloudDnsRecordReconciler) planChanges(ctx context.Context, dnsRecord *gcpv1.CloudDnsRecord, dnsZone *gcpv1.CloudDnsZone, desired *dns.ResourceRecordSet) (*plan.Plan, error) {
+	p := &plan.Plan{}
+	name := recordSetName(dnsZone, desired.Name, desired.Type)
+	if dnsRecord.Status.Fqdn != "" && (dnsRecord.Status.Fqdn != desired.Name || dnsRecord.Status.Type != desired.Type) {
+		p.Add(plan.ActionDelete, recordSetName(dnsZone, dnsRecord.Status.Fqdn, dnsRecord.Status.Type))
+	}
+	rrs, err := r.CloudDnsService.GetRecord(ctx, dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName(), desired.Name, desired.Type)
+	if gcp.ErrorCode(err) == "404" && adopting(dnsRecord) {
+		// an adopted record set is never created, the reconcile reports it missing instead
+		return p, nil
+	}
+	if gcp.ErrorCode(err) == "404" {
+		p.Add(plan.ActionCreate, name)
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields, err := recordSetChanges(rrs, desired)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		p.Add(plan.ActionUpdate, name, fields...)
+	}
+	return p, nil
+}
+
+// zoneNotReady reports in the Synced condition of dnsRecord that its zone does not exist or has no
+// managed zone yet, the record is reconciled again when the zone changes
+func (r *CloudDnsRecordReconciler) zoneNotReady(ctx context.Context, dnsRecord *gcpv1.CloudDnsRecord, status *statusPatcher) (ctrl.Result, error) {
+	message := fmt.Sprintf("CloudDnsZone %s does not exist or is not created yet", dnsRecord.Spec.ZoneRef.Name)
+	if meta.SetStatusCondition(&dnsRecord.Status.Conditions, metav1.Condition{
+		Type:               ConditionTypeSynced,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonZoneNotReady,
+		Message:            message,
+		ObservedGeneration: dnsRecord.Generation,
+	}) {
+		r.Recorder.Event(dnsRecord, corev1.EventTypeWarning, EventReasonZoneNotReady, message)
+	}
+	return ctrl.Result{RequeueAfter: resyncPeriod}, status.patch(ctx, dnsRecord)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CloudDnsRecordReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gcpv1.CloudDnsRecord{}, zoneRefIndexKey, zoneRefIndexer); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&gcpv1.CloudDnsRecord{}).
-		Complete(r)
+		For(&gcpv1.CloudDnsRecord{}, builder.WithPredicates(specChanged)).
+		Watches(&gcpv1.CloudDnsZone{}, handler.EnqueueRequestsFromMapFunc(r.recordsForZone)).
+		Watches(&gcpv1.GcpProjectBinding{}, handler.EnqueueRequestsFromMapFunc(enqueueAll(r.Client, func() client.ObjectList { return &gcpv1.CloudDnsRecordList{} }))).
+		Complete(tracing.Reconciler("CloudDnsRecord", r))
+}
+
+func zoneRefIndexer(obj client.Object) []string {
+	dnsRecord, ok := obj.(*gcpv1.CloudDnsRecord)
+	if !ok {
+		return nil
+	}
+	return []string{dnsRecord.Spec.ZoneRef.Name}
+}
+
+// recordsForZone maps a CloudDnsZone to the CloudDnsRecords in it
+func (r *CloudDnsRecordReconciler) recordsForZone(ctx context.Context, obj client.Object) []reconcile.Request {
+	var records gcpv1.CloudDnsRecordList
+	if err := r.List(ctx, &records, client.InNamespace(obj.GetNamespace()), client.MatchingFields{zoneRefIndexKey: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list CloudDnsRecords of zone", "zone", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(records.Items))
+	for _, item := range records.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name}})
+	}
+	return requests
+}
+
+// desiredRecordSet returns the record set of dnsRecord in dnsZone
+func desiredRecordSet(dnsRecord *gcpv1.CloudDnsRecord, dnsZone *gcpv1.CloudDnsZone) *dns.ResourceRecordSet {
+	return &dns.ResourceRecordSet{
+		Name:    dnsRecord.Fqdn(dnsZone.Spec.DnsName),
+		Type:    dnsRecord.Spec.Type,
+		Ttl:     dnsRecord.Spec.TTL,
+		Rrdatas: dnsRecord.Spec.Rrdatas,
+	}
+}
+
+// recordSetChanges returns the paths of the fields of rrs that differ from desired, ignoring the
+// order of the records
+func recordSetChanges(rrs *dns.ResourceRecordSet, desired *dns.ResourceRecordSet) ([]string, error) {
+	current := &dns.ResourceRecordSet{Name: rrs.Name, Type: rrs.Type, Ttl: rrs.Ttl, Rrdatas: sortedCopy(rrs.Rrdatas)}
+	wanted := &dns.ResourceRecordSet{Name: desired.Name, Type: desired.Type, Ttl: desired.Ttl, Rrdatas: sortedCopy(desired.Rrdatas)}
+	fields, err := plan.JSONFields(current, wanted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare record set %s: %w", rrs.Name, err)
+	}
+	return fields, nil
+}
+
+// recordSetName returns the full name of the record set fqdn of type_ in the managed zone of dnsZone
+func recordSetName(dnsZone *gcpv1.CloudDnsZone, fqdn string, type_ string) string {
+	return fmt.Sprintf("%s/rrsets/%s/%s", managedZoneName(dnsZone), fqdn, type_)
+}
+
+func sortedCopy(values []string) []string {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted
 }
@@ -21,13 +21,21 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	gcpdns "google.golang.org/api/dns/v2"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/fakegcp"
+	"github.com/tjololo/stilas/internal/services/gcp"
 )
 
 var _ = Describe("CloudDnsRecord Controller", func() {
@@ -51,7 +59,13 @@ var _ = Describe("CloudDnsRecord Controller", func() {
 						Name:      resourceName,
 						Namespace: "default",
 					},
-					// TODO(user): Specify other spec details if needed.
+					Spec: gcpv1.CloudDnsRecordSpec{
+						ZoneRef: gcpv1.CloudDnsZoneReference{Name: "test-zone"},
+						Name:    "www",
+						Type:    "A",
+						TTL:     300,
+						Rrdatas: []string{"10.0.0.1"},
+					},
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
@@ -69,16 +83,235 @@ var _ = Describe("CloudDnsRecord Controller", func() {
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
 			controllerReconciler := &CloudDnsRecordReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:          k8sClient,
+				CloudDnsService: &mockCloudDnsService{},
+				Scheme:          k8sClient.Scheme(),
+				Recorder:        record.NewFakeRecorder(100),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should wait for the zone of the record", func() {
+			recorder := record.NewFakeRecorder(100)
+			controllerReconciler := &CloudDnsRecordReconciler{
+				Client:          k8sClient,
+				CloudDnsService: &mockCloudDnsService{},
+				Scheme:          k8sClient.Scheme(),
+				Recorder:        recorder,
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: typeNamespacedName,
 			})
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+			resource := &gcpv1.CloudDnsRecord{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			synced := meta.FindStatusCondition(resource.Status.Conditions, ConditionTypeSynced)
+			Expect(synced).NotTo(BeNil())
+			Expect(synced.Reason).To(Equal(ConditionReasonZoneNotReady))
+			Expect(recorder.Events).To(Receive(And(
+				HavePrefix(corev1.EventTypeWarning),
+				ContainSubstring(EventReasonZoneNotReady),
+			)))
 		})
 	})
 })
+
+var _ = Describe("CloudDnsRecord events", func() {
+	var ctx context.Context
+	var cloudDns *fakegcp.CloudDns
+	var recorder *record.FakeRecorder
+	var r *CloudDnsRecordReconciler
+	name := types.NamespacedName{Namespace: "default", Name: "www"}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cloudDns = fakegcp.NewCloudDns()
+		dnsZone := &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "test-project", DnsName: "example.com"},
+		}
+		mz, err := cloudDns.CreateZone(ctx, "test-project", dnsZone.ConvertToManagedZone())
+		Expect(err).NotTo(HaveOccurred())
+		dnsZone.Status.Nameservers = mz.NameServers
+		dnsRecord := &gcpv1.CloudDnsRecord{
+			ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+			Spec: gcpv1.CloudDnsRecordSpec{
+				ZoneRef: gcpv1.CloudDnsZoneReference{Name: "example"},
+				Name:    "www",
+				Type:    "A",
+				TTL:     300,
+				Rrdatas: []string{"10.0.0.1"},
+			},
+		}
+		recorder = record.NewFakeRecorder(10)
+		r = &CloudDnsRecordReconciler{
+			Client:          fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(dnsZone, dnsRecord).WithStatusSubresource(dnsRecord).Build(),
+			Scheme:          k8sClient.Scheme(),
+			CloudDnsService: cloudDns,
+			Recorder:        recorder,
+		}
+	})
+
+	reconcileRecord := func() {
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("Should record the creation of the record set", func() {
+		reconcileRecord()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonCreated),
+			ContainSubstring("Created record set www.example.com. A"),
+		)))
+		_, err := cloudDns.GetRecord(ctx, "test-project", "default-example", "www.example.com.", "A")
+		Expect(err).NotTo(HaveOccurred())
+
+		reconcileRecord()
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("Should record the update of the record set", func() {
+		reconcileRecord()
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonCreated)))
+
+		var dnsRecord gcpv1.CloudDnsRecord
+		Expect(r.Client.Get(ctx, name, &dnsRecord)).To(Succeed())
+		dnsRecord.Spec.Rrdatas = []string{"10.0.0.2"}
+		Expect(r.Client.Update(ctx, &dnsRecord)).To(Succeed())
+		reconcileRecord()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonUpdated),
+			ContainSubstring("Updated record set www.example.com. A"),
+		)))
+		rrs, err := cloudDns.GetRecord(ctx, "test-project", "default-example", "www.example.com.", "A")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rrs.Rrdatas).To(Equal([]string{"10.0.0.2"}))
+	})
+
+	It("Should record the deletion of the record set", func() {
+		reconcileRecord()
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonCreated)))
+
+		var dnsRecord gcpv1.CloudDnsRecord
+		Expect(r.Client.Get(ctx, name, &dnsRecord)).To(Succeed())
+		Expect(r.Client.Delete(ctx, &dnsRecord)).To(Succeed())
+		reconcileRecord()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonDeleted),
+			ContainSubstring("Deleted record set www.example.com. A"),
+		)))
+		_, err := cloudDns.GetRecord(ctx, "test-project", "default-example", "www.example.com.", "A")
+		Expect(gcp.ErrorCode(err)).To(Equal("404"))
+		Expect(errors.IsNotFound(r.Client.Get(ctx, name, &dnsRecord))).To(BeTrue())
+	})
+})
+
+var _ = Describe("recordSetChanges", func() {
+	It("Should ignore the order of the records", func() {
+		current := &gcpdns.ResourceRecordSet{Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.2", "10.0.0.1"}}
+		desired := &gcpdns.ResourceRecordSet{Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1", "10.0.0.2"}}
+		Expect(recordSetChanges(current, desired)).To(BeEmpty())
+	})
+
+	It("Should give the changed ttl and records", func() {
+		current := &gcpdns.ResourceRecordSet{Name: "www.example.com.", Type: "A", Ttl: 60, Rrdatas: []string{"10.0.0.1"}}
+		desired := &gcpdns.ResourceRecordSet{Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.3"}}
+		Expect(recordSetChanges(current, desired)).To(ConsistOf("ttl", "rrdatas[0]"))
+	})
+})
+
+var _ = Describe("CloudDnsRecord deletion", func() {
+	var ctx context.Context
+	var cloudDns *fakegcp.CloudDns
+	var recorder *record.FakeRecorder
+	name := types.NamespacedName{Namespace: "default", Name: "www"}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cloudDns = fakegcp.NewCloudDns()
+		recorder = record.NewFakeRecorder(10)
+	})
+
+	deletingRecord := func() *gcpv1.CloudDnsRecord {
+		now := metav1.Now()
+		return &gcpv1.CloudDnsRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name.Name,
+				Namespace:         name.Namespace,
+				DeletionTimestamp: &now,
+				Finalizers:        []string{finalizerName},
+			},
+			Spec: gcpv1.CloudDnsRecordSpec{
+				ZoneRef: gcpv1.CloudDnsZoneReference{Name: "example"},
+				Name:    "www",
+				Type:    "A",
+				TTL:     300,
+				Rrdatas: []string{"10.0.0.1"},
+			},
+			Status: gcpv1.CloudDnsRecordStatus{Fqdn: "www.example.com.", Type: "A"},
+		}
+	}
+
+	reconcileDeletion := func(dnsZone *gcpv1.CloudDnsZone, annotations map[string]string) client.Client {
+		dnsRecord := deletingRecord()
+		dnsRecord.Annotations = annotations
+		c := fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(dnsZone, dnsRecord).WithStatusSubresource(dnsRecord).Build()
+		r := &CloudDnsRecordReconciler{
+			Client:          c,
+			Scheme:          k8sClient.Scheme(),
+			CloudDnsService: cloudDns,
+			Recorder:        recorder,
+		}
+		result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		return c
+	}
+
+	It("Should release the finalizer when the zone has no nameservers", func() {
+		c := reconcileDeletion(&gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "test-project", DnsName: "example.com"},
+		}, nil)
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDeleted)))
+		Expect(errors.IsNotFound(c.Get(ctx, name, &gcpv1.CloudDnsRecord{}))).To(BeTrue())
+	})
+
+	It("Should release the finalizer when the managed zone no longer exists", func() {
+		c := reconcileDeletion(&gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "test-project", DnsName: "example.com"},
+			Status:     gcpv1.CloudDnsZoneStatus{Nameservers: []string{"ns-cloud-a1.googledomains.com."}},
+		}, nil)
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDeleted)))
+		Expect(errors.IsNotFound(c.Get(ctx, name, &gcpv1.CloudDnsRecord{}))).To(BeTrue())
+	})
+	It("Should release a plan-only record without deleting its record set", func() {
+		dnsZone := &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "test-project", DnsName: "example.com"},
+		}
+		mz, err := cloudDns.CreateZone(ctx, "test-project", dnsZone.ConvertToManagedZone())
+		Expect(err).NotTo(HaveOccurred())
+		dnsZone.Status.Nameservers = mz.NameServers
+		_, err = cloudDns.CreateRecord(ctx, "test-project", "default-example", &gcpdns.ResourceRecordSet{Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		c := reconcileDeletion(dnsZone, map[string]string{gcpv1.PlanOnlyAnnotation: "true"})
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(EventReasonPlanned),
+			ContainSubstring("Delete projects/test-project/managedZones/default-example/rrsets/www.example.com./A"),
+		)))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDeleted)))
+		_, err = cloudDns.GetRecord(ctx, "test-project", "default-example", "www.example.com.", "A")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errors.IsNotFound(c.Get(ctx, name, &gcpv1.CloudDnsRecord{}))).To(BeTrue())
+	})
+})
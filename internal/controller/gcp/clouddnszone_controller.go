@@ -19,20 +19,32 @@ package gcp
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"google.golang.org/api/dns/v2"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/metrics"
+	"github.com/tjololo/stilas/internal/plan"
+	"github.com/tjololo/stilas/internal/policy"
 	"github.com/tjololo/stilas/internal/services/gcp"
+	"github.com/tjololo/stilas/internal/tracing"
 )
 
 // CloudDnsZoneReconciler reconciles a CloudDnsZone object
@@ -41,11 +53,23 @@ type CloudDnsZoneReconciler struct {
 	CloudDnsService gcp.CloudDnsService
 	Scheme          *runtime.Scheme
 	ClientOptions   []option.ClientOption
+	Recorder        record.EventRecorder
+	// ProjectPolicy restricts the projects a namespace can target, not enforced when nil
+	ProjectPolicy *policy.ProjectPolicy
+	// Paused suspends all changes to GCP, the zones only get their status refreshed
+	Paused bool
+	// PlanOnly reports the changes to GCP in the status of the zones instead of making them
+	PlanOnly bool
+
+	backoff gcp.Backoff
 }
 
 // +kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=clouddnszones,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=clouddnszones/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=clouddnszones/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -62,107 +86,390 @@ func (r *CloudDnsZoneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
-
-	if !controllerutil.ContainsFinalizer(&dnsZone, finalizerName) {
-		return ctrl.Result{}, r.addFinalizer(ctx, &dnsZone)
+	tracing.SetProject(ctx, dnsZone.Spec.ProjectID)
+	status := newStatusPatcher(r.Client, &dnsZone)
+	if dnsZone.DeletionTimestamp == nil {
+		allowed, err := checkProjectPolicy(ctx, status, r.Recorder, r.ProjectPolicy, &dnsZone, &dnsZone.Status.Conditions, dnsZone.Spec.ProjectID, "")
+		if !allowed {
+			return ctrl.Result{}, err
+		}
+	}
+	paused := setPaused(r.Recorder, &dnsZone, &dnsZone.Status.Conditions, r.Paused)
+	planned := !paused && planOnly(&dnsZone, r.PlanOnly)
+	if !planned {
+		dnsZone.Status.Plan = ""
+	}
+	if dnsZone.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(&dnsZone, finalizerName) {
+			return ctrl.Result{}, nil
+		}
+		if !dnsZone.Spec.CleanupOnDelete && dnsZone.Status.Delegation == nil {
+			return ctrl.Result{}, r.releaseZone(ctx, &dnsZone)
+		}
+		if paused {
+			logger.Info("CloudDnsZone is paused, the deletion waits until it is resumed")
+			return ctrl.Result{}, status.patch(ctx, &dnsZone)
+		}
+		if planned {
+			deletion := &plan.Plan{}
+			planUndelegate(deletion, &dnsZone)
+			if dnsZone.Spec.CleanupOnDelete {
+				deletion.Add(plan.ActionDelete, managedZoneName(&dnsZone))
+			}
+			return ctrl.Result{}, releasePlannedDeletion(ctx, r.Client, r.Recorder, status, &dnsZone, &dnsZone.Status.Plan, deletion)
+		}
+	}
+	ctx, err := withCredentials(ctx, r.Client, dnsZone.Namespace, dnsZone.Spec.ProviderConfigRef)
+	if err != nil {
+		return credentialsFailed(ctx, status, r.Recorder, &dnsZone, &dnsZone.Status.Conditions, err)
 	}
-
 	if dnsZone.DeletionTimestamp != nil {
+		if err := r.undelegate(ctx, &dnsZone); err != nil {
+			logger.Error(err, "unable to remove the delegation from the parent zone")
+			return handleGcpError(ctx, status, &dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
+		}
 		if dnsZone.Spec.CleanupOnDelete {
 			err := r.CloudDnsService.DeleteZone(ctx, dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName())
-			if err != nil {
+			if err != nil && gcp.ErrorCode(err) != "404" {
 				logger.Error(err, "unable to delete ManagedZone")
-			} else {
-				controllerutil.RemoveFinalizer(&dnsZone, finalizerName)
-				if err := r.Client.Update(ctx, &dnsZone); err != nil {
-					return ctrl.Result{}, err
-				}
+				r.Recorder.Eventf(&dnsZone, corev1.EventTypeWarning, EventReasonDeleteFailed, "Failed to delete zone %s: %v", dnsZone.GetCloudDnsZoneFullName(), err)
+				return handleGcpError(ctx, status, &dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
 			}
-			return ctrl.Result{}, err
-		}
-		controllerutil.RemoveFinalizer(&dnsZone, finalizerName)
-		if err := r.Client.Update(ctx, &dnsZone); err != nil {
-			return ctrl.Result{}, err
+			if err := removeFinalizer(ctx, r.Client, &dnsZone); err != nil {
+				return ctrl.Result{}, err
+			}
+			r.Recorder.Eventf(&dnsZone, corev1.EventTypeNormal, EventReasonDeleted, "Deleted zone %s", dnsZone.GetCloudDnsZoneFullName())
+			return ctrl.Result{}, nil
 		}
+		return ctrl.Result{}, r.releaseZone(ctx, &dnsZone)
+	}
+	if paused {
+		return r.observe(ctx, &dnsZone, status)
+	}
+	if planned {
+		return r.reconcilePlan(ctx, &dnsZone, status)
+	}
+	if err := addFinalizer(ctx, r.Client, &dnsZone); err != nil {
+		logger.Error(err, "unable to add finalizer")
+		return ctrl.Result{}, err
 	}
 
-	logger.Info(fmt.Sprintf("Reconciling CloudDnsZone: %+v", dnsZone.Spec))
+	logger.V(1).Info("Reconciling CloudDnsZone", "generation", dnsZone.Generation)
 	if dnsZone.Status.Operation != "" {
 		op, err := r.CloudDnsService.GetOperation(ctx, dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName(), dnsZone.Status.Operation)
 		if err != nil {
 			logger.Error(err, "unable to get Operation")
-			return ctrl.Result{}, err
+			return handleGcpError(ctx, status, &dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
 		}
-		if op.Status == "DONE" {
+		if dnsOperationDone(op) {
+			r.Recorder.Eventf(&dnsZone, corev1.EventTypeNormal, EventReasonOperationCompleted, "Operation %s completed", dnsZone.Status.Operation)
+			if dnsZone.Status.OperationStartTime != nil {
+				metrics.ObserveOperation("CloudDnsZone", op.Type, dnsZone.Status.OperationStartTime.Time)
+			}
 			dnsZone.Status.Operation = ""
-			return ctrl.Result{}, r.Client.Status().Update(ctx, &dnsZone)
+			dnsZone.Status.OperationStartTime = nil
+			return ctrl.Result{Requeue: true}, status.patch(ctx, &dnsZone)
 		}
-		return ctrl.Result{}, nil
+		return ctrl.Result{RequeueAfter: time.Second}, nil
 	}
 	zone, err := r.CloudDnsService.GetZone(ctx, dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName())
-	if err == nil && dnsZoneUpdated(&dnsZone, zone) {
-		logger.Info("ManagedZone updated, updating.")
-		zone.DnssecConfig.State = dnsZone.Spec.DnsSecSpec.State
-		op, err := r.CloudDnsService.UpdateZone(ctx, dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName(), zone)
-		if err != nil {
-			return ctrl.Result{}, err
+	if err == nil {
+		desired, fields, diffErr := zoneChanges(zone, &dnsZone)
+		if diffErr != nil {
+			return ctrl.Result{}, diffErr
 		}
-		if op.Status == "DONE" {
-			dnsZone.Status.Nameservers = zone.NameServers
-			return ctrl.Result{}, r.Client.Status().Update(ctx, &dnsZone)
-		} else {
-			dnsZone.Status.Operation = op.Id
-			return ctrl.Result{}, r.Client.Status().Update(ctx, &dnsZone)
+		if len(fields) > 0 {
+			drift := &plan.Plan{}
+			drift.Add(plan.ActionUpdate, managedZoneName(&dnsZone), fields...)
+			recordDrift("CloudDnsZone", drift)
+			logger.Info("ManagedZone updated, updating.", "fields", fields)
+			op, err := r.CloudDnsService.PatchZone(ctx, dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName(), zonePatch(desired, fields))
+			if err != nil {
+				r.Recorder.Eventf(&dnsZone, corev1.EventTypeWarning, EventReasonUpdateFailed, "Failed to update zone %s: %v", dnsZone.GetCloudDnsZoneFullName(), err)
+				return handleGcpError(ctx, status, &dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
+			}
+			r.Recorder.Eventf(&dnsZone, corev1.EventTypeNormal, EventReasonUpdating, "Updating zone %s, changed fields: %s", dnsZone.GetCloudDnsZoneFullName(), strings.Join(fields, ", "))
+			if slices.Contains(fields, "dnssecConfig.state") {
+				r.Recorder.Eventf(&dnsZone, corev1.EventTypeNormal, EventReasonDnsSecChanged, "Changed DNSSEC state of zone %s to %s", dnsZone.GetCloudDnsZoneFullName(), dnsZone.Spec.DnsSecSpec.State)
+			}
+			if dnsOperationDone(op) {
+				dnsZone.Status.Nameservers = desired.NameServers
+				return ctrl.Result{Requeue: true}, status.patch(ctx, &dnsZone)
+			} else {
+				dnsZone.Status.Operation = op.Id
+				dnsZone.Status.OperationStartTime = ptr.To(metav1.Now())
+				return ctrl.Result{RequeueAfter: time.Second}, status.patch(ctx, &dnsZone)
+			}
 		}
 	}
 	if err != nil && !googleapi.IsNotModified(err) {
 		apiErr := gcp.ApiErrorFromErr(err)
+		if apiErr != nil && apiErr.HTTPCode() == 404 && adopting(&dnsZone) {
+			return adoptedResourceMissing(ctx, status, r.Recorder, &dnsZone, &dnsZone.Status.Conditions, managedZoneName(&dnsZone))
+		}
 		if apiErr != nil && apiErr.HTTPCode() == 404 {
 			logger.Info("ManagedZone not found, creating.")
-			zone := dns.ManagedZone{
-				Name:        dnsZone.GetCloudDnsZoneFullName(),
-				Description: "DnsZone created by Stilas",
-				DnsName:     fmt.Sprintf("%s.", dnsZone.Spec.DnsName),
-				Visibility:  "PRIVATE",
-				DnssecConfig: &dns.ManagedZoneDnsSecConfig{
-					NonExistence: "NSEC3",
-					State:        dnsZone.Spec.DnsSecSpec.State,
-				},
-			}
-			if !dnsZone.Spec.PrivateZone {
-				zone.Visibility = "PUBLIC"
-			}
-			mz, err := r.CloudDnsService.CreateZone(ctx, dnsZone.Spec.ProjectID, &zone)
+			zone := dnsZone.ConvertToManagedZone()
+			mz, err := r.CloudDnsService.CreateZone(ctx, dnsZone.Spec.ProjectID, zone)
 			if err != nil {
-				return ctrl.Result{}, err
+				r.Recorder.Eventf(&dnsZone, corev1.EventTypeWarning, EventReasonCreateFailed, "Failed to create zone %s: %v", zone.Name, err)
+				return handleGcpError(ctx, status, &dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
 			}
+			r.Recorder.Eventf(&dnsZone, corev1.EventTypeNormal, EventReasonCreated, "Created zone %s for %s", zone.Name, zone.DnsName)
 			dnsZone.Status.Nameservers = mz.NameServers
-			if err := r.Client.Status().Update(ctx, &dnsZone); err != nil {
+			if err := status.patch(ctx, &dnsZone); err != nil {
 				return ctrl.Result{}, err
 			}
-			return ctrl.Result{}, nil
+			return ctrl.Result{Requeue: true}, nil
 		}
 		logger.Error(err, "unable to get ManagedZone")
-		return ctrl.Result{}, err
+		return handleGcpError(ctx, status, &dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
+	}
+	if err := r.syncDnsKeys(ctx, &dnsZone); err != nil {
+		logger.Error(err, "unable to sync DNS keys")
+		return handleGcpError(ctx, status, &dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
+	}
+	if err := r.delegate(ctx, &dnsZone); err != nil {
+		logger.Error(err, "unable to delegate zone from the parent zone")
+		return handleGcpError(ctx, status, &dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
+	}
+	setSynced(&dnsZone, &dnsZone.Status.Conditions, &r.backoff)
+	return ctrl.Result{RequeueAfter: resyncPeriod}, status.patch(ctx, &dnsZone)
+}
+
+// releaseZone removes the finalizer of a deleting dnsZone whose managed zone is left in place
+func (r *CloudDnsZoneReconciler) releaseZone(ctx context.Context, dnsZone *gcpv1.CloudDnsZone) error {
+	if err := removeFinalizer(ctx, r.Client, dnsZone); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(dnsZone, corev1.EventTypeNormal, EventReasonDeleted, "Removed finalizer, zone %s is left in place", dnsZone.GetCloudDnsZoneFullName())
+	return nil
+}
+
+// reconcilePlan reports the changes a reconcile would make to the managed zone of dnsZone in its
+// status, without making them, and refreshes the observed status
+func (r *CloudDnsZoneReconciler) reconcilePlan(ctx context.Context, dnsZone *gcpv1.CloudDnsZone, status *statusPatcher) (ctrl.Result, error) {
+	p, err := r.planChanges(ctx, dnsZone)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "unable to plan ManagedZone changes")
+		return handleGcpError(ctx, status, dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
+	}
+	reportPlan(r.Recorder, dnsZone, &dnsZone.Status.Plan, p)
+	return r.observe(ctx, dnsZone, status)
+}
+
+// planChanges returns the changes a reconcile would make to the managed zone of dnsZone
+func (r *CloudDnsZoneReconciler) planChanges(ctx context.Context, dnsZone *gcpv1.CloudDnsZone) (*plan.Plan, error) {
+	p := &plan.Plan{}
+	zone, err := r.CloudDnsService.GetZone(ctx, dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName())
+	if gcp.ErrorCode(err) == "404" && adopting(dnsZone) {
+		// an adopted zone is never created, the reconcile reports it missing instead
+		return p, nil
+	}
+	if gcp.ErrorCode(err) == "404" {
+		p.Add(plan.ActionCreate, managedZoneName(dnsZone))
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, fields, err := zoneChanges(zone, dnsZone)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		p.Add(plan.ActionUpdate, managedZoneName(dnsZone), fields...)
+	}
+	return p, nil
+}
+
+// observe refreshes the status of a paused zone from GCP without changing anything there
+func (r *CloudDnsZoneReconciler) observe(ctx context.Context, dnsZone *gcpv1.CloudDnsZone, status *statusPatcher) (ctrl.Result, error) {
+	zone, err := r.CloudDnsService.GetZone(ctx, dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName())
+	if err != nil && gcp.ErrorCode(err) != "404" {
+		log.FromContext(ctx).Error(err, "unable to get ManagedZone")
+		return handleGcpError(ctx, status, dnsZone, &dnsZone.Status.Conditions, &r.backoff, err)
+	}
+	if err == nil {
+		dnsZone.Status.Nameservers = zone.NameServers
 	}
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: resyncPeriod}, status.patch(ctx, dnsZone)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CloudDnsZoneReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gcpv1.CloudDnsZone{}, parentZoneRefIndexKey, parentZoneRefIndexer); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&gcpv1.CloudDnsZone{}).
-		Complete(r)
+		For(&gcpv1.CloudDnsZone{}, builder.WithPredicates(specChanged)).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Secret{}).
+		Watches(&gcpv1.CloudDnsZone{}, handler.EnqueueRequestsFromMapFunc(r.childZones)).
+		Watches(&gcpv1.GcpProjectBinding{}, handler.EnqueueRequestsFromMapFunc(enqueueAll(r.Client, func() client.ObjectList { return &gcpv1.CloudDnsZoneList{} }))).
+		Complete(tracing.Reconciler("CloudDnsZone", r))
 }
 
-func (r *CloudDnsZoneReconciler) addFinalizer(ctx context.Context, dnsZone *gcpv1.CloudDnsZone) error {
-	controllerutil.AddFinalizer(dnsZone, finalizerName)
-	if err := r.Client.Update(ctx, dnsZone); err != nil {
-		return err
+// zoneChanges returns zone as it should be according to the spec of dnsZone and the paths of the
+// fields that differ, zone is left unchanged
+func zoneChanges(zone *dns.ManagedZone, dnsZone *gcpv1.CloudDnsZone) (*dns.ManagedZone, []string, error) {
+	desired := *zone
+	desired.Description = dnsZone.Spec.Description
+	desired.Labels = dnsZone.Spec.Labels
+
+	dnssec := dns.ManagedZoneDnsSecConfig{}
+	if zone.DnssecConfig != nil {
+		dnssec = *zone.DnssecConfig
 	}
-	return nil
+	// Cloud DNS only changes the non existence and the key specs while DNSSEC is off, also when
+	// turning it on
+	unsigned := dnssec.State == "" || strings.EqualFold(dnssec.State, "off")
+	if unsigned {
+		if nonExistence := dnsZone.Spec.DnsSecSpec.CloudDnsNonExistence(); !strings.EqualFold(dnssec.NonExistence, nonExistence) {
+			dnssec.NonExistence = nonExistence
+		}
+	}
+	if !strings.EqualFold(dnssec.State, dnsZone.Spec.DnsSecSpec.State) {
+		dnssec.State = strings.ToLower(dnsZone.Spec.DnsSecSpec.State)
+	}
+	desired.DnssecConfig = &dnssec
+
+	logging := zone.CloudLoggingConfig != nil && zone.CloudLoggingConfig.EnableLogging
+	if logging != dnsZone.Spec.CloudLoggingSpec.Enabled {
+		desired.CloudLoggingConfig = &dns.ManagedZoneCloudLoggingConfig{
+			EnableLogging:   dnsZone.Spec.CloudLoggingSpec.Enabled,
+			ForceSendFields: []string{"EnableLogging"},
+		}
+	}
+
+	fields, err := plan.JSONFields(zone, &desired)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compare zone %s: %w", zone.Name, err)
+	}
+	// the key specs are compared as sets too, they are sent with the rest of the dnssec config
+	if keySpecs := dnsZone.Spec.DnsSecSpec.CloudDnsKeySpecs(); unsigned && keySpecs != nil && !sameKeySpecs(dnssec.DefaultKeySpecs, keySpecs) {
+		dnssec.DefaultKeySpecs = keySpecs
+		fields = append(fields, "dnssecConfig.defaultKeySpecs")
+	}
+	// the networks are compared as sets, Cloud DNS neither keeps their order nor our lack of kinds
+	if dnsZone.Spec.PrivateZone {
+		networks := dnsZone.CloudDnsPrivateVisibilityConfig()
+		if !samePrivateNetworks(zone.PrivateVisibilityConfig, networks) {
+			desired.PrivateVisibilityConfig = networks
+			fields = append(fields, "privateVisibilityConfig")
+		}
+	}
+	if dnsZone.Spec.ZoneType == gcpv1.ZoneTypeForwarding {
+		forwarding := dnsZone.CloudDnsForwardingConfig()
+		if !sameForwardingTargets(zone.ForwardingConfig, forwarding) {
+			desired.ForwardingConfig = forwarding
+			fields = append(fields, "forwardingConfig")
+		}
+	}
+	if dnsZone.Spec.ZoneType == gcpv1.ZoneTypePeering {
+		peering := dnsZone.CloudDnsPeeringConfig()
+		if !samePeeringNetwork(zone.PeeringConfig, peering) {
+			desired.PeeringConfig = peering
+			fields = append(fields, "peeringConfig")
+		}
+	}
+	return &desired, fields, nil
+}
+
+// sameForwardingTargets returns true when current forwards to the name servers of desired in the
+// same order and through the same paths
+func sameForwardingTargets(current *dns.ManagedZoneForwardingConfig, desired *dns.ManagedZoneForwardingConfig) bool {
+	var currentTargets, desiredTargets []*dns.ManagedZoneForwardingConfigNameServerTarget
+	if current != nil {
+		currentTargets = current.TargetNameServers
+	}
+	if desired != nil {
+		desiredTargets = desired.TargetNameServers
+	}
+	forwardingPath := func(target *dns.ManagedZoneForwardingConfigNameServerTarget) string {
+		if target.ForwardingPath == "" {
+			return "default"
+		}
+		return strings.ToLower(target.ForwardingPath)
+	}
+	return slices.EqualFunc(currentTargets, desiredTargets, func(a, b *dns.ManagedZoneForwardingConfigNameServerTarget) bool {
+		return a.Ipv4Address == b.Ipv4Address && forwardingPath(a) == forwardingPath(b)
+	})
+}
+
+// samePeeringNetwork returns true when current peers with the network of desired
+func samePeeringNetwork(current *dns.ManagedZonePeeringConfig, desired *dns.ManagedZonePeeringConfig) bool {
+	networkUrl := func(config *dns.ManagedZonePeeringConfig) string {
+		if config == nil || config.TargetNetwork == nil {
+			return ""
+		}
+		return config.TargetNetwork.NetworkUrl
+	}
+	return networkUrl(current) == networkUrl(desired)
+}
+
+// samePrivateNetworks returns true when current makes the zone visible to the networks and GKE
+// clusters of desired, which is sorted
+func samePrivateNetworks(current *dns.ManagedZonePrivateVisibilityConfig, desired *dns.ManagedZonePrivateVisibilityConfig) bool {
+	var networks, clusters []string
+	if current != nil {
+		for _, network := range current.Networks {
+			networks = append(networks, network.NetworkUrl)
+		}
+		for _, cluster := range current.GkeClusters {
+			clusters = append(clusters, cluster.GkeClusterName)
+		}
+	}
+	current = gcpv1.NewPrivateVisibilityConfig(networks, clusters)
+	return slices.EqualFunc(current.Networks, desired.Networks, func(a, b *dns.ManagedZonePrivateVisibilityConfigNetwork) bool {
+		return a.NetworkUrl == b.NetworkUrl
+	}) && slices.EqualFunc(current.GkeClusters, desired.GkeClusters, func(a, b *dns.ManagedZonePrivateVisibilityConfigGKECluster) bool {
+		return a.GkeClusterName == b.GkeClusterName
+	})
+}
+
+// zonePatch returns the patch setting the top level fields of desired named in fields, they are
+// sent even when empty so the patch clears them
+func zonePatch(desired *dns.ManagedZone, fields []string) *dns.ManagedZone {
+	patch := &dns.ManagedZone{}
+	forceSend := func(field string) {
+		if !slices.Contains(patch.ForceSendFields, field) {
+			patch.ForceSendFields = append(patch.ForceSendFields, field)
+		}
+	}
+	for _, field := range fields {
+		switch top, _, _ := strings.Cut(field, "."); top {
+		case "description":
+			patch.Description = desired.Description
+			forceSend("Description")
+		case "labels":
+			patch.Labels = desired.Labels
+			if patch.Labels == nil {
+				patch.Labels = map[string]string{}
+			}
+			forceSend("Labels")
+		case "dnssecConfig":
+			patch.DnssecConfig = desired.DnssecConfig
+		case "cloudLoggingConfig":
+			patch.CloudLoggingConfig = desired.CloudLoggingConfig
+		case "privateVisibilityConfig":
+			patch.PrivateVisibilityConfig = desired.PrivateVisibilityConfig
+		case "forwardingConfig":
+			patch.ForwardingConfig = desired.ForwardingConfig
+		case "peeringConfig":
+			patch.PeeringConfig = desired.PeeringConfig
+		}
+	}
+	return patch
+}
+
+// managedZoneName returns the full name of the managed zone of dnsZone
+func managedZoneName(dnsZone *gcpv1.CloudDnsZone) string {
+	return fmt.Sprintf("projects/%s/managedZones/%s", dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName())
 }
 
-func dnsZoneUpdated(new *gcpv1.CloudDnsZone, current *dns.ManagedZone) bool {
-	return !strings.EqualFold(new.Spec.DnsSecSpec.State, current.DnssecConfig.State)
+// dnsOperationDone returns true when a Cloud DNS operation has finished, the API reports the
+// status in lower case
+func dnsOperationDone(op *dns.Operation) bool {
+	return strings.EqualFold(op.Status, "done")
 }
@@ -18,16 +18,25 @@ package gcp
 
 import (
 	"context"
+	"slices"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	gcpdns "google.golang.org/api/dns/v2"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/fakegcp"
+	"github.com/tjololo/stilas/internal/services/gcp"
 )
 
 type mockCloudDnsService struct {
@@ -55,6 +64,10 @@ func (m *mockCloudDnsService) UpdateZone(_ context.Context, _ string, _ string,
 	return &gcpdns.Operation{Status: "DONE"}, nil
 }
 
+func (m *mockCloudDnsService) PatchZone(_ context.Context, _ string, _ string, _ *gcpdns.ManagedZone) (*gcpdns.Operation, error) {
+	return &gcpdns.Operation{Status: "done"}, nil
+}
+
 func (m *mockCloudDnsService) DeleteZone(_ context.Context, _ string, _ string) error {
 	return nil
 }
@@ -66,6 +79,30 @@ func (m *mockCloudDnsService) GetRecord(_ context.Context, _ string, _ string, _
 	}, nil
 }
 
+func (m *mockCloudDnsService) CreateRecord(_ context.Context, _ string, _ string, rrs *gcpdns.ResourceRecordSet) (*gcpdns.ResourceRecordSet, error) {
+	return rrs, nil
+}
+
+func (m *mockCloudDnsService) UpdateRecord(_ context.Context, _ string, _ string, rrs *gcpdns.ResourceRecordSet) (*gcpdns.ResourceRecordSet, error) {
+	return rrs, nil
+}
+
+func (m *mockCloudDnsService) DeleteRecord(_ context.Context, _ string, _ string, _ string, _ string) error {
+	return nil
+}
+
+func (m *mockCloudDnsService) ListZones(_ context.Context, _ string) ([]*gcpdns.ManagedZone, error) {
+	return nil, nil
+}
+
+func (m *mockCloudDnsService) ListDnsKeys(_ context.Context, _ string, _ string) ([]*gcpdns.DnsKey, error) {
+	return nil, nil
+}
+
+func (m *mockCloudDnsService) ListRecords(_ context.Context, _ string, _ string) ([]*gcpdns.ResourceRecordSet, error) {
+	return nil, nil
+}
+
 var _ = Describe("CloudDnsZone Controller", func() {
 	Context("When reconciling a resource", func() {
 		const resourceName = "test-resource"
@@ -109,6 +146,7 @@ var _ = Describe("CloudDnsZone Controller", func() {
 				Client:          k8sClient,
 				Scheme:          k8sClient.Scheme(),
 				CloudDnsService: &mockCloudDnsService{},
+				Recorder:        record.NewFakeRecorder(100),
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
@@ -120,3 +158,283 @@ var _ = Describe("CloudDnsZone Controller", func() {
 		})
 	})
 })
+
+var _ = Describe("CloudDnsZone events", func() {
+	var ctx context.Context
+	var cloudDns *fakegcp.CloudDns
+	var recorder *record.FakeRecorder
+	var r *CloudDnsZoneReconciler
+	name := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cloudDns = fakegcp.NewCloudDns()
+		dnsZone := &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+			Spec: gcpv1.CloudDnsZoneSpec{
+				ProjectID:       "test-project",
+				DnsName:         "example.com",
+				Description:     "DnsZone created by Stilas",
+				DnsSecSpec:      gcpv1.DnsSecSpec{State: "Off"},
+				CleanupOnDelete: true,
+			},
+		}
+		recorder = record.NewFakeRecorder(10)
+		r = &CloudDnsZoneReconciler{
+			Client:          fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(dnsZone).WithStatusSubresource(dnsZone).Build(),
+			Scheme:          k8sClient.Scheme(),
+			CloudDnsService: cloudDns,
+			Recorder:        recorder,
+		}
+	})
+
+	reconcileZone := func() {
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("Should record the creation of the managed zone", func() {
+		reconcileZone()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonCreated),
+			ContainSubstring("Created zone default-example for example.com."),
+		)))
+		_, err := cloudDns.GetZone(ctx, "test-project", "default-example")
+		Expect(err).NotTo(HaveOccurred())
+
+		reconcileZone()
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("Should record the update of the managed zone", func() {
+		reconcileZone()
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonCreated)))
+
+		var dnsZone gcpv1.CloudDnsZone
+		Expect(r.Client.Get(ctx, name, &dnsZone)).To(Succeed())
+		dnsZone.Spec.Description = "Updated by Stilas"
+		Expect(r.Client.Update(ctx, &dnsZone)).To(Succeed())
+		reconcileZone()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonUpdating),
+			ContainSubstring("changed fields: description"),
+		)))
+		zone, err := cloudDns.GetZone(ctx, "test-project", "default-example")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(zone.Description).To(Equal("Updated by Stilas"))
+	})
+
+	It("Should record the deletion of the managed zone", func() {
+		reconcileZone()
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonCreated)))
+
+		var dnsZone gcpv1.CloudDnsZone
+		Expect(r.Client.Get(ctx, name, &dnsZone)).To(Succeed())
+		Expect(r.Client.Delete(ctx, &dnsZone)).To(Succeed())
+		reconcileZone()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonDeleted),
+			ContainSubstring("Deleted zone default-example"),
+		)))
+		_, err := cloudDns.GetZone(ctx, "test-project", "default-example")
+		Expect(gcp.ErrorCode(err)).To(Equal("404"))
+		Expect(errors.IsNotFound(r.Client.Get(ctx, name, &dnsZone))).To(BeTrue())
+	})
+})
+
+var _ = Describe("CloudDnsZone credentials", func() {
+	var ctx context.Context
+	var cloudDns *fakegcp.CloudDns
+	var recorder *record.FakeRecorder
+	var dnsZone *gcpv1.CloudDnsZone
+	name := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cloudDns = fakegcp.NewCloudDns()
+		now := metav1.Now()
+		dnsZone = &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name.Name,
+				Namespace:         name.Namespace,
+				DeletionTimestamp: &now,
+				Finalizers:        []string{finalizerName},
+			},
+			Spec: gcpv1.CloudDnsZoneSpec{
+				ProjectID:         "test-project",
+				DnsName:           "example.com",
+				CleanupOnDelete:   true,
+				ProviderConfigRef: &gcpv1.ProviderConfigReference{Kind: gcpv1.ProviderConfigKind_Namespaced, Name: "missing"},
+			},
+		}
+		_, err := cloudDns.CreateZone(ctx, "test-project", dnsZone.ConvertToManagedZone())
+		Expect(err).NotTo(HaveOccurred())
+		recorder = record.NewFakeRecorder(10)
+	})
+
+	reconcileZone := func() (ctrl.Result, client.Client) {
+		c := fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(dnsZone).WithStatusSubresource(dnsZone).Build()
+		r := &CloudDnsZoneReconciler{
+			Client:          c,
+			Scheme:          k8sClient.Scheme(),
+			CloudDnsService: cloudDns,
+			Recorder:        recorder,
+		}
+		result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+		return result, c
+	}
+
+	It("Should not resolve the credentials of a paused deletion", func() {
+		dnsZone.Annotations = map[string]string{gcpv1.PausedAnnotation: "true"}
+		_, c := reconcileZone()
+		Expect(recorder.Events).NotTo(Receive(ContainSubstring(EventReasonCredentialsFailed)))
+		var stored gcpv1.CloudDnsZone
+		Expect(c.Get(ctx, name, &stored)).To(Succeed())
+		Expect(meta.FindStatusCondition(stored.Status.Conditions, ConditionTypeSynced)).To(BeNil())
+	})
+
+	It("Should not resolve the credentials of a plan-only deletion", func() {
+		dnsZone.Annotations = map[string]string{gcpv1.PlanOnlyAnnotation: "true"}
+		_, c := reconcileZone()
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonPlanned)))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDeleted)))
+		Expect(errors.IsNotFound(c.Get(ctx, name, &gcpv1.CloudDnsZone{}))).To(BeTrue())
+	})
+
+	It("Should report a deletion waiting for its credentials in a condition", func() {
+		result, c := reconcileZone()
+		Expect(result.RequeueAfter).To(Equal(resyncPeriod))
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeWarning),
+			ContainSubstring(EventReasonCredentialsFailed),
+		)))
+		var stored gcpv1.CloudDnsZone
+		Expect(c.Get(ctx, name, &stored)).To(Succeed())
+		Expect(stored.Finalizers).To(ContainElement(finalizerName))
+		condition := meta.FindStatusCondition(stored.Status.Conditions, ConditionTypeSynced)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ConditionReasonCredentialsFailed))
+		Expect(condition.Message).To(ContainSubstring("GcpProviderConfig missing"))
+		_, err := cloudDns.GetZone(ctx, "test-project", "default-example")
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("CloudDnsZone changes", func() {
+	var dnsZone *gcpv1.CloudDnsZone
+	var current *gcpdns.ManagedZone
+
+	BeforeEach(func() {
+		dnsZone = &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+			Spec: gcpv1.CloudDnsZoneSpec{
+				ProjectID:   "test-project",
+				DnsName:     "example.com",
+				Description: "DnsZone created by Stilas",
+				DnsSecSpec:  gcpv1.DnsSecSpec{State: "On", NonExistence: true},
+			},
+		}
+		current = dnsZone.ConvertToManagedZone()
+		current.CloudLoggingConfig = nil
+	})
+
+	It("Should find no changes for a zone created from the spec", func() {
+		_, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+	})
+
+	It("Should patch the description, labels and logging", func() {
+		current.Labels = map[string]string{"team": "dns"}
+		dnsZone.Spec.Description = ""
+		dnsZone.Spec.CloudLoggingSpec.Enabled = true
+		desired, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("description", "labels", "cloudLoggingConfig"))
+
+		patch := zonePatch(desired, fields)
+		Expect(patch.DnsName).To(BeEmpty())
+		Expect(patch.DnssecConfig).To(BeNil())
+		Expect(patch.ForceSendFields).To(ConsistOf("Description", "Labels"))
+		Expect(patch.Labels).To(BeEmpty())
+		Expect(patch.Labels).NotTo(BeNil())
+		Expect(patch.CloudLoggingConfig.EnableLogging).To(BeTrue())
+	})
+
+	It("Should only change the non existence while DNSSEC is off", func() {
+		dnsZone.Spec.DnsSecSpec.NonExistence = false
+		_, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+
+		current.DnssecConfig.State = "off"
+		desired, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("dnssecConfig.nonExistence", "dnssecConfig.state"))
+		Expect(zonePatch(desired, fields).DnssecConfig).To(Equal(&gcpdns.ManagedZoneDnsSecConfig{State: "on", NonExistence: "nsec"}))
+	})
+
+	It("Should keep the networks of a private zone in sync", func() {
+		dnsZone.Spec.PrivateZone = true
+		dnsZone.Spec.DnsSecSpec.State = "Off"
+		dnsZone.Spec.PrivateNetworks = []gcpv1.PrivateNetworkReference{{Network: "default"}, {Network: "apps"}}
+		current = dnsZone.ConvertToManagedZone()
+		current.CloudLoggingConfig = nil
+		current.PrivateVisibilityConfig.Kind = "dns#managedZonePrivateVisibilityConfig"
+		slices.Reverse(current.PrivateVisibilityConfig.Networks)
+		_, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+
+		dnsZone.Spec.PrivateNetworks = []gcpv1.PrivateNetworkReference{
+			{Network: "apps"},
+			{GkeCluster: &gcpv1.GkeClusterReference{Location: "europe-north1", Name: "apps"}},
+		}
+		desired, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("privateVisibilityConfig"))
+		patch := zonePatch(desired, fields)
+		Expect(patch.PrivateVisibilityConfig.Networks).To(HaveLen(1))
+		Expect(patch.PrivateVisibilityConfig.Networks[0].NetworkUrl).To(HaveSuffix("/networks/apps"))
+		Expect(patch.PrivateVisibilityConfig.GkeClusters[0].GkeClusterName).To(Equal("projects/test-project/locations/europe-north1/clusters/apps"))
+	})
+
+	It("Should keep the targets of forwarding and peering zones in sync", func() {
+		dnsZone.Spec.PrivateZone = true
+		dnsZone.Spec.DnsSecSpec.State = "Off"
+		dnsZone.Spec.ZoneType = gcpv1.ZoneTypeForwarding
+		dnsZone.Spec.ForwardingSpec = &gcpv1.ForwardingSpec{TargetNameServers: []gcpv1.ForwardingTarget{{IPv4Address: "10.0.0.53"}}}
+		current = dnsZone.ConvertToManagedZone()
+		current.CloudLoggingConfig = nil
+		current.ForwardingConfig.TargetNameServers[0].ForwardingPath = ""
+		_, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+
+		dnsZone.Spec.ForwardingSpec.TargetNameServers[0].ForwardingPath = "Private"
+		desired, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("forwardingConfig"))
+		Expect(zonePatch(desired, fields).ForwardingConfig.TargetNameServers[0].ForwardingPath).To(Equal("private"))
+
+		dnsZone.Spec.ZoneType = gcpv1.ZoneTypePeering
+		dnsZone.Spec.ForwardingSpec = nil
+		dnsZone.Spec.PeeringSpec = &gcpv1.PeeringSpec{TargetNetwork: gcpv1.NetworkReference{ProjectID: "host-project", Network: "shared"}}
+		current = dnsZone.ConvertToManagedZone()
+		current.CloudLoggingConfig = nil
+		_, fields, err = zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+
+		dnsZone.Spec.PeeringSpec.TargetNetwork.Network = "other"
+		desired, fields, err = zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("peeringConfig"))
+		Expect(zonePatch(desired, fields).PeeringConfig.TargetNetwork.NetworkUrl).To(HaveSuffix("/networks/other"))
+	})
+})
@@ -0,0 +1,272 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/dns/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/plan"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+// parentZoneRefIndexKey indexes CloudDnsZones by the name of their parent zone
+const parentZoneRefIndexKey = ".spec.parentZoneRef"
+
+// delegationTtl is the ttl of the NS and DS records of a delegation, the ttl Cloud DNS gives the
+// NS records of a zone apex
+const delegationTtl = 21600
+
+// delegationRecords returns the NS record set delegating dnsZone to its name servers, followed by
+// the DS record set of its active key signing keys while DNSSEC is on
+func delegationRecords(dnsZone *gcpv1.CloudDnsZone) []*dns.ResourceRecordSet {
+	name := strings.TrimSuffix(dnsZone.Spec.DnsName, ".") + "."
+	records := []*dns.ResourceRecordSet{{
+		Name:    name,
+		Type:    "NS",
+		Ttl:     delegationTtl,
+		Rrdatas: dnsZone.Status.Nameservers,
+	}}
+	if strings.EqualFold(dnsZone.Spec.DnsSecSpec.State, "off") {
+		return records
+	}
+	var ds []string
+	for _, key := range dnsZone.Status.DnsKeys {
+		if rdata := key.DsRdata(); key.IsActive && rdata != "" {
+			ds = append(ds, rdata)
+		}
+	}
+	if len(ds) > 0 {
+		records = append(records, &dns.ResourceRecordSet{Name: name, Type: "DS", Ttl: delegationTtl, Rrdatas: ds})
+	}
+	return records
+}
+
+// isSubdomain returns true when name is below the domain parent
+func isSubdomain(name string, parent string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	parent = strings.ToLower(strings.TrimSuffix(parent, "."))
+	return strings.HasSuffix(name, "."+parent)
+}
+
+// delegate keeps the NS and DS records of dnsZone in the managed zone of its parent and reports
+// them in the Delegated condition. The delegation waits while the parent or the zone has no name
+// servers yet, and leaves the parent zone unchanged while it is paused or in plan-only mode.
+func (r *CloudDnsZoneReconciler) delegate(ctx context.Context, dnsZone *gcpv1.CloudDnsZone) error {
+	ref := dnsZone.Spec.ParentZoneRef
+	if ref == nil {
+		meta.RemoveStatusCondition(&dnsZone.Status.Conditions, ConditionTypeDelegated)
+		return r.undelegate(ctx, dnsZone)
+	}
+	var parent gcpv1.CloudDnsZone
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: dnsZone.Namespace, Name: ref.Name}, &parent)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	switch {
+	case errors.IsNotFound(err) || len(parent.Status.Nameservers) == 0 || len(dnsZone.Status.Nameservers) == 0:
+		r.delegationWaiting(dnsZone, ConditionReasonParentZoneNotReady, EventReasonZoneNotReady,
+			fmt.Sprintf("Parent CloudDnsZone %s does not exist or is not created yet", ref.Name))
+		return nil
+	case !isSubdomain(dnsZone.Spec.DnsName, parent.Spec.DnsName):
+		r.delegationWaiting(dnsZone, ConditionReasonNotSubdomain, EventReasonDelegationFailed,
+			fmt.Sprintf("Zone %s is not a subdomain of %s, the domain of the parent CloudDnsZone %s", dnsZone.Spec.DnsName, parent.Spec.DnsName, parent.Name))
+		return nil
+	case pausedReason(&parent, r.Paused) != "":
+		r.delegationWaiting(dnsZone, ConditionReasonParentZonePaused, EventReasonDelegationPaused,
+			fmt.Sprintf("Parent CloudDnsZone %s is paused, its delegation records are not changed", parent.Name))
+		return nil
+	case planOnly(&parent, r.PlanOnly):
+		r.delegationWaiting(dnsZone, ConditionReasonParentZonePaused, EventReasonDelegationPaused,
+			fmt.Sprintf("Parent CloudDnsZone %s is in plan-only mode, its delegation records are not changed", parent.Name))
+		return nil
+	}
+	records := delegationRecords(dnsZone)
+	delegation := &gcpv1.ZoneDelegation{
+		ParentZone:  parent.Name,
+		ProjectID:   parent.Spec.ProjectID,
+		ManagedZone: parent.GetCloudDnsZoneFullName(),
+		Fqdn:        records[0].Name,
+		DsRecords:   len(records) > 1,
+	}
+	if current := dnsZone.Status.Delegation; current != nil &&
+		(current.ProjectID != delegation.ProjectID || current.ManagedZone != delegation.ManagedZone || current.Fqdn != delegation.Fqdn) {
+		if err := r.undelegate(ctx, dnsZone); err != nil {
+			return err
+		}
+	}
+	parentCtx, err := withCredentials(ctx, r.Client, parent.Namespace, parent.Spec.ProviderConfigRef)
+	if err != nil {
+		return err
+	}
+	for _, desired := range records {
+		if err := r.ensureDelegationRecord(parentCtx, dnsZone, delegation, desired); err != nil {
+			return err
+		}
+	}
+	if current := dnsZone.Status.Delegation; current != nil && current.DsRecords && !delegation.DsRecords {
+		if err := r.deleteDelegationRecord(parentCtx, dnsZone, current, "DS"); err != nil {
+			return err
+		}
+	}
+	if dnsZone.Status.Delegation == nil {
+		r.Recorder.Eventf(dnsZone, corev1.EventTypeNormal, EventReasonDelegated, "Delegated %s from zone %s", delegation.Fqdn, delegation.ManagedZone)
+	}
+	dnsZone.Status.Delegation = delegation
+	meta.SetStatusCondition(&dnsZone.Status.Conditions, metav1.Condition{
+		Type:               ConditionTypeDelegated,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonDelegated,
+		Message:            fmt.Sprintf("The delegation records exist in zone %s", delegation.ManagedZone),
+		ObservedGeneration: dnsZone.Generation,
+	})
+	return nil
+}
+
+// delegationWaiting sets the Delegated condition of dnsZone to False with reason and message, and
+// records a warning event when the condition changed
+func (r *CloudDnsZoneReconciler) delegationWaiting(dnsZone *gcpv1.CloudDnsZone, reason string, eventReason string, message string) {
+	changed := meta.SetStatusCondition(&dnsZone.Status.Conditions, metav1.Condition{
+		Type:               ConditionTypeDelegated,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: dnsZone.Generation,
+	})
+	if changed {
+		r.Recorder.Event(dnsZone, corev1.EventTypeWarning, eventReason, message)
+	}
+}
+
+// ensureDelegationRecord creates or updates the record set desired in the parent zone of the delegation
+func (r *CloudDnsZoneReconciler) ensureDelegationRecord(ctx context.Context, dnsZone *gcpv1.CloudDnsZone, delegation *gcpv1.ZoneDelegation, desired *dns.ResourceRecordSet) error {
+	rrs, err := r.CloudDnsService.GetRecord(ctx, delegation.ProjectID, delegation.ManagedZone, desired.Name, desired.Type)
+	if gcp.ErrorCode(err) == "404" {
+		if _, err := r.CloudDnsService.CreateRecord(ctx, delegation.ProjectID, delegation.ManagedZone, desired); err != nil {
+			r.Recorder.Eventf(dnsZone, corev1.EventTypeWarning, EventReasonDelegationFailed, "Failed to create record set %s %s in zone %s: %v", desired.Name, desired.Type, delegation.ManagedZone, err)
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fields, err := recordSetChanges(rrs, desired)
+	if err != nil || len(fields) == 0 {
+		return err
+	}
+	log.FromContext(ctx).Info("Delegation record set changed, updating.", "type", desired.Type, "fields", fields)
+	if _, err := r.CloudDnsService.UpdateRecord(ctx, delegation.ProjectID, delegation.ManagedZone, desired); err != nil {
+		r.Recorder.Eventf(dnsZone, corev1.EventTypeWarning, EventReasonDelegationFailed, "Failed to update record set %s %s in zone %s: %v", desired.Name, desired.Type, delegation.ManagedZone, err)
+		return err
+	}
+	return nil
+}
+
+// deleteDelegationRecord deletes the record set of type_ of the delegation, it may be gone already
+func (r *CloudDnsZoneReconciler) deleteDelegationRecord(ctx context.Context, dnsZone *gcpv1.CloudDnsZone, delegation *gcpv1.ZoneDelegation, type_ string) error {
+	err := r.CloudDnsService.DeleteRecord(ctx, delegation.ProjectID, delegation.ManagedZone, delegation.Fqdn, type_)
+	if err != nil && gcp.ErrorCode(err) != "404" {
+		r.Recorder.Eventf(dnsZone, corev1.EventTypeWarning, EventReasonDelegationFailed, "Failed to delete record set %s %s in zone %s: %v", delegation.Fqdn, type_, delegation.ManagedZone, err)
+		return err
+	}
+	return nil
+}
+
+// delegationRecordTypes returns the types of the record sets of the delegation in the parent zone
+func delegationRecordTypes(delegation *gcpv1.ZoneDelegation) []string {
+	recordTypes := []string{"NS"}
+	if delegation.DsRecords {
+		recordTypes = append(recordTypes, "DS")
+	}
+	return recordTypes
+}
+
+// planUndelegate adds the deletion of the NS and DS records undelegate removes from the parent zone to p
+func planUndelegate(p *plan.Plan, dnsZone *gcpv1.CloudDnsZone) {
+	delegation := dnsZone.Status.Delegation
+	if delegation == nil {
+		return
+	}
+	for _, type_ := range delegationRecordTypes(delegation) {
+		p.Add(plan.ActionDelete, fmt.Sprintf("projects/%s/managedZones/%s/rrsets/%s/%s", delegation.ProjectID, delegation.ManagedZone, delegation.Fqdn, type_))
+	}
+}
+
+// undelegate removes the NS and DS records of dnsZone from the managed zone of its parent. They are
+// left in place when the parent CloudDnsZone is gone, its credentials are needed to remove them.
+func (r *CloudDnsZoneReconciler) undelegate(ctx context.Context, dnsZone *gcpv1.CloudDnsZone) error {
+	delegation := dnsZone.Status.Delegation
+	if delegation == nil {
+		return nil
+	}
+	var parent gcpv1.CloudDnsZone
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: dnsZone.Namespace, Name: delegation.ParentZone}, &parent)
+	if errors.IsNotFound(err) {
+		dnsZone.Status.Delegation = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	parentCtx, err := withCredentials(ctx, r.Client, parent.Namespace, parent.Spec.ProviderConfigRef)
+	if err != nil {
+		return err
+	}
+	for _, type_ := range delegationRecordTypes(delegation) {
+		if err := r.deleteDelegationRecord(parentCtx, dnsZone, delegation, type_); err != nil {
+			return err
+		}
+	}
+	r.Recorder.Eventf(dnsZone, corev1.EventTypeNormal, EventReasonDelegationRemoved, "Removed the delegation of %s from zone %s", delegation.Fqdn, delegation.ManagedZone)
+	dnsZone.Status.Delegation = nil
+	return nil
+}
+
+func parentZoneRefIndexer(obj client.Object) []string {
+	dnsZone, ok := obj.(*gcpv1.CloudDnsZone)
+	if !ok || dnsZone.Spec.ParentZoneRef == nil {
+		return nil
+	}
+	return []string{dnsZone.Spec.ParentZoneRef.Name}
+}
+
+// childZones maps a CloudDnsZone to the CloudDnsZones delegated from it
+func (r *CloudDnsZoneReconciler) childZones(ctx context.Context, obj client.Object) []reconcile.Request {
+	var zones gcpv1.CloudDnsZoneList
+	if err := r.List(ctx, &zones, client.InNamespace(obj.GetNamespace()), client.MatchingFields{parentZoneRefIndexKey: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list the child zones of zone", "zone", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(zones.Items))
+	for _, item := range zones.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name}})
+	}
+	return requests
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/fakegcp"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+var _ = Describe("CloudDnsZone delegation", func() {
+	var ctx context.Context
+	var cloudDns *fakegcp.CloudDns
+	var recorder *record.FakeRecorder
+	var r *CloudDnsZoneReconciler
+	var child *gcpv1.CloudDnsZone
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cloudDns = fakegcp.NewCloudDns()
+		parent := &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "parent", Namespace: "default"},
+			Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "parent-project", DnsName: "example.com"},
+		}
+		mz, err := cloudDns.CreateZone(ctx, "parent-project", parent.ConvertToManagedZone())
+		Expect(err).NotTo(HaveOccurred())
+		parent.Status.Nameservers = mz.NameServers
+
+		child = &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "child", Namespace: "default"},
+			Spec: gcpv1.CloudDnsZoneSpec{
+				ProjectID:     "child-project",
+				DnsName:       "team.example.com",
+				DnsSecSpec:    gcpv1.DnsSecSpec{State: "On"},
+				ParentZoneRef: &gcpv1.CloudDnsZoneReference{Name: "parent"},
+			},
+		}
+		mz, err = cloudDns.CreateZone(ctx, "child-project", child.ConvertToManagedZone())
+		Expect(err).NotTo(HaveOccurred())
+		child.Status.Nameservers = mz.NameServers
+
+		recorder = record.NewFakeRecorder(10)
+		r = &CloudDnsZoneReconciler{
+			Client:          fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(parent).Build(),
+			Scheme:          k8sClient.Scheme(),
+			CloudDnsService: cloudDns,
+			Recorder:        recorder,
+		}
+	})
+
+	It("Should create the NS and DS records in the parent zone", func() {
+		Expect(r.syncDnsKeys(ctx, child)).To(Succeed())
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDelegated)))
+		Expect(child.Status.Delegation).To(Equal(&gcpv1.ZoneDelegation{
+			ParentZone:  "parent",
+			ProjectID:   "parent-project",
+			ManagedZone: "default-parent",
+			Fqdn:        "team.example.com.",
+			DsRecords:   true,
+		}))
+
+		ns, err := cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", "NS")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Rrdatas).To(Equal(child.Status.Nameservers))
+		Expect(ns.Ttl).To(BeEquivalentTo(delegationTtl))
+		ds, err := cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", "DS")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Rrdatas).To(ConsistOf(child.Status.DnsKeys[0].DsRdata()))
+
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("Should remove the DS records when DNSSEC is turned off", func() {
+		Expect(r.syncDnsKeys(ctx, child)).To(Succeed())
+		Expect(r.delegate(ctx, child)).To(Succeed())
+
+		child.Spec.DnsSecSpec.State = "Off"
+		Expect(r.syncDnsKeys(ctx, child)).To(Succeed())
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(child.Status.Delegation.DsRecords).To(BeFalse())
+		_, err := cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", "DS")
+		Expect(gcp.ErrorCode(err)).To(Equal("404"))
+		_, err = cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", "NS")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should remove the records when the parent zone ref is removed", func() {
+		Expect(r.syncDnsKeys(ctx, child)).To(Succeed())
+		Expect(r.delegate(ctx, child)).To(Succeed())
+
+		child.Spec.ParentZoneRef = nil
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(child.Status.Delegation).To(BeNil())
+		for _, type_ := range []string{"NS", "DS"} {
+			_, err := cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", type_)
+			Expect(gcp.ErrorCode(err)).To(Equal("404"))
+		}
+		Expect(r.undelegate(ctx, child)).To(Succeed())
+	})
+
+	It("Should wait for a parent zone that does not exist", func() {
+		child.Spec.ParentZoneRef.Name = "missing"
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(child.Status.Delegation).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonZoneNotReady)))
+		condition := meta.FindStatusCondition(child.Status.Conditions, ConditionTypeDelegated)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ConditionReasonParentZoneNotReady))
+
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("Should not write the records to a paused parent zone", func() {
+		var parent gcpv1.CloudDnsZone
+		Expect(r.Client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "parent"}, &parent)).To(Succeed())
+		parent.Annotations = map[string]string{gcpv1.PausedAnnotation: "true"}
+		Expect(r.Client.Update(ctx, &parent)).To(Succeed())
+
+		Expect(r.syncDnsKeys(ctx, child)).To(Succeed())
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(child.Status.Delegation).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDelegationPaused)))
+		Expect(meta.FindStatusCondition(child.Status.Conditions, ConditionTypeDelegated).Reason).To(Equal(ConditionReasonParentZonePaused))
+		for _, type_ := range []string{"NS", "DS"} {
+			_, err := cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", type_)
+			Expect(gcp.ErrorCode(err)).To(Equal("404"))
+		}
+
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("Should not write the records to a plan-only parent zone", func() {
+		var parent gcpv1.CloudDnsZone
+		Expect(r.Client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "parent"}, &parent)).To(Succeed())
+		parent.Annotations = map[string]string{gcpv1.PlanOnlyAnnotation: "true"}
+		Expect(r.Client.Update(ctx, &parent)).To(Succeed())
+
+		Expect(r.syncDnsKeys(ctx, child)).To(Succeed())
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(child.Status.Delegation).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("plan-only")))
+		_, err := cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", "NS")
+		Expect(gcp.ErrorCode(err)).To(Equal("404"))
+
+		parent.Annotations = nil
+		Expect(r.Client.Update(ctx, &parent)).To(Succeed())
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDelegated)))
+		Expect(meta.FindStatusCondition(child.Status.Conditions, ConditionTypeDelegated).Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("Should not delegate a zone outside of the parent domain", func() {
+		child.Spec.DnsName = "example.org"
+		Expect(r.delegate(ctx, child)).To(Succeed())
+		Expect(child.Status.Delegation).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDelegationFailed)))
+	})
+
+	Context("When a delegated zone is deleted", func() {
+		var parent gcpv1.CloudDnsZone
+
+		BeforeEach(func() {
+			Expect(r.syncDnsKeys(ctx, child)).To(Succeed())
+			Expect(r.delegate(ctx, child)).To(Succeed())
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDelegated)))
+			Expect(r.Client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "parent"}, &parent)).To(Succeed())
+			child.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			child.Finalizers = []string{finalizerName}
+		})
+
+		reconcileChild := func() {
+			r.Client = fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(&parent, child).WithStatusSubresource(child).Build()
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "child"}})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		expectDelegated := func() {
+			for _, type_ := range []string{"NS", "DS"} {
+				_, err := cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", type_)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			var zone gcpv1.CloudDnsZone
+			Expect(r.Client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "child"}, &zone)).To(Succeed())
+			Expect(zone.Finalizers).To(ContainElement(finalizerName))
+		}
+
+		It("Should keep the records in the parent zone while the zone is paused", func() {
+			child.Annotations = map[string]string{gcpv1.PausedAnnotation: "true"}
+			reconcileChild()
+			expectDelegated()
+		})
+
+		It("Should report the deletion of the records and release a plan-only zone", func() {
+			child.Annotations = map[string]string{gcpv1.PlanOnlyAnnotation: "true"}
+			reconcileChild()
+			Expect(recorder.Events).To(Receive(And(
+				ContainSubstring(EventReasonPlanned),
+				ContainSubstring("projects/parent-project/managedZones/default-parent/rrsets/team.example.com./NS"),
+				ContainSubstring("projects/parent-project/managedZones/default-parent/rrsets/team.example.com./DS"),
+				Not(ContainSubstring("projects/child-project")),
+			)))
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDeleted)))
+			for _, type_ := range []string{"NS", "DS"} {
+				_, err := cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", type_)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			var zone gcpv1.CloudDnsZone
+			Expect(errors.IsNotFound(r.Client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "child"}, &zone))).To(BeTrue())
+		})
+
+		It("Should remove the records from the parent zone otherwise", func() {
+			reconcileChild()
+			for _, type_ := range []string{"NS", "DS"} {
+				_, err := cloudDns.GetRecord(ctx, "parent-project", "default-parent", "team.example.com.", type_)
+				Expect(gcp.ErrorCode(err)).To(Equal("404"))
+			}
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDelegationRemoved)))
+		})
+	})
+})
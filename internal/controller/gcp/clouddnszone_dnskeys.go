@@ -0,0 +1,153 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/dns/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+)
+
+const (
+	// dsRecordsKey is the key of the DS records in the published ConfigMap or Secret
+	dsRecordsKey = "ds"
+	// dnsKeyRecordsKey is the key of the DNSKEY records in the published ConfigMap or Secret
+	dnsKeyRecordsKey = "dnskey"
+)
+
+func sameKeySpec(a *dns.DnsKeySpec, b *dns.DnsKeySpec) bool {
+	return a.KeyType == b.KeyType && strings.EqualFold(a.Algorithm, b.Algorithm) && a.KeyLength == b.KeyLength
+}
+
+// sameKeySpecs returns true when current and desired hold the same key specs in any order
+func sameKeySpecs(current []*dns.DnsKeySpec, desired []*dns.DnsKeySpec) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	for _, spec := range desired {
+		if !slices.ContainsFunc(current, func(c *dns.DnsKeySpec) bool { return sameKeySpec(c, spec) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// dnsKeyStatus returns the status of the keys of the zone dnsName with the DS records of its active
+// key signing keys and the DNSKEY records of its active keys, one per line
+func dnsKeyStatus(dnsName string, keys []*dns.DnsKey) ([]gcpv1.DnsKeyStatus, map[string]string, error) {
+	var statuses []gcpv1.DnsKeyStatus
+	var ds, dnskeys []string
+	for _, key := range keys {
+		status, err := gcpv1.ConvertFromDnsKey(dnsName, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		statuses = append(statuses, status)
+		if !key.IsActive {
+			continue
+		}
+		if status.DsRecord != "" {
+			ds = append(ds, status.DsRecord)
+		}
+		record, err := gcpv1.DnsKeyRecord(dnsName, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		dnskeys = append(dnskeys, record)
+	}
+	slices.SortFunc(statuses, func(a, b gcpv1.DnsKeyStatus) int {
+		if a.Type != b.Type {
+			return strings.Compare(a.Type, b.Type)
+		}
+		return cmp.Compare(a.KeyTag, b.KeyTag)
+	})
+	slices.Sort(ds)
+	slices.Sort(dnskeys)
+	records := map[string]string{
+		dsRecordsKey:     lines(ds),
+		dnsKeyRecordsKey: lines(dnskeys),
+	}
+	return statuses, records, nil
+}
+
+func lines(records []string) string {
+	if len(records) == 0 {
+		return ""
+	}
+	return strings.Join(records, "\n") + "\n"
+}
+
+// syncDnsKeys lists the DNSSEC keys of the managed zone into the status of dnsZone and publishes
+// their records to the ConfigMap or Secret of the spec
+func (r *CloudDnsZoneReconciler) syncDnsKeys(ctx context.Context, dnsZone *gcpv1.CloudDnsZone) error {
+	if dnsZone.Spec.PrivateZone || strings.EqualFold(dnsZone.Spec.DnsSecSpec.State, "off") {
+		dnsZone.Status.DnsKeys = nil
+		return nil
+	}
+	keys, err := r.CloudDnsService.ListDnsKeys(ctx, dnsZone.Spec.ProjectID, dnsZone.GetCloudDnsZoneFullName())
+	if err != nil {
+		return err
+	}
+	statuses, records, err := dnsKeyStatus(dnsZone.Spec.DnsName, keys)
+	if err != nil {
+		return fmt.Errorf("failed to read dns keys of zone %s: %w", dnsZone.GetCloudDnsZoneFullName(), err)
+	}
+	dnsZone.Status.DnsKeys = statuses
+	publish := dnsZone.Spec.DnsSecSpec.PublishKeys
+	if publish == nil {
+		return nil
+	}
+	kind := "ConfigMap"
+	var obj client.Object
+	var mutate controllerutil.MutateFn
+	if publish.Kind == "Secret" {
+		kind = "Secret"
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: publish.Name, Namespace: dnsZone.Namespace}}
+		obj, mutate = secret, func() error {
+			secret.Data = map[string][]byte{}
+			for key, value := range records {
+				secret.Data[key] = []byte(value)
+			}
+			return controllerutil.SetControllerReference(dnsZone, secret, r.Scheme)
+		}
+	} else {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: publish.Name, Namespace: dnsZone.Namespace}}
+		obj, mutate = configMap, func() error {
+			configMap.Data = records
+			return controllerutil.SetControllerReference(dnsZone, configMap, r.Scheme)
+		}
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, obj, mutate)
+	if err != nil {
+		r.Recorder.Eventf(dnsZone, corev1.EventTypeWarning, EventReasonDnsKeysPublishFailed, "Failed to publish the DNS keys of zone %s to %s %s: %v", dnsZone.GetCloudDnsZoneFullName(), kind, publish.Name, err)
+		return fmt.Errorf("failed to publish dns keys to %s %s: %w", kind, publish.Name, err)
+	}
+	if result != controllerutil.OperationResultNone {
+		r.Recorder.Eventf(dnsZone, corev1.EventTypeNormal, EventReasonDnsKeysPublished, "Published the DS and DNSKEY records of zone %s to %s %s", dnsZone.GetCloudDnsZoneFullName(), kind, publish.Name)
+	}
+	return nil
+}
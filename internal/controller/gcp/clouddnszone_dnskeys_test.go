@@ -0,0 +1,123 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gcpdns "google.golang.org/api/dns/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/fakegcp"
+)
+
+var _ = Describe("CloudDnsZone DNS keys", func() {
+	var dnsZone *gcpv1.CloudDnsZone
+	var current *gcpdns.ManagedZone
+
+	BeforeEach(func() {
+		dnsZone = &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", UID: "zone-uid"},
+			Spec: gcpv1.CloudDnsZoneSpec{
+				ProjectID:   "test-project",
+				DnsName:     "example.com",
+				Description: "DnsZone created by Stilas",
+				DnsSecSpec: gcpv1.DnsSecSpec{
+					State:              "On",
+					NonExistence:       true,
+					ZoneSigningKeySpec: &gcpv1.DnsKeySpec{Algorithm: "RSASHA256"},
+				},
+			},
+		}
+		current = dnsZone.ConvertToManagedZone()
+		current.CloudLoggingConfig = nil
+	})
+
+	It("Should set the key specs when they differ", func() {
+		current.DnssecConfig.DefaultKeySpecs = nil
+		current.DnssecConfig.State = "off"
+		desired, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("dnssecConfig.state", "dnssecConfig.defaultKeySpecs"))
+		Expect(zonePatch(desired, fields).DnssecConfig.DefaultKeySpecs).To(HaveLen(2))
+
+		dnsZone.Spec.DnsSecSpec.ZoneSigningKeySpec = nil
+		_, fields, err = zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("dnssecConfig.state"))
+	})
+
+	It("Should only change the key specs while DNSSEC is off", func() {
+		dnsZone.Spec.DnsSecSpec.ZoneSigningKeySpec = &gcpv1.DnsKeySpec{Algorithm: "ECDSAP256SHA256"}
+		_, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+
+		current.DnssecConfig.State = "off"
+		dnsZone.Spec.DnsSecSpec.State = "Off"
+		desired, fields, err := zoneChanges(current, dnsZone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("dnssecConfig.defaultKeySpecs"))
+		Expect(zonePatch(desired, fields).DnssecConfig.DefaultKeySpecs).To(ConsistOf(
+			HaveField("KeyType", gcpv1.DnsKeyTypeKeySigning),
+			HaveField("Algorithm", "ecdsap256sha256"),
+		))
+	})
+
+	It("Should list the keys into the status and publish their records", func() {
+		ctx := context.Background()
+		cloudDns := fakegcp.NewCloudDns()
+		_, err := cloudDns.CreateZone(ctx, "test-project", dnsZone.ConvertToManagedZone())
+		Expect(err).NotTo(HaveOccurred())
+		dnsZone.Spec.DnsSecSpec.PublishKeys = &gcpv1.DnsKeyPublishSpec{Kind: "ConfigMap", Name: "example-dnssec"}
+		recorder := record.NewFakeRecorder(10)
+		r := &CloudDnsZoneReconciler{
+			Client:          fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).Build(),
+			Scheme:          k8sClient.Scheme(),
+			CloudDnsService: cloudDns,
+			Recorder:        recorder,
+		}
+		Expect(r.syncDnsKeys(ctx, dnsZone)).To(Succeed())
+		Expect(dnsZone.Status.DnsKeys).To(HaveLen(2))
+		ksk := dnsZone.Status.DnsKeys[0]
+		Expect(ksk.Type).To(Equal("KeySigning"))
+		Expect(ksk.DsRecord).To(HavePrefix("example.com. IN DS "))
+		Expect(dnsZone.Status.DnsKeys[1].DsRecord).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDnsKeysPublished)))
+
+		var configMap corev1.ConfigMap
+		Expect(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example-dnssec"}, &configMap)).To(Succeed())
+		Expect(configMap.Data[dsRecordsKey]).To(Equal(ksk.DsRecord + "\n"))
+		Expect(strings.Count(configMap.Data[dnsKeyRecordsKey], " IN DNSKEY ")).To(Equal(2))
+		Expect(configMap.OwnerReferences).To(ConsistOf(HaveField("UID", dnsZone.UID)))
+
+		Expect(r.syncDnsKeys(ctx, dnsZone)).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive())
+
+		dnsZone.Spec.DnsSecSpec.State = "Off"
+		Expect(r.syncDnsKeys(ctx, dnsZone)).To(Succeed())
+		Expect(dnsZone.Status.DnsKeys).To(BeNil())
+	})
+})
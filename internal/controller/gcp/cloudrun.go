@@ -4,28 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
 	gcprun "cloud.google.com/go/run/apiv2"
 	"cloud.google.com/go/run/apiv2/runpb"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/utils/ptr"
 
-	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/plan"
+	"github.com/tjololo/stilas/internal/services/gcp"
 )
 
 type newCloudRunServiceClient func(ctx context.Context, opts ...option.ClientOption) (*gcprun.ServicesClient, error)
 
 func (r *CloudRunReconciler) updateRunService(ctx context.Context, updatedService *runpb.Service) (*gcprun.UpdateServiceOperation, error) {
-	c, err := r.getClient(ctx)
+	project := gcp.ProjectOf(updatedService.GetName())
+	c, err := r.getClient(ctx, project)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloud run client: %w", err)
 	}
-	defer func(c *gcprun.ServicesClient) {
-		_ = c.Close()
-	}(c)
-	crs, err := c.UpdateService(ctx, &runpb.UpdateServiceRequest{
-		Service: updatedService,
+	crs, err := gcp.Call(ctx, gcp.ServiceCloudRun, project, "UpdateService", func(ctx context.Context) (*gcprun.UpdateServiceOperation, error) {
+		return c.UpdateService(ctx, &runpb.UpdateServiceRequest{
+			Service: updatedService,
+		})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("UpdateService: failed to update cloud run service: %w", err)
@@ -33,16 +39,16 @@ func (r *CloudRunReconciler) updateRunService(ctx context.Context, updatedServic
 	return crs, nil
 }
 
-func (r *CloudRunReconciler) getRunService(ctx context.Context, run gcpv1.CloudRun) (*runpb.Service, error) {
-	c, err := r.getClient(ctx)
+func (r *CloudRunReconciler) getRunService(ctx context.Context, run gcpv2.CloudRun) (*runpb.Service, error) {
+	project := run.Spec.ProjectID
+	c, err := r.getClient(ctx, project)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloud run client: %w", err)
 	}
-	defer func(c *gcprun.ServicesClient) {
-		_ = c.Close()
-	}(c)
-	srv, err := c.GetService(ctx, &runpb.GetServiceRequest{
-		Name: run.GetGcpCloudRunServiceFullName(),
+	srv, err := gcp.Call(ctx, gcp.ServiceCloudRun, project, "GetService", func(ctx context.Context) (*runpb.Service, error) {
+		return c.GetService(ctx, &runpb.GetServiceRequest{
+			Name: run.GetGcpCloudRunServiceFullName(),
+		})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetService: failed to get cloud run service: %w", err)
@@ -51,47 +57,47 @@ func (r *CloudRunReconciler) getRunService(ctx context.Context, run gcpv1.CloudR
 }
 
 func (r *CloudRunReconciler) checkRunOperationStatus(ctx context.Context, operationName string) (bool, error) {
-	c, err := r.getClient(ctx)
+	project := gcp.ProjectOf(operationName)
+	c, err := r.getClient(ctx, project)
 	if err != nil {
 		return false, fmt.Errorf("failed to create cloud run client: %w", err)
 	}
-	defer func(c *gcprun.ServicesClient) {
-		_ = c.Close()
-	}(c)
 	crs := c.CreateServiceOperation(operationName)
-	_, err = crs.Poll(ctx)
+	_, err = gcp.Call(ctx, gcp.ServiceCloudRun, project, "GetOperation", func(ctx context.Context) (*runpb.Service, error) {
+		return crs.Poll(ctx)
+	})
 	if err != nil {
 		return crs.Done(), fmt.Errorf("Poll: failed to poll cloud run operation: %w", err)
 	}
 	return crs.Done(), nil
 }
 
-func (r *CloudRunReconciler) createRunService(ctx context.Context, cloudRun gcpv1.CloudRun) (*gcprun.CreateServiceOperation, error) {
-	c, err := r.getClient(ctx)
+func (r *CloudRunReconciler) createRunService(ctx context.Context, cloudRun gcpv2.CloudRun, config gcpv2.ConfigMapData) (*gcprun.CreateServiceOperation, error) {
+	project := cloudRun.Spec.ProjectID
+	c, err := r.getClient(ctx, project)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloud run client: %w", err)
 	}
-	defer func(c *gcprun.ServicesClient) {
-		_ = c.Close()
-	}(c)
-	runService := cloudRun.ConvertToCreateServiceRequest()
-	crs, err := c.CreateService(ctx, runService)
+	runService := cloudRun.ConvertToCreateServiceRequest(config)
+	crs, err := gcp.Call(ctx, gcp.ServiceCloudRun, project, "CreateService", func(ctx context.Context) (*gcprun.CreateServiceOperation, error) {
+		return c.CreateService(ctx, runService)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("CreateService: failed to create cloud run service: %w", err)
 	}
 	return crs, nil
 }
 
-func (r *CloudRunReconciler) deleteRunService(ctx context.Context, run gcpv1.CloudRun) (*gcprun.DeleteServiceOperation, error) {
-	c, err := r.getClient(ctx)
+func (r *CloudRunReconciler) deleteRunService(ctx context.Context, run gcpv2.CloudRun) (*gcprun.DeleteServiceOperation, error) {
+	project := run.Spec.ProjectID
+	c, err := r.getClient(ctx, project)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloud run client: %w", err)
 	}
-	defer func(c *gcprun.ServicesClient) {
-		_ = c.Close()
-	}(c)
-	dso, err := c.DeleteService(ctx, &runpb.DeleteServiceRequest{
-		Name: run.GetGcpCloudRunServiceFullName(),
+	dso, err := gcp.Call(ctx, gcp.ServiceCloudRun, project, "DeleteService", func(ctx context.Context) (*gcprun.DeleteServiceOperation, error) {
+		return c.DeleteService(ctx, &runpb.DeleteServiceRequest{
+			Name: run.GetGcpCloudRunServiceFullName(),
+		})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("DeleteService: failed to delete cloud run service: %w", err)
@@ -99,8 +105,215 @@ func (r *CloudRunReconciler) deleteRunService(ctx context.Context, run gcpv1.Clo
 	return dso, nil
 }
 
-func (r *CloudRunReconciler) getClient(ctx context.Context) (*gcprun.ServicesClient, error) {
-	return r.NewClient(ctx, r.ClientOptions...)
+// getClient returns the shared Cloud Run client used for calls to project with the credentials in ctx
+func (r *CloudRunReconciler) getClient(ctx context.Context, project string) (*gcprun.ServicesClient, error) {
+	return r.clients.Get(ctx, gcp.ClientKey(ctx, project), func(ctx context.Context) (*gcprun.ServicesClient, error) {
+		opts, err := gcp.ClientOptionsFor(ctx, r.ClientOptions)
+		if err != nil {
+			return nil, err
+		}
+		return r.NewClient(ctx, opts...)
+	})
+}
+
+// applyRevisionChanges copies the revision template fields managed by the CloudRun onto srv.
+// Returns true if srv was changed and needs to be updated.
+func applyRevisionChanges(srv *runpb.Service, run gcpv2.CloudRun, config gcpv2.ConfigMapData) bool {
+	desired := run.ConvertToCreateServiceRequest(config).Service.Template
+	if srv.Template == nil {
+		srv.Template = desired
+		return true
+	}
+	changed := false
+	if srv.Template.Annotations[gcpv2.ConfigHashAnnotation] != desired.Annotations[gcpv2.ConfigHashAnnotation] {
+		if srv.Template.Annotations == nil {
+			srv.Template.Annotations = map[string]string{}
+		}
+		if hash, ok := desired.Annotations[gcpv2.ConfigHashAnnotation]; ok {
+			srv.Template.Annotations[gcpv2.ConfigHashAnnotation] = hash
+		} else {
+			delete(srv.Template.Annotations, gcpv2.ConfigHashAnnotation)
+		}
+		changed = true
+	}
+	if len(srv.Template.Containers) != len(desired.Containers) {
+		srv.Template.Containers = desired.Containers
+		changed = true
+	}
+	for i, container := range desired.Containers {
+		current := srv.Template.Containers[i]
+		if current.Image != container.Image {
+			current.Image = container.Image
+			changed = true
+		}
+		if !envEqual(current.Env, container.Env) {
+			current.Env = container.Env
+			changed = true
+		}
+		if !volumeMountsEqual(current.VolumeMounts, container.VolumeMounts) {
+			current.VolumeMounts = container.VolumeMounts
+			changed = true
+		}
+		// without resources in the spec the limits Cloud Run defaulted are kept
+		if container.Resources != nil && !maps.Equal(current.GetResources().GetLimits(), container.Resources.Limits) {
+			current.Resources = container.Resources
+			changed = true
+		}
+		if !portsEqual(current.Ports, container.Ports) {
+			current.Ports = container.Ports
+			changed = true
+		}
+		// without a probe in the spec the probe Cloud Run defaulted is kept
+		if container.LivenessProbe != nil && !probeEqual(current.LivenessProbe, container.LivenessProbe) {
+			current.LivenessProbe = container.LivenessProbe
+			changed = true
+		}
+		if container.StartupProbe != nil && !probeEqual(current.StartupProbe, container.StartupProbe) {
+			current.StartupProbe = container.StartupProbe
+			changed = true
+		}
+	}
+	if !volumesEqual(srv.Template.Volumes, desired.Volumes) {
+		srv.Template.Volumes = desired.Volumes
+		changed = true
+	}
+	// the minimum is zero when not set, the maximum, concurrency and service account Cloud Run
+	// defaulted are kept when not set
+	scaling := run.Spec.Scaling
+	if minInstances := ptr.Deref(scaling.MinInstances, 0); srv.Template.GetScaling().GetMinInstanceCount() != minInstances {
+		if srv.Template.Scaling == nil {
+			srv.Template.Scaling = &runpb.RevisionScaling{}
+		}
+		srv.Template.Scaling.MinInstanceCount = minInstances
+		changed = true
+	}
+	if scaling.MaxInstances != nil && srv.Template.GetScaling().GetMaxInstanceCount() != *scaling.MaxInstances {
+		if srv.Template.Scaling == nil {
+			srv.Template.Scaling = &runpb.RevisionScaling{}
+		}
+		srv.Template.Scaling.MaxInstanceCount = *scaling.MaxInstances
+		changed = true
+	}
+	if desired.MaxInstanceRequestConcurrency != 0 && srv.Template.MaxInstanceRequestConcurrency != desired.MaxInstanceRequestConcurrency {
+		srv.Template.MaxInstanceRequestConcurrency = desired.MaxInstanceRequestConcurrency
+		changed = true
+	}
+	if desired.ServiceAccount != "" && srv.Template.ServiceAccount != desired.ServiceAccount {
+		srv.Template.ServiceAccount = desired.ServiceAccount
+		changed = true
+	}
+	return changed
+}
+
+// applyIngressChanges copies the ingress of the CloudRun onto srv.
+// Returns true if srv was changed and needs to be updated.
+func applyIngressChanges(srv *runpb.Service, run gcpv2.CloudRun) bool {
+	ingress := run.Spec.Networking.Ingress.IngressTraffic()
+	if ingress == runpb.IngressTraffic_INGRESS_TRAFFIC_UNSPECIFIED || srv.Ingress == ingress {
+		return false
+	}
+	srv.Ingress = ingress
+	return true
+}
+
+// applyTrafficChanges copies the traffic split of the CloudRun onto srv.
+// Returns true if srv was changed and needs to be updated.
+func applyTrafficChanges(srv *runpb.Service, run gcpv2.CloudRun) bool {
+	desired := run.ConvertToCreateServiceRequest(nil).Service.Traffic
+	if slices.EqualFunc(srv.Traffic, desired, trafficTargetEqual) {
+		return false
+	}
+	srv.Traffic = desired
+	return true
+}
+
+// ServiceChanges returns srv as it should be according to run and the paths of the fields that
+// differ, srv is left unchanged. Only the fields managed by the CloudRun are compared.
+func ServiceChanges(srv *runpb.Service, run gcpv2.CloudRun, config gcpv2.ConfigMapData) (*runpb.Service, []string, error) {
+	desired := proto.Clone(srv).(*runpb.Service)
+	revisionChanged := applyRevisionChanges(desired, run, config)
+	trafficChanged := applyTrafficChanges(desired, run)
+	ingressChanged := applyIngressChanges(desired, run)
+	if !revisionChanged && !trafficChanged && !ingressChanged {
+		return desired, nil, nil
+	}
+	fields, err := plan.ProtoFields(srv, desired)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compare service %s: %w", srv.Name, err)
+	}
+	return desired, fields, nil
+}
+
+func trafficTargetEqual(a, b *runpb.TrafficTarget) bool {
+	if a.Type != b.Type || a.Percent != b.Percent || a.Tag != b.Tag {
+		return false
+	}
+	return a.Type == runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST || a.Revision == b.Revision
+}
+
+func envEqual(a, b []*runpb.EnvVar) bool {
+	return slices.EqualFunc(a, b, func(x, y *runpb.EnvVar) bool { return proto.Equal(x, y) })
+}
+
+func volumeMountsEqual(a, b []*runpb.VolumeMount) bool {
+	return slices.EqualFunc(a, b, func(x, y *runpb.VolumeMount) bool { return proto.Equal(x, y) })
+}
+
+// defaultContainerPort is the port Cloud Run sends requests to when the container has none
+const defaultContainerPort = 8080
+
+// portsEqual compares the container ports, ignoring the names defaulted by Cloud Run
+func portsEqual(a, b []*runpb.ContainerPort) bool {
+	port := func(p *runpb.ContainerPort) int32 {
+		if p.ContainerPort == 0 {
+			return defaultContainerPort
+		}
+		return p.ContainerPort
+	}
+	return slices.EqualFunc(a, b, func(x, y *runpb.ContainerPort) bool { return port(x) == port(y) })
+}
+
+// probeEqual compares the probes, a probe port that is not set in desired is the one Cloud Run
+// defaulted to the container port and an empty path is the root
+func probeEqual(current, desired *runpb.Probe) bool {
+	if current == nil {
+		return false
+	}
+	if current.InitialDelaySeconds != desired.InitialDelaySeconds || current.TimeoutSeconds != desired.TimeoutSeconds ||
+		current.PeriodSeconds != desired.PeriodSeconds || current.FailureThreshold != desired.FailureThreshold {
+		return false
+	}
+	portEqual := func(current, desired int32) bool { return desired == 0 || current == desired }
+	path := func(path string) string {
+		if path == "" {
+			return "/"
+		}
+		return path
+	}
+	switch probe := desired.ProbeType.(type) {
+	case *runpb.Probe_HttpGet:
+		action := current.GetHttpGet()
+		return action != nil && path(action.Path) == path(probe.HttpGet.Path) && portEqual(action.Port, probe.HttpGet.Port)
+	case *runpb.Probe_TcpSocket:
+		action := current.GetTcpSocket()
+		return action != nil && portEqual(action.Port, probe.TcpSocket.Port)
+	case *runpb.Probe_Grpc:
+		action := current.GetGrpc()
+		return action != nil && action.Service == probe.Grpc.Service && portEqual(action.Port, probe.Grpc.Port)
+	}
+	return current.ProbeType == nil
+}
+
+// volumesEqual compares the secret volumes, ignoring the fields defaulted by Cloud Run
+func volumesEqual(a, b []*runpb.Volume) bool {
+	return slices.EqualFunc(a, b, func(x, y *runpb.Volume) bool {
+		if x.Name != y.Name || x.GetSecret().GetSecret() != y.GetSecret().GetSecret() {
+			return false
+		}
+		return slices.EqualFunc(x.GetSecret().GetItems(), y.GetSecret().GetItems(), func(i, j *runpb.VersionToPath) bool {
+			return i.Path == j.Path && i.Version == j.Version
+		})
+	})
 }
 
 func isRunServiceNotFoundError(err error) bool {
@@ -0,0 +1,69 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+// configMapRefIndexKey indexes CloudRuns by the names of the ConfigMaps they reference
+const configMapRefIndexKey = ".spec.configMapRefs"
+
+// ResolveConfigMaps reads the ConfigMaps referenced by the CloudRun, failing if a referenced key is missing
+func ResolveConfigMaps(ctx context.Context, c client.Reader, run gcpv2.CloudRun) (gcpv2.ConfigMapData, error) {
+	config := gcpv2.ConfigMapData{}
+	for _, name := range run.ConfigMapReferences() {
+		var configMap corev1.ConfigMap
+		if err := c.Get(ctx, types.NamespacedName{Namespace: run.Namespace, Name: name}, &configMap); err != nil {
+			return nil, fmt.Errorf("failed to get config map %s: %w", name, err)
+		}
+		config[name] = configMap.Data
+	}
+	for _, container := range run.Spec.Containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil || env.ValueFrom.ConfigMapKeyRef == nil {
+				continue
+			}
+			ref := env.ValueFrom.ConfigMapKeyRef
+			if _, ok := config[ref.Name][ref.Key]; !ok {
+				return nil, fmt.Errorf("config map %s has no key %s", ref.Name, ref.Key)
+			}
+		}
+	}
+	return config, nil
+}
+
+func configMapRefIndexer(obj client.Object) []string {
+	run, ok := obj.(*gcpv2.CloudRun)
+	if !ok {
+		return nil
+	}
+	return run.ConfigMapReferences()
+}
+
+// cloudRunsForConfigMap maps a ConfigMap to the CloudRuns referencing it
+func (r *CloudRunReconciler) cloudRunsForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.cloudRunsReferencing(ctx, configMapRefIndexKey, obj)
+}
@@ -19,19 +19,34 @@ package gcp
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/iam/apiv1/iampb"
 	gcprun "cloud.google.com/go/run/apiv2"
+	"cloud.google.com/go/run/apiv2/runpb"
 	"google.golang.org/api/option"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/metrics"
+	"github.com/tjololo/stilas/internal/plan"
+	"github.com/tjololo/stilas/internal/policy"
+	"github.com/tjololo/stilas/internal/services/gcp"
+	"github.com/tjololo/stilas/internal/tracing"
 )
 
 const finalizerName = "cloudrun.gcp.stilas.418.cloud/finalizer"
@@ -42,43 +57,92 @@ type CloudRunReconciler struct {
 	Scheme        *runtime.Scheme
 	NewClient     newCloudRunServiceClient
 	ClientOptions []option.ClientOption
+	SecretManager gcp.SecretManagerService
+	Recorder      record.EventRecorder
+	// ProjectPolicy restricts the projects a namespace can target, not enforced when nil
+	ProjectPolicy *policy.ProjectPolicy
+	// Paused suspends all changes to GCP, the resources only get their status refreshed
+	Paused bool
+	// PlanOnly reports the changes to GCP in the status of the resources instead of making them
+	PlanOnly bool
+
+	clients gcp.ClientCache[*gcprun.ServicesClient]
+	backoff gcp.Backoff
 }
 
 //+kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=cloudruns,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=cloudruns/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=cloudruns/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the CloudRun object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// A CloudRun is first checked against the GcpProjectBindings, unless it is deleting. The paused and
+// plan-only modes are then applied: a paused deletion waits, a plan-only deletion reports its plan
+// and releases the finalizer. Only then are the credentials of the provider config resolved, before
+// the service is deleted, or its status refreshed or changes planned while paused or plan-only.
+// Otherwise the finalizer is added and running operations are polled. Once they are done the secrets
+// are synced to Secret Manager, the service is created or updated from the spec and its ConfigMaps,
+// and its IAM policy is set.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.2/pkg/reconcile
 func (r *CloudRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
-	var run gcpv1.CloudRun
+	var run gcpv2.CloudRun
 	if err := r.Client.Get(ctx, req.NamespacedName, &run); err != nil {
 		if !errors.IsNotFound(err) {
 			logger.Error(err, "unable to fetch CloudRun")
 		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
-	logger.Info(fmt.Sprintf("Reconciling CloudRun: %+v", run.Spec))
-	if !controllerutil.ContainsFinalizer(&run, finalizerName) {
-		controllerutil.AddFinalizer(&run, finalizerName)
-		if err := r.Client.Update(ctx, &run); err != nil {
-			logger.Error(err, "unable to add finalizer")
+	logger.V(1).Info("Reconciling CloudRun", "generation", run.Generation)
+	tracing.SetProject(ctx, run.Spec.ProjectID)
+	status := newStatusPatcher(r.Client, &run)
+	if run.DeletionTimestamp == nil {
+		allowed, err := checkProjectPolicy(ctx, status, r.Recorder, r.ProjectPolicy, &run, &run.Status.Conditions, run.Spec.ProjectID, run.Spec.Location)
+		if !allowed {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, nil
 	}
-
+	paused := setPaused(r.Recorder, &run, &run.Status.Conditions, r.Paused)
+	planned := !paused && planOnly(&run, r.PlanOnly)
+	if !planned {
+		run.Status.Plan = ""
+	}
 	if run.DeletionTimestamp != nil {
-		return ctrl.Result{RequeueAfter: time.Second}, r.handleDeletion(ctx, run)
+		if !controllerutil.ContainsFinalizer(&run, finalizerName) {
+			return ctrl.Result{}, nil
+		}
+		if paused {
+			logger.Info("CloudRun is paused, the deletion waits until it is resumed")
+			return ctrl.Result{}, status.patch(ctx, &run)
+		}
+		if planned {
+			deletion := &plan.Plan{}
+			deletion.Add(plan.ActionDelete, run.GetGcpCloudRunServiceFullName())
+			return ctrl.Result{}, releasePlannedDeletion(ctx, r.Client, r.Recorder, status, &run, &run.Status.Plan, deletion)
+		}
+	}
+	ctx, err := withCredentials(ctx, r.Client, run.Namespace, cloudRunProviderConfigRef(run.Spec.ProviderConfigRef))
+	if err != nil {
+		return credentialsFailed(ctx, status, r.Recorder, &run, &run.Status.Conditions, err)
+	}
+	if run.DeletionTimestamp != nil {
+		return ctrl.Result{RequeueAfter: time.Second}, r.handleDeletion(ctx, run, status)
+	}
+	if paused {
+		return r.observe(ctx, &run, status)
+	}
+	if planned {
+		return r.reconcilePlan(ctx, &run, status)
+	}
+	if err := addFinalizer(ctx, r.Client, &run); err != nil {
+		logger.Error(err, "unable to add finalizer")
+		return ctrl.Result{}, err
 	}
 
 	runningOperations := getOngoingOperations(run.Status.Operations)
@@ -88,14 +152,20 @@ func (r *CloudRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			done, err := r.checkRunOperationStatus(ctx, operation.Name)
 			if err != nil {
 				logger.Error(err, "unable to check cloud run operation")
-				return ctrl.Result{}, err
+				r.Recorder.Eventf(&run, corev1.EventTypeWarning, EventReasonOperationFailed, "%s operation %s failed: %v", operation.OperationType, operation.Name, err)
+				return handleGcpError(ctx, status, &run, &run.Status.Conditions, &r.backoff, err)
 			}
 			if !done {
 				allDone = false
+			} else {
+				r.Recorder.Eventf(&run, corev1.EventTypeNormal, EventReasonOperationCompleted, "%s operation %s completed", operation.OperationType, operation.Name)
+				if operation.StartTime != nil {
+					metrics.ObserveOperation("CloudRun", string(operation.OperationType), operation.StartTime.Time)
+				}
 			}
 			updateOperationStatusByName(&run, operation.Name, done)
 		}
-		if err := r.Client.Status().Update(ctx, &run); err != nil {
+		if err := status.patch(ctx, &run); err != nil {
 			logger.Error(err, "unable to update cloud run status")
 			return ctrl.Result{}, err
 		}
@@ -103,77 +173,248 @@ func (r *CloudRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			return ctrl.Result{RequeueAfter: time.Second}, nil
 		}
 	} else {
+		secretsChanged, err := r.syncSecrets(ctx, &run)
+		if err != nil {
+			logger.Error(err, "unable to sync secrets")
+			r.Recorder.Event(&run, corev1.EventTypeWarning, EventReasonSecretSyncFailed, err.Error())
+			return handleGcpError(ctx, status, &run, &run.Status.Conditions, &r.backoff, err)
+		}
+		if secretsChanged {
+			if err := status.patch(ctx, &run); err != nil {
+				logger.Error(err, "unable to update cloud run status")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true}, nil
+		}
+		config, err := ResolveConfigMaps(ctx, r.Client, run)
+		if err != nil {
+			logger.Error(err, "unable to resolve config maps")
+			r.Recorder.Event(&run, corev1.EventTypeWarning, EventReasonConfigFailed, err.Error())
+			return ctrl.Result{}, err
+		}
 		srv, err := r.getRunService(ctx, run)
 		if err == nil {
-			if srv.Template.Containers[0].Image != run.Spec.Containers[0].Image {
-				srv.Template.Containers[0].Image = run.Spec.Containers[0].Image
-				logger.Info(fmt.Sprintf("Image has changed, current image: %s, new image: %s", srv.Template.Containers[0].Image, run.Spec.Containers[0].Image))
-				cr, err := r.updateRunService(ctx, srv)
+			desired, fields, err := ServiceChanges(srv, run, config)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if len(fields) > 0 {
+				drift := &plan.Plan{}
+				drift.Add(plan.ActionUpdate, run.GetGcpCloudRunServiceFullName(), fields...)
+				recordDrift("CloudRun", drift)
+				logger.Info("Service has changed, updating cloud run service", "fields", fields)
+				cr, err := r.updateRunService(ctx, desired)
 				if err != nil {
 					logger.Error(err, "unable to update cloud run service")
-					return ctrl.Result{}, err
+					r.Recorder.Eventf(&run, corev1.EventTypeWarning, EventReasonUpdateFailed, "Failed to update service %s: %v", run.GetGcpCloudRunServiceFullName(), err)
+					return handleGcpError(ctx, status, &run, &run.Status.Conditions, &r.backoff, err)
 				}
-				run.Status.Operations = append(run.Status.Operations, &gcpv1.CloudRunOperation{
+				r.Recorder.Eventf(&run, corev1.EventTypeNormal, EventReasonUpdating, "Updating service %s, changed fields: %s", run.GetGcpCloudRunServiceFullName(), strings.Join(fields, ", "))
+				run.Status.Operations = append(run.Status.Operations, &gcpv2.CloudRunOperation{
 					Name:          cr.Name(),
 					Done:          cr.Done(),
-					OperationType: gcpv1.CloudRunOperationType_Update,
+					OperationType: gcpv2.CloudRunOperationType_Update,
+					StartTime:     ptr.To(metav1.Now()),
 				})
-				if err := r.Client.Status().Update(ctx, &run); err != nil {
+				if err := status.patch(ctx, &run); err != nil {
 					logger.Error(err, "unable to update cloud run status")
 					return ctrl.Result{}, err
 				}
+				return ctrl.Result{RequeueAfter: time.Second}, nil
 			} else {
-				run.Status.Uri = srv.Uri
-				run.Status.LatestReadyRevision = srv.LatestReadyRevision
-				run.Status.Reconciling = srv.Reconciling
-				if err := r.Client.Status().Update(ctx, &run); err != nil {
+				observeService(&run, srv)
+				setSynced(&run, &run.Status.Conditions, &r.backoff)
+				if !srv.Reconciling {
+					if _, err := r.cleanupSecrets(ctx, &run, srv); err != nil {
+						logger.Error(err, "unable to clean up secrets")
+						return handleGcpError(ctx, status, &run, &run.Status.Conditions, &r.backoff, err)
+					}
+				}
+				if err := status.patch(ctx, &run); err != nil {
 					logger.Error(err, "unable to update cloud run status")
 					return ctrl.Result{}, err
 				}
-				err = r.setIamPolicy(ctx, run)
+				applied, err := r.setIamPolicy(ctx, run)
 				if err != nil {
 					logger.Error(err, "unable to set iam policy")
-					return ctrl.Result{}, err
+					r.Recorder.Eventf(&run, corev1.EventTypeWarning, EventReasonIamPolicyFailed, "Failed to apply iam policy: %v", err)
+					return handleGcpError(ctx, status, &run, &run.Status.Conditions, &r.backoff, err)
+				}
+				if applied {
+					drift := &plan.Plan{}
+					drift.Add(plan.ActionSetIamPolicy, run.GetGcpCloudRunServiceFullName())
+					recordDrift("CloudRun", drift)
+					r.Recorder.Eventf(&run, corev1.EventTypeNormal, EventReasonIamPolicyApplied, "Granted roles/run.invoker to %s", strings.Join(run.Spec.Security.InvokeMembers, ", "))
 				}
 			}
 		} else {
+			if isRunServiceNotFoundError(err) && adopting(&run) {
+				return adoptedResourceMissing(ctx, status, r.Recorder, &run, &run.Status.Conditions, run.GetGcpCloudRunServiceFullName())
+			}
 			if isRunServiceNotFoundError(err) {
-				cr, err := r.createRunService(ctx, run)
+				cr, err := r.createRunService(ctx, run, config)
 				if err != nil {
 					logger.Error(err, "unable to create cloud run service")
-					return ctrl.Result{}, err
+					r.Recorder.Eventf(&run, corev1.EventTypeWarning, EventReasonCreateFailed, "Failed to create service %s: %v", run.GetGcpCloudRunServiceFullName(), err)
+					return handleGcpError(ctx, status, &run, &run.Status.Conditions, &r.backoff, err)
 				}
-				run.Status.Operations = append(run.Status.Operations, &gcpv1.CloudRunOperation{
+				r.Recorder.Eventf(&run, corev1.EventTypeNormal, EventReasonCreating, "Creating service %s", run.GetGcpCloudRunServiceFullName())
+				run.Status.Operations = append(run.Status.Operations, &gcpv2.CloudRunOperation{
 					Name:          cr.Name(),
 					Done:          cr.Done(),
-					OperationType: gcpv1.CloudRunOperationType_Create,
+					OperationType: gcpv2.CloudRunOperationType_Create,
+					StartTime:     ptr.To(metav1.Now()),
 				})
-				if err := r.Client.Status().Update(ctx, &run); err != nil {
+				if err := status.patch(ctx, &run); err != nil {
 					logger.Error(err, "unable to update cloud run status")
 					return ctrl.Result{}, err
 				}
-				return ctrl.Result{}, nil
+				return ctrl.Result{RequeueAfter: time.Second}, nil
+			}
+			logger.Error(err, "unable to get cloud run service")
+			return handleGcpError(ctx, status, &run, &run.Status.Conditions, &r.backoff, err)
+		}
+	}
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
+
+// observe refreshes the status of a paused run from GCP without changing anything there
+func (r *CloudRunReconciler) observe(ctx context.Context, run *gcpv2.CloudRun, status *statusPatcher) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	if runningOperations := getOngoingOperations(run.Status.Operations); runningOperations != nil {
+		for _, operation := range *runningOperations {
+			done, err := r.checkRunOperationStatus(ctx, operation.Name)
+			if err != nil {
+				logger.Error(err, "unable to check cloud run operation")
+				return handleGcpError(ctx, status, run, &run.Status.Conditions, &r.backoff, err)
 			}
+			updateOperationStatusByName(run, operation.Name, done)
 		}
 	}
-	return ctrl.Result{RequeueAfter: time.Minute}, nil
+	srv, err := r.getRunService(ctx, *run)
+	if err != nil && !isRunServiceNotFoundError(err) {
+		logger.Error(err, "unable to get cloud run service")
+		return handleGcpError(ctx, status, run, &run.Status.Conditions, &r.backoff, err)
+	}
+	if err == nil {
+		observeService(run, srv)
+	}
+	if err := status.patch(ctx, run); err != nil {
+		logger.Error(err, "unable to update cloud run status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
+
+// reconcilePlan reports the changes a reconcile would make to the GCP resources of run in its
+// status, without making them, and refreshes the observed status
+func (r *CloudRunReconciler) reconcilePlan(ctx context.Context, run *gcpv2.CloudRun, status *statusPatcher) (ctrl.Result, error) {
+	p, err := r.planChanges(ctx, run)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "unable to plan cloud run changes")
+		return handleGcpError(ctx, status, run, &run.Status.Conditions, &r.backoff, err)
+	}
+	reportPlan(r.Recorder, run, &run.Status.Plan, p)
+	return r.observe(ctx, run, status)
+}
+
+// planChanges returns the changes a reconcile would make to the GCP resources of run
+func (r *CloudRunReconciler) planChanges(ctx context.Context, run *gcpv2.CloudRun) (*plan.Plan, error) {
+	p := &plan.Plan{}
+	if err := r.planSecrets(ctx, run, p); err != nil {
+		return nil, err
+	}
+	config, err := ResolveConfigMaps(ctx, r.Client, *run)
+	if err != nil {
+		return nil, err
+	}
+	name := run.GetGcpCloudRunServiceFullName()
+	srv, err := r.getRunService(ctx, *run)
+	if isRunServiceNotFoundError(err) && adopting(run) {
+		// an adopted service is never created, the reconcile reports it missing instead
+		return p, nil
+	}
+	if isRunServiceNotFoundError(err) {
+		p.Add(plan.ActionCreate, name)
+		p.Add(plan.ActionSetIamPolicy, name)
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, fields, err := ServiceChanges(srv, *run, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		p.Add(plan.ActionUpdate, name, fields...)
+	}
+	outdated, err := r.iamPolicyOutdated(ctx, *run)
+	if err != nil {
+		return nil, err
+	}
+	if outdated {
+		p.Add(plan.ActionSetIamPolicy, name)
+	}
+	return p, nil
+}
+
+// observeService copies the observed state of srv to the status of run
+func observeService(run *gcpv2.CloudRun, srv *runpb.Service) {
+	run.Status.Uri = srv.Uri
+	run.Status.LatestReadyRevision = srv.LatestReadyRevision
+	run.Status.Reconciling = srv.Reconciling
+	run.Status.Ready = srv.TerminalCondition.GetState() == runpb.Condition_CONDITION_SUCCEEDED
+	run.Status.Traffic = gcpv2.ConvertFromTrafficStatuses(srv.TrafficStatuses)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CloudRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.Add(&r.clients); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gcpv2.CloudRun{}, secretRefIndexKey, secretRefIndexer); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gcpv2.CloudRun{}, configMapRefIndexKey, configMapRefIndexer); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&gcpv1.CloudRun{}).
-		Complete(r)
+		For(&gcpv2.CloudRun{}, builder.WithPredicates(specChanged)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.cloudRunsForSecret)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.cloudRunsForConfigMap)).
+		Watches(&gcpv1.GcpProjectBinding{}, handler.EnqueueRequestsFromMapFunc(enqueueAll(r.Client, func() client.ObjectList { return &gcpv2.CloudRunList{} }))).
+		Complete(tracing.Reconciler("CloudRun", r))
 }
 
-func (r *CloudRunReconciler) setIamPolicy(ctx context.Context, cloudRun gcpv1.CloudRun) error {
-	c, err := r.getClient(ctx)
+// iamPolicyOutdated returns true if the IAM policy of the service does not grant roles/run.invoker
+// to exactly the invoke members
+func (r *CloudRunReconciler) iamPolicyOutdated(ctx context.Context, cloudRun gcpv2.CloudRun) (bool, error) {
+	c, err := r.getClient(ctx, cloudRun.Spec.ProjectID)
 	if err != nil {
-		return fmt.Errorf("failed to create cloud run client: %w", err)
+		return false, fmt.Errorf("failed to create cloud run client: %w", err)
+	}
+	current, err := gcp.Call(ctx, gcp.ServiceCloudRun, cloudRun.Spec.ProjectID, "GetIamPolicy", func(ctx context.Context) (*iampb.Policy, error) {
+		return c.GetIamPolicy(ctx, &iampb.GetIamPolicyRequest{
+			Resource: cloudRun.GetGcpCloudRunServiceFullName(),
+		})
+	})
+	if err != nil {
+		return false, fmt.Errorf("GetIamPolicy: failed to get iam policy: %w", err)
+	}
+	return !invokerPolicyEqual(current, cloudRun.Spec.Security.InvokeMembers), nil
+}
+
+// setIamPolicy grants roles/run.invoker to the invoke members, returns true if the policy was changed
+func (r *CloudRunReconciler) setIamPolicy(ctx context.Context, cloudRun gcpv2.CloudRun) (bool, error) {
+	outdated, err := r.iamPolicyOutdated(ctx, cloudRun)
+	if err != nil || !outdated {
+		return false, err
+	}
+	c, err := r.getClient(ctx, cloudRun.Spec.ProjectID)
+	if err != nil {
+		return false, fmt.Errorf("failed to create cloud run client: %w", err)
 	}
-	defer func(c *gcprun.ServicesClient) {
-		_ = c.Close()
-	}(c)
 
 	policyRequest := &iampb.SetIamPolicyRequest{
 		Resource: cloudRun.GetGcpCloudRunServiceFullName(),
@@ -181,34 +422,55 @@ func (r *CloudRunReconciler) setIamPolicy(ctx context.Context, cloudRun gcpv1.Cl
 			Bindings: []*iampb.Binding{
 				{
 					Role:    "roles/run.invoker",
-					Members: cloudRun.Spec.InvokeMembers,
+					Members: cloudRun.Spec.Security.InvokeMembers,
 				},
 			},
 		},
 	}
 
-	_, err = c.SetIamPolicy(ctx, policyRequest)
+	_, err = gcp.Call(ctx, gcp.ServiceCloudRun, cloudRun.Spec.ProjectID, "SetIamPolicy", func(ctx context.Context) (*iampb.Policy, error) {
+		return c.SetIamPolicy(ctx, policyRequest)
+	})
 	if err != nil {
-		return fmt.Errorf("SetIamPolicy: failed to set iam policy: %w", err)
+		return false, fmt.Errorf("SetIamPolicy: failed to set iam policy: %w", err)
+	}
+	return true, nil
+}
+
+// invokerPolicyEqual returns true if the only binding of policy grants roles/run.invoker to members,
+// a policy without bindings grants it to no members
+func invokerPolicyEqual(policy *iampb.Policy, members []string) bool {
+	if len(policy.GetBindings()) == 0 {
+		return len(members) == 0
+	}
+	if len(policy.GetBindings()) != 1 || policy.Bindings[0].Role != "roles/run.invoker" {
+		return false
 	}
-	return nil
+	current := slices.Clone(policy.Bindings[0].Members)
+	desired := slices.Clone(members)
+	slices.Sort(current)
+	slices.Sort(desired)
+	return slices.Equal(current, desired)
 }
 
-func (r *CloudRunReconciler) handleDeletion(ctx context.Context, cloudRun gcpv1.CloudRun) error {
+func (r *CloudRunReconciler) handleDeletion(ctx context.Context, cloudRun gcpv2.CloudRun, status *statusPatcher) error {
 	logger := log.FromContext(ctx)
-	deleteOperations := getOperationsByType(cloudRun.Status.Operations, gcpv1.CloudRunOperationType_Delete)
+	deleteOperations := getOperationsByType(cloudRun.Status.Operations, gcpv2.CloudRunOperationType_Delete)
 	if deleteOperations == nil {
 		dso, err := r.deleteRunService(ctx, cloudRun)
 		if err != nil {
 			logger.Error(err, "unable to delete cloud run service")
+			r.Recorder.Eventf(&cloudRun, corev1.EventTypeWarning, EventReasonDeleteFailed, "Failed to delete service %s: %v", cloudRun.GetGcpCloudRunServiceFullName(), err)
 			return err
 		}
-		cloudRun.Status.Operations = append(cloudRun.Status.Operations, &gcpv1.CloudRunOperation{
+		r.Recorder.Eventf(&cloudRun, corev1.EventTypeNormal, EventReasonDeleting, "Deleting service %s", cloudRun.GetGcpCloudRunServiceFullName())
+		cloudRun.Status.Operations = append(cloudRun.Status.Operations, &gcpv2.CloudRunOperation{
 			Name:          dso.Name(),
 			Done:          dso.Done(),
-			OperationType: gcpv1.CloudRunOperationType_Delete,
+			OperationType: gcpv2.CloudRunOperationType_Delete,
+			StartTime:     ptr.To(metav1.Now()),
 		})
-		if err := r.Client.Status().Update(ctx, &cloudRun); err != nil {
+		if err := status.patch(ctx, &cloudRun); err != nil {
 			logger.Error(err, "unable to update cloud run status")
 			return err
 		}
@@ -219,6 +481,7 @@ func (r *CloudRunReconciler) handleDeletion(ctx context.Context, cloudRun gcpv1.
 			done, err := r.checkRunOperationStatus(ctx, operation.Name)
 			if err != nil {
 				logger.Error(err, "unable to check cloud run operation")
+				r.Recorder.Eventf(&cloudRun, corev1.EventTypeWarning, EventReasonOperationFailed, "%s operation %s failed: %v", operation.OperationType, operation.Name, err)
 				return err
 			}
 			if !done {
@@ -226,24 +489,29 @@ func (r *CloudRunReconciler) handleDeletion(ctx context.Context, cloudRun gcpv1.
 			}
 			updateOperationStatusByName(&cloudRun, operation.Name, done)
 		}
-		if err := r.Client.Status().Update(ctx, &cloudRun); err != nil {
+		if err := status.patch(ctx, &cloudRun); err != nil {
 			logger.Error(err, "unable to update cloud run status")
 			return err
 		}
 		if !allDone {
 			return nil
 		}
-		controllerutil.RemoveFinalizer(&cloudRun, finalizerName)
-		if err := r.Client.Update(ctx, &cloudRun); err != nil {
+		if err := r.deleteSecrets(ctx, cloudRun); err != nil {
+			logger.Error(err, "unable to delete secrets")
+			r.Recorder.Eventf(&cloudRun, corev1.EventTypeWarning, EventReasonDeleteFailed, "Failed to delete synced secrets: %v", err)
+			return err
+		}
+		if err := removeFinalizer(ctx, r.Client, &cloudRun); err != nil {
 			logger.Error(err, "unable to remove finalizer")
 			return err
 		}
+		r.Recorder.Eventf(&cloudRun, corev1.EventTypeNormal, EventReasonDeleted, "Deleted service %s", cloudRun.GetGcpCloudRunServiceFullName())
 		return nil
 	}
 }
 
-func getOngoingOperations(operations []*gcpv1.CloudRunOperation) *[]gcpv1.CloudRunOperation {
-	var ongoing []gcpv1.CloudRunOperation
+func getOngoingOperations(operations []*gcpv2.CloudRunOperation) *[]gcpv2.CloudRunOperation {
+	var ongoing []gcpv2.CloudRunOperation
 	for _, operation := range operations {
 		if !operation.Done {
 			ongoing = append(ongoing, *operation)
@@ -255,8 +523,8 @@ func getOngoingOperations(operations []*gcpv1.CloudRunOperation) *[]gcpv1.CloudR
 	return &ongoing
 }
 
-func getOperationsByType(operations []*gcpv1.CloudRunOperation, operationType gcpv1.CloudRunOperationType) *[]gcpv1.CloudRunOperation {
-	var operationsOfType []gcpv1.CloudRunOperation
+func getOperationsByType(operations []*gcpv2.CloudRunOperation, operationType gcpv2.CloudRunOperationType) *[]gcpv2.CloudRunOperation {
+	var operationsOfType []gcpv2.CloudRunOperation
 	for _, operation := range operations {
 		if operation.OperationType == operationType {
 			operationsOfType = append(operationsOfType, *operation)
@@ -268,7 +536,7 @@ func getOperationsByType(operations []*gcpv1.CloudRunOperation, operationType gc
 	return &operationsOfType
 }
 
-func updateOperationStatusByName(cloudRun *gcpv1.CloudRun, operationName string, done bool) {
+func updateOperationStatusByName(cloudRun *gcpv2.CloudRun, operationName string, done bool) {
 	for _, operation := range cloudRun.Status.Operations {
 		if operation.Name == operationName {
 			operation.Done = done
@@ -18,31 +18,61 @@ package gcp
 
 import (
 	"context"
+	"fmt"
 	"net"
 
-	"cloud.google.com/go/longrunning/autogen/longrunningpb"
 	gcprun "cloud.google.com/go/run/apiv2"
 	"cloud.google.com/go/run/apiv2/runpb"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"google.golang.org/api/option"
+	"google.golang.org/api/secretmanager/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/fakegcp"
 )
 
-type fakeCloudRunServiceClient struct {
-	runpb.UnimplementedServicesServer
+type mockSecretManagerService struct {
 }
 
-func (f *fakeCloudRunServiceClient) CreateService(_ context.Context, _ *runpb.CreateServiceRequest) (*longrunningpb.Operation, error) {
-	return &longrunningpb.Operation{Name: "test-operation", Done: true}, nil
+func (m *mockSecretManagerService) GetSecret(_ context.Context, _ string, secretId string) (*secretmanager.Secret, error) {
+	return &secretmanager.Secret{Name: secretId}, nil
+}
+
+func (m *mockSecretManagerService) CreateSecret(_ context.Context, _ string, secretId string, _ map[string]string) (*secretmanager.Secret, error) {
+	return &secretmanager.Secret{Name: secretId}, nil
+}
+
+func (m *mockSecretManagerService) DeleteSecret(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+func (m *mockSecretManagerService) AddSecretVersion(_ context.Context, project string, secretId string, _ []byte) (*secretmanager.SecretVersion, error) {
+	return &secretmanager.SecretVersion{Name: fmt.Sprintf("projects/%s/secrets/%s/versions/1", project, secretId)}, nil
+}
+
+func (m *mockSecretManagerService) ListSecretVersions(_ context.Context, _ string, _ string) ([]*secretmanager.SecretVersion, error) {
+	return nil, nil
+}
+
+func (m *mockSecretManagerService) DestroySecretVersion(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockSecretManagerService) GrantSecretAccessor(_ context.Context, _ string, _ string, _ string) error {
+	return nil
 }
 
 var _ = Describe("CloudRun Controller", func() {
@@ -55,21 +85,22 @@ var _ = Describe("CloudRun Controller", func() {
 			Name:      resourceName,
 			Namespace: "default", // TODO(user):Modify as needed
 		}
-		cloudrun := &gcpv1.CloudRun{}
+		cloudrun := &gcpv2.CloudRun{}
+		var fakeCloudRun *fakegcp.CloudRun
 		var fakeServerAddr string
 		BeforeEach(func() {
 			By("creating the custom resource for the Kind CloudRun")
 			err := k8sClient.Get(ctx, typeNamespacedName, cloudrun)
 			if err != nil && errors.IsNotFound(err) {
-				resource := &gcpv1.CloudRun{
+				resource := &gcpv2.CloudRun{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      resourceName,
 						Namespace: "default",
 					},
-					Spec: gcpv1.CloudRunSpec{
+					Spec: gcpv2.CloudRunSpec{
 						Location:  "us-central1",
 						ProjectID: "test-project",
-						Containers: []gcpv1.CloudRunContainer{
+						Containers: []gcpv2.CloudRunContainer{
 							{
 								Image: "gcr.io/test-project/test-image",
 								Name:  "test-container",
@@ -79,14 +110,14 @@ var _ = Describe("CloudRun Controller", func() {
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
-			By("Creating the fakeCloudRunServiceClient")
-			fakeCloudRunServiceClient := &fakeCloudRunServiceClient{}
+			By("Starting the fake Cloud Run API")
+			fakeCloudRun = fakegcp.NewCloudRun()
 			l, err := net.Listen("tcp", "localhost:0")
 			if err != nil {
 				Fail("failed to listen")
 			}
 			gsrv := grpc.NewServer()
-			runpb.RegisterServicesServer(gsrv, fakeCloudRunServiceClient)
+			fakeCloudRun.Register(gsrv)
 			fakeServerAddr = l.Addr().String()
 			go func() {
 				if err := gsrv.Serve(l); err != nil {
@@ -97,7 +128,7 @@ var _ = Describe("CloudRun Controller", func() {
 
 		AfterEach(func() {
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &gcpv1.CloudRun{}
+			resource := &gcpv2.CloudRun{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -106,10 +137,13 @@ var _ = Describe("CloudRun Controller", func() {
 		})
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
+			recorder := record.NewFakeRecorder(100)
 			controllerReconciler := &CloudRunReconciler{
-				Client:    k8sClient,
-				Scheme:    k8sClient.Scheme(),
-				NewClient: gcprun.NewServicesClient,
+				Client:        k8sClient,
+				Scheme:        k8sClient.Scheme(),
+				NewClient:     gcprun.NewServicesClient,
+				SecretManager: &mockSecretManagerService{},
+				Recorder:      recorder,
 				ClientOptions: []option.ClientOption{
 					option.WithEndpoint(fakeServerAddr),
 					option.WithoutAuthentication(),
@@ -121,8 +155,272 @@ var _ = Describe("CloudRun Controller", func() {
 				NamespacedName: typeNamespacedName,
 			})
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+
+			By("Checking the service was created with a running operation")
+			const serviceName = "projects/test-project/locations/us-central1/services/default-test-resource"
+			Expect(fakeCloudRun.Service(serviceName)).NotTo(BeNil())
+			Expect(fakeCloudRun.Service(serviceName).Reconciling).To(BeTrue())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, cloudrun)).To(Succeed())
+			Expect(cloudrun.Status.Operations).To(HaveLen(1))
+			Expect(cloudrun.Status.Operations[0].OperationType).To(Equal(gcpv2.CloudRunOperationType_Create))
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonCreating)))
+
+			By("Polling the operation until the service is ready")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeCloudRun.Service(serviceName).Reconciling).To(BeFalse())
+			Expect(fakeCloudRun.Revisions(serviceName)).To(HaveLen(1))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, cloudrun)).To(Succeed())
+			Expect(cloudrun.Status.Operations[0].Done).To(BeTrue())
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonOperationCompleted)))
 		})
 	})
 })
+
+var _ = Describe("CloudRun events", func() {
+	const serviceName = "projects/test-project/locations/europe-north1/services/default-hello"
+	var ctx context.Context
+	var fakeCloudRun *fakegcp.CloudRun
+	var recorder *record.FakeRecorder
+	var r *CloudRunReconciler
+	name := types.NamespacedName{Namespace: "default", Name: "hello"}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeCloudRun = fakegcp.NewCloudRun()
+		l, err := net.Listen("tcp", "localhost:0")
+		Expect(err).NotTo(HaveOccurred())
+		gsrv := grpc.NewServer()
+		fakeCloudRun.Register(gsrv)
+		go func() {
+			_ = gsrv.Serve(l)
+		}()
+		DeferCleanup(gsrv.Stop)
+
+		run := &gcpv2.CloudRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+			Spec: gcpv2.CloudRunSpec{
+				ProjectID:  "test-project",
+				Location:   "europe-north1",
+				Containers: []gcpv2.CloudRunContainer{{Name: "app", Image: "gcr.io/test-project/hello:1"}},
+			},
+		}
+		Expect((&gcpv2.CloudRunCustomDefaulter{}).Default(ctx, run)).To(Succeed())
+		recorder = record.NewFakeRecorder(10)
+		r = &CloudRunReconciler{
+			Client:        fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(run).WithStatusSubresource(run).Build(),
+			Scheme:        k8sClient.Scheme(),
+			NewClient:     gcprun.NewServicesClient,
+			SecretManager: fakegcp.NewSecretManager(),
+			Recorder:      recorder,
+			ClientOptions: []option.ClientOption{
+				option.WithEndpoint(l.Addr().String()),
+				option.WithoutAuthentication(),
+				option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+			},
+		}
+	})
+
+	reconcileRun := func() {
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	// createService reconciles the run until its service is created and in sync
+	createService := func() {
+		reconcileRun()
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonCreating)))
+		reconcileRun()
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonOperationCompleted)))
+		reconcileRun()
+		Expect(recorder.Events).NotTo(Receive())
+	}
+
+	It("Should record the creation of the service", func() {
+		reconcileRun()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonCreating),
+			ContainSubstring("Creating service "+serviceName),
+		)))
+		reconcileRun()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonOperationCompleted),
+			ContainSubstring("create operation"),
+		)))
+		Expect(fakeCloudRun.Service(serviceName)).NotTo(BeNil())
+	})
+
+	It("Should record the update of the service", func() {
+		createService()
+
+		var run gcpv2.CloudRun
+		Expect(r.Client.Get(ctx, name, &run)).To(Succeed())
+		run.Spec.Containers[0].Image = "gcr.io/test-project/hello:2"
+		Expect(r.Client.Update(ctx, &run)).To(Succeed())
+		reconcileRun()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonUpdating),
+			ContainSubstring("Updating service "+serviceName),
+		)))
+		reconcileRun()
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonOperationCompleted)))
+		Expect(fakeCloudRun.Service(serviceName).Template.Containers[0].Image).To(Equal("gcr.io/test-project/hello:2"))
+	})
+
+	It("Should record the deletion of the service", func() {
+		createService()
+
+		var run gcpv2.CloudRun
+		Expect(r.Client.Get(ctx, name, &run)).To(Succeed())
+		Expect(r.Client.Delete(ctx, &run)).To(Succeed())
+		reconcileRun()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonDeleting),
+			ContainSubstring("Deleting service "+serviceName),
+		)))
+		reconcileRun()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeNormal),
+			ContainSubstring(EventReasonDeleted),
+			ContainSubstring("Deleted service "+serviceName),
+		)))
+		Expect(fakeCloudRun.Service(serviceName)).To(BeNil())
+		Expect(errors.IsNotFound(r.Client.Get(ctx, name, &run))).To(BeTrue())
+	})
+
+	It("Should release a plan-only service without deleting it", func() {
+		createService()
+
+		var run gcpv2.CloudRun
+		Expect(r.Client.Get(ctx, name, &run)).To(Succeed())
+		run.Annotations = map[string]string{gcpv1.PlanOnlyAnnotation: "true"}
+		Expect(r.Client.Update(ctx, &run)).To(Succeed())
+		Expect(r.Client.Delete(ctx, &run)).To(Succeed())
+		reconcileRun()
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(EventReasonPlanned),
+			ContainSubstring("Delete "+serviceName),
+		)))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDeleted)))
+		Expect(fakeCloudRun.Service(serviceName)).NotTo(BeNil())
+		Expect(errors.IsNotFound(r.Client.Get(ctx, name, &run))).To(BeTrue())
+	})
+})
+
+var _ = Describe("CloudRun changes", func() {
+	var run gcpv2.CloudRun
+	var srv *runpb.Service
+
+	BeforeEach(func() {
+		run = gcpv2.CloudRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+			Spec: gcpv2.CloudRunSpec{
+				Location:  "europe-north1",
+				ProjectID: "test-project",
+				Containers: []gcpv2.CloudRunContainer{
+					{Name: "hello", Image: "nginx:1.27"},
+				},
+				Networking: gcpv2.CloudRunNetworking{Ingress: gcpv2.CloudRunIngress_All},
+			},
+		}
+		srv = run.ConvertToCreateServiceRequest(nil).Service
+		// the defaults of Cloud Run
+		srv.Template.Scaling = &runpb.RevisionScaling{MaxInstanceCount: 100}
+		srv.Template.MaxInstanceRequestConcurrency = 80
+		srv.Template.ServiceAccount = "123-compute@developer.gserviceaccount.com"
+		srv.Template.Containers[0].Ports = []*runpb.ContainerPort{{Name: "http1", ContainerPort: 8080}}
+		srv.Template.Containers[0].StartupProbe = &runpb.Probe{
+			TimeoutSeconds:   240,
+			PeriodSeconds:    240,
+			FailureThreshold: 1,
+			ProbeType:        &runpb.Probe_TcpSocket{TcpSocket: &runpb.TCPSocketAction{Port: 8080}},
+		}
+	})
+
+	It("Should keep the values Cloud Run defaulted", func() {
+		_, fields, err := ServiceChanges(srv, run, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+	})
+
+	It("Should update the scaling of an existing service", func() {
+		run.Spec.Scaling.MinInstances = ptr.To[int32](1)
+		run.Spec.Scaling.MaxInstances = ptr.To[int32](10)
+		desired, fields, err := ServiceChanges(srv, run, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("template.scaling.minInstanceCount", "template.scaling.maxInstanceCount"))
+		Expect(desired.Template.Scaling.MinInstanceCount).To(BeEquivalentTo(1))
+		Expect(desired.Template.Scaling.MaxInstanceCount).To(BeEquivalentTo(10))
+
+		srv = desired
+		run.Spec.Scaling.MinInstances = nil
+		desired, fields, err = ServiceChanges(srv, run, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("template.scaling.minInstanceCount"))
+		Expect(desired.Template.Scaling.MinInstanceCount).To(BeZero())
+	})
+
+	It("Should update the concurrency of an existing service", func() {
+		run.Spec.Scaling.MaxConcurrency = 10
+		desired, fields, err := ServiceChanges(srv, run, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("template.maxInstanceRequestConcurrency"))
+		Expect(desired.Template.MaxInstanceRequestConcurrency).To(BeEquivalentTo(10))
+	})
+
+	It("Should update the service account of an existing service", func() {
+		run.Spec.Security.ServiceAccount = "hello@test-project.iam.gserviceaccount.com"
+		desired, fields, err := ServiceChanges(srv, run, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("template.serviceAccount"))
+		Expect(desired.Template.ServiceAccount).To(Equal("hello@test-project.iam.gserviceaccount.com"))
+	})
+
+	It("Should update the ingress of an existing service", func() {
+		run.Spec.Networking.Ingress = gcpv2.CloudRunIngress_Internal
+		desired, fields, err := ServiceChanges(srv, run, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(ConsistOf("ingress"))
+		Expect(desired.Ingress).To(Equal(runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_ONLY))
+	})
+
+	It("Should update the port of an existing service", func() {
+		run.Spec.Containers[0].Port = 9090
+		desired, fields, err := ServiceChanges(srv, run, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).NotTo(BeEmpty())
+		Expect(desired.Template.Containers[0].Ports).To(ConsistOf(HaveField("ContainerPort", BeEquivalentTo(9090))))
+	})
+
+	It("Should update the probes of an existing service", func() {
+		run.Spec.Containers[0].LivenessProbe = &gcpv2.CloudRunProbe{
+			ProbeSpec:        gcpv2.CloudRunProbeSpec{ProbeType: gcpv2.CloudRunProbeType_HTTPGet, Path: ptr.To("/healthz")},
+			TimeoutSeconds:   5,
+			PeriodSeconds:    10,
+			FailureThreshold: 3,
+		}
+		run.Spec.Containers[0].StartupProbe = &gcpv2.CloudRunProbe{
+			ProbeSpec:        gcpv2.CloudRunProbeSpec{ProbeType: gcpv2.CloudRunProbeType_TCPSocket},
+			TimeoutSeconds:   5,
+			PeriodSeconds:    10,
+			FailureThreshold: 3,
+		}
+		desired, fields, err := ServiceChanges(srv, run, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).NotTo(BeEmpty())
+		Expect(desired.Template.Containers[0].LivenessProbe.GetHttpGet().Path).To(Equal("/healthz"))
+		Expect(desired.Template.Containers[0].StartupProbe.PeriodSeconds).To(BeEquivalentTo(10))
+
+		// the ports Cloud Run defaulted in the probes are no change
+		desired.Template.Containers[0].StartupProbe.GetTcpSocket().Port = 8080
+		_, fields, err = ServiceChanges(desired, run, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+	})
+})
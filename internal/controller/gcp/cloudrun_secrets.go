@@ -0,0 +1,304 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/run/apiv2/runpb"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/plan"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+// secretRefIndexKey indexes CloudRuns by the names of the Kubernetes Secrets they reference
+const secretRefIndexKey = ".spec.secretRefs"
+
+// syncSecrets copies the Kubernetes Secret keys referenced by the CloudRun into Secret Manager,
+// adding a new version when the data has changed. Returns true if the status was changed.
+func (r *CloudRunReconciler) syncSecrets(ctx context.Context, run *gcpv2.CloudRun) (bool, error) {
+	changed := false
+	for _, ref := range run.SecretReferences() {
+		data, checksum, err := r.secretData(ctx, run, ref)
+		if err != nil {
+			return changed, err
+		}
+		if current, ok := run.SecretVersion(ref); ok && current.Checksum == checksum {
+			continue
+		}
+		secretId := run.SecretManagerSecretId(ref)
+		if err := r.ensureSecretManagerSecret(ctx, *run, secretId); err != nil {
+			return changed, err
+		}
+		version, err := r.SecretManager.AddSecretVersion(ctx, run.Spec.ProjectID, secretId, data)
+		if err != nil {
+			return changed, fmt.Errorf("AddSecretVersion: failed to add version to secret %s: %w", secretId, err)
+		}
+		r.Recorder.Eventf(run, corev1.EventTypeNormal, EventReasonSecretSynced, "Synced key %s of secret %s to %s", ref.Key, ref.Name, version.Name)
+		setSecretVersion(run, gcpv2.CloudRunSecretVersion{
+			SecretName:          ref.Name,
+			Key:                 ref.Key,
+			SecretManagerSecret: secretId,
+			Version:             path.Base(version.Name),
+			Checksum:            checksum,
+		})
+		changed = true
+	}
+	return changed, nil
+}
+
+// planSecrets adds the secret versions syncSecrets would add to p
+func (r *CloudRunReconciler) planSecrets(ctx context.Context, run *gcpv2.CloudRun, p *plan.Plan) error {
+	for _, ref := range run.SecretReferences() {
+		_, checksum, err := r.secretData(ctx, run, ref)
+		if err != nil {
+			return err
+		}
+		if current, ok := run.SecretVersion(ref); ok && current.Checksum == checksum {
+			continue
+		}
+		p.Add(plan.ActionAddSecretVersion, fmt.Sprintf("projects/%s/secrets/%s", run.Spec.ProjectID, run.SecretManagerSecretId(ref)))
+	}
+	return nil
+}
+
+// secretData returns the value of the key ref points to and its checksum
+func (r *CloudRunReconciler) secretData(ctx context.Context, run *gcpv2.CloudRun, ref gcpv2.CloudRunSecretKeySelector) ([]byte, string, error) {
+	var secret corev1.Secret
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: run.Namespace, Name: ref.Name}, &secret); err != nil {
+		return nil, "", fmt.Errorf("failed to get secret %s: %w", ref.Name, err)
+	}
+	data, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, "", fmt.Errorf("secret %s has no key %s", ref.Name, ref.Key)
+	}
+	hash := sha256.Sum256(data)
+	return data, hex.EncodeToString(hash[:]), nil
+}
+
+func (r *CloudRunReconciler) ensureSecretManagerSecret(ctx context.Context, run gcpv2.CloudRun, secretId string) error {
+	_, err := r.SecretManager.GetSecret(ctx, run.Spec.ProjectID, secretId)
+	if err != nil {
+		if apiErr := gcp.ApiErrorFromErr(err); apiErr == nil || apiErr.HTTPCode() != 404 {
+			return fmt.Errorf("GetSecret: failed to get secret %s: %w", secretId, err)
+		}
+		_, err = r.SecretManager.CreateSecret(ctx, run.Spec.ProjectID, secretId, map[string]string{
+			gcp.SecretManagedByLabel: "stilas",
+		})
+		if err != nil {
+			return fmt.Errorf("CreateSecret: failed to create secret %s: %w", secretId, err)
+		}
+	}
+	if run.Spec.Security.ServiceAccount != "" {
+		member := fmt.Sprintf("serviceAccount:%s", run.Spec.Security.ServiceAccount)
+		if err := r.SecretManager.GrantSecretAccessor(ctx, run.Spec.ProjectID, secretId, member); err != nil {
+			return fmt.Errorf("GrantSecretAccessor: failed to grant access to secret %s: %w", secretId, err)
+		}
+	}
+	return nil
+}
+
+// cleanupSecrets destroys the versions of the synced secrets no revision serving traffic reads and
+// deletes the Secret Manager secrets no longer referenced by the CloudRun once no such revision
+// reads them. It should only be called once srv has rolled out the latest revision. Nothing is
+// removed while a revision serving traffic has no recorded versions, like one created before they
+// were recorded. Returns true if the status was changed.
+func (r *CloudRunReconciler) cleanupSecrets(ctx context.Context, run *gcpv2.CloudRun, srv *runpb.Service) (bool, error) {
+	changed := recordRevisionSecretVersions(run, srv)
+	inUse, complete := revisionSecretVersionsInUse(run)
+	if !complete {
+		return changed, nil
+	}
+	referenced := map[gcpv2.CloudRunSecretKeySelector]bool{}
+	for _, ref := range run.SecretReferences() {
+		referenced[ref] = true
+	}
+	var kept []gcpv2.CloudRunSecretVersion
+	for _, version := range run.Status.SecretVersions {
+		if !referenced[gcpv2.CloudRunSecretKeySelector{Name: version.SecretName, Key: version.Key}] {
+			if inUse[version.SecretManagerSecret] != nil {
+				kept = append(kept, version)
+				continue
+			}
+			if err := r.deleteSecretManagerSecret(ctx, run.Spec.ProjectID, version.SecretManagerSecret); err != nil {
+				return changed, err
+			}
+			continue
+		}
+		kept = append(kept, version)
+		versions, err := r.SecretManager.ListSecretVersions(ctx, run.Spec.ProjectID, version.SecretManagerSecret)
+		if err != nil {
+			return changed, fmt.Errorf("ListSecretVersions: failed to list versions of secret %s: %w", version.SecretManagerSecret, err)
+		}
+		for _, v := range versions {
+			if id := path.Base(v.Name); id == version.Version || inUse[version.SecretManagerSecret][id] {
+				continue
+			}
+			if err := r.SecretManager.DestroySecretVersion(ctx, v.Name); err != nil {
+				return changed, fmt.Errorf("DestroySecretVersion: failed to destroy version %s: %w", v.Name, err)
+			}
+		}
+	}
+	changed = changed || len(kept) != len(run.Status.SecretVersions)
+	run.Status.SecretVersions = kept
+	return changed, nil
+}
+
+// servingRevisions returns the names of the revisions in the traffic split of run
+func servingRevisions(run *gcpv2.CloudRun) map[string]bool {
+	serving := map[string]bool{}
+	for _, target := range run.Status.Traffic {
+		switch {
+		case target.Revision != "":
+			serving[target.Revision] = true
+		case target.LatestRevision && run.Status.LatestReadyRevision != "":
+			serving[path.Base(run.Status.LatestReadyRevision)] = true
+		}
+	}
+	return serving
+}
+
+// recordRevisionSecretVersions records the versions the latest revision of srv reads and forgets
+// the revisions no longer serving traffic. Returns true if the status was changed.
+func recordRevisionSecretVersions(run *gcpv2.CloudRun, srv *runpb.Service) bool {
+	serving := servingRevisions(run)
+	var records []gcpv2.CloudRunRevisionSecretVersions
+	recorded := map[string]bool{}
+	for _, record := range run.Status.RevisionSecretVersions {
+		if serving[record.Revision] {
+			records = append(records, record)
+			recorded[record.Revision] = true
+		}
+	}
+	// the latest ready revision reads the current versions unless a newer revision failed
+	latest := path.Base(srv.LatestReadyRevision)
+	if srv.LatestReadyRevision != "" && srv.LatestReadyRevision == srv.LatestCreatedRevision && serving[latest] && !recorded[latest] {
+		record := gcpv2.CloudRunRevisionSecretVersions{Revision: latest}
+		for _, ref := range run.SecretReferences() {
+			if version, ok := run.SecretVersion(ref); ok {
+				record.Versions = append(record.Versions, fmt.Sprintf("%s/versions/%s", version.SecretManagerSecret, version.Version))
+			}
+		}
+		records = append(records, record)
+	}
+	changed := !equality.Semantic.DeepEqual(records, run.Status.RevisionSecretVersions)
+	run.Status.RevisionSecretVersions = records
+	return changed
+}
+
+// revisionSecretVersionsInUse returns the versions read by the revisions serving traffic by secret
+// id, and false if the versions of one of them are unknown
+func revisionSecretVersionsInUse(run *gcpv2.CloudRun) (map[string]map[string]bool, bool) {
+	recorded := map[string]bool{}
+	inUse := map[string]map[string]bool{}
+	for _, record := range run.Status.RevisionSecretVersions {
+		recorded[record.Revision] = true
+		for _, name := range record.Versions {
+			secretId, version, _ := strings.Cut(name, "/versions/")
+			if inUse[secretId] == nil {
+				inUse[secretId] = map[string]bool{}
+			}
+			inUse[secretId][version] = true
+		}
+	}
+	for revision := range servingRevisions(run) {
+		if !recorded[revision] {
+			return nil, false
+		}
+	}
+	return inUse, true
+}
+
+// deleteSecrets deletes all Secret Manager secrets synced for the CloudRun
+func (r *CloudRunReconciler) deleteSecrets(ctx context.Context, run gcpv2.CloudRun) error {
+	for _, version := range run.Status.SecretVersions {
+		if err := r.deleteSecretManagerSecret(ctx, run.Spec.ProjectID, version.SecretManagerSecret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *CloudRunReconciler) deleteSecretManagerSecret(ctx context.Context, project string, secretId string) error {
+	err := r.SecretManager.DeleteSecret(ctx, project, secretId)
+	if err != nil {
+		if apiErr := gcp.ApiErrorFromErr(err); apiErr != nil && apiErr.HTTPCode() == 404 {
+			return nil
+		}
+		return fmt.Errorf("DeleteSecret: failed to delete secret %s: %w", secretId, err)
+	}
+	return nil
+}
+
+func setSecretVersion(run *gcpv2.CloudRun, version gcpv2.CloudRunSecretVersion) {
+	for i, v := range run.Status.SecretVersions {
+		if v.SecretName == version.SecretName && v.Key == version.Key {
+			run.Status.SecretVersions[i] = version
+			return
+		}
+	}
+	run.Status.SecretVersions = append(run.Status.SecretVersions, version)
+}
+
+func secretRefIndexer(obj client.Object) []string {
+	run, ok := obj.(*gcpv2.CloudRun)
+	if !ok {
+		return nil
+	}
+	var names []string
+	seen := map[string]bool{}
+	for _, ref := range run.SecretReferences() {
+		if !seen[ref.Name] {
+			seen[ref.Name] = true
+			names = append(names, ref.Name)
+		}
+	}
+	return names
+}
+
+// cloudRunsForSecret maps a Kubernetes Secret to the CloudRuns referencing it
+func (r *CloudRunReconciler) cloudRunsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.cloudRunsReferencing(ctx, secretRefIndexKey, obj)
+}
+
+// cloudRunsReferencing lists the CloudRuns in the namespace of obj whose index field matches its name
+func (r *CloudRunReconciler) cloudRunsReferencing(ctx context.Context, indexKey string, obj client.Object) []reconcile.Request {
+	var runs gcpv2.CloudRunList
+	if err := r.Client.List(ctx, &runs, client.InNamespace(obj.GetNamespace()), client.MatchingFields{indexKey: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list cloud runs", "index", indexKey, "name", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(runs.Items))
+	for _, run := range runs.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: run.Namespace, Name: run.Name},
+		})
+	}
+	return requests
+}
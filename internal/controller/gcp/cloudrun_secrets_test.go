@@ -0,0 +1,152 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"cloud.google.com/go/run/apiv2/runpb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/fakegcp"
+)
+
+var _ = Describe("CloudRun secret cleanup", func() {
+	const serviceName = "projects/test-project/locations/europe-north1/services/default-hello"
+	var ctx context.Context
+	var secretManager *fakegcp.SecretManager
+	var r *CloudRunReconciler
+	var run *gcpv2.CloudRun
+	var secretId string
+	var srv *runpb.Service
+
+	// enabledVersions returns the ids of the enabled versions of the secret, newest first
+	enabledVersions := func(secretId string) []string {
+		versions, err := secretManager.ListSecretVersions(ctx, "test-project", secretId)
+		Expect(err).NotTo(HaveOccurred())
+		var ids []string
+		for _, version := range versions {
+			ids = append(ids, path.Base(version.Name))
+		}
+		return ids
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		secretManager = fakegcp.NewSecretManager()
+		r = &CloudRunReconciler{SecretManager: secretManager, Recorder: record.NewFakeRecorder(10)}
+		run = &gcpv2.CloudRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+			Spec: gcpv2.CloudRunSpec{
+				ProjectID: "test-project",
+				Location:  "europe-north1",
+				Containers: []gcpv2.CloudRunContainer{{
+					Name:  "app",
+					Image: "hello",
+					Env: []gcpv2.CloudRunEnvVar{{Name: "PASSWORD", ValueFrom: &gcpv2.CloudRunEnvVarSource{
+						SecretKeyRef: &gcpv2.CloudRunSecretKeySelector{Name: "db", Key: "password"},
+					}}},
+				}},
+				Security: gcpv2.CloudRunSecurity{ServiceAccount: "hello@test-project.iam.gserviceaccount.com"},
+			},
+		}
+		secretId = run.SecretManagerSecretId(gcpv2.CloudRunSecretKeySelector{Name: "db", Key: "password"})
+		_, err := secretManager.CreateSecret(ctx, "test-project", secretId, nil)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 3; i++ {
+			_, err := secretManager.AddSecretVersion(ctx, "test-project", secretId, []byte(fmt.Sprintf("password-%d", i)))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		run.Status.SecretVersions = []gcpv2.CloudRunSecretVersion{{
+			SecretName: "db", Key: "password", SecretManagerSecret: secretId, Version: "3",
+		}}
+		run.Status.LatestReadyRevision = serviceName + "/revisions/hello-00003"
+		run.Status.Traffic = []gcpv2.CloudRunTrafficStatus{
+			{Revision: "hello-00001", Percent: 50},
+			{LatestRevision: true, Percent: 50},
+		}
+		srv = &runpb.Service{
+			Name:                  serviceName,
+			LatestReadyRevision:   serviceName + "/revisions/hello-00003",
+			LatestCreatedRevision: serviceName + "/revisions/hello-00003",
+		}
+	})
+
+	It("Should keep the versions read by the revisions serving traffic", func() {
+		run.Status.RevisionSecretVersions = []gcpv2.CloudRunRevisionSecretVersions{
+			{Revision: "hello-00001", Versions: []string{secretId + "/versions/1"}},
+		}
+		changed, err := r.cleanupSecrets(ctx, run, srv)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(enabledVersions(secretId)).To(Equal([]string{"3", "1"}))
+		Expect(run.Status.RevisionSecretVersions).To(Equal([]gcpv2.CloudRunRevisionSecretVersions{
+			{Revision: "hello-00001", Versions: []string{secretId + "/versions/1"}},
+			{Revision: "hello-00003", Versions: []string{secretId + "/versions/3"}},
+		}))
+
+		run.Status.Traffic = []gcpv2.CloudRunTrafficStatus{{LatestRevision: true, Percent: 100}}
+		_, err = r.cleanupSecrets(ctx, run, srv)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(enabledVersions(secretId)).To(Equal([]string{"3"}))
+		Expect(run.Status.RevisionSecretVersions).To(Equal([]gcpv2.CloudRunRevisionSecretVersions{
+			{Revision: "hello-00003", Versions: []string{secretId + "/versions/3"}},
+		}))
+	})
+
+	It("Should not destroy versions while a revision serving traffic has no recorded versions", func() {
+		_, err := r.cleanupSecrets(ctx, run, srv)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(enabledVersions(secretId)).To(Equal([]string{"3", "2", "1"}))
+	})
+
+	It("Should not record the current versions for a revision older than a failed one", func() {
+		run.Status.Traffic = []gcpv2.CloudRunTrafficStatus{{LatestRevision: true, Percent: 100}}
+		srv.LatestCreatedRevision = serviceName + "/revisions/hello-00004"
+		_, err := r.cleanupSecrets(ctx, run, srv)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(run.Status.RevisionSecretVersions).To(BeEmpty())
+		Expect(enabledVersions(secretId)).To(Equal([]string{"3", "2", "1"}))
+	})
+
+	It("Should keep an unreferenced secret until no revision serving traffic reads it", func() {
+		run.Spec.Containers[0].Env = nil
+		run.Status.RevisionSecretVersions = []gcpv2.CloudRunRevisionSecretVersions{
+			{Revision: "hello-00001", Versions: []string{secretId + "/versions/1"}},
+			{Revision: "hello-00003", Versions: []string{secretId + "/versions/3"}},
+		}
+		_, err := r.cleanupSecrets(ctx, run, srv)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(run.Status.SecretVersions).To(HaveLen(1))
+		_, err = secretManager.GetSecret(ctx, "test-project", secretId)
+		Expect(err).NotTo(HaveOccurred())
+
+		run.Status.Traffic = []gcpv2.CloudRunTrafficStatus{{Revision: "hello-00004", Percent: 100}}
+		run.Status.RevisionSecretVersions = append(run.Status.RevisionSecretVersions, gcpv2.CloudRunRevisionSecretVersions{Revision: "hello-00004"})
+		_, err = r.cleanupSecrets(ctx, run, srv)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(run.Status.SecretVersions).To(BeEmpty())
+		_, err = secretManager.GetSecret(ctx, "test-project", secretId)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,130 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+const (
+	// ConditionTypeSynced tells if the last reconcile brought the GCP resource in line with the spec
+	ConditionTypeSynced = "Synced"
+
+	// ConditionReasonSynced is the reason of a True Synced condition
+	ConditionReasonSynced = "Synced"
+	// ConditionReasonTransientError is the reason of a False Synced condition after an error that is retried with backoff
+	ConditionReasonTransientError = "TransientError"
+	// ConditionReasonPermanentError is the reason of a False Synced condition after an error that needs a change to go away
+	ConditionReasonPermanentError = "PermanentError"
+	// ConditionReasonProjectNotAllowed is the reason of a False Synced condition when no GcpProjectBinding allows the project
+	ConditionReasonProjectNotAllowed = "ProjectNotAllowed"
+	// ConditionReasonAdoptedResourceNotFound is the reason of a False Synced condition when the GCP resource to adopt does not exist
+	ConditionReasonAdoptedResourceNotFound = "AdoptedResourceNotFound"
+	// ConditionReasonCredentialsFailed is the reason of a False Synced condition when the credentials of the provider config can not be read
+	ConditionReasonCredentialsFailed = "CredentialsFailed"
+	// ConditionReasonZoneNotReady is the reason of a False Synced condition of a record whose zone does not exist or has no managed zone yet
+	ConditionReasonZoneNotReady = "ZoneNotReady"
+
+	// ConditionTypeDelegated tells if the NS and DS records of a zone with a parent zone ref exist in
+	// the parent zone, the condition is removed with the parent zone ref
+	ConditionTypeDelegated = "Delegated"
+
+	// ConditionReasonDelegated is the reason of a True Delegated condition
+	ConditionReasonDelegated = "Delegated"
+	// ConditionReasonParentZoneNotReady is the reason of a False Delegated condition while the parent zone does not exist or has no managed zone yet
+	ConditionReasonParentZoneNotReady = "ParentZoneNotReady"
+	// ConditionReasonParentZonePaused is the reason of a False Delegated condition while the parent zone is paused or in plan-only mode
+	ConditionReasonParentZonePaused = "ParentZonePaused"
+	// ConditionReasonNotSubdomain is the reason of a False Delegated condition of a zone outside of the domain of its parent zone
+	ConditionReasonNotSubdomain = "NotSubdomain"
+
+	// ConditionTypePaused is True while no changes are made to the GCP resource, the condition is
+	// removed when the resource is reconciled again
+	ConditionTypePaused = "Paused"
+
+	// ConditionReasonPausedByAnnotation is the reason of a Paused condition set by the paused annotation
+	ConditionReasonPausedByAnnotation = "PausedByAnnotation"
+	// ConditionReasonPausedByManager is the reason of a Paused condition set by the --paused flag of the manager
+	ConditionReasonPausedByManager = "PausedByManager"
+)
+
+// permanentErrorRequeue is how long a resource is left alone after a permanent error, unless it is
+// changed, in case the problem is fixed outside of the cluster, like a missing IAM grant
+const permanentErrorRequeue = 30 * time.Minute
+
+// handleGcpError reports err, returned by a call to GCP while reconciling obj, in the Synced
+// condition of obj. Transient errors are retried after a jittered exponential backoff, permanent
+// errors only after permanentErrorRequeue. Errors that did not come from GCP are returned as is.
+func handleGcpError(ctx context.Context, status *statusPatcher, obj client.Object, conditions *[]metav1.Condition, backoff *gcp.Backoff, err error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	key := client.ObjectKeyFromObject(obj).String()
+	condition := metav1.Condition{
+		Type:               ConditionTypeSynced,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: obj.GetGeneration(),
+	}
+	var result ctrl.Result
+	switch gcp.ClassifyError(err) {
+	case gcp.ErrorClassTransient:
+		result.RequeueAfter = backoff.Next(key, err)
+		logger.Info("Transient GCP error, retrying", "after", result.RequeueAfter, "error", err.Error())
+		condition.Reason = ConditionReasonTransientError
+		// the message is kept stable between retries so they do not cause status updates
+		condition.Message = fmt.Sprintf("GCP answered %s, retrying with backoff", gcp.ErrorCode(err))
+	case gcp.ErrorClassPermanent:
+		backoff.Reset(key)
+		result.RequeueAfter = permanentErrorRequeue
+		logger.Error(err, "Permanent GCP error")
+		condition.Reason = ConditionReasonPermanentError
+		// the message leaves out the error text, it can contain request specific details, the full
+		// error is logged
+		condition.Message = fmt.Sprintf("GCP answered %s, retrying after %s or when the resource changes", gcp.ErrorCode(err), permanentErrorRequeue)
+		if reason := gcp.ErrorReason(err); reason != "" {
+			condition.Message = fmt.Sprintf("GCP answered %s %s, retrying after %s or when the resource changes", gcp.ErrorCode(err), reason, permanentErrorRequeue)
+		}
+	default:
+		return ctrl.Result{}, err
+	}
+	meta.SetStatusCondition(conditions, condition)
+	if err := status.patch(ctx, obj); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// setSynced sets the Synced condition of obj to True and forgets its earlier failures, returns true
+// if the condition changed and the status needs to be updated
+func setSynced(obj client.Object, conditions *[]metav1.Condition, backoff *gcp.Backoff) bool {
+	backoff.Reset(client.ObjectKeyFromObject(obj).String())
+	return meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionTypeSynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonSynced,
+		Message:            "The GCP resource matches the spec",
+		ObservedGeneration: obj.GetGeneration(),
+	})
+}
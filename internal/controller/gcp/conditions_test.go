@@ -0,0 +1,90 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/googleapi"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+var _ = Describe("GCP error handling", func() {
+	var zone *gcpv1.CloudDnsZone
+	var backoff *gcp.Backoff
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		zone = &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-zone", Namespace: "default", Generation: 2},
+		}
+		backoff = &gcp.Backoff{}
+	})
+
+	handle := func(err error) (time.Duration, error) {
+		c := fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(zone).WithStatusSubresource(zone).Build()
+		result, err := handleGcpError(ctx, newStatusPatcher(c, zone), zone, &zone.Status.Conditions, backoff, err)
+		return result.RequeueAfter, err
+	}
+
+	It("Should requeue transient errors with backoff", func() {
+		after, err := handle(&googleapi.Error{Code: http.StatusTooManyRequests})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(after).To(BeNumerically(">", 0))
+		condition := meta.FindStatusCondition(zone.Status.Conditions, ConditionTypeSynced)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ConditionReasonTransientError))
+		Expect(condition.ObservedGeneration).To(Equal(int64(2)))
+	})
+
+	It("Should report permanent errors without hot-looping", func() {
+		after, err := handle(&googleapi.Error{
+			Code:    http.StatusForbidden,
+			Message: "permission denied for request 1234",
+			Errors:  []googleapi.ErrorItem{{Reason: "forbidden"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(after).To(Equal(permanentErrorRequeue))
+		condition := meta.FindStatusCondition(zone.Status.Conditions, ConditionTypeSynced)
+		Expect(condition.Reason).To(Equal(ConditionReasonPermanentError))
+		Expect(condition.Message).To(HavePrefix("GCP answered 403 forbidden"))
+		Expect(condition.Message).NotTo(ContainSubstring("request 1234"))
+	})
+
+	It("Should return errors not coming from GCP", func() {
+		_, err := handle(errors.New("boom"))
+		Expect(err).To(MatchError("boom"))
+		Expect(zone.Status.Conditions).To(BeEmpty())
+	})
+
+	It("Should mark the resource synced", func() {
+		Expect(setSynced(zone, &zone.Status.Conditions, backoff)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(zone.Status.Conditions, ConditionTypeSynced)).To(BeTrue())
+		Expect(setSynced(zone, &zone.Status.Conditions, backoff)).To(BeFalse())
+	})
+})
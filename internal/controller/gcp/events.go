@@ -0,0 +1,82 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+// Reasons of the events recorded on the reconciled resources, shared by all GCP reconcilers so
+// `kubectl get events --field-selector reason=...` works across kinds
+const (
+	// EventReasonCreating is recorded when a create request is sent to GCP
+	EventReasonCreating = "Creating"
+	// EventReasonCreated is recorded when a GCP resource is created synchronously
+	EventReasonCreated = "Created"
+	// EventReasonCreateFailed is recorded when a create request is rejected
+	EventReasonCreateFailed = "CreateFailed"
+	// EventReasonUpdating is recorded when an update request is sent to GCP
+	EventReasonUpdating = "Updating"
+	// EventReasonUpdated is recorded when a GCP resource is updated synchronously
+	EventReasonUpdated = "Updated"
+	// EventReasonUpdateFailed is recorded when an update request is rejected
+	EventReasonUpdateFailed = "UpdateFailed"
+	// EventReasonDeleting is recorded when a delete request is sent to GCP
+	EventReasonDeleting = "Deleting"
+	// EventReasonDeleteFailed is recorded when a delete request is rejected
+	EventReasonDeleteFailed = "DeleteFailed"
+	// EventReasonDeleted is recorded when the GCP resource is gone and the finalizer removed
+	EventReasonDeleted = "Deleted"
+	// EventReasonOperationCompleted is recorded when a long-running operation finishes
+	EventReasonOperationCompleted = "OperationCompleted"
+	// EventReasonOperationFailed is recorded when a long-running operation can not be polled or fails
+	EventReasonOperationFailed = "OperationFailed"
+	// EventReasonIamPolicyApplied is recorded when the IAM policy of a resource is changed
+	EventReasonIamPolicyApplied = "IamPolicyApplied"
+	// EventReasonIamPolicyFailed is recorded when the IAM policy of a resource can not be read or set
+	EventReasonIamPolicyFailed = "IamPolicyFailed"
+	// EventReasonDnsSecChanged is recorded when the DNSSEC state of a zone is changed
+	EventReasonDnsSecChanged = "DnsSecChanged"
+	// EventReasonDnsKeysPublished is recorded when the DS and DNSKEY records of a zone are written to its ConfigMap or Secret
+	EventReasonDnsKeysPublished = "DnsKeysPublished"
+	// EventReasonDnsKeysPublishFailed is recorded when the ConfigMap or Secret of the DNS keys can not be written
+	EventReasonDnsKeysPublishFailed = "DnsKeysPublishFailed"
+	// EventReasonSecretSynced is recorded when a Kubernetes Secret key is copied to a new Secret Manager version
+	EventReasonSecretSynced = "SecretSynced"
+	// EventReasonSecretSyncFailed is recorded when a referenced Secret can not be synced
+	EventReasonSecretSyncFailed = "SecretSyncFailed"
+	// EventReasonConfigFailed is recorded when a referenced ConfigMap can not be resolved
+	EventReasonConfigFailed = "ConfigFailed"
+	// EventReasonCredentialsFailed is recorded when the credentials of the provider config can not be read
+	EventReasonCredentialsFailed = "CredentialsFailed"
+	// EventReasonProjectNotAllowed is recorded when no GcpProjectBinding allows the project or location of a resource
+	EventReasonProjectNotAllowed = "ProjectNotAllowed"
+	// EventReasonAdoptedResourceNotFound is recorded when the GCP resource a resource adopts does not exist
+	EventReasonAdoptedResourceNotFound = "AdoptedResourceNotFound"
+	// EventReasonZoneNotReady is recorded when the CloudDnsZone of a record, or the parent zone of a zone, does not exist or has no managed zone yet
+	EventReasonZoneNotReady = "ZoneNotReady"
+	// EventReasonDelegated is recorded when the NS records of a zone are created in its parent zone
+	EventReasonDelegated = "Delegated"
+	// EventReasonDelegationRemoved is recorded when the NS and DS records of a zone are deleted from its parent zone
+	EventReasonDelegationRemoved = "DelegationRemoved"
+	// EventReasonDelegationPaused is recorded when the delegation records of a zone are not changed because its parent zone is paused or in plan-only mode
+	EventReasonDelegationPaused = "DelegationPaused"
+	// EventReasonDelegationFailed is recorded when the delegation records of a zone can not be written to its parent zone
+	EventReasonDelegationFailed = "DelegationFailed"
+	// EventReasonPaused is recorded when the changes to the GCP resource are suspended
+	EventReasonPaused = "Paused"
+	// EventReasonResumed is recorded when a paused resource is reconciled again
+	EventReasonResumed = "Resumed"
+	// EventReasonPlanned is recorded in plan-only mode when the changes a reconcile would make in GCP change
+	EventReasonPlanned = "Planned"
+)
@@ -0,0 +1,105 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// resyncPeriod is how often a synced resource is compared with GCP to catch drift, since writes to
+// the status do not trigger a reconcile
+const resyncPeriod = time.Minute
+
+// specChanged only lets changes to the spec, seen as a new generation, and to the annotations
+// trigger a reconcile, so the status and finalizer writes of the reconciler do not
+var specChanged = predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{})
+
+// statusPatcher writes the status of an object as a merge patch of the changes made since it was
+// read or last written, instead of an update of the whole object that conflicts with any other
+// write. Nothing is written when the status did not change.
+type statusPatcher struct {
+	client client.Client
+	base   []byte
+}
+
+// newStatusPatcher returns a statusPatcher for obj as just read from the API server
+func newStatusPatcher(c client.Client, obj client.Object) *statusPatcher {
+	// an object that can not be converted fails in patch, where the error can be returned
+	base, _ := statusJSON(obj)
+	return &statusPatcher{client: c, base: base}
+}
+
+// patch writes the changes made to the status of obj
+func (p *statusPatcher) patch(ctx context.Context, obj client.Object) error {
+	status, err := statusJSON(obj)
+	if err != nil {
+		return err
+	}
+	patch, err := jsonpatch.CreateMergePatch(p.base, status)
+	if err != nil {
+		return err
+	}
+	if string(patch) == "{}" {
+		return nil
+	}
+	if err := p.client.Status().Patch(ctx, obj, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return err
+	}
+	p.base = status
+	return nil
+}
+
+func statusJSON(obj client.Object) ([]byte, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]interface{}{"status": u["status"]})
+}
+
+// addFinalizer adds finalizerName to obj with a patch, nothing is written if it is already there
+func addFinalizer(ctx context.Context, c client.Client, obj client.Object) error {
+	if controllerutil.ContainsFinalizer(obj, finalizerName) {
+		return nil
+	}
+	return patchFinalizers(ctx, c, obj, controllerutil.AddFinalizer)
+}
+
+// removeFinalizer removes finalizerName from obj with a patch, nothing is written if it is not there
+func removeFinalizer(ctx context.Context, c client.Client, obj client.Object) error {
+	if !controllerutil.ContainsFinalizer(obj, finalizerName) {
+		return nil
+	}
+	return patchFinalizers(ctx, c, obj, controllerutil.RemoveFinalizer)
+}
+
+// patchFinalizers patches the finalizers of obj after change, the resource version is part of the
+// patch as the list is replaced as a whole and finalizers added by others in between must not be lost
+func patchFinalizers(ctx context.Context, c client.Client, obj client.Object, change func(client.Object, string) bool) error {
+	base := obj.DeepCopyObject().(client.Object)
+	change(obj, finalizerName)
+	return c.Patch(ctx, obj, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+var _ = Describe("Patches", func() {
+	ctx := context.Background()
+	var zone *gcpv1.CloudDnsZone
+	var c client.Client
+
+	BeforeEach(func() {
+		zone = &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-zone", Namespace: "default", Generation: 1},
+		}
+		c = fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(zone).WithStatusSubresource(zone).Build()
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(zone), zone)).To(Succeed())
+	})
+
+	It("Should only patch the status when it changed", func() {
+		status := newStatusPatcher(c, zone)
+		resourceVersion := zone.ResourceVersion
+		Expect(status.patch(ctx, zone)).To(Succeed())
+		Expect(zone.ResourceVersion).To(Equal(resourceVersion))
+
+		setSynced(zone, &zone.Status.Conditions, &gcp.Backoff{})
+		Expect(status.patch(ctx, zone)).To(Succeed())
+		Expect(zone.ResourceVersion).NotTo(Equal(resourceVersion))
+
+		var stored gcpv1.CloudDnsZone
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(zone), &stored)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(stored.Status.Conditions, ConditionTypeSynced)).To(BeTrue())
+
+		resourceVersion = zone.ResourceVersion
+		setSynced(zone, &zone.Status.Conditions, &gcp.Backoff{})
+		Expect(status.patch(ctx, zone)).To(Succeed())
+		Expect(zone.ResourceVersion).To(Equal(resourceVersion))
+	})
+
+	It("Should patch the finalizer", func() {
+		Expect(addFinalizer(ctx, c, zone)).To(Succeed())
+		var stored gcpv1.CloudDnsZone
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(zone), &stored)).To(Succeed())
+		Expect(stored.Finalizers).To(ConsistOf(finalizerName))
+
+		resourceVersion := zone.ResourceVersion
+		Expect(addFinalizer(ctx, c, zone)).To(Succeed())
+		Expect(zone.ResourceVersion).To(Equal(resourceVersion))
+
+		Expect(removeFinalizer(ctx, c, zone)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(zone), &stored)).To(Succeed())
+		Expect(stored.Finalizers).To(BeEmpty())
+	})
+
+	It("Should not reconcile on status changes", func() {
+		updated := zone.DeepCopy()
+		setSynced(updated, &updated.Status.Conditions, &gcp.Backoff{})
+		Expect(specChanged.Update(event.UpdateEvent{ObjectOld: zone, ObjectNew: updated})).To(BeFalse())
+
+		updated.Generation++
+		Expect(specChanged.Update(event.UpdateEvent{ObjectOld: zone, ObjectNew: updated})).To(BeTrue())
+
+		updated = zone.DeepCopy()
+		updated.Annotations = map[string]string{"example.com/trigger": "now"}
+		Expect(specChanged.Update(event.UpdateEvent{ObjectOld: zone, ObjectNew: updated})).To(BeTrue())
+	})
+})
@@ -0,0 +1,63 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+)
+
+// pausedReason returns the reason of the Paused condition of obj when changes to its GCP resource
+// are suspended, by the paused annotation or by managerPaused, and "" when they are not
+func pausedReason(obj client.Object, managerPaused bool) string {
+	switch {
+	case obj.GetAnnotations()[gcpv1.PausedAnnotation] == "true":
+		return ConditionReasonPausedByAnnotation
+	case managerPaused:
+		return ConditionReasonPausedByManager
+	}
+	return ""
+}
+
+// setPaused reports in the Paused condition of obj whether changes to its GCP resource are
+// suspended, by the paused annotation or by managerPaused, and returns true if they are
+func setPaused(recorder record.EventRecorder, obj client.Object, conditions *[]metav1.Condition, managerPaused bool) bool {
+	reason := pausedReason(obj, managerPaused)
+	if reason == "" {
+		if meta.FindStatusCondition(*conditions, ConditionTypePaused) != nil {
+			meta.RemoveStatusCondition(conditions, ConditionTypePaused)
+			recorder.Event(obj, corev1.EventTypeNormal, EventReasonResumed, "Changes to GCP are no longer paused")
+		}
+		return false
+	}
+	if previous := meta.FindStatusCondition(*conditions, ConditionTypePaused); previous == nil || previous.Reason != reason {
+		recorder.Event(obj, corev1.EventTypeNormal, EventReasonPaused, "Changes to GCP are paused")
+	}
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionTypePaused,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            "Changes to GCP are paused, only the status is refreshed",
+		ObservedGeneration: obj.GetGeneration(),
+	})
+	return true
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+)
+
+var _ = Describe("Paused resources", func() {
+	var zone *gcpv1.CloudDnsZone
+	var recorder *record.FakeRecorder
+
+	BeforeEach(func() {
+		zone = &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-zone", Namespace: "default", Generation: 1},
+		}
+		recorder = record.NewFakeRecorder(10)
+	})
+
+	It("Should pause resources with the annotation", func() {
+		zone.Annotations = map[string]string{gcpv1.PausedAnnotation: "true"}
+		Expect(setPaused(recorder, zone, &zone.Status.Conditions, false)).To(BeTrue())
+		condition := meta.FindStatusCondition(zone.Status.Conditions, ConditionTypePaused)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ConditionReasonPausedByAnnotation))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonPaused)))
+
+		Expect(setPaused(recorder, zone, &zone.Status.Conditions, false)).To(BeTrue())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("Should pause all resources when the manager is paused", func() {
+		Expect(setPaused(recorder, zone, &zone.Status.Conditions, true)).To(BeTrue())
+		Expect(meta.FindStatusCondition(zone.Status.Conditions, ConditionTypePaused).Reason).To(Equal(ConditionReasonPausedByManager))
+	})
+
+	It("Should remove the condition when resumed", func() {
+		Expect(setPaused(recorder, zone, &zone.Status.Conditions, true)).To(BeTrue())
+		Expect(recorder.Events).To(Receive())
+		Expect(setPaused(recorder, zone, &zone.Status.Conditions, false)).To(BeFalse())
+		Expect(zone.Status.Conditions).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonResumed)))
+
+		zone.Annotations = map[string]string{gcpv1.PausedAnnotation: "false"}
+		Expect(setPaused(recorder, zone, &zone.Status.Conditions, false)).To(BeFalse())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+})
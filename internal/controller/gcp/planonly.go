@@ -0,0 +1,76 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/metrics"
+	"github.com/tjololo/stilas/internal/plan"
+)
+
+// planOnly returns true if the changes to the GCP resource of obj are only planned, by the
+// plan-only annotation or by managerPlanOnly
+func planOnly(obj client.Object, managerPlanOnly bool) bool {
+	return managerPlanOnly || obj.GetAnnotations()[gcpv1.PlanOnlyAnnotation] == "true"
+}
+
+// reportPlan writes p to planStatus, the plan field of the status of obj, and records it in an
+// event when it changed
+func reportPlan(recorder record.EventRecorder, obj client.Object, planStatus *string, p *plan.Plan) {
+	description := p.String()
+	if *planStatus == description {
+		return
+	}
+	*planStatus = description
+	recorder.Event(obj, corev1.EventTypeNormal, EventReasonPlanned, description)
+}
+
+// releasePlannedDeletion reports p, the deletion planned for obj, and removes the finalizer of obj
+// without applying p. The GCP resources of obj are left in place.
+func releasePlannedDeletion(ctx context.Context, c client.Client, recorder record.EventRecorder, status *statusPatcher,
+	obj client.Object, planStatus *string, p *plan.Plan) error {
+	reportPlan(recorder, obj, planStatus, p)
+	if err := status.patch(ctx, obj); err != nil {
+		return err
+	}
+	if err := removeFinalizer(ctx, c, obj); err != nil {
+		return err
+	}
+	recorder.Event(obj, corev1.EventTypeNormal, EventReasonDeleted, "Removed finalizer, the planned deletion was not applied")
+	return nil
+}
+
+// recordDrift counts the fields of the GCP resources found different from the spec in the drift
+// metrics. Creates and deletes are not drift.
+func recordDrift(kind string, p *plan.Plan) {
+	for _, change := range p.Changes {
+		switch change.Action {
+		case plan.ActionUpdate:
+			for _, field := range change.Fields {
+				metrics.DriftDetected.WithLabelValues(kind, plan.FieldLabel(field)).Inc()
+			}
+		case plan.ActionSetIamPolicy:
+			metrics.DriftDetected.WithLabelValues(kind, "iamPolicy").Inc()
+		}
+	}
+}
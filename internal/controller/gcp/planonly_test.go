@@ -0,0 +1,81 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/dns/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/plan"
+)
+
+var _ = Describe("Plan-only resources", func() {
+	var zone *gcpv1.CloudDnsZone
+	var recorder *record.FakeRecorder
+
+	BeforeEach(func() {
+		zone = &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-zone", Namespace: "default", Generation: 1},
+			Spec: gcpv1.CloudDnsZoneSpec{
+				ProjectID: "test-project",
+				DnsName:   "example.com",
+			},
+		}
+		recorder = record.NewFakeRecorder(10)
+	})
+
+	It("Should only plan resources with the annotation or when the manager plans", func() {
+		Expect(planOnly(zone, false)).To(BeFalse())
+		Expect(planOnly(zone, true)).To(BeTrue())
+		zone.Annotations = map[string]string{gcpv1.PlanOnlyAnnotation: "true"}
+		Expect(planOnly(zone, false)).To(BeTrue())
+	})
+
+	It("Should only record an event when the plan changes", func() {
+		p := &plan.Plan{}
+		p.Add(plan.ActionCreate, managedZoneName(zone))
+		reportPlan(recorder, zone, &zone.Status.Plan, p)
+		Expect(zone.Status.Plan).To(Equal("Create projects/test-project/managedZones/" + zone.GetCloudDnsZoneFullName()))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonPlanned)))
+
+		reportPlan(recorder, zone, &zone.Status.Plan, p)
+		Expect(recorder.Events).NotTo(Receive())
+
+		reportPlan(recorder, zone, &zone.Status.Plan, &plan.Plan{})
+		Expect(zone.Status.Plan).To(Equal("No changes"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("No changes")))
+	})
+
+	It("Should plan the DNSSEC state of a zone without changing it", func() {
+		zone.Spec.DnsSecSpec = gcpv1.DnsSecSpec{State: "on", NonExistence: true}
+		current := &dns.ManagedZone{Name: zone.GetCloudDnsZoneFullName(), DnssecConfig: &dns.ManagedZoneDnsSecConfig{State: "off", NonExistence: "nsec3"}}
+		desired, fields, err := zoneChanges(current, zone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(Equal([]string{"dnssecConfig.state"}))
+		Expect(desired.DnssecConfig.State).To(Equal("on"))
+		Expect(current.DnssecConfig.State).To(Equal("off"))
+
+		current.DnssecConfig.State = "ON"
+		_, fields, err = zoneChanges(current, zone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+	})
+})
@@ -0,0 +1,82 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/tjololo/stilas/internal/policy"
+)
+
+//+kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=gcpprojectbindings,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// checkProjectPolicy returns true if projectPolicy allows obj to target project in location. A
+// denied obj gets a False Synced condition with the ProjectNotAllowed reason and is not reconciled
+// further until a GcpProjectBinding changes. A nil projectPolicy allows everything.
+func checkProjectPolicy(ctx context.Context, status *statusPatcher, recorder record.EventRecorder, projectPolicy *policy.ProjectPolicy, obj client.Object, conditions *[]metav1.Condition, project string, location string) (bool, error) {
+	if projectPolicy == nil {
+		return true, nil
+	}
+	err := projectPolicy.Check(ctx, obj.GetNamespace(), project, location)
+	if err == nil {
+		return true, nil
+	}
+	if !policy.IsDenied(err) {
+		return false, err
+	}
+	log.FromContext(ctx).Info("Project not allowed", "reason", err.Error())
+	changed := meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionTypeSynced,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonProjectNotAllowed,
+		Message:            err.Error(),
+		ObservedGeneration: obj.GetGeneration(),
+	})
+	if changed {
+		recorder.Event(obj, corev1.EventTypeWarning, EventReasonProjectNotAllowed, err.Error())
+	}
+	return false, status.patch(ctx, obj)
+}
+
+// enqueueAll returns a map function enqueueing every object of list, used to re-check all
+// resources when a GcpProjectBinding changes
+func enqueueAll(c client.Client, newList func() client.ObjectList) func(ctx context.Context, _ client.Object) []reconcile.Request {
+	return func(ctx context.Context, _ client.Object) []reconcile.Request {
+		list := newList()
+		if err := c.List(ctx, list); err != nil {
+			log.FromContext(ctx).Error(err, "unable to list resources for project binding change")
+			return nil
+		}
+		var requests []reconcile.Request
+		_ = meta.EachListItem(list, func(obj runtime.Object) error {
+			o := obj.(client.Object)
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			return nil
+		})
+		return requests
+	}
+}
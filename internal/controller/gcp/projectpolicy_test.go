@@ -0,0 +1,118 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/fakegcp"
+	"github.com/tjololo/stilas/internal/policy"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+var _ = Describe("Project policy", func() {
+	ctx := context.Background()
+
+	It("Should report a denied project in the Synced condition", func() {
+		zone := &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-zone", Namespace: "team-a", Generation: 1},
+			Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "team-b-prod"},
+		}
+		binding := &gcpv1.GcpProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec:       gcpv1.GcpProjectBindingSpec{Namespaces: []string{"team-a"}, Projects: []string{"team-a-*"}},
+		}
+		c := fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(zone, binding).WithStatusSubresource(zone).Build()
+		recorder := record.NewFakeRecorder(10)
+		projectPolicy := &policy.ProjectPolicy{Client: c}
+
+		allowed, err := checkProjectPolicy(ctx, newStatusPatcher(c, zone), recorder, projectPolicy, zone, &zone.Status.Conditions, zone.Spec.ProjectID, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowed).To(BeFalse())
+		condition := meta.FindStatusCondition(zone.Status.Conditions, ConditionTypeSynced)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ConditionReasonProjectNotAllowed))
+		Expect(recorder.Events).To(Receive(HavePrefix(corev1.EventTypeWarning + " " + EventReasonProjectNotAllowed)))
+
+		zone.Spec.ProjectID = "team-a-prod"
+		allowed, err = checkProjectPolicy(ctx, newStatusPatcher(c, zone), recorder, projectPolicy, zone, &zone.Status.Conditions, zone.Spec.ProjectID, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowed).To(BeTrue())
+	})
+
+	It("Should not change the records of a zone in a denied project", func() {
+		cloudDns := fakegcp.NewCloudDns()
+		zone := &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-zone", Namespace: "team-a"},
+			Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "team-b-prod", DnsName: "example.com"},
+		}
+		mz, err := cloudDns.CreateZone(ctx, "team-b-prod", zone.ConvertToManagedZone())
+		Expect(err).NotTo(HaveOccurred())
+		zone.Status.Nameservers = mz.NameServers
+		dnsRecord := &gcpv1.CloudDnsRecord{
+			ObjectMeta: metav1.ObjectMeta{Name: "www", Namespace: "team-a", Generation: 1},
+			Spec: gcpv1.CloudDnsRecordSpec{
+				ZoneRef: gcpv1.CloudDnsZoneReference{Name: "test-zone"},
+				Name:    "www",
+				Type:    "A",
+				TTL:     300,
+				Rrdatas: []string{"10.0.0.1"},
+			},
+		}
+		binding := &gcpv1.GcpProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec:       gcpv1.GcpProjectBindingSpec{Namespaces: []string{"team-a"}, Projects: []string{"team-a-*"}},
+		}
+		c := fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(zone, dnsRecord, binding).WithStatusSubresource(dnsRecord).Build()
+		recorder := record.NewFakeRecorder(10)
+		r := &CloudDnsRecordReconciler{
+			Client:          c,
+			Scheme:          k8sClient.Scheme(),
+			CloudDnsService: cloudDns,
+			Recorder:        recorder,
+			ProjectPolicy:   &policy.ProjectPolicy{Client: c},
+		}
+
+		_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "www"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(HavePrefix(corev1.EventTypeWarning + " " + EventReasonProjectNotAllowed)))
+		Expect(c.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "www"}, dnsRecord)).To(Succeed())
+		condition := meta.FindStatusCondition(dnsRecord.Status.Conditions, ConditionTypeSynced)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ConditionReasonProjectNotAllowed))
+		_, err = cloudDns.GetRecord(ctx, "team-b-prod", zone.GetCloudDnsZoneFullName(), "www.example.com.", "A")
+		Expect(gcp.ErrorCode(err)).To(Equal("404"))
+	})
+
+	It("Should allow everything without a policy", func() {
+		allowed, err := checkProjectPolicy(ctx, nil, nil, nil, &gcpv1.CloudDnsZone{}, nil, "any-project", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowed).To(BeTrue())
+	})
+})
@@ -0,0 +1,177 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+//+kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=gcpproviderconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gcp.stilas.418.cloud,resources=clustergcpproviderconfigs,verbs=get;list;watch
+
+// withCredentials returns a context making the GCP calls of a resource in namespace with the
+// credentials of the provider config ref refers to. Without a ref the GcpProviderConfig named
+// default in the namespace is used if it exists, the ambient credentials of the manager otherwise.
+func withCredentials(ctx context.Context, c client.Reader, namespace string, ref *gcpv1.ProviderConfigReference) (context.Context, error) {
+	creds, err := resolveCredentials(ctx, c, namespace, ref)
+	if err != nil {
+		return ctx, err
+	}
+	return gcp.WithCredentials(ctx, creds), nil
+}
+
+// credentialsFailed reports err, the failure to resolve the credentials of obj, in a False Synced
+// condition with the CredentialsFailed reason and in an event when the condition changes. Provider
+// configs are not watched, obj is retried after resyncPeriod. A deleting obj keeps its finalizer
+// until its credentials are back, removing the finalizer by hand leaves its GCP resources in place.
+func credentialsFailed(ctx context.Context, status *statusPatcher, recorder record.EventRecorder, obj client.Object, conditions *[]metav1.Condition, err error) (ctrl.Result, error) {
+	log.FromContext(ctx).Error(err, "unable to resolve credentials")
+	message := err.Error()
+	if obj.GetDeletionTimestamp() != nil {
+		message = fmt.Sprintf("The deletion waits for the credentials, removing the finalizer leaves the GCP resources in place: %s", message)
+	}
+	changed := meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionTypeSynced,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonCredentialsFailed,
+		Message:            message,
+		ObservedGeneration: obj.GetGeneration(),
+	})
+	if changed {
+		recorder.Event(obj, corev1.EventTypeWarning, EventReasonCredentialsFailed, message)
+	}
+	return ctrl.Result{RequeueAfter: resyncPeriod}, status.patch(ctx, obj)
+}
+
+// cloudRunProviderConfigRef returns ref as the provider config reference of the v1 API
+func cloudRunProviderConfigRef(ref *gcpv2.ProviderConfigReference) *gcpv1.ProviderConfigReference {
+	if ref == nil {
+		return nil
+	}
+	return &gcpv1.ProviderConfigReference{Kind: gcpv1.ProviderConfigKind(ref.Kind), Name: ref.Name}
+}
+
+func resolveCredentials(ctx context.Context, c client.Reader, namespace string, ref *gcpv1.ProviderConfigReference) (gcp.Credentials, error) {
+	if ref == nil {
+		var config gcpv1.GcpProviderConfig
+		err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: gcpv1.DefaultProviderConfigName}, &config)
+		if errors.IsNotFound(err) {
+			return gcp.AmbientCredentials, nil
+		}
+		if err != nil {
+			return gcp.Credentials{}, fmt.Errorf("failed to get default GcpProviderConfig: %w", err)
+		}
+		return credentialsFromSpec(ctx, c, config.Spec, namespace, fmt.Sprintf("GcpProviderConfig/%s/%s", namespace, config.Name))
+	}
+	if ref.Kind == gcpv1.ProviderConfigKind_Cluster {
+		var config gcpv1.ClusterGcpProviderConfig
+		if err := c.Get(ctx, client.ObjectKey{Name: ref.Name}, &config); err != nil {
+			return gcp.Credentials{}, fmt.Errorf("failed to get ClusterGcpProviderConfig %s: %w", ref.Name, err)
+		}
+		if err := namespaceAllowed(ctx, c, config, namespace); err != nil {
+			return gcp.Credentials{}, err
+		}
+		return credentialsFromSpec(ctx, c, config.Spec.GcpProviderConfigSpec, "", fmt.Sprintf("ClusterGcpProviderConfig/%s", config.Name))
+	}
+	var config gcpv1.GcpProviderConfig
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &config); err != nil {
+		return gcp.Credentials{}, fmt.Errorf("failed to get GcpProviderConfig %s: %w", ref.Name, err)
+	}
+	return credentialsFromSpec(ctx, c, config.Spec, namespace, fmt.Sprintf("GcpProviderConfig/%s/%s", namespace, config.Name))
+}
+
+// namespaceAllowed returns an error unless the allowed namespaces of config select namespace
+func namespaceAllowed(ctx context.Context, c client.Reader, config gcpv1.ClusterGcpProviderConfig, namespace string) error {
+	if config.Spec.AllowedNamespaces == nil {
+		return fmt.Errorf("ClusterGcpProviderConfig %s allows no namespaces", config.Name)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(config.Spec.AllowedNamespaces)
+	if err != nil {
+		return fmt.Errorf("invalid allowedNamespaces in ClusterGcpProviderConfig %s: %w", config.Name, err)
+	}
+	var ns corev1.Namespace
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	if !selector.Matches(labels.Set(ns.Labels)) {
+		return fmt.Errorf("ClusterGcpProviderConfig %s does not allow namespace %s", config.Name, namespace)
+	}
+	return nil
+}
+
+// credentialsFromSpec reads the credentials described by spec. Secrets of a namespaced config are
+// read in secretNamespace, the namespace of a cluster config is taken from its secret reference.
+// Only a cluster config can impersonate or read another token than the one of the manager, both
+// are done with the identity of the manager.
+func credentialsFromSpec(ctx context.Context, c client.Reader, spec gcpv1.GcpProviderConfigSpec, secretNamespace string, source string) (gcp.Credentials, error) {
+	if secretNamespace != "" {
+		if spec.Impersonation != nil {
+			return gcp.Credentials{}, fmt.Errorf("%s: impersonation is only allowed in a ClusterGcpProviderConfig", source)
+		}
+		if wif := spec.WorkloadIdentityFederation; wif != nil && wif.TokenPath != "" && wif.TokenPath != gcpv1.DefaultWorkloadIdentityTokenPath {
+			return gcp.Credentials{}, fmt.Errorf("%s: workloadIdentityFederation.tokenPath can only be changed in a ClusterGcpProviderConfig", source)
+		}
+	}
+	var config gcp.CredentialsConfig
+	if ref := spec.ServiceAccountKeySecretRef; ref != nil {
+		namespace := secretNamespace
+		if namespace == "" {
+			namespace = ref.Namespace
+		}
+		if namespace == "" {
+			return gcp.Credentials{}, fmt.Errorf("%s: serviceAccountKeySecretRef.namespace is required", source)
+		}
+		key := ref.Key
+		if key == "" {
+			key = gcpv1.DefaultCredentialsKey
+		}
+		var secret corev1.Secret
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &secret); err != nil {
+			return gcp.Credentials{}, fmt.Errorf("%s: failed to get credentials secret: %w", source, err)
+		}
+		data, ok := secret.Data[key]
+		if !ok {
+			return gcp.Credentials{}, fmt.Errorf("%s: key %s not found in secret %s/%s", source, key, namespace, ref.Name)
+		}
+		config.ServiceAccountKey = data
+	}
+	if wif := spec.WorkloadIdentityFederation; wif != nil {
+		config.WorkloadIdentityAudience = wif.Audience
+		config.WorkloadIdentityServiceAccount = wif.ServiceAccount
+		config.WorkloadIdentityTokenPath = wif.TokenPath
+	}
+	if impersonation := spec.Impersonation; impersonation != nil {
+		config.ImpersonateServiceAccount = impersonation.ServiceAccount
+		config.Delegates = impersonation.Delegates
+	}
+	return config.Credentials(source), nil
+}
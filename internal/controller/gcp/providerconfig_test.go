@@ -0,0 +1,147 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+var _ = Describe("Provider configs", func() {
+	ctx := context.Background()
+	credentialsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "gcp-credentials", Namespace: "team-a"},
+		Data:       map[string][]byte{gcpv1.DefaultCredentialsKey: []byte(`{"type":"service_account"}`)},
+	}
+	teamB := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"team": "b"}}}
+	newClient := func(objs ...client.Object) client.Client {
+		return fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(objs...).Build()
+	}
+
+	It("Should fall back to the ambient credentials", func() {
+		creds, err := resolveCredentials(ctx, newClient(), "team-a", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(creds.Key).To(Equal(gcp.AmbientCredentials.Key))
+	})
+
+	It("Should use the default provider config of the namespace", func() {
+		c := newClient(credentialsSecret, &gcpv1.GcpProviderConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: gcpv1.DefaultProviderConfigName, Namespace: "team-a"},
+			Spec: gcpv1.GcpProviderConfigSpec{
+				ServiceAccountKeySecretRef: &gcpv1.CredentialsSecretSelector{Name: "gcp-credentials"},
+			},
+		})
+		creds, err := resolveCredentials(ctx, c, "team-a", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(creds.Key).To(HavePrefix("GcpProviderConfig/team-a/default@"))
+
+		_, err = resolveCredentials(ctx, c, "team-b", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should read the secret of a cluster provider config in its namespace", func() {
+		c := newClient(credentialsSecret, teamB, &gcpv1.ClusterGcpProviderConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+			Spec: gcpv1.ClusterGcpProviderConfigSpec{
+				GcpProviderConfigSpec: gcpv1.GcpProviderConfigSpec{
+					ServiceAccountKeySecretRef: &gcpv1.CredentialsSecretSelector{Namespace: "team-a", Name: "gcp-credentials"},
+					Impersonation:              &gcpv1.Impersonation{ServiceAccount: "deployer@test-project.iam.gserviceaccount.com"},
+				},
+				AllowedNamespaces: &metav1.LabelSelector{},
+			},
+		})
+		creds, err := resolveCredentials(ctx, c, "team-b", &gcpv1.ProviderConfigReference{
+			Kind: gcpv1.ProviderConfigKind_Cluster,
+			Name: "shared",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(creds.Key).To(HavePrefix("ClusterGcpProviderConfig/shared@"))
+	})
+
+	It("Should only let the allowed namespaces use a cluster provider config", func() {
+		config := &gcpv1.ClusterGcpProviderConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+			Spec: gcpv1.ClusterGcpProviderConfigSpec{
+				GcpProviderConfigSpec: gcpv1.GcpProviderConfigSpec{
+					Impersonation: &gcpv1.Impersonation{ServiceAccount: "deployer@test-project.iam.gserviceaccount.com"},
+				},
+			},
+		}
+		ref := &gcpv1.ProviderConfigReference{Kind: gcpv1.ProviderConfigKind_Cluster, Name: "shared"}
+		_, err := resolveCredentials(ctx, newClient(teamB, config), "team-b", ref)
+		Expect(err).To(MatchError(ContainSubstring("allows no namespaces")))
+
+		config.Spec.AllowedNamespaces = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}}
+		c := newClient(teamB, config, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-c"}})
+		_, err = resolveCredentials(ctx, c, "team-b", ref)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = resolveCredentials(ctx, c, "team-c", ref)
+		Expect(err).To(MatchError(ContainSubstring("does not allow namespace team-c")))
+	})
+
+	It("Should not let a namespaced provider config use the identity of the manager", func() {
+		c := newClient(
+			&gcpv1.GcpProviderConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "impersonating", Namespace: "team-a"},
+				Spec: gcpv1.GcpProviderConfigSpec{
+					Impersonation: &gcpv1.Impersonation{ServiceAccount: "deployer@test-project.iam.gserviceaccount.com"},
+				},
+			},
+			&gcpv1.GcpProviderConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "team-a"},
+				Spec: gcpv1.GcpProviderConfigSpec{
+					WorkloadIdentityFederation: &gcpv1.WorkloadIdentityFederation{Audience: "audience", TokenPath: "/etc/shadow"},
+				},
+			},
+			&gcpv1.GcpProviderConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "federated", Namespace: "team-a"},
+				Spec: gcpv1.GcpProviderConfigSpec{
+					WorkloadIdentityFederation: &gcpv1.WorkloadIdentityFederation{Audience: "audience", TokenPath: gcpv1.DefaultWorkloadIdentityTokenPath},
+				},
+			},
+		)
+		_, err := resolveCredentials(ctx, c, "team-a", &gcpv1.ProviderConfigReference{Name: "impersonating"})
+		Expect(err).To(MatchError(ContainSubstring("impersonation is only allowed in a ClusterGcpProviderConfig")))
+		_, err = resolveCredentials(ctx, c, "team-a", &gcpv1.ProviderConfigReference{Name: "token"})
+		Expect(err).To(MatchError(ContainSubstring("tokenPath can only be changed in a ClusterGcpProviderConfig")))
+		_, err = resolveCredentials(ctx, c, "team-a", &gcpv1.ProviderConfigReference{Name: "federated"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should fail when a referenced config or secret is missing", func() {
+		_, err := resolveCredentials(ctx, newClient(), "team-a", &gcpv1.ProviderConfigReference{Name: "missing"})
+		Expect(err).To(HaveOccurred())
+
+		c := newClient(&gcpv1.GcpProviderConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "team-a"},
+			Spec: gcpv1.GcpProviderConfigSpec{
+				ServiceAccountKeySecretRef: &gcpv1.CredentialsSecretSelector{Name: "gcp-credentials"},
+			},
+		})
+		_, err = resolveCredentials(ctx, c, "team-a", &gcpv1.ProviderConfigReference{Name: "broken"})
+		Expect(err).To(MatchError(ContainSubstring("failed to get credentials secret")))
+	})
+})
@@ -0,0 +1,869 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/dns/v2"
+	"google.golang.org/api/googleapi"
+
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+var _ gcp.CloudDnsService = &CloudDns{}
+
+// apexTtl is the ttl of the SOA and NS record sets Cloud DNS creates with a zone
+const apexTtl = 21600
+
+// CloudDns is an in-memory Cloud DNS API v2. It implements gcp.CloudDnsService directly and
+// serves the REST API of the managed zones, operations, record sets, changes and DNS keys as an
+// http.Handler, so a GcpCloudDnsService can be pointed at it with httptest.
+//
+// Like the real API it creates the SOA and NS record sets of a zone, refuses to delete zones
+// with other record sets, answers 404 for missing resources, 409 for existing ones and 412 for
+// changes deleting record sets that do not match.
+type CloudDns struct {
+	// PollsUntilDone is the number of polls of a zone operation that report it pending, zero
+	// returns done operations
+	PollsUntilDone int
+	// PageSize is the maximum number of items per page of the list calls, zero returns all
+	PageSize int
+	// Now returns the time of the changes, time.Now when nil
+	Now func() time.Time
+
+	mu       sync.Mutex
+	zones    map[string]*cloudDnsZone
+	errors   map[string][]error
+	sequence uint64
+	mux      *http.ServeMux
+}
+
+type cloudDnsZone struct {
+	zone       *dns.ManagedZone
+	records    map[string]*dns.ResourceRecordSet
+	operations map[string]*cloudDnsOperation
+	changes    []*dns.Change
+	keys       []*dns.DnsKey
+}
+
+type cloudDnsOperation struct {
+	operation *dns.Operation
+	polls     int
+	// finish applies the change of the operation to the zone
+	finish func()
+}
+
+// NewCloudDns returns an empty fake Cloud DNS API
+func NewCloudDns() *CloudDns {
+	f := &CloudDns{
+		zones:  map[string]*cloudDnsZone{},
+		errors: map[string][]error{},
+	}
+	f.mux = f.routes()
+	return f
+}
+
+// InjectError makes the next call of method, like GetZone or CreateRecord, fail with err. Errors
+// injected for the same method are returned in order, one per call.
+func (f *CloudDns) InjectError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[method] = append(f.errors[method], err)
+}
+
+// injected returns the next error injected for method, the lock must be held
+func (f *CloudDns) injected(method string) error {
+	queued := f.errors[method]
+	if len(queued) == 0 {
+		return nil
+	}
+	f.errors[method] = queued[1:]
+	return queued[0]
+}
+
+func (f *CloudDns) now() string {
+	if f.Now != nil {
+		return f.Now().UTC().Format(time.RFC3339Nano)
+	}
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+func (f *CloudDns) nextId() uint64 {
+	f.sequence++
+	return f.sequence
+}
+
+// apiError returns the error the REST API answers with code and reason
+func apiError(code int, reason string, format string, args ...any) *googleapi.Error {
+	message := fmt.Sprintf(format, args...)
+	return &googleapi.Error{
+		Code:    code,
+		Message: message,
+		Errors:  []googleapi.ErrorItem{{Reason: reason, Message: message}},
+	}
+}
+
+func zoneKey(project string, zone string) string {
+	return project + "/" + zone
+}
+
+func recordKey(name string, type_ string) string {
+	return name + "/" + type_
+}
+
+// zone returns the zone or a 404 error, the lock must be held
+func (f *CloudDns) zone(project string, zone string) (*cloudDnsZone, error) {
+	z, ok := f.zones[zoneKey(project, zone)]
+	if !ok {
+		return nil, apiError(http.StatusNotFound, "notFound", "The 'parameters.managedZone' resource named '%s' does not exist.", zone)
+	}
+	return z, nil
+}
+
+func copyZone(zone *dns.ManagedZone) *dns.ManagedZone {
+	copied := *zone
+	copied.Labels = cloneMap(zone.Labels)
+	copied.NameServers = slices.Clone(zone.NameServers)
+	if zone.DnssecConfig != nil {
+		dnssec := *zone.DnssecConfig
+		dnssec.DefaultKeySpecs = nil
+		for _, spec := range zone.DnssecConfig.DefaultKeySpecs {
+			s := *spec
+			dnssec.DefaultKeySpecs = append(dnssec.DefaultKeySpecs, &s)
+		}
+		copied.DnssecConfig = &dnssec
+	}
+	if zone.CloudLoggingConfig != nil {
+		logging := *zone.CloudLoggingConfig
+		copied.CloudLoggingConfig = &logging
+	}
+	if zone.PrivateVisibilityConfig != nil {
+		visibility := *zone.PrivateVisibilityConfig
+		visibility.Networks = nil
+		for _, network := range zone.PrivateVisibilityConfig.Networks {
+			n := *network
+			visibility.Networks = append(visibility.Networks, &n)
+		}
+		visibility.GkeClusters = nil
+		for _, cluster := range zone.PrivateVisibilityConfig.GkeClusters {
+			c := *cluster
+			visibility.GkeClusters = append(visibility.GkeClusters, &c)
+		}
+		copied.PrivateVisibilityConfig = &visibility
+	}
+	if zone.ForwardingConfig != nil {
+		forwarding := *zone.ForwardingConfig
+		forwarding.TargetNameServers = nil
+		for _, target := range zone.ForwardingConfig.TargetNameServers {
+			t := *target
+			forwarding.TargetNameServers = append(forwarding.TargetNameServers, &t)
+		}
+		copied.ForwardingConfig = &forwarding
+	}
+	if zone.PeeringConfig != nil {
+		peering := *zone.PeeringConfig
+		if peering.TargetNetwork != nil {
+			network := *peering.TargetNetwork
+			peering.TargetNetwork = &network
+		}
+		copied.PeeringConfig = &peering
+	}
+	return &copied
+}
+
+func copyRecord(rrs *dns.ResourceRecordSet) *dns.ResourceRecordSet {
+	copied := *rrs
+	copied.Rrdatas = slices.Clone(rrs.Rrdatas)
+	copied.SignatureRrdatas = slices.Clone(rrs.SignatureRrdatas)
+	return &copied
+}
+
+func cloneMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// normalizeZone lowercases the enum values like the API does, it accepts them in any case
+func normalizeZone(zone *dns.ManagedZone) {
+	zone.Visibility = strings.ToLower(zone.Visibility)
+	if zone.Visibility == "" {
+		zone.Visibility = "public"
+	}
+	if zone.DnssecConfig != nil {
+		zone.DnssecConfig.State = strings.ToLower(zone.DnssecConfig.State)
+		zone.DnssecConfig.NonExistence = strings.ToLower(zone.DnssecConfig.NonExistence)
+		if zone.DnssecConfig.State == "" {
+			zone.DnssecConfig.State = "off"
+		}
+		zone.DnssecConfig.Kind = "dns#managedZoneDnsSecConfig"
+		if zone.DnssecConfig.State != "off" && len(zone.DnssecConfig.DefaultKeySpecs) == 0 {
+			zone.DnssecConfig.DefaultKeySpecs = []*dns.DnsKeySpec{
+				{Algorithm: "rsasha256", KeyLength: 2048, KeyType: "keySigning"},
+				{Algorithm: "rsasha256", KeyLength: 1024, KeyType: "zoneSigning"},
+			}
+		}
+		for _, spec := range zone.DnssecConfig.DefaultKeySpecs {
+			spec.Algorithm = strings.ToLower(spec.Algorithm)
+			spec.Kind = "dns#dnsKeySpec"
+		}
+	}
+	if zone.PrivateVisibilityConfig != nil {
+		zone.PrivateVisibilityConfig.Kind = "dns#managedZonePrivateVisibilityConfig"
+		for _, network := range zone.PrivateVisibilityConfig.Networks {
+			network.Kind = "dns#managedZonePrivateVisibilityConfigNetwork"
+		}
+		for _, cluster := range zone.PrivateVisibilityConfig.GkeClusters {
+			cluster.Kind = "dns#managedZonePrivateVisibilityConfigGKECluster"
+		}
+	}
+	if zone.ForwardingConfig != nil {
+		zone.ForwardingConfig.Kind = "dns#managedZoneForwardingConfig"
+		for _, target := range zone.ForwardingConfig.TargetNameServers {
+			target.Kind = "dns#managedZoneForwardingConfigNameServerTarget"
+			target.ForwardingPath = strings.ToLower(target.ForwardingPath)
+			if target.ForwardingPath == "" {
+				target.ForwardingPath = "default"
+			}
+		}
+	}
+	if zone.PeeringConfig != nil {
+		zone.PeeringConfig.Kind = "dns#managedZonePeeringConfig"
+		if zone.PeeringConfig.TargetNetwork != nil {
+			zone.PeeringConfig.TargetNetwork.Kind = "dns#managedZonePeeringConfigTargetNetwork"
+		}
+	}
+}
+
+func validateZone(zone *dns.ManagedZone) error {
+	if zone.Visibility != "public" && zone.Visibility != "private" {
+		return apiError(http.StatusBadRequest, "invalid", "Invalid value for 'entity.managedZone.visibility': '%s'", zone.Visibility)
+	}
+	if zone.DnssecConfig != nil && zone.DnssecConfig.State != "off" && zone.Visibility == "private" {
+		return apiError(http.StatusBadRequest, "invalid", "DNSSEC is not supported for private zones.")
+	}
+	if zone.PrivateVisibilityConfig != nil && zone.Visibility != "private" {
+		return apiError(http.StatusBadRequest, "invalid", "Only private zones can have a private visibility config.")
+	}
+	if (zone.ForwardingConfig != nil || zone.PeeringConfig != nil) && zone.Visibility != "private" {
+		return apiError(http.StatusBadRequest, "invalid", "Only private zones can forward or peer.")
+	}
+	if zone.ForwardingConfig != nil && zone.PeeringConfig != nil {
+		return apiError(http.StatusBadRequest, "invalid", "A zone can not both forward and peer.")
+	}
+	if zone.ForwardingConfig != nil {
+		if len(zone.ForwardingConfig.TargetNameServers) == 0 {
+			return apiError(http.StatusBadRequest, "required", "Required field 'entity.managedZone.forwardingConfig.targetNameServers' not specified.")
+		}
+		for _, target := range zone.ForwardingConfig.TargetNameServers {
+			if ip := net.ParseIP(target.Ipv4Address); ip == nil || ip.To4() == nil {
+				return apiError(http.StatusBadRequest, "invalid", "Invalid value for 'entity.managedZone.forwardingConfig.targetNameServers.ipv4Address': '%s'", target.Ipv4Address)
+			}
+			if target.ForwardingPath != "default" && target.ForwardingPath != "private" {
+				return apiError(http.StatusBadRequest, "invalid", "Invalid value for 'entity.managedZone.forwardingConfig.targetNameServers.forwardingPath': '%s'", target.ForwardingPath)
+			}
+		}
+	}
+	if zone.PeeringConfig != nil && (zone.PeeringConfig.TargetNetwork == nil || zone.PeeringConfig.TargetNetwork.NetworkUrl == "") {
+		return apiError(http.StatusBadRequest, "required", "Required field 'entity.managedZone.peeringConfig.targetNetwork.networkUrl' not specified.")
+	}
+	return nil
+}
+
+func (f *CloudDns) GetZone(_ context.Context, project string, zone string) (*dns.ManagedZone, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("GetZone"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	return copyZone(z.zone), nil
+}
+
+func (f *CloudDns) CreateZone(_ context.Context, project string, zone *dns.ManagedZone) (*dns.ManagedZone, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("CreateZone"); err != nil {
+		return nil, err
+	}
+	if zone.Name == "" {
+		return nil, apiError(http.StatusBadRequest, "required", "Required field 'entity.managedZone.name' not specified.")
+	}
+	if !strings.HasSuffix(zone.DnsName, ".") {
+		return nil, apiError(http.StatusBadRequest, "invalidFieldValue", "Invalid value for 'entity.managedZone.dnsName': '%s'", zone.DnsName)
+	}
+	if _, ok := f.zones[zoneKey(project, zone.Name)]; ok {
+		return nil, apiError(http.StatusConflict, "alreadyExists", "The resource 'entity.managedZone' named '%s' already exists", zone.Name)
+	}
+	created := copyZone(zone)
+	normalizeZone(created)
+	if err := validateZone(created); err != nil {
+		return nil, err
+	}
+	created.Kind = "dns#managedZone"
+	created.Id = f.nextId()
+	created.CreationTime = f.now()
+	created.NameServers = nameServers(created)
+	z := &cloudDnsZone{
+		zone:       created,
+		records:    map[string]*dns.ResourceRecordSet{},
+		operations: map[string]*cloudDnsOperation{},
+	}
+	z.records[recordKey(created.DnsName, "SOA")] = &dns.ResourceRecordSet{
+		Kind:    "dns#resourceRecordSet",
+		Name:    created.DnsName,
+		Type:    "SOA",
+		Ttl:     apexTtl,
+		Rrdatas: []string{fmt.Sprintf("%s cloud-dns-hostmaster.google.com. 1 21600 3600 259200 300", created.NameServers[0])},
+	}
+	z.records[recordKey(created.DnsName, "NS")] = &dns.ResourceRecordSet{
+		Kind:    "dns#resourceRecordSet",
+		Name:    created.DnsName,
+		Type:    "NS",
+		Ttl:     apexTtl,
+		Rrdatas: slices.Clone(created.NameServers),
+	}
+	f.updateKeys(z)
+	f.zones[zoneKey(project, created.Name)] = z
+	return copyZone(created), nil
+}
+
+// nameServers returns the name servers Cloud DNS assigns to zone
+func nameServers(zone *dns.ManagedZone) []string {
+	if zone.Visibility == "private" {
+		return []string{"ns-gcp-private.googledomains.com."}
+	}
+	set := 'a' + rune(hash(zone.DnsName)%5)
+	var servers []string
+	for i := 1; i <= 4; i++ {
+		servers = append(servers, fmt.Sprintf("ns-cloud-%c%d.googledomains.com.", set, i))
+	}
+	return servers
+}
+
+func (f *CloudDns) UpdateZone(_ context.Context, project string, zone string, mz *dns.ManagedZone) (*dns.Operation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("UpdateZone"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	return f.changeZone(z, zone, copyZone(mz))
+}
+
+func (f *CloudDns) PatchZone(_ context.Context, project string, zone string, mz *dns.ManagedZone) (*dns.Operation, error) {
+	patch, err := json.Marshal(mz)
+	if err != nil {
+		return nil, apiError(http.StatusBadRequest, "parseError", "Invalid JSON payload received: %v", err)
+	}
+	return f.patchZone(project, zone, patch)
+}
+
+// patchZone replaces the top level fields of the zone present in the JSON patch, like the
+// PATCH method of the REST API
+func (f *CloudDns) patchZone(project string, zone string, patch []byte) (*dns.Operation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("PatchZone"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	current, err := json.Marshal(z.zone)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(current, &fields); err != nil {
+		return nil, err
+	}
+	patched := map[string]json.RawMessage{}
+	if err := json.Unmarshal(patch, &patched); err != nil {
+		return nil, apiError(http.StatusBadRequest, "parseError", "Invalid JSON payload received: %v", err)
+	}
+	for field, value := range patched {
+		if string(value) == "null" {
+			delete(fields, field)
+		} else {
+			fields[field] = value
+		}
+	}
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	updated := &dns.ManagedZone{}
+	if err := json.Unmarshal(merged, updated); err != nil {
+		return nil, apiError(http.StatusBadRequest, "parseError", "Invalid JSON payload received: %v", err)
+	}
+	return f.changeZone(z, zone, updated)
+}
+
+// changeZone validates the new state of the zone and starts the operation changing it, the lock
+// must be held
+func (f *CloudDns) changeZone(z *cloudDnsZone, zone string, updated *dns.ManagedZone) (*dns.Operation, error) {
+	if updated.Name != "" && updated.Name != zone {
+		return nil, apiError(http.StatusBadRequest, "invalid", "The name of the managed zone can not be changed")
+	}
+	normalizeZone(updated)
+	if updated.DnsName != z.zone.DnsName {
+		return nil, apiError(http.StatusBadRequest, "immutableField", "The field 'entity.managedZone.dnsName' can not be changed")
+	}
+	if updated.Visibility != z.zone.Visibility {
+		return nil, apiError(http.StatusBadRequest, "immutableField", "The field 'entity.managedZone.visibility' can not be changed")
+	}
+	if err := validateZone(updated); err != nil {
+		return nil, err
+	}
+	if current, desired := z.zone.DnssecConfig, updated.DnssecConfig; current != nil && desired != nil &&
+		current.State != "off" && desired.NonExistence != "" && desired.NonExistence != current.NonExistence {
+		return nil, apiError(http.StatusBadRequest, "invalid", "The non existence of a zone can only be changed while DNSSEC is off")
+	}
+	if current, desired := z.zone.DnssecConfig, updated.DnssecConfig; current != nil && desired != nil &&
+		current.State != "off" && desired.State != "off" && !sameKeySpecs(current.DefaultKeySpecs, desired.DefaultKeySpecs) {
+		return nil, apiError(http.StatusBadRequest, "invalid", "The default key specs of a zone can only be changed while DNSSEC is off")
+	}
+	// the output only fields keep their values
+	updated.Kind = z.zone.Kind
+	updated.Name = z.zone.Name
+	updated.Id = z.zone.Id
+	updated.CreationTime = z.zone.CreationTime
+	updated.NameServers = slices.Clone(z.zone.NameServers)
+	op := &dns.Operation{
+		Kind:      "dns#operation",
+		Id:        strconv.FormatUint(f.nextId(), 10),
+		StartTime: f.now(),
+		Status:    "pending",
+		Type:      "UPDATE",
+		User:      "fake@stilas.418.cloud",
+		ZoneContext: &dns.OperationManagedZoneContext{
+			OldValue: copyZone(z.zone),
+			NewValue: copyZone(updated),
+		},
+	}
+	operation := &cloudDnsOperation{operation: op, finish: func() {
+		z.zone = updated
+		f.updateKeys(z)
+		op.Status = "done"
+	}}
+	z.operations[op.Id] = operation
+	if f.PollsUntilDone == 0 {
+		operation.finish()
+	}
+	copied := *op
+	return &copied, nil
+}
+
+func (f *CloudDns) GetOperation(_ context.Context, project string, zone string, operation string) (*dns.Operation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("GetOperation"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	op, ok := z.operations[operation]
+	if !ok {
+		return nil, apiError(http.StatusNotFound, "notFound", "The 'parameters.operation' resource named '%s' does not exist.", operation)
+	}
+	if op.operation.Status != "done" {
+		op.polls++
+		if op.polls >= f.PollsUntilDone {
+			op.finish()
+		}
+	}
+	copied := *op.operation
+	return &copied, nil
+}
+
+func (f *CloudDns) DeleteZone(_ context.Context, project string, zone string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("DeleteZone"); err != nil {
+		return err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return err
+	}
+	for _, rrs := range z.records {
+		if !isApexRecord(z.zone, rrs.Name, rrs.Type) {
+			return apiError(http.StatusBadRequest, "containerNotEmpty", "The resource named '%s' cannot be deleted because it is not empty", zone)
+		}
+	}
+	delete(f.zones, zoneKey(project, zone))
+	return nil
+}
+
+// isApexRecord returns true for the SOA and NS record sets created with the zone
+func isApexRecord(zone *dns.ManagedZone, name string, type_ string) bool {
+	return name == zone.DnsName && (type_ == "SOA" || type_ == "NS")
+}
+
+func (f *CloudDns) ListZones(_ context.Context, project string) ([]*dns.ManagedZone, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("ListZones"); err != nil {
+		return nil, err
+	}
+	var zones []*dns.ManagedZone
+	for key, z := range f.zones {
+		if strings.HasPrefix(key, project+"/") {
+			zones = append(zones, copyZone(z.zone))
+		}
+	}
+	slices.SortFunc(zones, func(a, b *dns.ManagedZone) int { return strings.Compare(a.Name, b.Name) })
+	return zones, nil
+}
+
+// validateRecord checks rrs can be stored in the zone
+func validateRecord(zone *dns.ManagedZone, rrs *dns.ResourceRecordSet) error {
+	if rrs.Type == "" {
+		return apiError(http.StatusBadRequest, "required", "Required field 'entity.rrset.type' not specified.")
+	}
+	if len(rrs.Rrdatas) == 0 && rrs.RoutingPolicy == nil {
+		return apiError(http.StatusBadRequest, "required", "Required field 'entity.rrset.rrdatas' not specified.")
+	}
+	if rrs.Name != zone.DnsName && !strings.HasSuffix(rrs.Name, "."+zone.DnsName) {
+		return apiError(http.StatusBadRequest, "invalidRecordName", "Invalid value for 'entity.rrset.name': '%s', it is not within the zone %s", rrs.Name, zone.DnsName)
+	}
+	if rrs.Type == "CNAME" && rrs.Name == zone.DnsName {
+		return apiError(http.StatusBadRequest, "invalidRecordType", "A CNAME record set can not be at the apex of the zone")
+	}
+	return nil
+}
+
+func storedRecord(rrs *dns.ResourceRecordSet) *dns.ResourceRecordSet {
+	stored := copyRecord(rrs)
+	stored.Kind = "dns#resourceRecordSet"
+	if stored.Ttl == 0 {
+		stored.Ttl = 300
+	}
+	return stored
+}
+
+func (f *CloudDns) GetRecord(_ context.Context, project string, zone string, record string, type_ string) (*dns.ResourceRecordSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("GetRecord"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	rrs, ok := z.records[recordKey(record, type_)]
+	if !ok {
+		return nil, apiError(http.StatusNotFound, "notFound", "The 'parameters.name' resource named '%s' does not exist.", record)
+	}
+	return copyRecord(rrs), nil
+}
+
+func (f *CloudDns) CreateRecord(_ context.Context, project string, zone string, rrs *dns.ResourceRecordSet) (*dns.ResourceRecordSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("CreateRecord"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRecord(z.zone, rrs); err != nil {
+		return nil, err
+	}
+	if _, ok := z.records[recordKey(rrs.Name, rrs.Type)]; ok {
+		return nil, apiError(http.StatusConflict, "alreadyExists", "The resource 'entity.rrset' named '%s (%s)' already exists", rrs.Name, rrs.Type)
+	}
+	stored := storedRecord(rrs)
+	z.records[recordKey(stored.Name, stored.Type)] = stored
+	return copyRecord(stored), nil
+}
+
+func (f *CloudDns) UpdateRecord(_ context.Context, project string, zone string, rrs *dns.ResourceRecordSet) (*dns.ResourceRecordSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("UpdateRecord"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := z.records[recordKey(rrs.Name, rrs.Type)]; !ok {
+		return nil, apiError(http.StatusNotFound, "notFound", "The 'parameters.name' resource named '%s' does not exist.", rrs.Name)
+	}
+	if err := validateRecord(z.zone, rrs); err != nil {
+		return nil, err
+	}
+	stored := storedRecord(rrs)
+	z.records[recordKey(stored.Name, stored.Type)] = stored
+	return copyRecord(stored), nil
+}
+
+func (f *CloudDns) DeleteRecord(_ context.Context, project string, zone string, record string, type_ string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("DeleteRecord"); err != nil {
+		return err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return err
+	}
+	if _, ok := z.records[recordKey(record, type_)]; !ok {
+		return apiError(http.StatusNotFound, "notFound", "The 'parameters.name' resource named '%s' does not exist.", record)
+	}
+	if type_ == "SOA" && record == z.zone.DnsName {
+		return apiError(http.StatusBadRequest, "invalidOperation", "The SOA record set of a zone can not be deleted")
+	}
+	delete(z.records, recordKey(record, type_))
+	return nil
+}
+
+func (f *CloudDns) ListRecords(_ context.Context, project string, zone string) ([]*dns.ResourceRecordSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("ListRecords"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	var records []*dns.ResourceRecordSet
+	for _, rrs := range z.records {
+		records = append(records, copyRecord(rrs))
+	}
+	slices.SortFunc(records, func(a, b *dns.ResourceRecordSet) int {
+		return strings.Compare(recordKey(a.Name, a.Type), recordKey(b.Name, b.Type))
+	})
+	return records, nil
+}
+
+func (f *CloudDns) ListDnsKeys(_ context.Context, project string, zone string) ([]*dns.DnsKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("ListDnsKeys"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*dns.DnsKey
+	for _, key := range z.keys {
+		copied := *key
+		keys = append(keys, &copied)
+	}
+	return keys, nil
+}
+
+// updateKeys creates a key for each default key spec when DNSSEC is turned on, like Cloud DNS, and
+// turning DNSSEC off removes every key. The key specs can not change while DNSSEC is on. The lock
+// must be held.
+func (f *CloudDns) updateKeys(z *cloudDnsZone) {
+	dnssec := z.zone.DnssecConfig
+	if dnssec == nil || dnssec.State == "off" {
+		z.keys = nil
+		return
+	}
+	if len(z.keys) > 0 {
+		return
+	}
+	for _, spec := range dnssec.DefaultKeySpecs {
+		id := strconv.FormatUint(f.nextId(), 10)
+		publicKey := fakePublicKey(z.zone.DnsName+"/"+id, spec.KeyLength)
+		flags := uint16(256)
+		if spec.KeyType == "keySigning" {
+			flags = 257
+		}
+		z.keys = append(z.keys, &dns.DnsKey{
+			Kind:         "dns#dnsKey",
+			Id:           id,
+			Algorithm:    spec.Algorithm,
+			KeyLength:    spec.KeyLength,
+			Type:         spec.KeyType,
+			IsActive:     true,
+			CreationTime: f.now(),
+			PublicKey:    base64.StdEncoding.EncodeToString(publicKey),
+			KeyTag:       int64(keyTag(flags, dnsKeyAlgorithms[spec.Algorithm], publicKey)),
+		})
+	}
+}
+
+// sameKeySpecs returns true when a and b hold the same key specs in any order
+func sameKeySpecs(a []*dns.DnsKeySpec, b []*dns.DnsKeySpec) bool {
+	specs := func(keySpecs []*dns.DnsKeySpec) []string {
+		var specs []string
+		for _, spec := range keySpecs {
+			specs = append(specs, fmt.Sprintf("%s/%s/%d", spec.KeyType, spec.Algorithm, spec.KeyLength))
+		}
+		slices.Sort(specs)
+		return specs
+	}
+	return slices.Equal(specs(a), specs(b))
+}
+
+// dnsKeyAlgorithms are the DNSSEC algorithm numbers of the Cloud DNS algorithms
+var dnsKeyAlgorithms = map[string]byte{
+	"rsasha1":         5,
+	"rsasha256":       8,
+	"rsasha512":       10,
+	"ecdsap256sha256": 13,
+	"ecdsap384sha384": 14,
+}
+
+// fakePublicKey returns bits of key material derived from seed, it is no real key but has a
+// stable key tag and DS digest
+func fakePublicKey(seed string, bits int64) []byte {
+	var key []byte
+	block := sha256.Sum256([]byte(seed))
+	for int64(len(key))*8 < bits {
+		key = append(key, block[:]...)
+		block = sha256.Sum256(block[:])
+	}
+	return key[:bits/8]
+}
+
+// keyTag computes the key tag of a DNSKEY record, RFC 4034 appendix B
+func keyTag(flags uint16, algorithm byte, publicKey []byte) uint16 {
+	rdata := append([]byte{byte(flags >> 8), byte(flags), 3, algorithm}, publicKey...)
+	var sum uint32
+	for i, b := range rdata {
+		if i%2 == 0 {
+			sum += uint32(b) << 8
+		} else {
+			sum += uint32(b)
+		}
+	}
+	sum += sum >> 16 & 0xffff
+	return uint16(sum & 0xffff)
+}
+
+// CreateChange applies the deletions and additions of change atomically, a deletion must match
+// the current record set exactly
+func (f *CloudDns) CreateChange(_ context.Context, project string, zone string, change *dns.Change) (*dns.Change, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("CreateChange"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]*dns.ResourceRecordSet, len(z.records))
+	for key, rrs := range z.records {
+		records[key] = rrs
+	}
+	for _, deletion := range change.Deletions {
+		current, ok := records[recordKey(deletion.Name, deletion.Type)]
+		if !ok {
+			return nil, apiError(http.StatusNotFound, "notFound", "The 'entity.change.deletions[%s]' resource named '%s (%s)' does not exist.", deletion.Name, deletion.Name, deletion.Type)
+		}
+		if current.Ttl != deletion.Ttl || !slices.Equal(current.Rrdatas, deletion.Rrdatas) {
+			return nil, apiError(http.StatusPreconditionFailed, "conditionNotMet", "Precondition not met for 'entity.change.deletions[%s]'", deletion.Name)
+		}
+		delete(records, recordKey(deletion.Name, deletion.Type))
+	}
+	for _, addition := range change.Additions {
+		if err := validateRecord(z.zone, addition); err != nil {
+			return nil, err
+		}
+		if _, ok := records[recordKey(addition.Name, addition.Type)]; ok {
+			return nil, apiError(http.StatusConflict, "alreadyExists", "The resource 'entity.change.additions[%s]' named '%s (%s)' already exists", addition.Name, addition.Name, addition.Type)
+		}
+		records[recordKey(addition.Name, addition.Type)] = storedRecord(addition)
+	}
+	z.records = records
+	created := &dns.Change{
+		Kind:      "dns#change",
+		Id:        strconv.Itoa(len(z.changes) + 1),
+		StartTime: f.now(),
+		Status:    "done",
+		IsServing: true,
+	}
+	for _, addition := range change.Additions {
+		created.Additions = append(created.Additions, storedRecord(addition))
+	}
+	for _, deletion := range change.Deletions {
+		created.Deletions = append(created.Deletions, copyRecord(deletion))
+	}
+	z.changes = append(z.changes, created)
+	return created, nil
+}
+
+// GetChange returns the change with the id
+func (f *CloudDns) GetChange(_ context.Context, project string, zone string, id string) (*dns.Change, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("GetChange"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range z.changes {
+		if change.Id == id {
+			return change, nil
+		}
+	}
+	return nil, apiError(http.StatusNotFound, "notFound", "The 'parameters.changeId' resource named '%s' does not exist.", id)
+}
+
+// ListChanges returns the changes of the zone, oldest first
+func (f *CloudDns) ListChanges(_ context.Context, project string, zone string) ([]*dns.Change, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("ListChanges"); err != nil {
+		return nil, err
+	}
+	z, err := f.zone(project, zone)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(z.changes), nil
+}
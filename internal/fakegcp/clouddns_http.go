@@ -0,0 +1,262 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegcp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/api/dns/v2"
+	"google.golang.org/api/googleapi"
+)
+
+const zonesPath = "/dns/v2/projects/{project}/locations/{location}/managedZones"
+
+// ServeHTTP serves the REST API of Cloud DNS v2 for the managed zones, operations, record sets
+// and changes
+func (f *CloudDns) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mux.ServeHTTP(w, r)
+}
+
+func (f *CloudDns) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	zonePath := zonesPath + "/{managedZone}"
+	recordPath := zonePath + "/rrsets/{name}/{type}"
+
+	mux.HandleFunc("GET "+zonesPath, func(w http.ResponseWriter, r *http.Request) {
+		zones, err := f.ListZones(r.Context(), r.PathValue("project"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		page, next, err := f.page(r, len(zones))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, &dns.ManagedZonesListResponse{ManagedZones: zones[page[0]:page[1]], NextPageToken: next})
+	})
+	mux.HandleFunc("POST "+zonesPath, func(w http.ResponseWriter, r *http.Request) {
+		zone := &dns.ManagedZone{}
+		if !readJSON(w, r, zone) {
+			return
+		}
+		result, err := f.CreateZone(r.Context(), r.PathValue("project"), zone)
+		respond(w, result, err)
+	})
+	mux.HandleFunc("GET "+zonePath, func(w http.ResponseWriter, r *http.Request) {
+		result, err := f.GetZone(r.Context(), r.PathValue("project"), r.PathValue("managedZone"))
+		respond(w, result, err)
+	})
+	mux.HandleFunc("PUT "+zonePath, func(w http.ResponseWriter, r *http.Request) {
+		zone := &dns.ManagedZone{}
+		if !readJSON(w, r, zone) {
+			return
+		}
+		result, err := f.UpdateZone(r.Context(), r.PathValue("project"), r.PathValue("managedZone"), zone)
+		respond(w, result, err)
+	})
+	mux.HandleFunc("PATCH "+zonePath, func(w http.ResponseWriter, r *http.Request) {
+		patch, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, apiError(http.StatusBadRequest, "parseError", "Invalid JSON payload received: %v", err))
+			return
+		}
+		result, err := f.patchZone(r.PathValue("project"), r.PathValue("managedZone"), patch)
+		respond(w, result, err)
+	})
+	mux.HandleFunc("DELETE "+zonePath, func(w http.ResponseWriter, r *http.Request) {
+		if err := f.DeleteZone(r.Context(), r.PathValue("project"), r.PathValue("managedZone")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET "+zonePath+"/operations/{operation}", func(w http.ResponseWriter, r *http.Request) {
+		result, err := f.GetOperation(r.Context(), r.PathValue("project"), r.PathValue("managedZone"), r.PathValue("operation"))
+		respond(w, result, err)
+	})
+
+	mux.HandleFunc("GET "+zonePath+"/dnsKeys", func(w http.ResponseWriter, r *http.Request) {
+		keys, err := f.ListDnsKeys(r.Context(), r.PathValue("project"), r.PathValue("managedZone"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		page, next, err := f.page(r, len(keys))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, &dns.DnsKeysListResponse{DnsKeys: keys[page[0]:page[1]], NextPageToken: next})
+	})
+	mux.HandleFunc("GET "+zonePath+"/rrsets", func(w http.ResponseWriter, r *http.Request) {
+		records, err := f.ListRecords(r.Context(), r.PathValue("project"), r.PathValue("managedZone"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		name, type_ := r.URL.Query().Get("name"), r.URL.Query().Get("type")
+		var filtered []*dns.ResourceRecordSet
+		for _, rrs := range records {
+			if (name == "" || rrs.Name == name) && (type_ == "" || rrs.Type == type_) {
+				filtered = append(filtered, rrs)
+			}
+		}
+		page, next, err := f.page(r, len(filtered))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, &dns.ResourceRecordSetsListResponse{Rrsets: filtered[page[0]:page[1]], NextPageToken: next})
+	})
+	mux.HandleFunc("POST "+zonePath+"/rrsets", func(w http.ResponseWriter, r *http.Request) {
+		rrs := &dns.ResourceRecordSet{}
+		if !readJSON(w, r, rrs) {
+			return
+		}
+		result, err := f.CreateRecord(r.Context(), r.PathValue("project"), r.PathValue("managedZone"), rrs)
+		respond(w, result, err)
+	})
+	mux.HandleFunc("GET "+recordPath, func(w http.ResponseWriter, r *http.Request) {
+		result, err := f.GetRecord(r.Context(), r.PathValue("project"), r.PathValue("managedZone"), r.PathValue("name"), r.PathValue("type"))
+		respond(w, result, err)
+	})
+	mux.HandleFunc("PATCH "+recordPath, func(w http.ResponseWriter, r *http.Request) {
+		rrs := &dns.ResourceRecordSet{}
+		if !readJSON(w, r, rrs) {
+			return
+		}
+		// the name and type in the path select the record set, like in the real API
+		rrs.Name, rrs.Type = r.PathValue("name"), r.PathValue("type")
+		result, err := f.UpdateRecord(r.Context(), r.PathValue("project"), r.PathValue("managedZone"), rrs)
+		respond(w, result, err)
+	})
+	mux.HandleFunc("DELETE "+recordPath, func(w http.ResponseWriter, r *http.Request) {
+		if err := f.DeleteRecord(r.Context(), r.PathValue("project"), r.PathValue("managedZone"), r.PathValue("name"), r.PathValue("type")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET "+zonePath+"/changes", func(w http.ResponseWriter, r *http.Request) {
+		changes, err := f.ListChanges(r.Context(), r.PathValue("project"), r.PathValue("managedZone"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		page, next, err := f.page(r, len(changes))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, &dns.ChangesListResponse{Changes: changes[page[0]:page[1]], NextPageToken: next})
+	})
+	mux.HandleFunc("POST "+zonePath+"/changes", func(w http.ResponseWriter, r *http.Request) {
+		change := &dns.Change{}
+		if !readJSON(w, r, change) {
+			return
+		}
+		result, err := f.CreateChange(r.Context(), r.PathValue("project"), r.PathValue("managedZone"), change)
+		respond(w, result, err)
+	})
+	mux.HandleFunc("GET "+zonePath+"/changes/{changeId}", func(w http.ResponseWriter, r *http.Request) {
+		result, err := f.GetChange(r.Context(), r.PathValue("project"), r.PathValue("managedZone"), r.PathValue("changeId"))
+		respond(w, result, err)
+	})
+	return mux
+}
+
+// page returns the bounds of the page of a list with total items requested by the maxResults
+// and pageToken parameters, and the token of the next page
+func (f *CloudDns) page(r *http.Request, total int) ([2]int, string, error) {
+	start := 0
+	if token := r.URL.Query().Get("pageToken"); token != "" {
+		parsed, err := strconv.Atoi(token)
+		if err != nil || parsed < 0 || parsed > total {
+			return [2]int{}, "", apiError(http.StatusBadRequest, "invalid", "Invalid value for 'parameters.pageToken': '%s'", token)
+		}
+		start = parsed
+	}
+	size := f.PageSize
+	if maxResults := r.URL.Query().Get("maxResults"); maxResults != "" {
+		parsed, err := strconv.Atoi(maxResults)
+		if err != nil || parsed <= 0 {
+			return [2]int{}, "", apiError(http.StatusBadRequest, "invalid", "Invalid value for 'parameters.maxResults': '%s'", maxResults)
+		}
+		size = parsed
+	}
+	if size == 0 || start+size >= total {
+		return [2]int{start, total}, "", nil
+	}
+	return [2]int{start, start + size}, strconv.Itoa(start + size), nil
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, apiError(http.StatusBadRequest, "parseError", "Invalid JSON payload received: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// respond writes the result of a call, or its error
+func respond(w http.ResponseWriter, result any, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, result)
+}
+
+// writeError writes err in the error format of the Google REST APIs, errors that are not a
+// googleapi.Error are internal errors
+func writeError(w http.ResponseWriter, err error) {
+	apiErr := &googleapi.Error{}
+	if !errors.As(err, &apiErr) {
+		apiErr = apiError(http.StatusInternalServerError, "backendError", "%v", err)
+	}
+	type errorItem struct {
+		Domain  string `json:"domain"`
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	}
+	body := struct {
+		Error struct {
+			Code    int         `json:"code"`
+			Message string      `json:"message"`
+			Errors  []errorItem `json:"errors,omitempty"`
+		} `json:"error"`
+	}{}
+	body.Error.Code = apiErr.Code
+	body.Error.Message = apiErr.Message
+	for _, item := range apiErr.Errors {
+		body.Error.Errors = append(body.Error.Errors, errorItem{Domain: "global", Reason: item.Reason, Message: item.Message})
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(apiErr.Code)
+	_ = json.NewEncoder(w).Encode(body)
+}
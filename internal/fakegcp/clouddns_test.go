@@ -0,0 +1,143 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/dns/v2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/tjololo/stilas/internal/fakegcp/conformance"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+// newHTTPCloudDns serves fake on an httptest server until the spec ends and returns the options
+// pointing a Cloud DNS client at it
+func newHTTPCloudDns(fake *CloudDns) []option.ClientOption {
+	server := httptest.NewServer(fake)
+	DeferCleanup(server.Close)
+	return []option.ClientOption{
+		option.WithEndpoint(server.URL + "/"),
+		option.WithoutAuthentication(),
+	}
+}
+
+var _ = Describe("CloudDns", func() {
+	Context("in memory", func() {
+		conformance.DescribeCloudDnsService(func() gcp.CloudDnsService {
+			return NewCloudDns()
+		})
+	})
+
+	Context("behind GcpCloudDnsService", func() {
+		conformance.DescribeCloudDnsService(func() gcp.CloudDnsService {
+			fake := NewCloudDns()
+			fake.PageSize = 1
+			fake.PollsUntilDone = 2
+			return &gcp.GcpCloudDnsService{NewService: dns.NewService, ClientOptions: newHTTPCloudDns(fake)}
+		})
+	})
+
+	Context("changes", func() {
+		var (
+			ctx     context.Context
+			fake    *CloudDns
+			service *dns.Service
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			fake = NewCloudDns()
+			var err error
+			service, err = dns.NewService(ctx, newHTTPCloudDns(fake)...)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fake.CreateZone(ctx, "test-project", &dns.ManagedZone{Name: "example", DnsName: "example.com."})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		www := func(ip string) *dns.ResourceRecordSet {
+			return &dns.ResourceRecordSet{Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{ip}}
+		}
+
+		httpCode := func(err error) int {
+			apiErr, ok := err.(*googleapi.Error)
+			Expect(ok).To(BeTrue(), "expected a googleapi.Error, got %v", err)
+			return apiErr.Code
+		}
+
+		It("applies the deletions and additions of a change", func() {
+			_, err := service.Changes.Create("test-project", "global", "example", &dns.Change{Additions: []*dns.ResourceRecordSet{www("10.0.0.1")}}).Context(ctx).Do()
+			Expect(err).NotTo(HaveOccurred())
+			change, err := service.Changes.Create("test-project", "global", "example", &dns.Change{
+				Deletions: []*dns.ResourceRecordSet{www("10.0.0.1")},
+				Additions: []*dns.ResourceRecordSet{www("10.0.0.2")},
+			}).Context(ctx).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(change.Status).To(Equal("done"))
+
+			got, err := service.Changes.Get("test-project", "global", "example", change.Id).Context(ctx).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Additions).To(HaveLen(1))
+			list, err := service.Changes.List("test-project", "global", "example").Context(ctx).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list.Changes).To(HaveLen(2))
+
+			rrs, err := fake.GetRecord(ctx, "test-project", "example", "www.example.com.", "A")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rrs.Rrdatas).To(Equal([]string{"10.0.0.2"}))
+		})
+
+		It("answers 412 and changes nothing when a deletion does not match", func() {
+			_, err := service.Changes.Create("test-project", "global", "example", &dns.Change{Additions: []*dns.ResourceRecordSet{www("10.0.0.1")}}).Context(ctx).Do()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = service.Changes.Create("test-project", "global", "example", &dns.Change{
+				Deletions: []*dns.ResourceRecordSet{www("10.0.0.9")},
+				Additions: []*dns.ResourceRecordSet{www("10.0.0.2")},
+			}).Context(ctx).Do()
+			Expect(httpCode(err)).To(Equal(http.StatusPreconditionFailed))
+
+			rrs, err := fake.GetRecord(ctx, "test-project", "example", "www.example.com.", "A")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rrs.Rrdatas).To(Equal([]string{"10.0.0.1"}))
+		})
+
+		It("answers 409 for additions that exist and 404 for deletions that do not", func() {
+			_, err := service.Changes.Create("test-project", "global", "example", &dns.Change{Additions: []*dns.ResourceRecordSet{www("10.0.0.1")}}).Context(ctx).Do()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = service.Changes.Create("test-project", "global", "example", &dns.Change{Additions: []*dns.ResourceRecordSet{www("10.0.0.1")}}).Context(ctx).Do()
+			Expect(httpCode(err)).To(Equal(http.StatusConflict))
+			_, err = service.Changes.Create("test-project", "global", "example", &dns.Change{
+				Deletions: []*dns.ResourceRecordSet{{Name: "missing.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"}}},
+			}).Context(ctx).Do()
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+		})
+
+		It("returns injected errors over http", func() {
+			fake.InjectError("GetZone", &googleapi.Error{Code: http.StatusTooManyRequests, Message: "quota exceeded"})
+			_, err := service.ManagedZones.Get("test-project", "global", "example").Context(ctx).Do()
+			Expect(httpCode(err)).To(Equal(http.StatusTooManyRequests))
+			_, err = service.ManagedZones.Get("test-project", "global", "example").Context(ctx).Do()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
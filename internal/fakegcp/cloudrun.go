@@ -0,0 +1,492 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegcp
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/iam/apiv1/iampb"
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	"cloud.google.com/go/run/apiv2/runpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CloudRun is an in-memory Cloud Run Admin API v2. It serves the Services and the Operations
+// services over gRPC, like the real API does on one endpoint.
+//
+// Creates, updates and deletes return running operations that finish after PollsUntilDone polls,
+// the services report Reconciling until then. Each change to the revision template creates a
+// revision, the latest one becomes ready when the operation finishes.
+type CloudRun struct {
+	runpb.UnimplementedServicesServer
+	longrunningpb.UnimplementedOperationsServer
+
+	// PollsUntilDone is the number of polls of an operation that report it running, zero
+	// finishes it on the first poll
+	PollsUntilDone int
+	// Now returns the time of the changes, time.Now when nil
+	Now func() time.Time
+
+	mu         sync.Mutex
+	services   map[string]*runpb.Service
+	revisions  map[string][]string
+	policies   map[string]*iampb.Policy
+	operations map[string]*cloudRunOperation
+	errors     map[string][]error
+	sequence   int
+}
+
+type cloudRunOperation struct {
+	operation *longrunningpb.Operation
+	polls     int
+	// finish applies the change to the services and returns the service of the response
+	finish func() *runpb.Service
+}
+
+// NewCloudRun returns an empty fake Cloud Run API
+func NewCloudRun() *CloudRun {
+	return &CloudRun{
+		services:   map[string]*runpb.Service{},
+		revisions:  map[string][]string{},
+		policies:   map[string]*iampb.Policy{},
+		operations: map[string]*cloudRunOperation{},
+		errors:     map[string][]error{},
+	}
+}
+
+// Register adds the Services and Operations servers to s
+func (f *CloudRun) Register(s *grpc.Server) {
+	runpb.RegisterServicesServer(s, f)
+	longrunningpb.RegisterOperationsServer(s, f)
+}
+
+// InjectError makes the next call of method, like CreateService or GetOperation, fail with err.
+// Errors injected for the same method are returned in order, one per call.
+func (f *CloudRun) InjectError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[method] = append(f.errors[method], err)
+}
+
+// Service returns a copy of the service with the full name, nil if it does not exist
+func (f *CloudRun) Service(name string) *runpb.Service {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if srv, ok := f.services[name]; ok {
+		return proto.Clone(srv).(*runpb.Service)
+	}
+	return nil
+}
+
+// Revisions returns the names of the revisions created for the service with the full name
+func (f *CloudRun) Revisions(name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.revisions[name])
+}
+
+// injected returns the next error injected for method, the lock must be held
+func (f *CloudRun) injected(method string) error {
+	queued := f.errors[method]
+	if len(queued) == 0 {
+		return nil
+	}
+	f.errors[method] = queued[1:]
+	return queued[0]
+}
+
+func (f *CloudRun) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
+	}
+	return time.Now()
+}
+
+func (f *CloudRun) CreateService(_ context.Context, req *runpb.CreateServiceRequest) (*longrunningpb.Operation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("CreateService"); err != nil {
+		return nil, err
+	}
+	if req.GetServiceId() == "" || req.GetService() == nil {
+		return nil, status.Error(codes.InvalidArgument, "service_id and service are required")
+	}
+	if req.GetService().GetName() != "" {
+		return nil, status.Error(codes.InvalidArgument, "service.name must be empty, the name is built from parent and service_id")
+	}
+	name := req.GetParent() + "/services/" + req.GetServiceId()
+	if _, _, _, ok := parseServiceName(name); !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid parent %s", req.GetParent())
+	}
+	if _, ok := f.services[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "service %s already exists", name)
+	}
+	srv := proto.Clone(req.GetService()).(*runpb.Service)
+	srv.Name = name
+	srv.Uid = fmt.Sprintf("%x", hash(name))
+	srv.CreateTime = timestamppb.New(f.now())
+	srv.Creator = "fake@stilas.418.cloud"
+	srv.Uri = serviceUri(name)
+	revisions, err := f.applyChange(srv, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetValidateOnly() {
+		return &longrunningpb.Operation{Name: f.operationName(name), Done: true}, nil
+	}
+	f.services[name] = srv
+	f.revisions[name] = revisions
+	return f.startOperation(srv, func() *runpb.Service {
+		return f.finishChange(name)
+	}), nil
+}
+
+func (f *CloudRun) GetService(_ context.Context, req *runpb.GetServiceRequest) (*runpb.Service, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("GetService"); err != nil {
+		return nil, err
+	}
+	srv, ok := f.services[req.GetName()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "service %s not found", req.GetName())
+	}
+	return proto.Clone(srv).(*runpb.Service), nil
+}
+
+func (f *CloudRun) ListServices(_ context.Context, req *runpb.ListServicesRequest) (*runpb.ListServicesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("ListServices"); err != nil {
+		return nil, err
+	}
+	response := &runpb.ListServicesResponse{}
+	for name, srv := range f.services {
+		if strings.HasPrefix(name, req.GetParent()+"/services/") {
+			response.Services = append(response.Services, proto.Clone(srv).(*runpb.Service))
+		}
+	}
+	slices.SortFunc(response.Services, func(a, b *runpb.Service) int { return strings.Compare(a.Name, b.Name) })
+	return response, nil
+}
+
+func (f *CloudRun) UpdateService(_ context.Context, req *runpb.UpdateServiceRequest) (*longrunningpb.Operation, error) {
+	f.mu.Lock()
+	if err := f.injected("UpdateService"); err != nil {
+		f.mu.Unlock()
+		return nil, err
+	}
+	name := req.GetService().GetName()
+	current, ok := f.services[name]
+	if !ok && req.GetAllowMissing() {
+		f.mu.Unlock()
+		parent, id := splitServiceName(name)
+		service := proto.Clone(req.GetService()).(*runpb.Service)
+		service.Name = ""
+		return f.CreateService(context.Background(), &runpb.CreateServiceRequest{Parent: parent, ServiceId: id, Service: service, ValidateOnly: req.GetValidateOnly()})
+	}
+	defer f.mu.Unlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "service %s not found", name)
+	}
+	if etag := req.GetService().GetEtag(); etag != "" && etag != current.Etag {
+		return nil, status.Errorf(codes.Aborted, "etag %s does not match the current etag %s", etag, current.Etag)
+	}
+	srv := proto.Clone(current).(*runpb.Service)
+	// only the fields a client can set are taken from the request
+	srv.Description = req.GetService().GetDescription()
+	srv.Labels = req.GetService().GetLabels()
+	srv.Annotations = req.GetService().GetAnnotations()
+	srv.Ingress = req.GetService().GetIngress()
+	srv.Template = req.GetService().GetTemplate()
+	srv.Traffic = req.GetService().GetTraffic()
+	srv.Scaling = req.GetService().GetScaling()
+	revisions, err := f.applyChange(srv, current)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetValidateOnly() {
+		return &longrunningpb.Operation{Name: f.operationName(name), Done: true}, nil
+	}
+	f.services[name] = srv
+	f.revisions[name] = revisions
+	return f.startOperation(srv, func() *runpb.Service {
+		return f.finishChange(name)
+	}), nil
+}
+
+func (f *CloudRun) DeleteService(_ context.Context, req *runpb.DeleteServiceRequest) (*longrunningpb.Operation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("DeleteService"); err != nil {
+		return nil, err
+	}
+	name := req.GetName()
+	srv, ok := f.services[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "service %s not found", name)
+	}
+	if req.GetEtag() != "" && req.GetEtag() != srv.Etag {
+		return nil, status.Errorf(codes.Aborted, "etag %s does not match the current etag %s", req.GetEtag(), srv.Etag)
+	}
+	if req.GetValidateOnly() {
+		return &longrunningpb.Operation{Name: f.operationName(name), Done: true}, nil
+	}
+	srv.DeleteTime = timestamppb.New(f.now())
+	srv.Reconciling = true
+	return f.startOperation(srv, func() *runpb.Service {
+		deleted := f.services[name]
+		delete(f.services, name)
+		delete(f.revisions, name)
+		delete(f.policies, name)
+		return deleted
+	}), nil
+}
+
+// applyChange validates srv and fills the fields the API computes, creating a revision when the
+// template differs from the one of current, nil for a new service. Returns the revisions of the
+// service with the new one, the lock must be held.
+func (f *CloudRun) applyChange(srv *runpb.Service, current *runpb.Service) ([]string, error) {
+	if len(srv.GetTemplate().GetContainers()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service.template.containers must not be empty")
+	}
+	if len(srv.Traffic) == 0 {
+		srv.Traffic = []*runpb.TrafficTarget{{Type: runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST, Percent: 100}}
+	}
+	var total int32
+	for _, target := range srv.Traffic {
+		total += target.Percent
+	}
+	if total != 100 {
+		return nil, status.Errorf(codes.InvalidArgument, "the traffic percentages add up to %d, not 100", total)
+	}
+	revisions := slices.Clone(f.revisions[srv.Name])
+	if current == nil || !proto.Equal(current.GetTemplate(), srv.GetTemplate()) {
+		revision := srv.GetTemplate().GetRevision()
+		if revision == "" {
+			_, id := splitServiceName(srv.Name)
+			revision = fmt.Sprintf("%s-%05d-%s", id, len(revisions)+1, revisionSuffix(srv.Name, len(revisions)+1))
+		}
+		if slices.Contains(revisions, revision) {
+			return nil, status.Errorf(codes.AlreadyExists, "revision %s already exists", revision)
+		}
+		revisions = append(revisions, revision)
+		srv.LatestCreatedRevision = srv.Name + "/revisions/" + revision
+	}
+	for _, target := range srv.Traffic {
+		if target.Type == runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_REVISION && !slices.Contains(revisions, target.Revision) {
+			return nil, status.Errorf(codes.InvalidArgument, "traffic target revision %s does not exist", target.Revision)
+		}
+	}
+	srv.Generation++
+	srv.Etag = fmt.Sprintf(`"%d"`, srv.Generation)
+	srv.UpdateTime = timestamppb.New(f.now())
+	srv.Reconciling = true
+	srv.TerminalCondition = &runpb.Condition{Type: "Ready", State: runpb.Condition_CONDITION_RECONCILING, LastTransitionTime: srv.UpdateTime}
+	return revisions, nil
+}
+
+// finishChange makes the latest revision of the service ready and serves the traffic, the lock
+// must be held
+func (f *CloudRun) finishChange(name string) *runpb.Service {
+	srv, ok := f.services[name]
+	if !ok {
+		return nil
+	}
+	srv.Reconciling = false
+	srv.ObservedGeneration = srv.Generation
+	srv.LatestReadyRevision = srv.LatestCreatedRevision
+	srv.TerminalCondition = &runpb.Condition{Type: "Ready", State: runpb.Condition_CONDITION_SUCCEEDED, LastTransitionTime: timestamppb.New(f.now())}
+	srv.TrafficStatuses = nil
+	for _, target := range srv.Traffic {
+		trafficStatus := &runpb.TrafficTargetStatus{Type: target.Type, Revision: target.Revision, Percent: target.Percent, Tag: target.Tag}
+		if target.Type == runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST {
+			trafficStatus.Revision = srv.LatestReadyRevision[strings.LastIndex(srv.LatestReadyRevision, "/")+1:]
+		}
+		if target.Tag != "" {
+			trafficStatus.Uri = strings.Replace(srv.Uri, "https://", "https://"+target.Tag+"---", 1)
+		}
+		srv.TrafficStatuses = append(srv.TrafficStatuses, trafficStatus)
+	}
+	return proto.Clone(srv).(*runpb.Service)
+}
+
+// startOperation returns a running operation for srv that runs finish when it is done, the lock
+// must be held
+func (f *CloudRun) startOperation(srv *runpb.Service, finish func() *runpb.Service) *longrunningpb.Operation {
+	metadata, _ := anypb.New(srv)
+	op := &longrunningpb.Operation{Name: f.operationName(srv.Name), Metadata: metadata}
+	f.operations[op.Name] = &cloudRunOperation{operation: op, finish: finish}
+	return proto.Clone(op).(*longrunningpb.Operation)
+}
+
+func (f *CloudRun) operationName(service string) string {
+	f.sequence++
+	parent, _, _ := strings.Cut(service, "/services/")
+	return fmt.Sprintf("%s/operations/%08d-fake", parent, f.sequence)
+}
+
+func (f *CloudRun) GetOperation(_ context.Context, req *longrunningpb.GetOperationRequest) (*longrunningpb.Operation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("GetOperation"); err != nil {
+		return nil, err
+	}
+	op, ok := f.operations[req.GetName()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "operation %s not found", req.GetName())
+	}
+	if !op.operation.Done {
+		if op.polls < f.PollsUntilDone {
+			op.polls++
+		} else {
+			f.finish(op)
+		}
+	}
+	return proto.Clone(op.operation).(*longrunningpb.Operation), nil
+}
+
+func (f *CloudRun) WaitOperation(_ context.Context, req *longrunningpb.WaitOperationRequest) (*longrunningpb.Operation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("WaitOperation"); err != nil {
+		return nil, err
+	}
+	op, ok := f.operations[req.GetName()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "operation %s not found", req.GetName())
+	}
+	if !op.operation.Done {
+		f.finish(op)
+	}
+	return proto.Clone(op.operation).(*longrunningpb.Operation), nil
+}
+
+func (f *CloudRun) DeleteOperation(_ context.Context, req *longrunningpb.DeleteOperationRequest) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("DeleteOperation"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.operations[req.GetName()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "operation %s not found", req.GetName())
+	}
+	delete(f.operations, req.GetName())
+	return &emptypb.Empty{}, nil
+}
+
+// finish completes op with the service its change results in, the lock must be held
+func (f *CloudRun) finish(op *cloudRunOperation) {
+	op.operation.Done = true
+	srv := op.finish()
+	if srv == nil {
+		op.operation.Result = &longrunningpb.Operation_Error{Error: status.New(codes.NotFound, "the service was deleted").Proto()}
+		return
+	}
+	response, _ := anypb.New(srv)
+	op.operation.Result = &longrunningpb.Operation_Response{Response: response}
+}
+
+func (f *CloudRun) GetIamPolicy(_ context.Context, req *iampb.GetIamPolicyRequest) (*iampb.Policy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("GetIamPolicy"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.services[req.GetResource()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "service %s not found", req.GetResource())
+	}
+	if policy, ok := f.policies[req.GetResource()]; ok {
+		return proto.Clone(policy).(*iampb.Policy), nil
+	}
+	return &iampb.Policy{Version: 1, Etag: []byte("BwAAAAAAAAA=")}, nil
+}
+
+func (f *CloudRun) SetIamPolicy(_ context.Context, req *iampb.SetIamPolicyRequest) (*iampb.Policy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("SetIamPolicy"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.services[req.GetResource()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "service %s not found", req.GetResource())
+	}
+	current, ok := f.policies[req.GetResource()]
+	if ok && len(req.GetPolicy().GetEtag()) > 0 && string(req.GetPolicy().GetEtag()) != string(current.Etag) {
+		return nil, status.Error(codes.Aborted, "the etag of the policy does not match, read it again")
+	}
+	policy := proto.Clone(req.GetPolicy()).(*iampb.Policy)
+	f.sequence++
+	policy.Etag = []byte(fmt.Sprintf("BwAAAAA%05d", f.sequence))
+	f.policies[req.GetResource()] = policy
+	return proto.Clone(policy).(*iampb.Policy), nil
+}
+
+func (f *CloudRun) TestIamPermissions(_ context.Context, req *iampb.TestIamPermissionsRequest) (*iampb.TestIamPermissionsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injected("TestIamPermissions"); err != nil {
+		return nil, err
+	}
+	return &iampb.TestIamPermissionsResponse{Permissions: req.GetPermissions()}, nil
+}
+
+// parseServiceName splits projects/{project}/locations/{location}/services/{id}
+func parseServiceName(name string) (project string, location string, id string, ok bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "locations" || parts[4] != "services" || parts[5] == "" {
+		return "", "", "", false
+	}
+	return parts[1], parts[3], parts[5], true
+}
+
+// splitServiceName returns the parent and id of the service with the full name
+func splitServiceName(name string) (parent string, id string) {
+	parent, id, _ = strings.Cut(name, "/services/")
+	return parent, id
+}
+
+// serviceUri returns the run.app URL of a service, stable for its name like the real ones
+func serviceUri(name string) string {
+	_, location, id, _ := parseServiceName(name)
+	return fmt.Sprintf("https://%s-%x-%s.a.run.app", id, hash(name)%(1<<40), location)
+}
+
+// revisionSuffix returns the random looking suffix of the revision n of a service
+func revisionSuffix(name string, n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	h := hash(fmt.Sprintf("%s/%d", name, n))
+	return string([]byte{letters[h%26], letters[h/26%26], letters[h/676%26]})
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegcp
+
+import (
+	"context"
+
+	"cloud.google.com/go/iam/apiv1/iampb"
+	gcprun "cloud.google.com/go/run/apiv2"
+	"cloud.google.com/go/run/apiv2/runpb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("CloudRun", func() {
+	const (
+		parent      = "projects/test-project/locations/europe-north1"
+		serviceName = parent + "/services/test-service"
+	)
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+		server *Server
+		client *gcprun.ServicesClient
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		var err error
+		server, err = NewServer("localhost")
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			Expect(server.Start(ctx)).To(Succeed())
+		}()
+		client, err = gcprun.NewServicesClient(ctx, server.CloudRunClientOptions()...)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			Expect(client.Close()).To(Succeed())
+			cancel()
+		})
+	})
+
+	createService := func() *runpb.Service {
+		op, err := client.CreateService(ctx, &runpb.CreateServiceRequest{
+			Parent:    parent,
+			ServiceId: "test-service",
+			Service: &runpb.Service{
+				Template: &runpb.RevisionTemplate{
+					Containers: []*runpb.Container{{Image: "gcr.io/test-project/test-image"}},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		srv, err := op.Wait(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		return srv
+	}
+
+	It("creates a service with a ready revision serving all traffic", func() {
+		srv := createService()
+		Expect(srv.Name).To(Equal(serviceName))
+		Expect(srv.Reconciling).To(BeFalse())
+		Expect(srv.Uri).NotTo(BeEmpty())
+		Expect(srv.LatestReadyRevision).To(HavePrefix(serviceName + "/revisions/test-service-00001-"))
+		Expect(srv.TrafficStatuses).To(HaveLen(1))
+		Expect(srv.TrafficStatuses[0].Percent).To(BeEquivalentTo(100))
+
+		_, err := client.CreateService(ctx, &runpb.CreateServiceRequest{Parent: parent, ServiceId: "test-service", Service: srv})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("keeps operations running until they are polled PollsUntilDone times", func() {
+		server.CloudRun.PollsUntilDone = 1
+		op, err := client.CreateService(ctx, &runpb.CreateServiceRequest{
+			Parent:    parent,
+			ServiceId: "test-service",
+			Service: &runpb.Service{
+				Template: &runpb.RevisionTemplate{
+					Containers: []*runpb.Container{{Image: "gcr.io/test-project/test-image"}},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.CloudRun.Service(serviceName).Reconciling).To(BeTrue())
+
+		polled := client.CreateServiceOperation(op.Name())
+		_, err = polled.Poll(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(polled.Done()).To(BeFalse())
+		srv, err := polled.Poll(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(polled.Done()).To(BeTrue())
+		Expect(srv.Reconciling).To(BeFalse())
+	})
+
+	It("creates a revision only when the template changes", func() {
+		srv := createService()
+		srv.Labels = map[string]string{"team": "stilas"}
+		op, err := client.UpdateService(ctx, &runpb.UpdateServiceRequest{Service: srv})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = op.Wait(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.CloudRun.Revisions(serviceName)).To(HaveLen(1))
+
+		srv = server.CloudRun.Service(serviceName)
+		srv.Template.Containers[0].Image = "gcr.io/test-project/test-image:v2"
+		op, err = client.UpdateService(ctx, &runpb.UpdateServiceRequest{Service: srv})
+		Expect(err).NotTo(HaveOccurred())
+		updated, err := op.Wait(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.CloudRun.Revisions(serviceName)).To(HaveLen(2))
+		Expect(updated.LatestReadyRevision).To(HavePrefix(serviceName + "/revisions/test-service-00002-"))
+		Expect(updated.Labels).To(HaveKeyWithValue("team", "stilas"))
+	})
+
+	It("rejects traffic that does not add up to 100 percent", func() {
+		srv := createService()
+		srv.Traffic = []*runpb.TrafficTarget{{Type: runpb.TrafficTargetAllocationType_TRAFFIC_TARGET_ALLOCATION_TYPE_LATEST, Percent: 50}}
+		_, err := client.UpdateService(ctx, &runpb.UpdateServiceRequest{Service: srv})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+
+	It("deletes the service when the operation finishes", func() {
+		createService()
+		op, err := client.DeleteService(ctx, &runpb.DeleteServiceRequest{Name: serviceName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.CloudRun.Service(serviceName)).NotTo(BeNil())
+		_, err = op.Wait(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.CloudRun.Service(serviceName)).To(BeNil())
+
+		_, err = client.GetService(ctx, &runpb.GetServiceRequest{Name: serviceName})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("sets and gets the iam policy of a service", func() {
+		createService()
+		policy, err := client.SetIamPolicy(ctx, &iampb.SetIamPolicyRequest{
+			Resource: serviceName,
+			Policy: &iampb.Policy{Bindings: []*iampb.Binding{
+				{Role: "roles/run.invoker", Members: []string{"allUsers"}},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Etag).NotTo(BeEmpty())
+
+		got, err := client.GetIamPolicy(ctx, &iampb.GetIamPolicyRequest{Resource: serviceName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got.Bindings).To(HaveLen(1))
+		Expect(got.Bindings[0].Members).To(ConsistOf("allUsers"))
+
+		_, err = client.SetIamPolicy(ctx, &iampb.SetIamPolicyRequest{
+			Resource: serviceName,
+			Policy:   &iampb.Policy{Etag: []byte("stale")},
+		})
+		Expect(status.Code(err)).To(Equal(codes.Aborted))
+	})
+
+	It("returns injected errors once, in order", func() {
+		server.CloudRun.InjectError("GetService", status.Error(codes.Internal, "internal error"))
+		server.CloudRun.InjectError("GetService", status.Error(codes.PermissionDenied, "denied"))
+
+		_, err := client.GetService(ctx, &runpb.GetServiceRequest{Name: serviceName})
+		Expect(status.Code(err)).To(Equal(codes.Internal))
+		_, err = client.GetService(ctx, &runpb.GetServiceRequest{Name: serviceName})
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		_, err = client.GetService(ctx, &runpb.GetServiceRequest{Name: serviceName})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+})
+
+var _ = Describe("SecretManager", func() {
+	var secrets *SecretManager
+
+	BeforeEach(func() {
+		secrets = NewSecretManager()
+	})
+
+	It("returns 404 for missing secrets", func() {
+		_, err := secrets.GetSecret(context.Background(), "test-project", "missing")
+		var apiErr *googleapi.Error
+		Expect(err).To(BeAssignableToTypeOf(apiErr))
+		Expect(err.(*googleapi.Error).Code).To(Equal(404))
+	})
+
+	It("lists the enabled versions newest first", func() {
+		ctx := context.Background()
+		_, err := secrets.CreateSecret(ctx, "test-project", "test-secret", nil)
+		Expect(err).NotTo(HaveOccurred())
+		first, err := secrets.AddSecretVersion(ctx, "test-project", "test-secret", []byte("a"))
+		Expect(err).NotTo(HaveOccurred())
+		second, err := secrets.AddSecretVersion(ctx, "test-project", "test-secret", []byte("b"))
+		Expect(err).NotTo(HaveOccurred())
+		third, err := secrets.AddSecretVersion(ctx, "test-project", "test-secret", []byte("c"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secrets.DestroySecretVersion(ctx, second.Name)).To(Succeed())
+
+		versions, err := secrets.ListSecretVersions(ctx, "test-project", "test-secret")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(versions).To(HaveLen(2))
+		Expect(versions[0].Name).To(Equal(third.Name))
+		Expect(versions[1].Name).To(Equal(first.Name))
+	})
+})
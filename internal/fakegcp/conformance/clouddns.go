@@ -0,0 +1,460 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance contains Ginkgo specs that implementations of the service interfaces must
+// pass, so the fakes used in tests behave like the clients of the real APIs.
+package conformance
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/dns/v2"
+
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+// Project is the project the conformance specs create their resources in
+const Project = "conformance-project"
+
+// httpCode returns the HTTP status code of an error returned by a Google API client, 0 for
+// other errors
+func httpCode(err error) int {
+	if apiErr := gcp.ApiErrorFromErr(err); apiErr != nil {
+		return apiErr.HTTPCode()
+	}
+	return 0
+}
+
+// DescribeCloudDnsService registers the specs a gcp.CloudDnsService must pass. newService is
+// called before each spec and must return a service backed by an empty project.
+func DescribeCloudDnsService(newService func() gcp.CloudDnsService) {
+	var (
+		ctx     context.Context
+		service gcp.CloudDnsService
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		service = newService()
+	})
+
+	createZone := func(name string, dnsName string) *dns.ManagedZone {
+		zone, err := service.CreateZone(ctx, Project, &dns.ManagedZone{
+			Name:        name,
+			DnsName:     dnsName,
+			Description: "conformance zone",
+			Visibility:  "public",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return zone
+	}
+
+	waitForOperation := func(zone string, op *dns.Operation) {
+		Eventually(func() (string, error) {
+			if op.Status == "done" {
+				return op.Status, nil
+			}
+			var err error
+			op, err = service.GetOperation(ctx, Project, zone, op.Id)
+			if err != nil {
+				return "", err
+			}
+			return op.Status, nil
+		}).WithTimeout(10 * time.Second).WithPolling(10 * time.Millisecond).Should(Equal("done"))
+	}
+
+	Context("managed zones", func() {
+		It("answers 404 for a missing zone", func() {
+			_, err := service.GetZone(ctx, Project, "missing")
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+			_, err = service.UpdateZone(ctx, Project, "missing", &dns.ManagedZone{Name: "missing", DnsName: "missing.example.com."})
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+			_, err = service.PatchZone(ctx, Project, "missing", &dns.ManagedZone{Description: "missing"})
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+			Expect(httpCode(service.DeleteZone(ctx, Project, "missing"))).To(Equal(http.StatusNotFound))
+			_, err = service.ListRecords(ctx, Project, "missing")
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+		})
+
+		It("creates a zone with the fields computed by the api", func() {
+			zone := createZone("example", "example.com.")
+			Expect(zone.Name).To(Equal("example"))
+			Expect(zone.DnsName).To(Equal("example.com."))
+			Expect(zone.Id).NotTo(BeZero())
+			Expect(zone.CreationTime).NotTo(BeEmpty())
+			Expect(zone.NameServers).NotTo(BeEmpty())
+			Expect(zone.Visibility).To(Equal("public"))
+
+			got, err := service.GetZone(ctx, Project, "example")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Id).To(Equal(zone.Id))
+			Expect(got.Description).To(Equal("conformance zone"))
+			Expect(got.NameServers).To(Equal(zone.NameServers))
+		})
+
+		It("answers 409 when the zone already exists", func() {
+			createZone("example", "example.com.")
+			_, err := service.CreateZone(ctx, Project, &dns.ManagedZone{Name: "example", DnsName: "example.com."})
+			Expect(httpCode(err)).To(Equal(http.StatusConflict))
+		})
+
+		It("creates the SOA and NS record sets of a zone", func() {
+			zone := createZone("example", "example.com.")
+			records, err := service.ListRecords(ctx, Project, "example")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(2))
+			ns, err := service.GetRecord(ctx, Project, "example", "example.com.", "NS")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ns.Rrdatas).To(ConsistOf(zone.NameServers))
+			_, err = service.GetRecord(ctx, Project, "example", "example.com.", "SOA")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("lists the zones of the project", func() {
+			createZone("example", "example.com.")
+			createZone("example-org", "example.org.")
+			createZone("example-net", "example.net.")
+			zones, err := service.ListZones(ctx, Project)
+			Expect(err).NotTo(HaveOccurred())
+			var names []string
+			for _, zone := range zones {
+				names = append(names, zone.Name)
+			}
+			Expect(names).To(ConsistOf("example", "example-org", "example-net"))
+		})
+
+		It("updates a zone through an operation", func() {
+			zone := createZone("example", "example.com.")
+			zone.Description = "updated"
+			zone.Labels = map[string]string{"team": "stilas"}
+			zone.DnssecConfig = &dns.ManagedZoneDnsSecConfig{State: "on", NonExistence: "nsec3"}
+			op, err := service.UpdateZone(ctx, Project, "example", zone)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(op.Id).NotTo(BeEmpty())
+			Expect(op.Status).To(BeElementOf("pending", "done"))
+			waitForOperation("example", op)
+
+			got, err := service.GetZone(ctx, Project, "example")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Description).To(Equal("updated"))
+			Expect(got.Labels).To(Equal(map[string]string{"team": "stilas"}))
+			Expect(got.DnssecConfig).NotTo(BeNil())
+			Expect(got.DnssecConfig.State).To(Equal("on"))
+			Expect(got.Id).To(Equal(zone.Id))
+		})
+
+		It("patches only the fields that are set or force sent", func() {
+			zone := createZone("example", "example.com.")
+			zone.Labels = map[string]string{"team": "stilas"}
+			op, err := service.UpdateZone(ctx, Project, "example", zone)
+			Expect(err).NotTo(HaveOccurred())
+			waitForOperation("example", op)
+
+			op, err = service.PatchZone(ctx, Project, "example", &dns.ManagedZone{
+				Description:        "patched",
+				CloudLoggingConfig: &dns.ManagedZoneCloudLoggingConfig{EnableLogging: true},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			waitForOperation("example", op)
+			got, err := service.GetZone(ctx, Project, "example")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Description).To(Equal("patched"))
+			Expect(got.Labels).To(Equal(map[string]string{"team": "stilas"}))
+			Expect(got.CloudLoggingConfig).NotTo(BeNil())
+			Expect(got.CloudLoggingConfig.EnableLogging).To(BeTrue())
+
+			op, err = service.PatchZone(ctx, Project, "example", &dns.ManagedZone{
+				Labels:          map[string]string{},
+				ForceSendFields: []string{"Description", "Labels"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			waitForOperation("example", op)
+			got, err = service.GetZone(ctx, Project, "example")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Description).To(BeEmpty())
+			Expect(got.Labels).To(BeEmpty())
+			Expect(got.CloudLoggingConfig.EnableLogging).To(BeTrue())
+		})
+
+		It("answers 400 when the dns name or visibility of a zone is changed", func() {
+			zone := createZone("example", "example.com.")
+			zone.DnsName = "example.org."
+			_, err := service.UpdateZone(ctx, Project, "example", zone)
+			Expect(httpCode(err)).To(Equal(http.StatusBadRequest))
+			_, err = service.PatchZone(ctx, Project, "example", &dns.ManagedZone{DnsName: "example.org."})
+			Expect(httpCode(err)).To(Equal(http.StatusBadRequest))
+			_, err = service.PatchZone(ctx, Project, "example", &dns.ManagedZone{Visibility: "private"})
+			Expect(httpCode(err)).To(Equal(http.StatusBadRequest))
+		})
+
+		It("changes the networks a private zone is visible to", func() {
+			const network = "https://www.googleapis.com/compute/v1/projects/" + Project + "/global/networks/default"
+			zone, err := service.CreateZone(ctx, Project, &dns.ManagedZone{
+				Name:       "internal",
+				DnsName:    "example.internal.",
+				Visibility: "private",
+				PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{
+					Networks: []*dns.ManagedZonePrivateVisibilityConfigNetwork{{NetworkUrl: network}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zone.PrivateVisibilityConfig.Networks).To(HaveLen(1))
+			Expect(zone.PrivateVisibilityConfig.Networks[0].NetworkUrl).To(Equal(network))
+
+			op, err := service.PatchZone(ctx, Project, "internal", &dns.ManagedZone{
+				PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{
+					GkeClusters: []*dns.ManagedZonePrivateVisibilityConfigGKECluster{
+						{GkeClusterName: "projects/" + Project + "/locations/europe-north1/clusters/apps"},
+					},
+					ForceSendFields: []string{"Networks", "GkeClusters"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			waitForOperation("internal", op)
+			got, err := service.GetZone(ctx, Project, "internal")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.PrivateVisibilityConfig.Networks).To(BeEmpty())
+			Expect(got.PrivateVisibilityConfig.GkeClusters).To(HaveLen(1))
+		})
+
+		It("answers 400 when a public zone has a private visibility config", func() {
+			_, err := service.CreateZone(ctx, Project, &dns.ManagedZone{
+				Name:                    "example",
+				DnsName:                 "example.com.",
+				Visibility:              "public",
+				PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{},
+			})
+			Expect(httpCode(err)).To(Equal(http.StatusBadRequest))
+		})
+
+		It("creates and updates forwarding zones", func() {
+			zone, err := service.CreateZone(ctx, Project, &dns.ManagedZone{
+				Name:       "corp",
+				DnsName:    "corp.internal.",
+				Visibility: "private",
+				ForwardingConfig: &dns.ManagedZoneForwardingConfig{
+					TargetNameServers: []*dns.ManagedZoneForwardingConfigNameServerTarget{{Ipv4Address: "10.0.0.53"}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zone.ForwardingConfig.TargetNameServers).To(HaveLen(1))
+			Expect(zone.ForwardingConfig.TargetNameServers[0].ForwardingPath).To(Equal("default"))
+
+			op, err := service.PatchZone(ctx, Project, "corp", &dns.ManagedZone{
+				ForwardingConfig: &dns.ManagedZoneForwardingConfig{
+					TargetNameServers: []*dns.ManagedZoneForwardingConfigNameServerTarget{
+						{Ipv4Address: "10.0.0.53", ForwardingPath: "private"},
+						{Ipv4Address: "10.0.1.53", ForwardingPath: "private"},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			waitForOperation("corp", op)
+			got, err := service.GetZone(ctx, Project, "corp")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.ForwardingConfig.TargetNameServers).To(HaveLen(2))
+			Expect(got.ForwardingConfig.TargetNameServers[1].ForwardingPath).To(Equal("private"))
+		})
+
+		It("creates peering zones", func() {
+			const network = "https://www.googleapis.com/compute/v1/projects/host-project/global/networks/shared"
+			zone, err := service.CreateZone(ctx, Project, &dns.ManagedZone{
+				Name:       "shared",
+				DnsName:    "shared.internal.",
+				Visibility: "private",
+				PeeringConfig: &dns.ManagedZonePeeringConfig{
+					TargetNetwork: &dns.ManagedZonePeeringConfigTargetNetwork{NetworkUrl: network},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zone.PeeringConfig.TargetNetwork.NetworkUrl).To(Equal(network))
+		})
+
+		It("answers 400 for public forwarding and peering zones", func() {
+			_, err := service.CreateZone(ctx, Project, &dns.ManagedZone{
+				Name:       "corp",
+				DnsName:    "corp.internal.",
+				Visibility: "public",
+				ForwardingConfig: &dns.ManagedZoneForwardingConfig{
+					TargetNameServers: []*dns.ManagedZoneForwardingConfigNameServerTarget{{Ipv4Address: "10.0.0.53"}},
+				},
+			})
+			Expect(httpCode(err)).To(Equal(http.StatusBadRequest))
+			_, err = service.CreateZone(ctx, Project, &dns.ManagedZone{
+				Name:       "shared",
+				DnsName:    "shared.internal.",
+				Visibility: "public",
+				PeeringConfig: &dns.ManagedZonePeeringConfig{
+					TargetNetwork: &dns.ManagedZonePeeringConfigTargetNetwork{NetworkUrl: "https://www.googleapis.com/compute/v1/projects/host-project/global/networks/shared"},
+				},
+			})
+			Expect(httpCode(err)).To(Equal(http.StatusBadRequest))
+		})
+
+		It("lists the DNS keys of a signed zone and only changes its key specs while DNSSEC is off", func() {
+			_, err := service.CreateZone(ctx, Project, &dns.ManagedZone{
+				Name:         "signed",
+				DnsName:      "signed.example.com.",
+				Visibility:   "public",
+				DnssecConfig: &dns.ManagedZoneDnsSecConfig{State: "on", NonExistence: "nsec3"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			keys, err := service.ListDnsKeys(ctx, Project, "signed")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(HaveLen(2))
+			for _, key := range keys {
+				Expect(key.IsActive).To(BeTrue())
+				Expect(key.PublicKey).NotTo(BeEmpty())
+				Expect(key.KeyTag).NotTo(BeZero())
+			}
+
+			zone, err := service.GetZone(ctx, Project, "signed")
+			Expect(err).NotTo(HaveOccurred())
+			var specs []*dns.DnsKeySpec
+			for _, spec := range zone.DnssecConfig.DefaultKeySpecs {
+				if spec.KeyType == "keySigning" {
+					specs = append(specs, spec)
+				}
+			}
+			specs = append(specs, &dns.DnsKeySpec{Algorithm: "ecdsap256sha256", KeyLength: 256, KeyType: "zoneSigning"})
+			_, err = service.PatchZone(ctx, Project, "signed", &dns.ManagedZone{
+				DnssecConfig: &dns.ManagedZoneDnsSecConfig{State: "on", NonExistence: "nsec3", DefaultKeySpecs: specs},
+			})
+			Expect(httpCode(err)).To(Equal(http.StatusBadRequest))
+
+			op, err := service.PatchZone(ctx, Project, "signed", &dns.ManagedZone{
+				DnssecConfig: &dns.ManagedZoneDnsSecConfig{State: "off", NonExistence: "nsec3", DefaultKeySpecs: zone.DnssecConfig.DefaultKeySpecs},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			waitForOperation("signed", op)
+			for _, state := range []string{"off", "on"} {
+				op, err := service.PatchZone(ctx, Project, "signed", &dns.ManagedZone{
+					DnssecConfig: &dns.ManagedZoneDnsSecConfig{State: state, NonExistence: "nsec3", DefaultKeySpecs: specs},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				waitForOperation("signed", op)
+			}
+			keys, err = service.ListDnsKeys(ctx, Project, "signed")
+			Expect(err).NotTo(HaveOccurred())
+			var active []string
+			for _, key := range keys {
+				if key.IsActive {
+					active = append(active, key.Type+"/"+key.Algorithm)
+				}
+			}
+			Expect(active).To(ConsistOf("keySigning/rsasha256", "zoneSigning/ecdsap256sha256"))
+
+			_, err = service.ListDnsKeys(ctx, Project, "missing")
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+		})
+
+		It("answers 404 for a missing operation", func() {
+			createZone("example", "example.com.")
+			_, err := service.GetOperation(ctx, Project, "example", "404")
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+		})
+
+		It("deletes a zone only when it has no record sets but the SOA and NS", func() {
+			createZone("example", "example.com.")
+			_, err := service.CreateRecord(ctx, Project, "example", &dns.ResourceRecordSet{
+				Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(httpCode(service.DeleteZone(ctx, Project, "example"))).To(Equal(http.StatusBadRequest))
+
+			Expect(service.DeleteRecord(ctx, Project, "example", "www.example.com.", "A")).To(Succeed())
+			Expect(service.DeleteZone(ctx, Project, "example")).To(Succeed())
+			_, err = service.GetZone(ctx, Project, "example")
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("record sets", func() {
+		BeforeEach(func() {
+			createZone("example", "example.com.")
+		})
+
+		It("creates, updates and deletes a record set", func() {
+			created, err := service.CreateRecord(ctx, Project, "example", &dns.ResourceRecordSet{
+				Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.Rrdatas).To(Equal([]string{"10.0.0.1"}))
+
+			updated, err := service.UpdateRecord(ctx, Project, "example", &dns.ResourceRecordSet{
+				Name: "www.example.com.", Type: "A", Ttl: 60, Rrdatas: []string{"10.0.0.2", "10.0.0.3"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Ttl).To(BeEquivalentTo(60))
+
+			got, err := service.GetRecord(ctx, Project, "example", "www.example.com.", "A")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Ttl).To(BeEquivalentTo(60))
+			Expect(got.Rrdatas).To(ConsistOf("10.0.0.2", "10.0.0.3"))
+
+			records, err := service.ListRecords(ctx, Project, "example")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(3))
+
+			Expect(service.DeleteRecord(ctx, Project, "example", "www.example.com.", "A")).To(Succeed())
+			_, err = service.GetRecord(ctx, Project, "example", "www.example.com.", "A")
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+		})
+
+		It("answers 409 when the record set already exists", func() {
+			rrs := &dns.ResourceRecordSet{Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"}}
+			_, err := service.CreateRecord(ctx, Project, "example", rrs)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = service.CreateRecord(ctx, Project, "example", rrs)
+			Expect(httpCode(err)).To(Equal(http.StatusConflict))
+		})
+
+		It("keeps record sets of different types with the same name apart", func() {
+			_, err := service.CreateRecord(ctx, Project, "example", &dns.ResourceRecordSet{
+				Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = service.CreateRecord(ctx, Project, "example", &dns.ResourceRecordSet{
+				Name: "www.example.com.", Type: "TXT", Ttl: 300, Rrdatas: []string{`"hello"`},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(service.DeleteRecord(ctx, Project, "example", "www.example.com.", "TXT")).To(Succeed())
+			_, err = service.GetRecord(ctx, Project, "example", "www.example.com.", "A")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("answers 404 for missing record sets", func() {
+			_, err := service.GetRecord(ctx, Project, "example", "missing.example.com.", "A")
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+			_, err = service.UpdateRecord(ctx, Project, "example", &dns.ResourceRecordSet{
+				Name: "missing.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"},
+			})
+			Expect(httpCode(err)).To(Equal(http.StatusNotFound))
+			Expect(httpCode(service.DeleteRecord(ctx, Project, "example", "missing.example.com.", "A"))).To(Equal(http.StatusNotFound))
+		})
+
+		It("answers 400 for record sets outside the zone", func() {
+			_, err := service.CreateRecord(ctx, Project, "example", &dns.ResourceRecordSet{
+				Name: "www.example.org.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"},
+			})
+			Expect(httpCode(err)).To(Equal(http.StatusBadRequest))
+		})
+	})
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakegcp contains in-memory fakes of the GCP APIs used by stilas. They keep state like
+// the real APIs, so they serve both the controller tests and a manager running without access to
+// GCP, e.g. in a kind cluster.
+package fakegcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Server serves the fake gRPC APIs and the fake REST APIs on two local listeners until the
+// context passed to Start is done. It implements manager.Runnable so it can be added to a
+// controller-runtime manager.
+type Server struct {
+	CloudRun      *CloudRun
+	CloudDns      *CloudDns
+	SecretManager *SecretManager
+
+	listener     net.Listener
+	server       *grpc.Server
+	httpListener net.Listener
+	httpServer   *http.Server
+}
+
+// NewServer listens on two free ports of host, usually "localhost", and registers new fakes on them
+func NewServer(host string) (*Server, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("NewServer: failed to listen on %s: %w", host, err)
+	}
+	httpListener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("NewServer: failed to listen on %s: %w", host, err)
+	}
+	s := &Server{
+		CloudRun:      NewCloudRun(),
+		CloudDns:      NewCloudDns(),
+		SecretManager: NewSecretManager(),
+		listener:      l,
+		server:        grpc.NewServer(),
+		httpListener:  httpListener,
+	}
+	s.CloudRun.Register(s.server)
+	s.httpServer = &http.Server{Handler: s.CloudDns, ReadHeaderTimeout: 10 * time.Second}
+	return s, nil
+}
+
+// Addr returns the address the gRPC APIs are served on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// HTTPAddr returns the address the REST APIs are served on
+func (s *Server) HTTPAddr() string {
+	return s.httpListener.Addr().String()
+}
+
+// CloudRunClientOptions returns the options that point a Cloud Run client at the fake
+func (s *Server) CloudRunClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(s.Addr()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+}
+
+// CloudDnsClientOptions returns the options that point a Cloud DNS client at the fake
+func (s *Server) CloudDnsClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint("http://" + s.HTTPAddr() + "/"),
+		option.WithoutAuthentication(),
+	}
+}
+
+// Start serves the fakes until ctx is done
+func (s *Server) Start(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.server.Stop()
+		_ = s.httpServer.Close()
+	}()
+	errs := make(chan error, 1)
+	go func() {
+		if err := s.httpServer.Serve(s.httpListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- fmt.Errorf("Start: failed to serve fake rest apis: %w", err)
+		}
+		close(errs)
+	}()
+	if err := s.server.Serve(s.listener); err != nil {
+		return fmt.Errorf("Start: failed to serve fake grpc apis: %w", err)
+	}
+	return <-errs
+}
+
+// NeedLeaderElection returns false, the fakes are served by every replica
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/secretmanager/v1"
+
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+var _ gcp.SecretManagerService = &SecretManager{}
+
+// SecretManager is an in-memory gcp.SecretManagerService, the versions only keep the size of
+// their data
+type SecretManager struct {
+	mu       sync.Mutex
+	secrets  map[string]*secretmanager.Secret
+	versions map[string][]*secretmanager.SecretVersion
+	access   map[string][]string
+}
+
+// NewSecretManager returns an empty fake Secret Manager
+func NewSecretManager() *SecretManager {
+	return &SecretManager{
+		secrets:  map[string]*secretmanager.Secret{},
+		versions: map[string][]*secretmanager.SecretVersion{},
+		access:   map[string][]string{},
+	}
+}
+
+func secretName(project string, secretId string) string {
+	return fmt.Sprintf("projects/%s/secrets/%s", project, secretId)
+}
+
+func notFound(name string) error {
+	return &googleapi.Error{Code: http.StatusNotFound, Message: fmt.Sprintf("%s not found", name)}
+}
+
+func (s *SecretManager) GetSecret(_ context.Context, project string, secretId string) (*secretmanager.Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secret, ok := s.secrets[secretName(project, secretId)]
+	if !ok {
+		return nil, notFound(secretName(project, secretId))
+	}
+	copied := *secret
+	return &copied, nil
+}
+
+func (s *SecretManager) CreateSecret(_ context.Context, project string, secretId string, labels map[string]string) (*secretmanager.Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := secretName(project, secretId)
+	if _, ok := s.secrets[name]; ok {
+		return nil, &googleapi.Error{Code: http.StatusConflict, Message: fmt.Sprintf("%s already exists", name)}
+	}
+	secret := &secretmanager.Secret{Name: name, Labels: labels}
+	s.secrets[name] = secret
+	copied := *secret
+	return &copied, nil
+}
+
+func (s *SecretManager) DeleteSecret(_ context.Context, project string, secretId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := secretName(project, secretId)
+	if _, ok := s.secrets[name]; !ok {
+		return notFound(name)
+	}
+	delete(s.secrets, name)
+	delete(s.versions, name)
+	delete(s.access, name)
+	return nil
+}
+
+func (s *SecretManager) AddSecretVersion(_ context.Context, project string, secretId string, _ []byte) (*secretmanager.SecretVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := secretName(project, secretId)
+	if _, ok := s.secrets[name]; !ok {
+		return nil, notFound(name)
+	}
+	version := &secretmanager.SecretVersion{Name: fmt.Sprintf("%s/versions/%d", name, len(s.versions[name])+1), State: "ENABLED"}
+	s.versions[name] = append(s.versions[name], version)
+	copied := *version
+	return &copied, nil
+}
+
+func (s *SecretManager) ListSecretVersions(_ context.Context, project string, secretId string) ([]*secretmanager.SecretVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := secretName(project, secretId)
+	if _, ok := s.secrets[name]; !ok {
+		return nil, notFound(name)
+	}
+	var versions []*secretmanager.SecretVersion
+	for i := len(s.versions[name]) - 1; i >= 0; i-- {
+		if version := s.versions[name][i]; version.State == "ENABLED" {
+			copied := *version
+			versions = append(versions, &copied)
+		}
+	}
+	return versions, nil
+}
+
+func (s *SecretManager) DestroySecretVersion(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, versions := range s.versions {
+		for _, version := range versions {
+			if version.Name == name {
+				version.State = "DESTROYED"
+				return nil
+			}
+		}
+	}
+	return notFound(name)
+}
+
+func (s *SecretManager) GrantSecretAccessor(_ context.Context, project string, secretId string, member string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := secretName(project, secretId)
+	if _, ok := s.secrets[name]; !ok {
+		return notFound(name)
+	}
+	if !slices.Contains(s.access[name], member) {
+		s.access[name] = append(s.access[name], member)
+	}
+	return nil
+}
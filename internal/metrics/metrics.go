@@ -0,0 +1,76 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics holds the stilas specific Prometheus metrics, registered on the
+// controller-runtime registry so they are served by the manager's metrics endpoint
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "stilas"
+
+var (
+	// GcpApiCalls counts the calls made to Google APIs
+	GcpApiCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gcp_api_calls_total",
+		Help:      "Number of calls made to Google APIs by service, method and status code.",
+	}, []string{"service", "method", "code"})
+
+	// GcpApiCallDuration observes the latency of the calls made to Google APIs
+	GcpApiCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "gcp_api_call_duration_seconds",
+		Help:      "Latency of the calls made to Google APIs by service, method and status code.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"service", "method", "code"})
+
+	// OperationDuration observes how long GCP long-running operations took to complete
+	OperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "gcp_operation_duration_seconds",
+		Help:      "Time from starting a long-running operation until it was observed done, by resource kind and operation type.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"kind", "type"})
+
+	// DriftDetected counts the reconciles that found the GCP resource different from the spec
+	DriftDetected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "drift_detected_total",
+		Help:      "Number of times a reconcile found a GCP resource different from its spec, by resource kind and field.",
+	}, []string{"kind", "field"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(GcpApiCalls, GcpApiCallDuration, OperationDuration, DriftDetected)
+}
+
+// ObserveCall records a call to method of the Google API service started at start that returned the
+// status code code
+func ObserveCall(service string, method string, code string, start time.Time) {
+	GcpApiCalls.WithLabelValues(service, method, code).Inc()
+	GcpApiCallDuration.WithLabelValues(service, method, code).Observe(time.Since(start).Seconds())
+}
+
+// ObserveOperation records the duration of a completed operation started at start
+func ObserveOperation(kind string, operationType string, start time.Time) {
+	OperationDuration.WithLabelValues(kind, operationType).Observe(time.Since(start).Seconds())
+}
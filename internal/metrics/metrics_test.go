@@ -0,0 +1,77 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// histogram returns the observations of one series of a histogram vector
+func histogram(observer prometheus.Observer) *dto.Histogram {
+	var m dto.Metric
+	Expect(observer.(prometheus.Metric).Write(&m)).To(Succeed())
+	return m.GetHistogram()
+}
+
+var _ = Describe("Call metrics", func() {
+	BeforeEach(func() {
+		GcpApiCalls.Reset()
+		GcpApiCallDuration.Reset()
+		OperationDuration.Reset()
+	})
+
+	It("Should count the calls and observe their latency by service, method and status code", func() {
+		start := time.Now()
+		ObserveCall("run", "GetService", "OK", start)
+		ObserveCall("run", "GetService", "OK", start)
+		ObserveCall("dns", "GetZone", "404", start)
+
+		Expect(testutil.CollectAndCompare(GcpApiCalls, strings.NewReader(`
+# HELP stilas_gcp_api_calls_total Number of calls made to Google APIs by service, method and status code.
+# TYPE stilas_gcp_api_calls_total counter
+stilas_gcp_api_calls_total{code="404",method="GetZone",service="dns"} 1
+stilas_gcp_api_calls_total{code="OK",method="GetService",service="run"} 2
+`))).To(Succeed())
+		Expect(testutil.CollectAndCount(GcpApiCallDuration)).To(Equal(2))
+		Expect(histogram(GcpApiCallDuration.WithLabelValues("run", "GetService", "OK")).GetSampleCount()).To(BeEquivalentTo(2))
+		Expect(histogram(GcpApiCallDuration.WithLabelValues("dns", "GetZone", "404")).GetSampleCount()).To(BeEquivalentTo(1))
+	})
+
+	It("Should observe the duration of the operations by kind and type", func() {
+		ObserveOperation("CloudRun", "create", time.Now().Add(-3*time.Second))
+
+		Expect(testutil.CollectAndCount(OperationDuration)).To(Equal(1))
+		observed := histogram(OperationDuration.WithLabelValues("CloudRun", "create"))
+		Expect(observed.GetSampleCount()).To(BeEquivalentTo(1))
+		Expect(observed.GetSampleSum()).To(BeNumerically(">=", 3))
+	})
+
+	It("Should register the metrics on the controller-runtime registry", func() {
+		Expect(testutil.GatherAndCount(ctrlmetrics.Registry, "stilas_gcp_api_calls_total", "stilas_drift_detected_total")).To(Equal(0))
+		DriftDetected.WithLabelValues("CloudRun", "traffic").Inc()
+		DeferCleanup(DriftDetected.Reset)
+		Expect(testutil.GatherAndCount(ctrlmetrics.Registry, "stilas_drift_detected_total")).To(Equal(1))
+	})
+})
@@ -0,0 +1,107 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+// collectTimeout bounds the list calls made during a scrape
+const collectTimeout = 10 * time.Second
+
+// conditionTypeSynced is the condition the controllers set when the GCP resource matches the spec
+const conditionTypeSynced = "Synced"
+
+var managedResourcesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "managed_resources"),
+	"Number of resources managed by stilas by kind, GCP project and readiness.",
+	[]string{"kind", "project", "ready"}, nil,
+)
+
+// ResourceCollector reports the managed resources per project and readiness, computed from the
+// manager's cache at scrape time so deleted resources never leave stale series behind
+type ResourceCollector struct {
+	Client client.Reader
+}
+
+// NewResourceCollector returns a ResourceCollector listing resources with c
+func NewResourceCollector(c client.Reader) *ResourceCollector {
+	return &ResourceCollector{Client: c}
+}
+
+func (r *ResourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- managedResourcesDesc
+}
+
+func (r *ResourceCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	logger := log.FromContext(ctx).WithName("metrics")
+
+	type key struct {
+		kind    string
+		project string
+		ready   bool
+	}
+	counts := map[key]int{}
+
+	var runs gcpv2.CloudRunList
+	if err := r.Client.List(ctx, &runs); err != nil {
+		logger.Error(err, "unable to list cloud runs")
+	}
+	for _, run := range runs.Items {
+		counts[key{"CloudRun", run.Spec.ProjectID, run.Status.Ready}]++
+	}
+
+	var zones gcpv1.CloudDnsZoneList
+	if err := r.Client.List(ctx, &zones); err != nil {
+		logger.Error(err, "unable to list cloud dns zones")
+	}
+	zoneProjects := map[types.NamespacedName]string{}
+	for _, zone := range zones.Items {
+		ready := zone.Status.Operation == "" && len(zone.Status.Nameservers) > 0
+		counts[key{"CloudDnsZone", zone.Spec.ProjectID, ready}]++
+		zoneProjects[types.NamespacedName{Namespace: zone.Namespace, Name: zone.Name}] = zone.Spec.ProjectID
+	}
+
+	var records gcpv1.CloudDnsRecordList
+	if err := r.Client.List(ctx, &records); err != nil {
+		logger.Error(err, "unable to list cloud dns records")
+	}
+	for _, record := range records.Items {
+		// a record is created in the project of its zone, the project is empty while the zone is missing
+		project := zoneProjects[types.NamespacedName{Namespace: record.Namespace, Name: record.Spec.ZoneRef.Name}]
+		ready := meta.IsStatusConditionTrue(record.Status.Conditions, conditionTypeSynced)
+		counts[key{"CloudDnsRecord", project, ready}]++
+	}
+
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(managedResourcesDesc, prometheus.GaugeValue, float64(count),
+			k.kind, k.project, strconv.FormatBool(k.ready))
+	}
+}
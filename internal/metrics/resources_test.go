@@ -0,0 +1,107 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+var _ = Describe("ResourceCollector", func() {
+	newCollector := func(objs ...client.Object) *ResourceCollector {
+		scheme := runtime.NewScheme()
+		Expect(gcpv1.AddToScheme(scheme)).To(Succeed())
+		Expect(gcpv2.AddToScheme(scheme)).To(Succeed())
+		return NewResourceCollector(fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build())
+	}
+	synced := []metav1.Condition{{Type: conditionTypeSynced, Status: metav1.ConditionTrue, Reason: "Synced"}}
+
+	It("Should count the resources by kind, project and readiness", func() {
+		collector := newCollector(
+			&gcpv2.CloudRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "team-a"},
+				Spec:       gcpv2.CloudRunSpec{ProjectID: "project-a"},
+				Status:     gcpv2.CloudRunStatus{Ready: true},
+			},
+			&gcpv2.CloudRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "world", Namespace: "team-a"},
+				Spec:       gcpv2.CloudRunSpec{ProjectID: "project-a"},
+			},
+			&gcpv1.CloudDnsZone{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "team-a"},
+				Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "project-a", DnsName: "example.com"},
+				Status:     gcpv1.CloudDnsZoneStatus{Nameservers: []string{"ns-cloud-a1.googledomains.com."}},
+			},
+			&gcpv1.CloudDnsZone{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "team-b"},
+				Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "project-b", DnsName: "example.org"},
+			},
+			&gcpv1.CloudDnsRecord{
+				ObjectMeta: metav1.ObjectMeta{Name: "www", Namespace: "team-a"},
+				Spec:       gcpv1.CloudDnsRecordSpec{ZoneRef: gcpv1.CloudDnsZoneReference{Name: "example"}},
+				Status:     gcpv1.CloudDnsRecordStatus{Conditions: synced},
+			},
+			&gcpv1.CloudDnsRecord{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team-a"},
+				Spec:       gcpv1.CloudDnsRecordSpec{ZoneRef: gcpv1.CloudDnsZoneReference{Name: "example"}},
+				Status:     gcpv1.CloudDnsRecordStatus{Conditions: synced},
+			},
+			&gcpv1.CloudDnsRecord{
+				ObjectMeta: metav1.ObjectMeta{Name: "www", Namespace: "team-b"},
+				Spec:       gcpv1.CloudDnsRecordSpec{ZoneRef: gcpv1.CloudDnsZoneReference{Name: "example"}},
+			},
+		)
+
+		Expect(testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP stilas_managed_resources Number of resources managed by stilas by kind, GCP project and readiness.
+# TYPE stilas_managed_resources gauge
+stilas_managed_resources{kind="CloudDnsRecord",project="project-a",ready="true"} 2
+stilas_managed_resources{kind="CloudDnsRecord",project="project-b",ready="false"} 1
+stilas_managed_resources{kind="CloudDnsZone",project="project-a",ready="true"} 1
+stilas_managed_resources{kind="CloudDnsZone",project="project-b",ready="false"} 1
+stilas_managed_resources{kind="CloudRun",project="project-a",ready="false"} 1
+stilas_managed_resources{kind="CloudRun",project="project-a",ready="true"} 1
+`))).To(Succeed())
+	})
+
+	It("Should count the records of a missing zone without a project", func() {
+		collector := newCollector(&gcpv1.CloudDnsRecord{
+			ObjectMeta: metav1.ObjectMeta{Name: "www", Namespace: "team-a"},
+			Spec:       gcpv1.CloudDnsRecordSpec{ZoneRef: gcpv1.CloudDnsZoneReference{Name: "missing"}},
+		})
+
+		Expect(testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP stilas_managed_resources Number of resources managed by stilas by kind, GCP project and readiness.
+# TYPE stilas_managed_resources gauge
+stilas_managed_resources{kind="CloudDnsRecord",project="",ready="false"} 1
+`))).To(Succeed())
+	})
+
+	It("Should report nothing without resources", func() {
+		Expect(testutil.CollectAndCount(newCollector())).To(Equal(0))
+	})
+})
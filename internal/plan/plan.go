@@ -0,0 +1,193 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plan describes the changes a reconcile makes, or would make in plan-only mode, to GCP
+// resources. The same plans back the drift metrics and events of the reconcilers, so what a plan
+// reports is what a reconcile does.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Action is what is done to a GCP resource
+type Action string
+
+const (
+	ActionCreate           Action = "Create"
+	ActionUpdate           Action = "Update"
+	ActionDelete           Action = "Delete"
+	ActionSetIamPolicy     Action = "SetIamPolicy"
+	ActionAddSecretVersion Action = "AddSecretVersion"
+)
+
+// Change is one action on a GCP resource
+type Change struct {
+	Action Action
+	// Resource is the full name of the GCP resource
+	Resource string
+	// Fields are the paths of the fields that differ from the spec, like template.containers[0].image
+	Fields []string
+}
+
+func (c Change) String() string {
+	if len(c.Fields) == 0 {
+		return fmt.Sprintf("%s %s", c.Action, c.Resource)
+	}
+	return fmt.Sprintf("%s %s (%s)", c.Action, c.Resource, strings.Join(c.Fields, ", "))
+}
+
+// Plan is the list of changes needed to bring GCP in line with a spec
+type Plan struct {
+	Changes []Change
+}
+
+// Add adds a change to the plan
+func (p *Plan) Add(action Action, resource string, fields ...string) {
+	p.Changes = append(p.Changes, Change{Action: action, Resource: resource, Fields: fields})
+}
+
+// Empty returns true if nothing needs to change
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String returns the plan as one change per line
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "No changes"
+	}
+	lines := make([]string, 0, len(p.Changes))
+	for _, change := range p.Changes {
+		lines = append(lines, change.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+var indexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// FieldLabel returns field without list indexes, to be used as a metric label of bounded cardinality
+func FieldLabel(field string) string {
+	return indexPattern.ReplaceAllString(field, "")
+}
+
+// FieldChange is a field that differs, with its current and desired values in JSON form, nil
+// when the field is not set
+type FieldChange struct {
+	Path    string
+	Current any
+	Desired any
+}
+
+// ProtoFields returns the paths of the fields that differ between current and desired, named as
+// in the JSON form of the messages
+func ProtoFields(current proto.Message, desired proto.Message) ([]string, error) {
+	changes, err := ProtoChanges(current, desired)
+	return paths(changes), err
+}
+
+// ProtoChanges returns the fields that differ between current and desired with their values
+func ProtoChanges(current proto.Message, desired proto.Message) ([]FieldChange, error) {
+	currentJSON, err := protojson.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	desiredJSON, err := protojson.Marshal(desired)
+	if err != nil {
+		return nil, err
+	}
+	return jsonChanges(currentJSON, desiredJSON)
+}
+
+// JSONFields returns the paths of the fields that differ between current and desired when
+// marshalled to JSON, like the resources of the Google REST APIs
+func JSONFields(current any, desired any) ([]string, error) {
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	desiredJSON, err := json.Marshal(desired)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := jsonChanges(currentJSON, desiredJSON)
+	return paths(changes), err
+}
+
+func paths(changes []FieldChange) []string {
+	var fields []string
+	for _, change := range changes {
+		fields = append(fields, change.Path)
+	}
+	return fields
+}
+
+func jsonChanges(currentJSON []byte, desiredJSON []byte) ([]FieldChange, error) {
+	var current, desired any
+	if err := json.Unmarshal(currentJSON, &current); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(desiredJSON, &desired); err != nil {
+		return nil, err
+	}
+	var changes []FieldChange
+	diff("", current, desired, &changes)
+	return changes, nil
+}
+
+func diff(path string, current any, desired any, changes *[]FieldChange) {
+	currentMap, currentIsMap := current.(map[string]any)
+	desiredMap, desiredIsMap := desired.(map[string]any)
+	if currentIsMap && desiredIsMap {
+		keys := make([]string, 0, len(currentMap)+len(desiredMap))
+		for key := range currentMap {
+			keys = append(keys, key)
+		}
+		for key := range desiredMap {
+			if _, ok := currentMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			diff(child, currentMap[key], desiredMap[key], changes)
+		}
+		return
+	}
+	currentList, currentIsList := current.([]any)
+	desiredList, desiredIsList := desired.([]any)
+	if currentIsList && desiredIsList && len(currentList) == len(desiredList) {
+		for i := range currentList {
+			diff(fmt.Sprintf("%s[%d]", path, i), currentList[i], desiredList[i], changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(current, desired) {
+		*changes = append(*changes, FieldChange{Path: path, Current: current, Desired: desired})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"cloud.google.com/go/run/apiv2/runpb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/dns/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("Plan", func() {
+	It("Should describe the changes one per line", func() {
+		p := &Plan{}
+		Expect(p.String()).To(Equal("No changes"))
+		p.Add(ActionCreate, "projects/p/managedZones/z")
+		p.Add(ActionUpdate, "projects/p/locations/l/services/s", "template.containers[0].image", "traffic")
+		Expect(p.Empty()).To(BeFalse())
+		Expect(p.String()).To(Equal("Create projects/p/managedZones/z\n" +
+			"Update projects/p/locations/l/services/s (template.containers[0].image, traffic)"))
+	})
+
+	It("Should list the changed fields of proto messages", func() {
+		current := &runpb.Service{
+			Template: &runpb.RevisionTemplate{
+				Containers: []*runpb.Container{{Image: "nginx:1.25", Env: []*runpb.EnvVar{{Name: "A"}}}},
+			},
+		}
+		desired := proto.Clone(current).(*runpb.Service)
+		Expect(ProtoFields(current, desired)).To(BeEmpty())
+
+		desired.Template.Containers[0].Image = "nginx:1.27"
+		desired.Template.Containers[0].Env = append(desired.Template.Containers[0].Env, &runpb.EnvVar{Name: "B"})
+		desired.Traffic = []*runpb.TrafficTarget{{Percent: 100}}
+		Expect(ProtoFields(current, desired)).To(Equal([]string{
+			"template.containers[0].env",
+			"template.containers[0].image",
+			"traffic",
+		}))
+	})
+
+	It("Should give the values of the changed fields", func() {
+		current := &runpb.Service{Template: &runpb.RevisionTemplate{Containers: []*runpb.Container{{Image: "nginx:1.25"}}}}
+		desired := &runpb.Service{Template: &runpb.RevisionTemplate{Containers: []*runpb.Container{{Image: "nginx:1.27"}}}, Description: "d"}
+		Expect(ProtoChanges(current, desired)).To(Equal([]FieldChange{
+			{Path: "description", Current: nil, Desired: "d"},
+			{Path: "template.containers[0].image", Current: "nginx:1.25", Desired: "nginx:1.27"},
+		}))
+	})
+
+	It("Should list the changed fields of REST resources", func() {
+		current := &dns.ManagedZone{Name: "zone", DnssecConfig: &dns.ManagedZoneDnsSecConfig{State: "off"}}
+		desired := &dns.ManagedZone{Name: "zone", DnssecConfig: &dns.ManagedZoneDnsSecConfig{State: "on"}}
+		Expect(JSONFields(current, desired)).To(Equal([]string{"dnssecConfig.state"}))
+	})
+
+	It("Should drop list indexes from metric labels", func() {
+		Expect(FieldLabel("template.containers[0].env[12].name")).To(Equal("template.containers.env.name"))
+	})
+})
@@ -0,0 +1,111 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy decides which GCP projects and locations the resources of a namespace may target,
+// based on the GcpProjectBindings of the cluster. It is used by the admission webhooks and again by
+// the reconcilers, so resources created while the webhooks were down are not reconciled either.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+)
+
+// DeniedError is returned when no GcpProjectBinding allows a namespace to target a project and location
+type DeniedError struct {
+	Namespace string
+	Project   string
+	Location  string
+}
+
+func (e *DeniedError) Error() string {
+	if e.Location == "" {
+		return fmt.Sprintf("no GcpProjectBinding allows namespace %s to use project %s", e.Namespace, e.Project)
+	}
+	return fmt.Sprintf("no GcpProjectBinding allows namespace %s to use project %s in location %s", e.Namespace, e.Project, e.Location)
+}
+
+// IsDenied returns true if err is or wraps a DeniedError
+func IsDenied(err error) bool {
+	var denied *DeniedError
+	return errors.As(err, &denied)
+}
+
+// ProjectPolicy checks resources against the GcpProjectBindings read with Client
+type ProjectPolicy struct {
+	Client client.Reader
+}
+
+// Check returns a DeniedError unless a GcpProjectBinding allows namespace to target project in
+// location. An empty location is used for global resources and only checks the project.
+func (p *ProjectPolicy) Check(ctx context.Context, namespace string, project string, location string) error {
+	var bindings gcpv1.GcpProjectBindingList
+	if err := p.Client.List(ctx, &bindings); err != nil {
+		return fmt.Errorf("failed to list project bindings: %w", err)
+	}
+	var namespaceLabels labels.Set
+	namespaceFetched := false
+	for _, binding := range bindings.Items {
+		if !matchesAny(binding.Spec.Projects, project) {
+			continue
+		}
+		if location != "" && len(binding.Spec.Locations) > 0 && !matchesAny(binding.Spec.Locations, location) {
+			continue
+		}
+		if slices.Contains(binding.Spec.Namespaces, namespace) {
+			return nil
+		}
+		if binding.Spec.NamespaceSelector == nil {
+			continue
+		}
+		if !namespaceFetched {
+			var ns corev1.Namespace
+			if err := p.Client.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+				return fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+			}
+			namespaceLabels = labels.Set(ns.Labels)
+			namespaceFetched = true
+		}
+		selector, err := metav1.LabelSelectorAsSelector(binding.Spec.NamespaceSelector)
+		if err != nil {
+			return fmt.Errorf("invalid namespace selector in GcpProjectBinding %s: %w", binding.Name, err)
+		}
+		if selector.Matches(namespaceLabels) {
+			return nil
+		}
+	}
+	return &DeniedError{Namespace: namespace, Project: project, Location: location}
+}
+
+// matchesAny returns true if value matches one of the shell patterns
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
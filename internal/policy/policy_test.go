@@ -0,0 +1,103 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+)
+
+var _ = Describe("ProjectPolicy", func() {
+	ctx := context.Background()
+	newPolicy := func(objs ...client.Object) *ProjectPolicy {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(gcpv1.AddToScheme(scheme)).To(Succeed())
+		return &ProjectPolicy{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()}
+	}
+	teamA := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}}
+	teamB := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"team": "b"}}}
+
+	It("Should deny everything without bindings", func() {
+		err := newPolicy(teamA).Check(ctx, "team-a", "team-a-prod", "europe-north1")
+		Expect(IsDenied(err)).To(BeTrue())
+		Expect(err.Error()).To(Equal("no GcpProjectBinding allows namespace team-a to use project team-a-prod in location europe-north1"))
+	})
+
+	It("Should allow listed namespaces to use matching projects", func() {
+		p := newPolicy(teamA, teamB, &gcpv1.GcpProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: gcpv1.GcpProjectBindingSpec{
+				Namespaces: []string{"team-a"},
+				Projects:   []string{"team-a-*"},
+			},
+		})
+		Expect(p.Check(ctx, "team-a", "team-a-prod", "europe-north1")).To(Succeed())
+		Expect(p.Check(ctx, "team-a", "team-a-dev", "")).To(Succeed())
+		Expect(IsDenied(p.Check(ctx, "team-a", "team-b-prod", ""))).To(BeTrue())
+		Expect(IsDenied(p.Check(ctx, "team-b", "team-a-prod", ""))).To(BeTrue())
+	})
+
+	It("Should allow namespaces matching the selector", func() {
+		p := newPolicy(teamA, teamB, &gcpv1.GcpProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-b"},
+			Spec: gcpv1.GcpProjectBindingSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+				Projects:          []string{"team-b-prod"},
+			},
+		})
+		Expect(p.Check(ctx, "team-b", "team-b-prod", "")).To(Succeed())
+		Expect(IsDenied(p.Check(ctx, "team-a", "team-b-prod", ""))).To(BeTrue())
+	})
+
+	It("Should restrict the locations of regional resources", func() {
+		p := newPolicy(teamA, &gcpv1.GcpProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: gcpv1.GcpProjectBindingSpec{
+				Namespaces: []string{"team-a"},
+				Projects:   []string{"team-a-prod"},
+				Locations:  []string{"europe-*"},
+			},
+		})
+		Expect(p.Check(ctx, "team-a", "team-a-prod", "europe-north1")).To(Succeed())
+		Expect(p.Check(ctx, "team-a", "team-a-prod", "")).To(Succeed())
+		Expect(IsDenied(p.Check(ctx, "team-a", "team-a-prod", "us-central1"))).To(BeTrue())
+	})
+
+	It("Should fail when the namespace can not be read", func() {
+		p := newPolicy(&gcpv1.GcpProjectBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "selector"},
+			Spec: gcpv1.GcpProjectBindingSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				Projects:          []string{"*"},
+			},
+		})
+		err := p.Check(ctx, "team-a", "team-a-prod", "")
+		Expect(err).To(HaveOccurred())
+		Expect(IsDenied(err)).To(BeFalse())
+	})
+})
@@ -0,0 +1,102 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/status"
+
+	"github.com/tjololo/stilas/internal/metrics"
+	"github.com/tjololo/stilas/internal/tracing"
+)
+
+// Names of the Google API services used in the metrics labels
+const (
+	ServiceCloudRun      = "run"
+	ServiceCloudDns      = "dns"
+	ServiceSecretManager = "secretmanager"
+)
+
+// Call invokes fn as a call to method of the Google API service for project in a child span of ctx,
+// after waiting for the rate limit of the project and service, recording its latency and status
+// code. fn must pass the context it is given on to the client.
+func Call[T any](ctx context.Context, service string, project string, method string, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, span := tracing.Tracer().Start(ctx, fmt.Sprintf("%s/%s", service, method), trace.WithAttributes(
+		semconv.RPCService(service),
+		semconv.RPCMethod(method),
+		attribute.String("gcp.project_id", project),
+	), trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	if err := DefaultRateLimiter.Wait(ctx, service, project); err != nil {
+		var zero T
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return zero, fmt.Errorf("%s: rate limit wait cancelled: %w", method, err)
+	}
+	start := time.Now()
+	result, err := fn(ctx)
+	code := ErrorCode(err)
+	metrics.ObserveCall(service, method, code, start)
+	span.SetAttributes(attribute.String("gcp.status_code", code))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
+
+// callErr is Call for methods only returning an error
+func callErr(ctx context.Context, service string, project string, method string, fn func(ctx context.Context) error) error {
+	_, err := Call(ctx, service, project, method, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// ErrorCode returns the status code of an error returned by a Google API client: the HTTP status for
+// REST clients, the gRPC code name for gRPC clients and OK for a nil error
+func ErrorCode(err error) string {
+	if err == nil {
+		return "OK"
+	}
+	var gErr *googleapi.Error
+	if errors.As(err, &gErr) {
+		return strconv.Itoa(gErr.Code)
+	}
+	var aErr *apierror.APIError
+	if errors.As(err, &aErr) {
+		if aErr.HTTPCode() > 0 {
+			return strconv.Itoa(aErr.HTTPCode())
+		}
+		return aErr.GRPCStatus().Code().String()
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code().String()
+	}
+	return "Unknown"
+}
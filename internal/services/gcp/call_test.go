@@ -0,0 +1,85 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/tjololo/stilas/internal/metrics"
+	"github.com/tjololo/stilas/internal/tracing"
+)
+
+var _ = Describe("Call", func() {
+	It("Should count calls by service, method and status code", func() {
+		before := testutil.ToFloat64(metrics.GcpApiCalls.WithLabelValues("test", "Get", "404"))
+		_, err := Call(context.Background(), "test", "test-project", "Get", func(ctx context.Context) (string, error) {
+			return "", &googleapi.Error{Code: 404}
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(testutil.ToFloat64(metrics.GcpApiCalls.WithLabelValues("test", "Get", "404"))).To(Equal(before + 1))
+
+		result, err := Call(context.Background(), "test", "test-project", "Get", func(ctx context.Context) (string, error) {
+			return "ok", nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal("ok"))
+		Expect(testutil.ToFloat64(metrics.GcpApiCalls.WithLabelValues("test", "Get", "OK"))).To(BeNumerically(">=", 1))
+	})
+
+	It("Should run the call in a child span of the context", func() {
+		exporter := tracetest.NewInMemoryExporter()
+		tp := tracing.Install(sdktrace.WithSyncer(exporter), 1)
+		DeferCleanup(tp.Shutdown)
+
+		ctx, parent := tracing.Tracer().Start(context.Background(), "parent")
+		_, err := Call(ctx, "test", "test-project", "Delete", func(ctx context.Context) (string, error) {
+			return "", &googleapi.Error{Code: 403}
+		})
+		parent.End()
+		Expect(err).To(HaveOccurred())
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name).To(Equal("test/Delete"))
+		Expect(spans[0].Parent.SpanID()).To(Equal(parent.SpanContext().SpanID()))
+		Expect(spans[0].Status.Code).To(Equal(otelcodes.Error))
+		Expect(spans[0].Attributes).To(ContainElement(attribute.String("gcp.status_code", "403")))
+	})
+
+	DescribeTable("ErrorCode",
+		func(err error, code string) {
+			Expect(ErrorCode(err)).To(Equal(code))
+		},
+		Entry("nil", nil, "OK"),
+		Entry("REST error", fmt.Errorf("wrapped: %w", &googleapi.Error{Code: 429}), "429"),
+		Entry("gRPC error", fmt.Errorf("wrapped: %w", status.Error(codes.Unavailable, "down")), "Unavailable"),
+		Entry("other error", errors.New("boom"), "Unknown"),
+	)
+})
@@ -0,0 +1,198 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultClientMaxAge is how long a cached client is used before it is rebuilt, so credentials
+	// read from files when the client is created, like rotated service account keys, are picked up
+	DefaultClientMaxAge = time.Hour
+	// clientCloseDelay gives the calls still using a replaced client time to finish before it is closed
+	clientCloseDelay = time.Minute
+)
+
+// ErrClientCacheClosed is returned by Get once the cache is closed
+var ErrClientCacheClosed = errors.New("client cache is closed")
+
+// ClientCache lazily creates one long-lived Google API client per key, usually the project and the
+// credentials used to reach it, and shares it between concurrent reconciles. The zero value is
+// ready to use. It is a manager.Runnable evicting the clients older than MaxAge while it runs and
+// closing all clients when the manager stops.
+type ClientCache[C any] struct {
+	// MaxAge is how long a client is used before it is rebuilt, defaults to DefaultClientMaxAge
+	MaxAge time.Duration
+
+	mu      sync.Mutex
+	clients map[string]cachedClient[C]
+	dialing map[string]*pendingClient[C]
+	stopped bool
+}
+
+type cachedClient[C any] struct {
+	client  C
+	created time.Time
+}
+
+// pendingClient is a client being created, the callers asking for its key meanwhile wait for done
+type pendingClient[C any] struct {
+	done   chan struct{}
+	client C
+	err    error
+}
+
+// Get returns the client of key, creating it with newClient if it is missing or too old. The
+// context given to newClient is never cancelled as it may be kept by the client for token refreshes.
+// Concurrent callers of the same key share one newClient call, made without holding the lock so
+// other keys are served meanwhile.
+func (c *ClientCache[C]) Get(ctx context.Context, key string, newClient func(ctx context.Context) (C, error)) (C, error) {
+	var zero C
+	c.mu.Lock()
+	if c.stopped {
+		c.mu.Unlock()
+		return zero, ErrClientCacheClosed
+	}
+	if cached, ok := c.clients[key]; ok && time.Since(cached.created) < c.maxAge() {
+		c.mu.Unlock()
+		return cached.client, nil
+	}
+	if pending, ok := c.dialing[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-pending.done:
+			return pending.client, pending.err
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+	pending := &pendingClient[C]{done: make(chan struct{})}
+	if c.dialing == nil {
+		c.dialing = map[string]*pendingClient[C]{}
+	}
+	c.dialing[key] = pending
+	c.mu.Unlock()
+
+	client, err := newClient(context.WithoutCancel(ctx))
+
+	c.mu.Lock()
+	delete(c.dialing, key)
+	switch {
+	case err != nil:
+		pending.err = err
+	case c.stopped:
+		// the cache was closed while the client was created, nothing would ever close it
+		closeClient(client)
+		pending.err = ErrClientCacheClosed
+	default:
+		if cached, ok := c.clients[key]; ok {
+			closeLater(cached.client)
+		}
+		if c.clients == nil {
+			c.clients = map[string]cachedClient[C]{}
+		}
+		c.clients[key] = cachedClient[C]{client: client, created: time.Now()}
+		pending.client = client
+	}
+	c.mu.Unlock()
+	close(pending.done)
+	return pending.client, pending.err
+}
+
+// Invalidate drops the client of key so the next Get creates a new one
+func (c *ClientCache[C]) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.clients[key]; ok {
+		delete(c.clients, key)
+		closeLater(cached.client)
+	}
+}
+
+// evict drops the clients older than MaxAge, the clients of keys no longer used, like those of
+// rotated credentials, would otherwise stay open forever
+func (c *ClientCache[C]) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, cached := range c.clients {
+		if time.Since(cached.created) >= c.maxAge() {
+			delete(c.clients, key)
+			closeLater(cached.client)
+		}
+	}
+}
+
+// Len returns the number of cached clients
+func (c *ClientCache[C]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.clients)
+}
+
+// Start evicts the clients older than MaxAge until ctx is done and then closes all cached clients
+func (c *ClientCache[C]) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.maxAge())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.evict()
+		case <-ctx.Done():
+			c.Close()
+			return nil
+		}
+	}
+}
+
+// NeedLeaderElection is false so the clients are closed on shutdown whether or not this replica is the leader
+func (c *ClientCache[C]) NeedLeaderElection() bool {
+	return false
+}
+
+// Close closes all cached clients, later calls to Get fail with ErrClientCacheClosed
+func (c *ClientCache[C]) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cached := range c.clients {
+		closeClient(cached.client)
+	}
+	c.clients = nil
+	c.stopped = true
+}
+
+func (c *ClientCache[C]) maxAge() time.Duration {
+	if c.MaxAge > 0 {
+		return c.MaxAge
+	}
+	return DefaultClientMaxAge
+}
+
+// closeLater closes client once the calls still using it had time to finish
+func closeLater(client any) {
+	time.AfterFunc(clientCloseDelay, func() { closeClient(client) })
+}
+
+// closeClient closes gRPC clients, REST clients have nothing to close
+func closeClient(client any) {
+	if closer, ok := client.(interface{ Close() error }); ok {
+		_ = closer.Close()
+	}
+}
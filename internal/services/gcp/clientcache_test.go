@@ -0,0 +1,160 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeClient struct {
+	id     int32
+	closed atomic.Bool
+}
+
+func (f *fakeClient) Close() error {
+	f.closed.Store(true)
+	return nil
+}
+
+var _ = Describe("ClientCache", func() {
+	var cache *ClientCache[*fakeClient]
+	var created atomic.Int32
+	newClient := func(ctx context.Context) (*fakeClient, error) {
+		return &fakeClient{id: created.Add(1)}, nil
+	}
+
+	BeforeEach(func() {
+		cache = &ClientCache[*fakeClient]{}
+		created.Store(0)
+	})
+
+	It("Should create one client per key shared by concurrent callers", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(key string) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, err := cache.Get(context.Background(), key, newClient)
+				Expect(err).NotTo(HaveOccurred())
+			}([]string{"project-a", "project-b"}[i%2])
+		}
+		wg.Wait()
+		Expect(created.Load()).To(Equal(int32(2)))
+		Expect(cache.Len()).To(Equal(2))
+	})
+
+	It("Should not cache failed clients", func() {
+		_, err := cache.Get(context.Background(), "project-a", func(ctx context.Context) (*fakeClient, error) {
+			return nil, errors.New("no credentials")
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(cache.Len()).To(Equal(0))
+	})
+
+	It("Should not hand a cancelled context to the client", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := cache.Get(ctx, "project-a", func(ctx context.Context) (*fakeClient, error) {
+			Expect(ctx.Err()).NotTo(HaveOccurred())
+			return newClient(ctx)
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should rebuild clients older than MaxAge or invalidated", func() {
+		cache.MaxAge = time.Millisecond
+		first, _ := cache.Get(context.Background(), "project-a", newClient)
+		time.Sleep(2 * time.Millisecond)
+		second, _ := cache.Get(context.Background(), "project-a", newClient)
+		Expect(second.id).NotTo(Equal(first.id))
+
+		cache.MaxAge = time.Hour
+		cache.Invalidate("project-a")
+		third, _ := cache.Get(context.Background(), "project-a", newClient)
+		Expect(third.id).NotTo(Equal(second.id))
+	})
+
+	It("Should close all clients when stopped", func() {
+		client, _ := cache.Get(context.Background(), "project-a", newClient)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- cache.Start(ctx) }()
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(client.closed.Load()).To(BeTrue())
+		Expect(cache.Len()).To(Equal(0))
+	})
+
+	It("Should serve other keys while a client is created", func() {
+		release := make(chan struct{})
+		done := make(chan *fakeClient)
+		go func() {
+			defer GinkgoRecover()
+			client, err := cache.Get(context.Background(), "project-a", func(ctx context.Context) (*fakeClient, error) {
+				<-release
+				return newClient(ctx)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			done <- client
+		}()
+		Eventually(func() int {
+			cache.mu.Lock()
+			defer cache.mu.Unlock()
+			return len(cache.dialing)
+		}).Should(Equal(1))
+
+		_, err := cache.Get(context.Background(), "project-b", newClient)
+		Expect(err).NotTo(HaveOccurred())
+		close(release)
+		Eventually(done).Should(Receive())
+		Expect(cache.Len()).To(Equal(2))
+	})
+
+	It("Should evict the clients older than MaxAge while running", func() {
+		cache.MaxAge = 10 * time.Millisecond
+		_, err := cache.Get(context.Background(), "old-credentials", newClient)
+		Expect(err).NotTo(HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = cache.Start(ctx) }()
+		Eventually(cache.Len).Should(Equal(0))
+	})
+
+	It("Should close the clients it can not cache once stopped", func() {
+		var client *fakeClient
+		_, err := cache.Get(context.Background(), "project-a", func(ctx context.Context) (*fakeClient, error) {
+			client, _ = newClient(ctx)
+			cache.Close()
+			return client, nil
+		})
+		Expect(err).To(MatchError(ErrClientCacheClosed))
+		Expect(client.closed.Load()).To(BeTrue())
+		Expect(cache.Len()).To(Equal(0))
+
+		_, err = cache.Get(context.Background(), "project-a", newClient)
+		Expect(err).To(MatchError(ErrClientCacheClosed))
+		Expect(created.Load()).To(Equal(int32(1)))
+	})
+})
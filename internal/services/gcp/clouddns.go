@@ -18,10 +18,25 @@ type CloudDnsService interface {
 	CreateZone(ctx context.Context, project string, zone *dns.ManagedZone) (*dns.ManagedZone, error)
 	// UpdateZone updates an existing ManagedZone object for the given project and zone
 	UpdateZone(ctx context.Context, project string, zone string, mz *dns.ManagedZone) (*dns.Operation, error)
+	// PatchZone changes the fields of an existing ManagedZone that are set in mz, or listed in its
+	// ForceSendFields, for the given project and zone
+	PatchZone(ctx context.Context, project string, zone string, mz *dns.ManagedZone) (*dns.Operation, error)
 	// DeleteZone deletes a ManagedZone object for the given project and zone
 	DeleteZone(ctx context.Context, project string, zone string) error
 	// GetRecord returns a RecordSet object for the given project, zone and record
 	GetRecord(ctx context.Context, project string, zone string, record string, type_ string) (*dns.ResourceRecordSet, error)
+	// CreateRecord creates a new RecordSet in the given project and zone
+	CreateRecord(ctx context.Context, project string, zone string, rrs *dns.ResourceRecordSet) (*dns.ResourceRecordSet, error)
+	// UpdateRecord replaces the records and ttl of an existing RecordSet in the given project and zone
+	UpdateRecord(ctx context.Context, project string, zone string, rrs *dns.ResourceRecordSet) (*dns.ResourceRecordSet, error)
+	// DeleteRecord deletes a RecordSet for the given project, zone and record
+	DeleteRecord(ctx context.Context, project string, zone string, record string, type_ string) error
+	// ListZones returns all ManagedZones of the given project
+	ListZones(ctx context.Context, project string) ([]*dns.ManagedZone, error)
+	// ListRecords returns all RecordSets of the given project and zone
+	ListRecords(ctx context.Context, project string, zone string) ([]*dns.ResourceRecordSet, error)
+	// ListDnsKeys returns the active and inactive DNSSEC keys of the given project and zone
+	ListDnsKeys(ctx context.Context, project string, zone string) ([]*dns.DnsKey, error)
 }
 
 type newCloudDnsService func(ctx context.Context, opts ...option.ClientOption) (*dns.Service, error)
@@ -29,14 +44,38 @@ type newCloudDnsService func(ctx context.Context, opts ...option.ClientOption) (
 type GcpCloudDnsService struct {
 	NewService    newCloudDnsService
 	ClientOptions []option.ClientOption
+
+	clients ClientCache[*dns.Service]
+}
+
+// service returns the shared client used for calls to project with the credentials in ctx
+func (g *GcpCloudDnsService) service(ctx context.Context, project string) (*dns.Service, error) {
+	return g.clients.Get(ctx, ClientKey(ctx, project), func(ctx context.Context) (*dns.Service, error) {
+		opts, err := ClientOptionsFor(ctx, g.ClientOptions)
+		if err != nil {
+			return nil, err
+		}
+		return g.NewService(ctx, opts...)
+	})
+}
+
+// Start closes the shared clients when ctx is done
+func (g *GcpCloudDnsService) Start(ctx context.Context) error {
+	return g.clients.Start(ctx)
+}
+
+func (g *GcpCloudDnsService) NeedLeaderElection() bool {
+	return g.clients.NeedLeaderElection()
 }
 
 func (g *GcpCloudDnsService) GetZone(ctx context.Context, project string, zone string) (*dns.ManagedZone, error) {
-	svc, err := g.NewService(ctx, g.ClientOptions...)
+	svc, err := g.service(ctx, project)
 	if err != nil {
 		return nil, err
 	}
-	mz, err := svc.ManagedZones.Get(project, "global", zone).Do()
+	mz, err := Call(ctx, ServiceCloudDns, project, "ManagedZones.Get", func(ctx context.Context) (*dns.ManagedZone, error) {
+		return svc.ManagedZones.Get(project, "global", zone).Context(ctx).Do()
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -44,11 +83,13 @@ func (g *GcpCloudDnsService) GetZone(ctx context.Context, project string, zone s
 }
 
 func (g *GcpCloudDnsService) GetOperation(ctx context.Context, project string, zone string, operation string) (*dns.Operation, error) {
-	svc, err := g.NewService(ctx, g.ClientOptions...)
+	svc, err := g.service(ctx, project)
 	if err != nil {
 		return nil, err
 	}
-	op, err := svc.ManagedZoneOperations.Get(project, "global", zone, operation).Do()
+	op, err := Call(ctx, ServiceCloudDns, project, "ManagedZoneOperations.Get", func(ctx context.Context) (*dns.Operation, error) {
+		return svc.ManagedZoneOperations.Get(project, "global", zone, operation).Context(ctx).Do()
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +97,13 @@ func (g *GcpCloudDnsService) GetOperation(ctx context.Context, project string, z
 }
 
 func (g *GcpCloudDnsService) CreateZone(ctx context.Context, project string, zone *dns.ManagedZone) (*dns.ManagedZone, error) {
-	svc, err := g.NewService(ctx, g.ClientOptions...)
+	svc, err := g.service(ctx, project)
 	if err != nil {
 		return nil, err
 	}
-	mz, err := svc.ManagedZones.Create(project, "global", zone).Do()
+	mz, err := Call(ctx, ServiceCloudDns, project, "ManagedZones.Create", func(ctx context.Context) (*dns.ManagedZone, error) {
+		return svc.ManagedZones.Create(project, "global", zone).Context(ctx).Do()
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -68,23 +111,37 @@ func (g *GcpCloudDnsService) CreateZone(ctx context.Context, project string, zon
 }
 
 func (g *GcpCloudDnsService) UpdateZone(ctx context.Context, project string, zoneName string, zone *dns.ManagedZone) (*dns.Operation, error) {
-	svc, err := g.NewService(ctx, g.ClientOptions...)
+	svc, err := g.service(ctx, project)
 	if err != nil {
 		return nil, err
 	}
-	op, err := svc.ManagedZones.Update(project, "global", zoneName, zone).Do()
+	op, err := Call(ctx, ServiceCloudDns, project, "ManagedZones.Update", func(ctx context.Context) (*dns.Operation, error) {
+		return svc.ManagedZones.Update(project, "global", zoneName, zone).Context(ctx).Do()
+	})
 	if err != nil {
 		return nil, err
 	}
 	return op, nil
 }
 
+func (g *GcpCloudDnsService) PatchZone(ctx context.Context, project string, zoneName string, zone *dns.ManagedZone) (*dns.Operation, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return Call(ctx, ServiceCloudDns, project, "ManagedZones.Patch", func(ctx context.Context) (*dns.Operation, error) {
+		return svc.ManagedZones.Patch(project, "global", zoneName, zone).Context(ctx).Do()
+	})
+}
+
 func (g *GcpCloudDnsService) DeleteZone(ctx context.Context, project string, zone string) error {
-	svc, err := g.NewService(ctx, g.ClientOptions...)
+	svc, err := g.service(ctx, project)
 	if err != nil {
 		return err
 	}
-	err = svc.ManagedZones.Delete(project, "global", zone).Do()
+	err = callErr(ctx, ServiceCloudDns, project, "ManagedZones.Delete", func(ctx context.Context) error {
+		return svc.ManagedZones.Delete(project, "global", zone).Context(ctx).Do()
+	})
 	if err != nil {
 		return err
 	}
@@ -92,16 +149,92 @@ func (g *GcpCloudDnsService) DeleteZone(ctx context.Context, project string, zon
 }
 
 func (g *GcpCloudDnsService) GetRecord(ctx context.Context, project string, zone string, record string, type_ string) (*dns.ResourceRecordSet, error) {
-	svc, err := g.NewService(ctx, g.ClientOptions...)
+	svc, err := g.service(ctx, project)
 	if err != nil {
 		return nil, err
 	}
-	rs, err := svc.ResourceRecordSets.Get(project, "global", zone, record, type_).Do()
+	rs, err := Call(ctx, ServiceCloudDns, project, "ResourceRecordSets.Get", func(ctx context.Context) (*dns.ResourceRecordSet, error) {
+		return svc.ResourceRecordSets.Get(project, "global", zone, record, type_).Context(ctx).Do()
+	})
 	if err != nil {
 		return nil, err
 	}
 	return rs, nil
+}
+
+func (g *GcpCloudDnsService) CreateRecord(ctx context.Context, project string, zone string, rrs *dns.ResourceRecordSet) (*dns.ResourceRecordSet, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return Call(ctx, ServiceCloudDns, project, "ResourceRecordSets.Create", func(ctx context.Context) (*dns.ResourceRecordSet, error) {
+		return svc.ResourceRecordSets.Create(project, "global", zone, rrs).Context(ctx).Do()
+	})
+}
 
+func (g *GcpCloudDnsService) UpdateRecord(ctx context.Context, project string, zone string, rrs *dns.ResourceRecordSet) (*dns.ResourceRecordSet, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return Call(ctx, ServiceCloudDns, project, "ResourceRecordSets.Patch", func(ctx context.Context) (*dns.ResourceRecordSet, error) {
+		return svc.ResourceRecordSets.Patch(project, "global", zone, rrs.Name, rrs.Type, rrs).Context(ctx).Do()
+	})
+}
+
+func (g *GcpCloudDnsService) DeleteRecord(ctx context.Context, project string, zone string, record string, type_ string) error {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return err
+	}
+	return callErr(ctx, ServiceCloudDns, project, "ResourceRecordSets.Delete", func(ctx context.Context) error {
+		return svc.ResourceRecordSets.Delete(project, "global", zone, record, type_).Context(ctx).Do()
+	})
+}
+
+func (g *GcpCloudDnsService) ListZones(ctx context.Context, project string) ([]*dns.ManagedZone, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return Call(ctx, ServiceCloudDns, project, "ManagedZones.List", func(ctx context.Context) ([]*dns.ManagedZone, error) {
+		var zones []*dns.ManagedZone
+		err := svc.ManagedZones.List(project, "global").Pages(ctx, func(page *dns.ManagedZonesListResponse) error {
+			zones = append(zones, page.ManagedZones...)
+			return nil
+		})
+		return zones, err
+	})
+}
+
+func (g *GcpCloudDnsService) ListRecords(ctx context.Context, project string, zone string) ([]*dns.ResourceRecordSet, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return Call(ctx, ServiceCloudDns, project, "ResourceRecordSets.List", func(ctx context.Context) ([]*dns.ResourceRecordSet, error) {
+		var records []*dns.ResourceRecordSet
+		err := svc.ResourceRecordSets.List(project, "global", zone).Pages(ctx, func(page *dns.ResourceRecordSetsListResponse) error {
+			records = append(records, page.Rrsets...)
+			return nil
+		})
+		return records, err
+	})
+}
+
+func (g *GcpCloudDnsService) ListDnsKeys(ctx context.Context, project string, zone string) ([]*dns.DnsKey, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return Call(ctx, ServiceCloudDns, project, "DnsKeys.List", func(ctx context.Context) ([]*dns.DnsKey, error) {
+		var keys []*dns.DnsKey
+		err := svc.DnsKeys.List(project, "global", zone).Pages(ctx, func(page *dns.DnsKeysListResponse) error {
+			keys = append(keys, page.DnsKeys...)
+			return nil
+		})
+		return keys, err
+	})
 }
 
 func ApiErrorFromErr(err error) *apierror.APIError {
@@ -0,0 +1,151 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+)
+
+const (
+	cloudPlatformScope   = "https://www.googleapis.com/auth/cloud-platform"
+	stsTokenUrl          = "https://sts.googleapis.com/v1/token"
+	jwtSubjectTokenType  = "urn:ietf:params:oauth:token-type:jwt"
+	impersonationUrlTmpl = "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken"
+)
+
+// AmbientCredentials are the credentials of the manager itself, found through Application Default Credentials
+var AmbientCredentials = Credentials{Key: "ambient"}
+
+// Credentials authenticate the calls made to the Google APIs on behalf of a resource
+type Credentials struct {
+	// Key identifies the credentials in the client caches, it changes when the credentials change
+	// so clients are rebuilt when they are rotated
+	Key string
+	// ClientOptions returns the options authenticating a new client, nil for the ambient credentials
+	ClientOptions func(ctx context.Context) ([]option.ClientOption, error)
+}
+
+// CredentialsConfig describes where the credentials come from, at most one of ServiceAccountKey
+// and WorkloadIdentityAudience is set
+type CredentialsConfig struct {
+	// ServiceAccountKey is a service account key in JSON format
+	ServiceAccountKey []byte `json:"serviceAccountKey,omitempty"`
+	// WorkloadIdentityAudience is the workload identity pool provider exchanging the token at WorkloadIdentityTokenPath
+	WorkloadIdentityAudience string `json:"workloadIdentityAudience,omitempty"`
+	// WorkloadIdentityServiceAccount is impersonated with the federated token when set
+	WorkloadIdentityServiceAccount string `json:"workloadIdentityServiceAccount,omitempty"`
+	// WorkloadIdentityTokenPath is the file holding the token to exchange
+	WorkloadIdentityTokenPath string `json:"workloadIdentityTokenPath,omitempty"`
+	// ImpersonateServiceAccount is impersonated with the credentials above when set
+	ImpersonateServiceAccount string `json:"impersonateServiceAccount,omitempty"`
+	// Delegates is the delegation chain used to impersonate ImpersonateServiceAccount
+	Delegates []string `json:"delegates,omitempty"`
+}
+
+type credentialsContextKey struct{}
+
+// WithCredentials returns a context making the calls of the services in this package with creds
+func WithCredentials(ctx context.Context, creds Credentials) context.Context {
+	return context.WithValue(ctx, credentialsContextKey{}, creds)
+}
+
+// CredentialsFromContext returns the credentials set by WithCredentials, or the ambient credentials
+func CredentialsFromContext(ctx context.Context) Credentials {
+	if creds, ok := ctx.Value(credentialsContextKey{}).(Credentials); ok {
+		return creds
+	}
+	return AmbientCredentials
+}
+
+// Credentials returns the credentials described by c. source names where the config was read
+// from and is part of the cache key together with a hash of the config.
+func (c CredentialsConfig) Credentials(source string) Credentials {
+	raw, _ := json.Marshal(c)
+	sum := sha256.Sum256(raw)
+	return Credentials{
+		Key:           fmt.Sprintf("%s@%s", source, hex.EncodeToString(sum[:8])),
+		ClientOptions: c.clientOptions,
+	}
+}
+
+func (c CredentialsConfig) clientOptions(ctx context.Context) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+	switch {
+	case len(c.ServiceAccountKey) > 0:
+		opts = append(opts, option.WithCredentialsJSON(c.ServiceAccountKey))
+	case c.WorkloadIdentityAudience != "":
+		externalAccount, err := c.externalAccountJSON()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, option.WithCredentialsJSON(externalAccount))
+	}
+	if c.ImpersonateServiceAccount == "" {
+		return opts, nil
+	}
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: c.ImpersonateServiceAccount,
+		Delegates:       c.Delegates,
+		Scopes:          []string{cloudPlatformScope},
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate %s: %w", c.ImpersonateServiceAccount, err)
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
+
+// externalAccountJSON returns the credential configuration file of the workload identity federation
+func (c CredentialsConfig) externalAccountJSON() ([]byte, error) {
+	config := map[string]any{
+		"type":               "external_account",
+		"audience":           c.WorkloadIdentityAudience,
+		"subject_token_type": jwtSubjectTokenType,
+		"token_url":          stsTokenUrl,
+		"credential_source": map[string]any{
+			"file": c.WorkloadIdentityTokenPath,
+		},
+	}
+	if c.WorkloadIdentityServiceAccount != "" {
+		config["service_account_impersonation_url"] = fmt.Sprintf(impersonationUrlTmpl, c.WorkloadIdentityServiceAccount)
+	}
+	return json.Marshal(config)
+}
+
+// ClientOptionsFor returns base followed by the options of the credentials in ctx
+func ClientOptionsFor(ctx context.Context, base []option.ClientOption) ([]option.ClientOption, error) {
+	creds := CredentialsFromContext(ctx)
+	if creds.ClientOptions == nil {
+		return base, nil
+	}
+	opts, err := creds.ClientOptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client options for %s: %w", creds.Key, err)
+	}
+	return append(append([]option.ClientOption{}, base...), opts...), nil
+}
+
+// ClientKey returns the client cache key of the calls to project with the credentials in ctx
+func ClientKey(ctx context.Context, project string) string {
+	return project + "/" + CredentialsFromContext(ctx).Key
+}
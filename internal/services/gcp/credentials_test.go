@@ -0,0 +1,74 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/option"
+)
+
+var _ = Describe("Credentials", func() {
+	It("Should use the ambient credentials without a provider config", func() {
+		ctx := context.Background()
+		Expect(CredentialsFromContext(ctx).Key).To(Equal("ambient"))
+		Expect(ClientKey(ctx, "test-project")).To(Equal("test-project/ambient"))
+		base := []option.ClientOption{option.WithEndpoint("localhost:8080")}
+		Expect(ClientOptionsFor(ctx, base)).To(HaveLen(1))
+	})
+
+	It("Should change the client key when the credentials change", func() {
+		config := CredentialsConfig{ServiceAccountKey: []byte(`{"type":"service_account"}`)}
+		key := config.Credentials("GcpProviderConfig/default/default").Key
+		Expect(key).To(HavePrefix("GcpProviderConfig/default/default@"))
+		Expect(config.Credentials("GcpProviderConfig/default/default").Key).To(Equal(key))
+
+		config.ServiceAccountKey = []byte(`{"type":"service_account","private_key_id":"rotated"}`)
+		Expect(config.Credentials("GcpProviderConfig/default/default").Key).NotTo(Equal(key))
+
+		ctx := WithCredentials(context.Background(), config.Credentials("GcpProviderConfig/default/default"))
+		Expect(ClientKey(ctx, "test-project")).To(HavePrefix("test-project/GcpProviderConfig/default/default@"))
+	})
+
+	It("Should add the credential options after the base options", func() {
+		config := CredentialsConfig{ServiceAccountKey: []byte(`{"type":"service_account"}`)}
+		ctx := WithCredentials(context.Background(), config.Credentials("test"))
+		opts, err := ClientOptionsFor(ctx, []option.ClientOption{option.WithEndpoint("localhost:8080")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(HaveLen(2))
+	})
+
+	It("Should build the workload identity federation credential configuration", func() {
+		config := CredentialsConfig{
+			WorkloadIdentityAudience:       "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider",
+			WorkloadIdentityServiceAccount: "stilas@test-project.iam.gserviceaccount.com",
+			WorkloadIdentityTokenPath:      "/var/run/secrets/stilas/serviceaccount/token",
+		}
+		raw, err := config.externalAccountJSON()
+		Expect(err).NotTo(HaveOccurred())
+		var externalAccount map[string]any
+		Expect(json.Unmarshal(raw, &externalAccount)).To(Succeed())
+		Expect(externalAccount).To(HaveKeyWithValue("type", "external_account"))
+		Expect(externalAccount).To(HaveKeyWithValue("audience", config.WorkloadIdentityAudience))
+		Expect(externalAccount).To(HaveKeyWithValue("credential_source", map[string]any{"file": config.WorkloadIdentityTokenPath}))
+		Expect(externalAccount).To(HaveKeyWithValue("service_account_impersonation_url",
+			"https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/stilas@test-project.iam.gserviceaccount.com:generateAccessToken"))
+	})
+})
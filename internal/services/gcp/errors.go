@@ -0,0 +1,193 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ErrorClass tells how a reconciler should react to an error returned by a Google API
+type ErrorClass int
+
+const (
+	// ErrorClassUnknown is an error that did not come from a Google API, like a network or Kubernetes error
+	ErrorClassUnknown ErrorClass = iota
+	// ErrorClassTransient is an error that is likely to go away when retried later, like exceeded quota
+	ErrorClassTransient
+	// ErrorClassPermanent is an error that needs a change to the resource or the project to go away
+	ErrorClassPermanent
+)
+
+// transientHTTPCodes are the HTTP status codes of REST errors that are retried. Cloud DNS answers 412
+// when a change conflicts with another change in progress.
+var transientHTTPCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusPreconditionFailed,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// transientGRPCCodes are the codes of gRPC errors that are retried
+var transientGRPCCodes = []codes.Code{
+	codes.ResourceExhausted,
+	codes.Unavailable,
+	codes.Aborted,
+	codes.DeadlineExceeded,
+	codes.Internal,
+}
+
+// ClassifyError returns the ErrorClass of an error returned by a Google API client
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassUnknown
+	}
+	var gErr *googleapi.Error
+	if errors.As(err, &gErr) {
+		return classifyHTTPCode(gErr.Code)
+	}
+	var code codes.Code
+	var aErr *apierror.APIError
+	if errors.As(err, &aErr) {
+		if aErr.HTTPCode() > 0 {
+			return classifyHTTPCode(aErr.HTTPCode())
+		}
+		code = aErr.GRPCStatus().Code()
+	} else if s, ok := status.FromError(err); ok {
+		code = s.Code()
+	} else {
+		return ErrorClassUnknown
+	}
+	for _, transient := range transientGRPCCodes {
+		if code == transient {
+			return ErrorClassTransient
+		}
+	}
+	return ErrorClassPermanent
+}
+
+func classifyHTTPCode(code int) ErrorClass {
+	for _, transient := range transientHTTPCodes {
+		if code == transient {
+			return ErrorClassTransient
+		}
+	}
+	return ErrorClassPermanent
+}
+
+// ErrorReason returns the reason of an error returned by a Google API client, the reason of the
+// first error item of a REST error or the ErrorInfo reason of a gRPC error, "" if it has none
+func ErrorReason(err error) string {
+	var gErr *googleapi.Error
+	if errors.As(err, &gErr) {
+		for _, item := range gErr.Errors {
+			if item.Reason != "" {
+				return item.Reason
+			}
+		}
+		return ""
+	}
+	var aErr *apierror.APIError
+	if !errors.As(err, &aErr) {
+		aErr, _ = apierror.FromError(err)
+	}
+	if aErr != nil {
+		return aErr.Reason()
+	}
+	return ""
+}
+
+// RetryDelay returns the delay requested by the server in the RetryInfo details of a gRPC error
+// or the Retry-After header of a REST error
+func RetryDelay(err error) (time.Duration, bool) {
+	var gErr *googleapi.Error
+	if errors.As(err, &gErr) && gErr.Header != nil {
+		if seconds, convErr := strconv.Atoi(gErr.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	var aErr *apierror.APIError
+	if !errors.As(err, &aErr) {
+		aErr, _ = apierror.FromError(err)
+	}
+	if aErr != nil {
+		if info := aErr.Details().RetryInfo; info != nil && info.GetRetryDelay() != nil {
+			return info.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// Backoff tracks the consecutive transient failures per key and hands out jittered exponentially
+// growing delays. The zero value is ready to use.
+type Backoff struct {
+	// Base is the delay after the first failure, defaults to 5 seconds
+	Base time.Duration
+	// Max caps the delay, defaults to 10 minutes
+	Max time.Duration
+
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+// Next records a failure of key with err and returns how long to wait before retrying. A delay
+// requested by the server is used when it is longer than the computed one.
+func (b *Backoff) Next(key string, err error) time.Duration {
+	b.mu.Lock()
+	if b.failures == nil {
+		b.failures = map[string]int{}
+	}
+	failures := b.failures[key]
+	b.failures[key] = failures + 1
+	b.mu.Unlock()
+
+	base, maxDelay := b.Base, b.Max
+	if base <= 0 {
+		base = 5 * time.Second
+	}
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Minute
+	}
+	delay := time.Duration(float64(base) * math.Pow(2, float64(failures)))
+	if delay > maxDelay || delay <= 0 {
+		delay = maxDelay
+	}
+	delay = wait.Jitter(delay, 0.2)
+	if retryDelay, ok := RetryDelay(err); ok && retryDelay > delay {
+		delay = retryDelay
+	}
+	return delay
+}
+
+// Reset forgets the failures of key after a successful reconcile
+func (b *Backoff) Reset(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, key)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Errors", func() {
+	quotaError := func(retryDelay time.Duration) error {
+		s, err := status.New(codes.ResourceExhausted, "quota exceeded").WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(retryDelay),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		aErr, ok := apierror.FromError(s.Err())
+		Expect(ok).To(BeTrue())
+		return fmt.Errorf("CreateService: failed to create cloud run service: %w", aErr)
+	}
+
+	DescribeTable("ClassifyError",
+		func(err error, class ErrorClass) {
+			Expect(ClassifyError(err)).To(Equal(class))
+		},
+		Entry("nil", nil, ErrorClassUnknown),
+		Entry("quota exceeded", quotaError(time.Second), ErrorClassTransient),
+		Entry("REST rate limited", &googleapi.Error{Code: http.StatusTooManyRequests}, ErrorClassTransient),
+		Entry("REST unavailable", &googleapi.Error{Code: http.StatusServiceUnavailable}, ErrorClassTransient),
+		Entry("DNS precondition failed", fmt.Errorf("wrapped: %w", &googleapi.Error{Code: http.StatusPreconditionFailed}), ErrorClassTransient),
+		Entry("gRPC unavailable", status.Error(codes.Unavailable, "down"), ErrorClassTransient),
+		Entry("gRPC aborted", status.Error(codes.Aborted, "conflict"), ErrorClassTransient),
+		Entry("REST forbidden", &googleapi.Error{Code: http.StatusForbidden}, ErrorClassPermanent),
+		Entry("gRPC invalid argument", status.Error(codes.InvalidArgument, "bad image"), ErrorClassPermanent),
+		Entry("other error", context.DeadlineExceeded, ErrorClassUnknown),
+	)
+
+	It("Should read the reason of REST and gRPC errors", func() {
+		Expect(ErrorReason(&googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "forbidden"}}})).To(Equal("forbidden"))
+		Expect(ErrorReason(&googleapi.Error{Code: http.StatusForbidden})).To(BeEmpty())
+
+		s, err := status.New(codes.PermissionDenied, "denied").WithDetails(&errdetails.ErrorInfo{Reason: "IAM_PERMISSION_DENIED"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ErrorReason(fmt.Errorf("wrapped: %w", s.Err()))).To(Equal("IAM_PERMISSION_DENIED"))
+		Expect(ErrorReason(status.Error(codes.InvalidArgument, "bad image"))).To(BeEmpty())
+	})
+
+	It("Should read the retry delay from RetryInfo and Retry-After", func() {
+		delay, ok := RetryDelay(quotaError(42 * time.Second))
+		Expect(ok).To(BeTrue())
+		Expect(delay).To(Equal(42 * time.Second))
+
+		delay, ok = RetryDelay(&googleapi.Error{Code: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"7"}}})
+		Expect(ok).To(BeTrue())
+		Expect(delay).To(Equal(7 * time.Second))
+
+		_, ok = RetryDelay(&googleapi.Error{Code: http.StatusServiceUnavailable})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Should back off exponentially with jitter until reset", func() {
+		backoff := &Backoff{Base: time.Second, Max: 8 * time.Second}
+		err := &googleapi.Error{Code: http.StatusServiceUnavailable}
+		for _, expected := range []time.Duration{1, 2, 4, 8, 8} {
+			Expect(backoff.Next("default/test", err)).To(BeNumerically("~", expected*time.Second*11/10, expected*time.Second/10))
+		}
+		Expect(backoff.Next("default/other", err)).To(BeNumerically("<", 2*time.Second))
+		backoff.Reset("default/test")
+		Expect(backoff.Next("default/test", err)).To(BeNumerically("<", 2*time.Second))
+		Expect(backoff.Next("default/test", quotaError(time.Minute))).To(Equal(time.Minute))
+	})
+})
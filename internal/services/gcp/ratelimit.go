@@ -0,0 +1,80 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultRateLimitQPS is the default sustained rate of calls per project and API
+	DefaultRateLimitQPS = 10
+	// DefaultRateLimitBurst is the default number of calls per project and API allowed at once
+	DefaultRateLimitBurst = 20
+)
+
+// DefaultRateLimiter limits all calls made through Call
+var DefaultRateLimiter = NewRateLimiter(DefaultRateLimitQPS, DefaultRateLimitBurst)
+
+// RateLimiter holds a token bucket per project and API, so a burst of reconciles for one project
+// stays below its quota without slowing down the other projects
+type RateLimiter struct {
+	qps   rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[rateLimitKey]*rate.Limiter
+}
+
+type rateLimitKey struct {
+	service string
+	project string
+}
+
+// NewRateLimiter returns a RateLimiter allowing qps calls per second with bursts of burst calls
+// per project and API. A qps of 0 or less disables rate limiting.
+func NewRateLimiter(qps float64, burst int) *RateLimiter {
+	limit := rate.Limit(qps)
+	if qps <= 0 {
+		limit = rate.Inf
+	}
+	return &RateLimiter{
+		qps:      limit,
+		burst:    burst,
+		limiters: map[rateLimitKey]*rate.Limiter{},
+	}
+}
+
+// Wait blocks until a call to service for project is allowed or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context, service string, project string) error {
+	return l.limiter(service, project).Wait(ctx)
+}
+
+func (l *RateLimiter) limiter(service string, project string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := rateLimitKey{service: service, project: project}
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(l.qps, l.burst)
+		l.limiters[key] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimiter", func() {
+	It("Should limit each project and API separately", func() {
+		limiter := NewRateLimiter(1, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		Expect(limiter.Wait(ctx, ServiceCloudRun, "project-a")).To(Succeed())
+		Expect(limiter.Wait(ctx, ServiceCloudRun, "project-b")).To(Succeed())
+		Expect(limiter.Wait(ctx, ServiceCloudDns, "project-a")).To(Succeed())
+		Expect(limiter.Wait(ctx, ServiceCloudRun, "project-a")).NotTo(Succeed())
+	})
+
+	It("Should not limit when disabled", func() {
+		limiter := NewRateLimiter(0, 0)
+		for i := 0; i < 100; i++ {
+			Expect(limiter.Wait(context.Background(), ServiceCloudRun, "project-a")).To(Succeed())
+		}
+	})
+})
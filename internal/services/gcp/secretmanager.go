@@ -0,0 +1,231 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/secretmanager/v1"
+)
+
+// SecretManagedByLabel marks the Secret Manager secrets created by stilas
+const SecretManagedByLabel = "managed-by"
+
+const secretAccessorRole = "roles/secretmanager.secretAccessor"
+
+// SecretManagerService is an interface for interacting with Google Secret Manager
+type SecretManagerService interface {
+	// GetSecret returns a Secret object for the given project and secret id
+	GetSecret(ctx context.Context, project string, secretId string) (*secretmanager.Secret, error)
+	// CreateSecret creates a new Secret with automatic replication for the given project
+	CreateSecret(ctx context.Context, project string, secretId string, labels map[string]string) (*secretmanager.Secret, error)
+	// DeleteSecret deletes a Secret and all its versions for the given project and secret id
+	DeleteSecret(ctx context.Context, project string, secretId string) error
+	// AddSecretVersion adds a new version holding data to the given project and secret id
+	AddSecretVersion(ctx context.Context, project string, secretId string, data []byte) (*secretmanager.SecretVersion, error)
+	// ListSecretVersions returns the enabled versions of the given project and secret id, newest first
+	ListSecretVersions(ctx context.Context, project string, secretId string) ([]*secretmanager.SecretVersion, error)
+	// DestroySecretVersion destroys the version with the given full resource name
+	DestroySecretVersion(ctx context.Context, name string) error
+	// GrantSecretAccessor grants member access to the versions of the given project and secret id
+	GrantSecretAccessor(ctx context.Context, project string, secretId string, member string) error
+}
+
+type newSecretManagerService func(ctx context.Context, opts ...option.ClientOption) (*secretmanager.Service, error)
+
+type GcpSecretManagerService struct {
+	NewService    newSecretManagerService
+	ClientOptions []option.ClientOption
+
+	clients ClientCache[*secretmanager.Service]
+}
+
+// service returns the shared client used for calls to project with the credentials in ctx
+func (g *GcpSecretManagerService) service(ctx context.Context, project string) (*secretmanager.Service, error) {
+	return g.clients.Get(ctx, ClientKey(ctx, project), func(ctx context.Context) (*secretmanager.Service, error) {
+		opts, err := ClientOptionsFor(ctx, g.ClientOptions)
+		if err != nil {
+			return nil, err
+		}
+		return g.NewService(ctx, opts...)
+	})
+}
+
+// Start closes the shared clients when ctx is done
+func (g *GcpSecretManagerService) Start(ctx context.Context) error {
+	return g.clients.Start(ctx)
+}
+
+func (g *GcpSecretManagerService) NeedLeaderElection() bool {
+	return g.clients.NeedLeaderElection()
+}
+
+func (g *GcpSecretManagerService) GetSecret(ctx context.Context, project string, secretId string) (*secretmanager.Secret, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := Call(ctx, ServiceSecretManager, project, "Secrets.Get", func(ctx context.Context) (*secretmanager.Secret, error) {
+		return svc.Projects.Secrets.Get(secretName(project, secretId)).Context(ctx).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (g *GcpSecretManagerService) CreateSecret(ctx context.Context, project string, secretId string, labels map[string]string) (*secretmanager.Secret, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := Call(ctx, ServiceSecretManager, project, "Secrets.Create", func(ctx context.Context) (*secretmanager.Secret, error) {
+		return svc.Projects.Secrets.Create(fmt.Sprintf("projects/%s", project), &secretmanager.Secret{
+			Labels: labels,
+			Replication: &secretmanager.Replication{
+				Automatic: &secretmanager.Automatic{},
+			},
+		}).SecretId(secretId).Context(ctx).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (g *GcpSecretManagerService) DeleteSecret(ctx context.Context, project string, secretId string) error {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return err
+	}
+	_, err = Call(ctx, ServiceSecretManager, project, "Secrets.Delete", func(ctx context.Context) (*secretmanager.Empty, error) {
+		return svc.Projects.Secrets.Delete(secretName(project, secretId)).Context(ctx).Do()
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (g *GcpSecretManagerService) AddSecretVersion(ctx context.Context, project string, secretId string, data []byte) (*secretmanager.SecretVersion, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	version, err := Call(ctx, ServiceSecretManager, project, "Secrets.AddVersion", func(ctx context.Context) (*secretmanager.SecretVersion, error) {
+		return svc.Projects.Secrets.AddVersion(secretName(project, secretId), &secretmanager.AddSecretVersionRequest{
+			Payload: &secretmanager.SecretPayload{
+				Data: base64.StdEncoding.EncodeToString(data),
+			},
+		}).Context(ctx).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+func (g *GcpSecretManagerService) ListSecretVersions(ctx context.Context, project string, secretId string) ([]*secretmanager.SecretVersion, error) {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	var versions []*secretmanager.SecretVersion
+	call := svc.Projects.Secrets.Versions.List(secretName(project, secretId)).Filter("state:ENABLED")
+	for {
+		page, err := Call(ctx, ServiceSecretManager, project, "Secrets.Versions.List", func(ctx context.Context) (*secretmanager.ListSecretVersionsResponse, error) {
+			return call.Context(ctx).Do()
+		})
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, page.Versions...)
+		if page.NextPageToken == "" {
+			return versions, nil
+		}
+		call.PageToken(page.NextPageToken)
+	}
+}
+
+func (g *GcpSecretManagerService) DestroySecretVersion(ctx context.Context, name string) error {
+	project := ProjectOf(name)
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return err
+	}
+	_, err = Call(ctx, ServiceSecretManager, project, "Secrets.Versions.Destroy", func(ctx context.Context) (*secretmanager.SecretVersion, error) {
+		return svc.Projects.Secrets.Versions.Destroy(name, &secretmanager.DestroySecretVersionRequest{}).Context(ctx).Do()
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (g *GcpSecretManagerService) GrantSecretAccessor(ctx context.Context, project string, secretId string, member string) error {
+	svc, err := g.service(ctx, project)
+	if err != nil {
+		return err
+	}
+	name := secretName(project, secretId)
+	policy, err := Call(ctx, ServiceSecretManager, project, "Secrets.GetIamPolicy", func(ctx context.Context) (*secretmanager.Policy, error) {
+		return svc.Projects.Secrets.GetIamPolicy(name).Context(ctx).Do()
+	})
+	if err != nil {
+		return err
+	}
+	for _, binding := range policy.Bindings {
+		if binding.Role != secretAccessorRole {
+			continue
+		}
+		for _, m := range binding.Members {
+			if m == member {
+				return nil
+			}
+		}
+		binding.Members = append(binding.Members, member)
+		_, err = Call(ctx, ServiceSecretManager, project, "Secrets.SetIamPolicy", func(ctx context.Context) (*secretmanager.Policy, error) {
+			return svc.Projects.Secrets.SetIamPolicy(name, &secretmanager.SetIamPolicyRequest{Policy: policy}).Context(ctx).Do()
+		})
+		return err
+	}
+	policy.Bindings = append(policy.Bindings, &secretmanager.Binding{
+		Role:    secretAccessorRole,
+		Members: []string{member},
+	})
+	_, err = Call(ctx, ServiceSecretManager, project, "Secrets.SetIamPolicy", func(ctx context.Context) (*secretmanager.Policy, error) {
+		return svc.Projects.Secrets.SetIamPolicy(name, &secretmanager.SetIamPolicyRequest{Policy: policy}).Context(ctx).Do()
+	})
+	return err
+}
+
+// ProjectOf returns the project of a full resource name of the form projects/<project>/...
+func ProjectOf(name string) string {
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+func secretName(project string, secretId string) string {
+	return fmt.Sprintf("projects/%s/secrets/%s", project, secretId)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stilasctl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+func newConvertCommand(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert manifests of other tools to stilas manifests",
+	}
+	cmd.AddCommand(newConvertKnativeCommand(flags))
+	return cmd
+}
+
+func newConvertKnativeCommand(flags *globalFlags) *cobra.Command {
+	var file, project, location string
+	cmd := &cobra.Command{
+		Use:   "knative",
+		Short: "Convert Knative Services to CloudRuns",
+		Long: "Convert serving.knative.dev/v1 Services to CloudRuns. The containers, env, resources, traffic and " +
+			"autoscaling annotations are converted, the fields a CloudRun can not express are printed as warnings. " +
+			"The CloudRuns are in the namespace of the Services unless --namespace is given.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			in := cmd.InOrStdin()
+			if file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return fmt.Errorf("failed to open %s: %w", file, err)
+				}
+				defer f.Close()
+				in = f
+			}
+			runs, err := convertKnativeServices(in, cmd.ErrOrStderr(), flags.namespace, project, location)
+			if err != nil {
+				return err
+			}
+			return writeManifests(cmd.OutOrStdout(), &importFlags{}, runs)
+		},
+	}
+	cmd.Flags().StringVarP(&file, "filename", "f", "-", "The file with the Knative Services, - for stdin.")
+	cmd.Flags().StringVar(&project, "project", "", "The GCP project of the CloudRuns.")
+	cmd.Flags().StringVar(&location, "location", "", "The location of the CloudRuns.")
+	_ = cmd.MarkFlagRequired("project")
+	_ = cmd.MarkFlagRequired("location")
+	return cmd
+}
+
+// convertKnativeServices converts the Knative Services in the YAML documents of in to CloudRuns,
+// the documents of other kinds are skipped with a warning
+func convertKnativeServices(in io.Reader, warnings io.Writer, namespace string, project string, location string) ([]client.Object, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+	var runs []client.Object
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return runs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		var typeMeta metav1.TypeMeta
+		if err := yaml.Unmarshal(doc, &typeMeta); err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		if typeMeta.APIVersion == "" && typeMeta.Kind == "" {
+			continue
+		}
+		if typeMeta.APIVersion != gcpv2.KnativeServingAPIVersion || typeMeta.Kind != gcpv2.KnativeServiceKind {
+			fmt.Fprintf(warnings, "Warning: skipping %s %s, only %s %s is converted\n", typeMeta.APIVersion, typeMeta.Kind, gcpv2.KnativeServingAPIVersion, gcpv2.KnativeServiceKind)
+			continue
+		}
+		var ksvc gcpv2.KnativeService
+		if err := yaml.Unmarshal(doc, &ksvc); err != nil {
+			return nil, fmt.Errorf("failed to read Knative Service: %w", err)
+		}
+		run := &gcpv2.CloudRun{ObjectMeta: metav1.ObjectMeta{
+			Name:        ksvc.Metadata.Name,
+			Namespace:   ksvc.Metadata.Namespace,
+			Labels:      ksvc.Metadata.Labels,
+			Annotations: map[string]string{},
+		}}
+		if namespace != "" {
+			run.Namespace = namespace
+		}
+		var converted []string
+		run.Spec, converted = gcpv2.ConvertFromKnativeService(&ksvc, project, location)
+		if unknown := unknownFields(doc); unknown != nil {
+			converted = append(converted, fmt.Sprintf("fields not converted: %v", unknown))
+		}
+		printWarnings(warnings, "CloudRun/"+run.Name, converted)
+		runs = append(runs, run)
+	}
+}
+
+// unknownFields returns the error of reading the fields of doc that are not read into a
+// KnativeService, nil when all are read. The status is ignored.
+func unknownFields(doc []byte) error {
+	var content map[string]any
+	if err := yaml.Unmarshal(doc, &content); err != nil {
+		return err
+	}
+	delete(content, "status")
+	withoutStatus, err := yaml.Marshal(content)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(withoutStatus, &gcpv2.KnativeService{})
+}
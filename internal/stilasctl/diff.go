@@ -0,0 +1,97 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stilasctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	controllergcp "github.com/tjololo/stilas/internal/controller/gcp"
+	"github.com/tjololo/stilas/internal/plan"
+)
+
+func newDiffCommand(clients clientsFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff NAME",
+		Short: "Show the fields of the live Cloud Run service that differ from a CloudRun",
+		Long: "Show the fields of the live Cloud Run service that differ from a CloudRun. Only the fields " +
+			"managed by stilas are compared, so the diff is what the next reconcile changes. " +
+			"GCP is called with the application default credentials.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := clients(cmd)
+			if err != nil {
+				return err
+			}
+			run, config, err := getCloudRun(cmd.Context(), c.Kube, namespace, args[0])
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			name := run.GetGcpCloudRunServiceFullName()
+			live, err := c.GetService(cmd.Context(), name)
+			if status.Code(err) == codes.NotFound {
+				fmt.Fprintf(out, "Service %s does not exist, it is created by the next reconcile\n", name)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			desired, _, err := controllergcp.ServiceChanges(live, *run, config)
+			if err != nil {
+				return err
+			}
+			changes, err := plan.ProtoChanges(live, desired)
+			if err != nil {
+				return fmt.Errorf("failed to compare service %s: %w", name, err)
+			}
+			printDiff(out, name, changes)
+			return nil
+		},
+	}
+}
+
+// printDiff writes the changes with the live value prefixed by - and the desired value by +
+func printDiff(w io.Writer, name string, changes []plan.FieldChange) {
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "Service %s matches the CloudRun\n", name)
+		return
+	}
+	fmt.Fprintf(w, "--- live %s\n+++ desired\n", name)
+	for _, change := range changes {
+		fmt.Fprintln(w, change.Path)
+		if change.Current != nil {
+			fmt.Fprintf(w, "- %s\n", jsonValue(change.Current))
+		}
+		if change.Desired != nil {
+			fmt.Fprintf(w, "+ %s\n", jsonValue(change.Desired))
+		}
+	}
+}
+
+func jsonValue(value any) string {
+	out, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(out)
+}
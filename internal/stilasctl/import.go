@@ -0,0 +1,257 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stilasctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"cloud.google.com/go/run/apiv2/runpb"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+// invokerRole is the role the invoke members of a CloudRun are granted
+const invokerRole = "roles/run.invoker"
+
+type importFlags struct {
+	project   string
+	outputDir string
+	planOnly  bool
+}
+
+func newImportCommand(clients clientsFunc) *cobra.Command {
+	flags := &importFlags{}
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Write stilas manifests for existing GCP resources",
+		Long: "Write stilas manifests for existing GCP resources. The manifests carry the external name and " +
+			"adopt annotations, so applying them takes over the resources instead of creating new ones. " +
+			"Settings stilas can not express are printed as warnings, the first reconcile removes them. " +
+			"GCP is called with the application default credentials.",
+	}
+	cmd.PersistentFlags().StringVar(&flags.project, "project", "", "The GCP project to import from.")
+	cmd.PersistentFlags().StringVar(&flags.outputDir, "output-dir", "", "Write one file per resource to the directory instead of printing them.")
+	cmd.PersistentFlags().BoolVar(&flags.planOnly, "plan-only", false, "Annotate the manifests to only plan changes until the annotation is removed.")
+	_ = cmd.MarkPersistentFlagRequired("project")
+	cmd.AddCommand(newImportCloudRunCommand(flags, clients), newImportDnsCommand(flags, clients))
+	return cmd
+}
+
+func newImportCloudRunCommand(flags *importFlags, clients clientsFunc) *cobra.Command {
+	var location string
+	cmd := &cobra.Command{
+		Use:   "cloudrun",
+		Short: "Write CloudRun manifests for the Cloud Run services of a project and location",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, namespace, err := clients(cmd)
+			if err != nil {
+				return err
+			}
+			services, err := c.ListServices(cmd.Context(), fmt.Sprintf("projects/%s/locations/%s", flags.project, location))
+			if err != nil {
+				return err
+			}
+			var objects []client.Object
+			for _, srv := range services {
+				run, warnings, err := importService(cmd.Context(), c, namespace, srv)
+				if err != nil {
+					return err
+				}
+				printWarnings(cmd.ErrOrStderr(), "CloudRun/"+run.Name, warnings)
+				objects = append(objects, run)
+			}
+			return writeManifests(cmd.OutOrStdout(), flags, objects)
+		},
+	}
+	cmd.Flags().StringVar(&location, "location", "", "The location of the services.")
+	_ = cmd.MarkFlagRequired("location")
+	return cmd
+}
+
+func newImportDnsCommand(flags *importFlags, clients clientsFunc) *cobra.Command {
+	var zoneName string
+	cmd := &cobra.Command{
+		Use:   "dns",
+		Short: "Write CloudDnsZone and CloudDnsRecord manifests for the managed zones of a project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, namespace, err := clients(cmd)
+			if err != nil {
+				return err
+			}
+			zones, err := c.Dns.ListZones(cmd.Context(), flags.project)
+			if err != nil {
+				return err
+			}
+			var objects []client.Object
+			for _, zone := range zones {
+				if zoneName != "" && zone.Name != zoneName {
+					continue
+				}
+				dnsZone := &gcpv1.CloudDnsZone{ObjectMeta: importedMeta(zone.Name, namespace, zone.Name)}
+				var warnings []string
+				dnsZone.Spec, warnings = gcpv1.ConvertFromManagedZone(flags.project, zone)
+				printWarnings(cmd.ErrOrStderr(), "CloudDnsZone/"+dnsZone.Name, warnings)
+				objects = append(objects, dnsZone)
+
+				records, err := c.Dns.ListRecords(cmd.Context(), flags.project, zone.Name)
+				if err != nil {
+					return err
+				}
+				for _, rrs := range records {
+					if gcpv1.IsZoneManagedRecordSet(dnsZone, rrs) {
+						continue
+					}
+					dnsRecord := &gcpv1.CloudDnsRecord{}
+					dnsRecord.Spec, warnings = gcpv1.ConvertFromResourceRecordSet(dnsZone, rrs)
+					name := resourceName(strings.Join([]string{dnsZone.Name, strings.ReplaceAll(dnsRecord.Spec.Name, "@", "apex"), strings.ToLower(rrs.Type)}, "-"))
+					dnsRecord.ObjectMeta = importedMeta(name, namespace, "")
+					printWarnings(cmd.ErrOrStderr(), "CloudDnsRecord/"+name, warnings)
+					objects = append(objects, dnsRecord)
+				}
+			}
+			if zoneName != "" && len(objects) == 0 {
+				return fmt.Errorf("managed zone %s not found in project %s", zoneName, flags.project)
+			}
+			return writeManifests(cmd.OutOrStdout(), flags, objects)
+		},
+	}
+	cmd.Flags().StringVar(&zoneName, "zone", "", "Only import the managed zone with this name.")
+	return cmd
+}
+
+// importService returns a CloudRun adopting srv with the invokers of its IAM policy as invoke members
+func importService(ctx context.Context, c *Clients, namespace string, srv *runpb.Service) (*gcpv2.CloudRun, []string, error) {
+	id := gcpv2.ServiceIdOf(srv.Name)
+	run := &gcpv2.CloudRun{ObjectMeta: importedMeta(resourceName(id), namespace, id)}
+	var warnings []string
+	run.Spec, warnings = gcpv2.ConvertFromService(srv)
+	policy, err := c.GetIamPolicy(ctx, srv.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, binding := range policy.GetBindings() {
+		if binding.Role != invokerRole || binding.Condition != nil {
+			warnings = append(warnings, fmt.Sprintf("IAM binding of %s to %s is removed, only %s is managed", binding.Role, strings.Join(binding.Members, ", "), invokerRole))
+			continue
+		}
+		run.Spec.Security.InvokeMembers = append(run.Spec.Security.InvokeMembers, binding.Members...)
+	}
+	return run, warnings, nil
+}
+
+// importedMeta returns the metadata of a manifest adopting the GCP resource externalName, no
+// external name annotation is set when it is empty
+func importedMeta(name string, namespace string, externalName string) metav1.ObjectMeta {
+	annotations := map[string]string{gcpv1.AdoptAnnotation: "true"}
+	if externalName != "" {
+		annotations[gcpv1.ExternalNameAnnotation] = externalName
+	}
+	return metav1.ObjectMeta{Name: name, Namespace: namespace, Annotations: annotations}
+}
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// resourceName turns name into a valid Kubernetes resource name
+func resourceName(name string) string {
+	name = invalidNameCharacters.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.Trim(name, "-")
+}
+
+func printWarnings(w io.Writer, resource string, warnings []string) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "Warning: %s: %s\n", resource, warning)
+	}
+}
+
+// writeManifests writes the objects as YAML documents to w, or to a file per object in the output
+// directory of flags
+func writeManifests(w io.Writer, flags *importFlags, objects []client.Object) error {
+	s, err := scheme()
+	if err != nil {
+		return err
+	}
+	if flags.outputDir != "" {
+		if err := os.MkdirAll(flags.outputDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	for i, obj := range objects {
+		if flags.planOnly {
+			obj.GetAnnotations()[gcpv1.PlanOnlyAnnotation] = "true"
+		}
+		out, err := manifest(s, obj)
+		if err != nil {
+			return err
+		}
+		if flags.outputDir != "" {
+			kind := obj.GetObjectKind().GroupVersionKind().Kind
+			path := filepath.Join(flags.outputDir, fmt.Sprintf("%s-%s.yaml", strings.ToLower(kind), obj.GetName()))
+			if err := os.WriteFile(path, out, 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+		}
+		if _, err := w.Write(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifest returns obj as YAML with its apiVersion and kind, without the empty status
+func manifest(s *runtime.Scheme, obj client.Object) ([]byte, error) {
+	gvk, err := apiutil.GVKForObject(obj, s)
+	if err != nil {
+		return nil, err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	delete(content, "status")
+	delete(content["metadata"].(map[string]any), "creationTimestamp")
+	if run, ok := obj.(*gcpv2.CloudRun); ok && len(run.Spec.Security.InvokeMembers) == 0 {
+		// written out as an empty list, a missing one defaults to allUsers
+		if err := unstructured.SetNestedSlice(content, []any{}, "spec", "security", "invokeMembers"); err != nil {
+			return nil, err
+		}
+	}
+	return yaml.Marshal(content)
+}
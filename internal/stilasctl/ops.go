@@ -0,0 +1,80 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stilasctl
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+func newOpsCommand(flags *globalFlags, clients clientsFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ops",
+		Short: "List the GCP operations tracked in the status of the CloudRuns and CloudDnsZones",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, namespace, err := clients(cmd)
+			if err != nil {
+				return err
+			}
+			var runs gcpv2.CloudRunList
+			if err := c.Kube.List(cmd.Context(), &runs, listOptions(namespace)...); err != nil {
+				return fmt.Errorf("failed to list CloudRuns: %w", err)
+			}
+			var zones gcpv1.CloudDnsZoneList
+			if err := c.Kube.List(cmd.Context(), &zones, listOptions(namespace)...); err != nil {
+				return fmt.Errorf("failed to list CloudDnsZones: %w", err)
+			}
+			return printOps(cmd.OutOrStdout(), time.Now(), runs.Items, zones.Items)
+		},
+	}
+	cmd.Flags().BoolVarP(&flags.allNamespaces, "all-namespaces", "A", false, "List the operations of all namespaces.")
+	return cmd
+}
+
+// printOps writes a table of the operations, the age is relative to now
+func printOps(w io.Writer, now time.Time, runs []gcpv2.CloudRun, zones []gcpv1.CloudDnsZone) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tKIND\tNAME\tTYPE\tDONE\tAGE\tOPERATION")
+	for _, run := range runs {
+		for _, op := range run.Status.Operations {
+			fmt.Fprintf(tw, "%s\tCloudRun\t%s\t%s\t%t\t%s\t%s\n", run.Namespace, run.Name, op.OperationType, op.Done, age(now, op.StartTime), op.Name)
+		}
+	}
+	for _, zone := range zones {
+		if zone.Status.Operation != "" {
+			// the zone status only tracks the running operation
+			fmt.Fprintf(tw, "%s\tCloudDnsZone\t%s\t%s\t%t\t%s\t%s\n", zone.Namespace, zone.Name, "-", false, age(now, zone.Status.OperationStartTime), zone.Status.Operation)
+		}
+	}
+	return tw.Flush()
+}
+
+func age(now time.Time, start *metav1.Time) string {
+	if start == nil {
+		return "-"
+	}
+	return now.Sub(start.Time).Round(time.Second).String()
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stilasctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	controllergcp "github.com/tjololo/stilas/internal/controller/gcp"
+)
+
+func newRenderCommand(clients clientsFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "render NAME",
+		Short: "Print the CreateServiceRequest stilas sends to Cloud Run for a CloudRun",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := clients(cmd)
+			if err != nil {
+				return err
+			}
+			run, config, err := getCloudRun(cmd.Context(), c.Kube, namespace, args[0])
+			if err != nil {
+				return err
+			}
+			out, err := protojson.Marshal(run.ConvertToCreateServiceRequest(config))
+			if err != nil {
+				return fmt.Errorf("failed to marshal CreateServiceRequest: %w", err)
+			}
+			// protojson varies its whitespace on purpose, the output is indented again to be stable
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, out, "", "  "); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), indented.String())
+			return nil
+		},
+	}
+}
+
+// getCloudRun reads a CloudRun and the ConfigMaps it references, like the reconciler does
+func getCloudRun(ctx context.Context, c client.Reader, namespace string, name string) (*gcpv2.CloudRun, gcpv2.ConfigMapData, error) {
+	var run gcpv2.CloudRun
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &run); err != nil {
+		return nil, nil, fmt.Errorf("failed to get CloudRun %s/%s: %w", namespace, name, err)
+	}
+	config, err := controllergcp.ResolveConfigMaps(ctx, c, run)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &run, config, nil
+}
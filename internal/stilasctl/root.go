@@ -0,0 +1,212 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package stilasctl holds the commands of stilasctl, the command line tool showing the stilas
+// resources of a cluster next to what they manage in GCP. Installed on the PATH as kubectl-stilas
+// it runs as the kubectl plugin "kubectl stilas".
+package stilasctl
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/iam/apiv1/iampb"
+	gcprun "cloud.google.com/go/run/apiv2"
+	"cloud.google.com/go/run/apiv2/runpb"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/dns/v2"
+	"google.golang.org/api/iterator"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+// PluginName is the name of the binary kubectl runs for "kubectl stilas"
+const PluginName = "kubectl-stilas"
+
+// Clients are what the commands read from
+type Clients struct {
+	// Kube reads the stilas resources and the ConfigMaps they reference
+	Kube client.Reader
+	// Namespace is the namespace of the current kubeconfig context, used when none is given
+	Namespace string
+	// GetService reads a Cloud Run service by its full name
+	GetService func(ctx context.Context, name string) (*runpb.Service, error)
+	// ListServices lists the Cloud Run services of a parent, projects/{project}/locations/{location}
+	ListServices func(ctx context.Context, parent string) ([]*runpb.Service, error)
+	// GetIamPolicy reads the IAM policy of a Cloud Run service by its full name
+	GetIamPolicy func(ctx context.Context, name string) (*iampb.Policy, error)
+	// Dns reads the managed zones and record sets of Cloud DNS
+	Dns gcp.CloudDnsService
+}
+
+// connectFunc returns the clients for the kubeconfig selected by the flags
+type connectFunc func(ctx context.Context, flags *globalFlags) (*Clients, error)
+
+type globalFlags struct {
+	kubeconfig    string
+	context       string
+	namespace     string
+	allNamespaces bool
+}
+
+// NewRootCommand returns the stilasctl command, binary is the name it was run as
+func NewRootCommand(binary string) *cobra.Command {
+	return newRootCommand(binary, connect)
+}
+
+func newRootCommand(binary string, connect connectFunc) *cobra.Command {
+	flags := &globalFlags{}
+	cmd := &cobra.Command{
+		Use:           "stilasctl",
+		Short:         "Inspect stilas resources and the GCP resources they manage",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	if binary == PluginName {
+		cmd.Annotations = map[string]string{cobra.CommandDisplayNameAnnotation: "kubectl stilas"}
+	}
+	cmd.PersistentFlags().StringVar(&flags.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use.")
+	cmd.PersistentFlags().StringVar(&flags.context, "context", "", "The kubeconfig context to use.")
+	cmd.PersistentFlags().StringVarP(&flags.namespace, "namespace", "n", "", "The namespace of the resources, the one of the context when not set.")
+
+	clients := func(cmd *cobra.Command) (*Clients, string, error) {
+		c, err := connect(cmd.Context(), flags)
+		if err != nil {
+			return nil, "", err
+		}
+		namespace := flags.namespace
+		if namespace == "" {
+			namespace = c.Namespace
+		}
+		if flags.allNamespaces {
+			namespace = ""
+		}
+		return c, namespace, nil
+	}
+	cmd.AddCommand(
+		newStatusCommand(flags, clients),
+		newOpsCommand(flags, clients),
+		newRenderCommand(clients),
+		newDiffCommand(clients),
+		newImportCommand(clients),
+		newConvertCommand(flags),
+	)
+	return cmd
+}
+
+// clientsFunc connects with the global flags and returns the namespace to read, empty for all
+type clientsFunc func(cmd *cobra.Command) (*Clients, string, error)
+
+func scheme() (*runtime.Scheme, error) {
+	s := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, gcpv1.AddToScheme, gcpv2.AddToScheme} {
+		if err := add(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// connect reads the kubeconfig like kubectl and uses the application default credentials for GCP
+func connect(_ context.Context, flags *globalFlags) (*Clients, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = flags.kubeconfig
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: flags.context})
+	restConfig, err := config.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	namespace, _, err := config.Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace of kubeconfig context: %w", err)
+	}
+	s, err := scheme()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: s})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return &Clients{
+		Kube:         c,
+		Namespace:    namespace,
+		GetService:   getService,
+		ListServices: listServices,
+		GetIamPolicy: getIamPolicy,
+		Dns:          &gcp.GcpCloudDnsService{NewService: dns.NewService},
+	}, nil
+}
+
+func getService(ctx context.Context, name string) (*runpb.Service, error) {
+	c, err := gcprun.NewServicesClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cloud run client: %w", err)
+	}
+	defer c.Close()
+	srv, err := c.GetService(ctx, &runpb.GetServiceRequest{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("GetService: failed to get cloud run service: %w", err)
+	}
+	return srv, nil
+}
+
+func listServices(ctx context.Context, parent string) ([]*runpb.Service, error) {
+	c, err := gcprun.NewServicesClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cloud run client: %w", err)
+	}
+	defer c.Close()
+	var services []*runpb.Service
+	it := c.ListServices(ctx, &runpb.ListServicesRequest{Parent: parent})
+	for {
+		srv, err := it.Next()
+		if err == iterator.Done {
+			return services, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ListServices: failed to list cloud run services: %w", err)
+		}
+		services = append(services, srv)
+	}
+}
+
+func getIamPolicy(ctx context.Context, name string) (*iampb.Policy, error) {
+	c, err := gcprun.NewServicesClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cloud run client: %w", err)
+	}
+	defer c.Close()
+	policy, err := c.GetIamPolicy(ctx, &iampb.GetIamPolicyRequest{Resource: name})
+	if err != nil {
+		return nil, fmt.Errorf("GetIamPolicy: failed to get iam policy of %s: %w", name, err)
+	}
+	return policy, nil
+}
+
+// listOptions returns the options listing the resources of namespace, all namespaces when empty
+func listOptions(namespace string) []client.ListOption {
+	if namespace == "" {
+		return nil
+	}
+	return []client.ListOption{client.InNamespace(namespace)}
+}
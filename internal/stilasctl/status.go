@@ -0,0 +1,194 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stilasctl
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+)
+
+// conditionTypePaused is the condition set by the reconcilers on paused resources
+const conditionTypePaused = "Paused"
+
+func newStatusCommand(flags *globalFlags, clients clientsFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the CloudRuns, CloudDnsZones and CloudDnsRecords with their conditions and URIs as a tree",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, namespace, err := clients(cmd)
+			if err != nil {
+				return err
+			}
+			var runs gcpv2.CloudRunList
+			if err := c.Kube.List(cmd.Context(), &runs, listOptions(namespace)...); err != nil {
+				return fmt.Errorf("failed to list CloudRuns: %w", err)
+			}
+			var zones gcpv1.CloudDnsZoneList
+			if err := c.Kube.List(cmd.Context(), &zones, listOptions(namespace)...); err != nil {
+				return fmt.Errorf("failed to list CloudDnsZones: %w", err)
+			}
+			var records gcpv1.CloudDnsRecordList
+			if err := c.Kube.List(cmd.Context(), &records, listOptions(namespace)...); err != nil {
+				return fmt.Errorf("failed to list CloudDnsRecords: %w", err)
+			}
+			printStatus(cmd.OutOrStdout(), runs.Items, zones.Items, records.Items)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&flags.allNamespaces, "all-namespaces", "A", false, "Show the resources of all namespaces.")
+	return cmd
+}
+
+// printStatus writes the resources as a tree with a root per namespace
+func printStatus(w io.Writer, runs []gcpv2.CloudRun, zones []gcpv1.CloudDnsZone, records []gcpv1.CloudDnsRecord) {
+	namespaces := map[string]*node{}
+	namespace := func(name string) *node {
+		if namespaces[name] == nil {
+			namespaces[name] = &node{label: name}
+		}
+		return namespaces[name]
+	}
+	for i := range runs {
+		run := &runs[i]
+		n := namespace(run.Namespace).add("CloudRun/%s  %s  %s", run.Name, runState(run), run.Status.Uri)
+		addConditions(n, run.Status.Conditions)
+		for _, traffic := range run.Status.Traffic {
+			revision := traffic.Revision
+			if traffic.LatestRevision {
+				revision = "latest"
+			}
+			t := n.add("Traffic %d%% %s", traffic.Percent, revision)
+			if traffic.Tag != "" {
+				t.add("Tag %s  %s", traffic.Tag, traffic.Uri)
+			}
+		}
+		for _, op := range run.Status.Operations {
+			if !op.Done {
+				n.add("Operation %s  %s", op.OperationType, op.Name)
+			}
+		}
+		addPlan(n, run.Status.Plan)
+	}
+	for i := range zones {
+		zone := &zones[i]
+		visibility := "public"
+		if zone.Spec.PrivateZone {
+			visibility = "private"
+		}
+		if zone.Spec.ZoneType != "" && zone.Spec.ZoneType != gcpv1.ZoneTypeStandard {
+			visibility += " " + strings.ToLower(string(zone.Spec.ZoneType))
+		}
+		n := namespace(zone.Namespace).add("CloudDnsZone/%s  %s  %s", zone.Name, zone.Spec.DnsName, visibility)
+		addConditions(n, zone.Status.Conditions)
+		if len(zone.Status.Nameservers) > 0 {
+			n.add("Nameservers %s", strings.Join(zone.Status.Nameservers, ", "))
+		}
+		if zone.Status.Operation != "" {
+			n.add("Operation %s", zone.Status.Operation)
+		}
+		addPlan(n, zone.Status.Plan)
+	}
+	for i := range records {
+		record := &records[i]
+		n := namespace(record.Namespace).add("CloudDnsRecord/%s", record.Name)
+		addConditions(n, record.Status.Conditions)
+	}
+	if len(namespaces) == 0 {
+		fmt.Fprintln(w, "No resources found")
+		return
+	}
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		namespaces[name].print(w)
+	}
+}
+
+func runState(run *gcpv2.CloudRun) string {
+	switch {
+	case meta.IsStatusConditionTrue(run.Status.Conditions, conditionTypePaused):
+		return "Paused"
+	case run.Status.Ready:
+		return "Ready"
+	case run.Status.Reconciling:
+		return "Reconciling"
+	default:
+		return "NotReady"
+	}
+}
+
+func addConditions(n *node, conditions []metav1.Condition) {
+	for _, condition := range conditions {
+		label := fmt.Sprintf("%s=%s  %s", condition.Type, condition.Status, condition.Reason)
+		if condition.Message != "" {
+			label += ": " + condition.Message
+		}
+		n.add("%s", label)
+	}
+}
+
+func addPlan(n *node, plan string) {
+	if plan == "" {
+		return
+	}
+	p := n.add("Plan")
+	for _, line := range strings.Split(plan, "\n") {
+		p.add("%s", line)
+	}
+}
+
+// node is a line of a tree
+type node struct {
+	label    string
+	children []*node
+}
+
+// add adds a child with a label formatted like fmt.Sprintf, trailing blanks are trimmed
+func (n *node) add(format string, args ...any) *node {
+	child := &node{label: strings.TrimRight(fmt.Sprintf(format, args...), " ")}
+	n.children = append(n.children, child)
+	return child
+}
+
+func (n *node) print(w io.Writer) {
+	fmt.Fprintln(w, n.label)
+	n.printChildren(w, "")
+}
+
+func (n *node) printChildren(w io.Writer, prefix string) {
+	for i, child := range n.children {
+		branch, indent := "├── ", "│   "
+		if i == len(n.children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintln(w, prefix+branch+child.label)
+		child.printChildren(w, prefix+indent)
+	}
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stilasctl
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	"cloud.google.com/go/iam/apiv1/iampb"
+	"cloud.google.com/go/run/apiv2/runpb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/dns/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/services/gcp"
+)
+
+// fakeDns serves the managed zones and record sets to list, the other calls are not used
+type fakeDns struct {
+	gcp.CloudDnsService
+	zones   []*dns.ManagedZone
+	records []*dns.ResourceRecordSet
+}
+
+func (f *fakeDns) ListZones(context.Context, string) ([]*dns.ManagedZone, error) {
+	return f.zones, nil
+}
+
+func (f *fakeDns) ListRecords(context.Context, string, string) ([]*dns.ResourceRecordSet, error) {
+	return f.records, nil
+}
+
+var _ = Describe("stilasctl", func() {
+	var run *gcpv2.CloudRun
+	var zone *gcpv1.CloudDnsZone
+	var live *runpb.Service
+	var stderr *bytes.Buffer
+
+	BeforeEach(func() {
+		run = &gcpv2.CloudRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+			Spec: gcpv2.CloudRunSpec{
+				ProjectID: "test-project",
+				Location:  "europe-north1",
+				Containers: []gcpv2.CloudRunContainer{{
+					Image: "nginx:1.27",
+					Env: []gcpv2.CloudRunEnvVar{{
+						Name: "GREETING",
+						ValueFrom: &gcpv2.CloudRunEnvVarSource{
+							ConfigMapKeyRef: &gcpv2.CloudRunConfigMapKeySelector{Name: "hello-config", Key: "greeting"},
+						},
+					}},
+				}},
+			},
+			Status: gcpv2.CloudRunStatus{
+				Ready: true,
+				Uri:   "https://hello-abc.a.run.app",
+				Conditions: []metav1.Condition{{
+					Type: "Synced", Status: metav1.ConditionTrue, Reason: "Synced", Message: "The GCP resource matches the spec",
+				}},
+				Operations: []*gcpv2.CloudRunOperation{{
+					Name: "projects/test-project/locations/europe-north1/operations/1", OperationType: gcpv2.CloudRunOperationType_Update,
+				}},
+			},
+		}
+		zone = &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "other"},
+			Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "test-project", DnsName: "example.com"},
+			Status: gcpv1.CloudDnsZoneStatus{
+				Nameservers: []string{"ns-cloud-a1.googledomains.com.", "ns-cloud-a2.googledomains.com."},
+				Plan:        "Update projects/test-project/managedZones/other-example (dnssecConfig.state)",
+			},
+		}
+		live = nil
+	})
+
+	execute := func(args ...string) (string, error) {
+		s, err := scheme()
+		Expect(err).NotTo(HaveOccurred())
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "hello-config", Namespace: "default"},
+			Data:       map[string]string{"greeting": "hei"},
+		}
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(run, zone, configMap).Build()
+		connect := func(context.Context, *globalFlags) (*Clients, error) {
+			return &Clients{
+				Kube:      c,
+				Namespace: "default",
+				GetService: func(_ context.Context, name string) (*runpb.Service, error) {
+					if live == nil {
+						return nil, status.Error(codes.NotFound, name)
+					}
+					return live, nil
+				},
+				ListServices: func(_ context.Context, parent string) ([]*runpb.Service, error) {
+					Expect(parent).To(Equal("projects/test-project/locations/europe-north1"))
+					if live == nil {
+						return nil, nil
+					}
+					return []*runpb.Service{live}, nil
+				},
+				GetIamPolicy: func(context.Context, string) (*iampb.Policy, error) {
+					return &iampb.Policy{Bindings: []*iampb.Binding{
+						{Role: "roles/run.invoker", Members: []string{"group:team@example.com"}},
+						{Role: "roles/run.developer", Members: []string{"user:dev@example.com"}},
+					}}, nil
+				},
+				Dns: &fakeDns{
+					zones: []*dns.ManagedZone{{Name: "example-com", DnsName: "example.com.", Visibility: "public"}},
+					records: []*dns.ResourceRecordSet{
+						{Name: "example.com.", Type: "SOA", Ttl: 21600, Rrdatas: []string{"ns-cloud-a1.googledomains.com. hostmaster 1 21600 3600 259200 300"}},
+						{Name: "example.com.", Type: "NS", Ttl: 21600, Rrdatas: []string{"ns-cloud-a1.googledomains.com."}},
+						{Name: "example.com.", Type: "TXT", Ttl: 300, Rrdatas: []string{"\"v=spf1 -all\""}},
+						{Name: "www.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"203.0.113.10"}},
+					},
+				},
+			}, nil
+		}
+		out := &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+		cmd := newRootCommand("stilasctl", connect)
+		cmd.SetOut(out)
+		cmd.SetErr(stderr)
+		cmd.SetArgs(args)
+		err = cmd.Execute()
+		return out.String(), err
+	}
+
+	It("Should show the resources of all namespaces as a tree", func() {
+		out, err := execute("status", "-A")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(`default
+└── CloudRun/hello  Ready  https://hello-abc.a.run.app
+    ├── Synced=True  Synced: The GCP resource matches the spec
+    └── Operation update  projects/test-project/locations/europe-north1/operations/1
+other
+└── CloudDnsZone/example  example.com  public
+    ├── Nameservers ns-cloud-a1.googledomains.com., ns-cloud-a2.googledomains.com.
+    └── Plan
+        └── Update projects/test-project/managedZones/other-example (dnssecConfig.state)
+`))
+	})
+
+	It("Should only show the namespace of the context by default", func() {
+		out, err := execute("status")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("CloudRun/hello"))
+		Expect(out).NotTo(ContainSubstring("CloudDnsZone/example"))
+	})
+
+	It("Should list the tracked operations", func() {
+		out, err := execute("ops", "-A")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("NAMESPACE"))
+		Expect(out).To(MatchRegexp(`default\s+CloudRun\s+hello\s+update\s+false\s+-\s+projects/test-project/locations/europe-north1/operations/1`))
+	})
+
+	It("Should render the request with the resolved ConfigMaps", func() {
+		out, err := execute("render", "hello")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring(`"image": "nginx:1.27"`))
+		Expect(out).To(ContainSubstring(`"value": "hei"`))
+		Expect(out).To(ContainSubstring(gcpv2.ConfigHashAnnotation))
+	})
+
+	It("Should fail to render a CloudRun that does not exist", func() {
+		_, err := execute("render", "missing")
+		Expect(err).To(MatchError(ContainSubstring("failed to get CloudRun default/missing")))
+	})
+
+	It("Should tell when the service does not exist", func() {
+		out, err := execute("diff", "hello")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("does not exist"))
+	})
+
+	It("Should show the fields that differ from the live service", func() {
+		desired := run.ConvertToCreateServiceRequest(gcpv2.ConfigMapData{"hello-config": {"greeting": "hei"}}).Service
+		desired.Name = run.GetGcpCloudRunServiceFullName()
+		desired.Template.Containers[0].Image = "nginx:1.25"
+		live = desired
+
+		out, err := execute("diff", "hello")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("--- live " + run.GetGcpCloudRunServiceFullName()))
+		Expect(out).To(ContainSubstring("template.containers[0].image\n- \"nginx:1.25\"\n+ \"nginx:1.27\"\n"))
+
+		live.Template.Containers[0].Image = "nginx:1.27"
+		out, err = execute("diff", "hello")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("matches the CloudRun"))
+	})
+
+	It("Should import the Cloud Run services as adopting CloudRuns", func() {
+		live = run.ConvertToCreateServiceRequest(gcpv2.ConfigMapData{"hello-config": {"greeting": "hei"}}).Service
+		live.Name = "projects/test-project/locations/europe-north1/services/legacy-hello"
+
+		out, err := execute("import", "cloudrun", "--project", "test-project", "--location", "europe-north1", "-n", "imported", "--plan-only")
+		Expect(err).NotTo(HaveOccurred())
+		var imported gcpv2.CloudRun
+		Expect(yaml.UnmarshalStrict([]byte(out), &imported)).To(Succeed())
+		Expect(imported.APIVersion).To(Equal("gcp.stilas.418.cloud/v2"))
+		Expect(imported.Kind).To(Equal("CloudRun"))
+		Expect(imported.Name).To(Equal("legacy-hello"))
+		Expect(imported.Namespace).To(Equal("imported"))
+		Expect(imported.Annotations).To(Equal(map[string]string{
+			gcpv1.ExternalNameAnnotation: "legacy-hello",
+			gcpv1.AdoptAnnotation:        "true",
+			gcpv1.PlanOnlyAnnotation:     "true",
+		}))
+		Expect(imported.Spec.Containers[0].Image).To(Equal("nginx:1.27"))
+		Expect(imported.Spec.Security.InvokeMembers).To(Equal([]string{"group:team@example.com"}))
+		Expect(stderr.String()).To(ContainSubstring("Warning: CloudRun/legacy-hello: IAM binding of roles/run.developer"))
+	})
+
+	It("Should import the managed zones with their record sets", func() {
+		dir := GinkgoT().TempDir()
+		_, err := execute("import", "dns", "--project", "test-project", "--output-dir", dir)
+		Expect(err).NotTo(HaveOccurred())
+		files, err := os.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, file := range files {
+			names = append(names, file.Name())
+		}
+		Expect(names).To(ConsistOf("clouddnszone-example-com.yaml", "clouddnsrecord-example-com-apex-txt.yaml", "clouddnsrecord-example-com-www-a.yaml"))
+
+		content, err := os.ReadFile(filepath.Join(dir, "clouddnsrecord-example-com-www-a.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		var record gcpv1.CloudDnsRecord
+		Expect(yaml.UnmarshalStrict(content, &record)).To(Succeed())
+		Expect(record.Annotations).To(HaveKeyWithValue(gcpv1.AdoptAnnotation, "true"))
+		Expect(record.Spec).To(Equal(gcpv1.CloudDnsRecordSpec{
+			ZoneRef: gcpv1.CloudDnsZoneReference{Name: "example-com"},
+			Name:    "www",
+			Type:    "A",
+			TTL:     300,
+			Rrdatas: []string{"203.0.113.10"},
+		}))
+	})
+
+	It("Should fail to import a managed zone that does not exist", func() {
+		_, err := execute("import", "dns", "--project", "test-project", "--zone", "missing")
+		Expect(err).To(MatchError(ContainSubstring("managed zone missing not found")))
+	})
+
+	It("Should convert Knative Services to CloudRuns", func() {
+		manifests := GinkgoT().TempDir() + "/knative.yaml"
+		Expect(os.WriteFile(manifests, []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: hello
+  namespace: apps
+spec:
+  template:
+    metadata:
+      annotations:
+        autoscaling.knative.dev/max-scale: "5"
+    spec:
+      containers:
+        - image: nginx:1.27
+          ports:
+            - containerPort: 8080
+  unknownField: true
+status:
+  url: https://hello.apps.example.com
+`), 0o644)).To(Succeed())
+
+		out, err := execute("convert", "knative", "-f", manifests, "--project", "test-project", "--location", "europe-north1")
+		Expect(err).NotTo(HaveOccurred())
+		var converted gcpv2.CloudRun
+		Expect(yaml.UnmarshalStrict([]byte(out), &converted)).To(Succeed())
+		Expect(converted.Kind).To(Equal("CloudRun"))
+		Expect(converted.Namespace).To(Equal("apps"))
+		Expect(converted.Spec.ProjectID).To(Equal("test-project"))
+		Expect(converted.Spec.Containers[0].Image).To(Equal("nginx:1.27"))
+		Expect(*converted.Spec.Scaling.MaxInstances).To(BeEquivalentTo(5))
+		Expect(stderr.String()).To(ContainSubstring("skipping v1 ConfigMap"))
+		Expect(stderr.String()).To(ContainSubstring(`Warning: CloudRun/hello: fields not converted: `))
+		Expect(stderr.String()).To(ContainSubstring(`unknown field "unknownField"`))
+	})
+
+	It("Should be named like a kubectl plugin when run as one", func() {
+		cmd := NewRootCommand(PluginName)
+		Expect(cmd.DisplayName()).To(Equal("kubectl stilas"))
+	})
+})
@@ -0,0 +1,124 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing sets up OpenTelemetry tracing of the reconciles and the Google API calls they make.
+// The Google API clients instrument their own gRPC and HTTP transports with the global tracer
+// provider, so their spans become children of the spans created here as long as the context is
+// passed on to every call.
+package tracing
+
+import (
+	"context"
+	"flag"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TracerName is the instrumentation name of the spans created by stilas
+const TracerName = "github.com/tjololo/stilas"
+
+// Options configures the OTLP exporter. The standard OTEL_EXPORTER_OTLP_* environment variables
+// are honored for everything not set by a flag.
+type Options struct {
+	// Enabled turns on tracing, spans are dropped when false
+	Enabled bool
+	// Endpoint is the host:port of the OTLP gRPC collector
+	Endpoint string
+	// Insecure disables TLS towards the collector
+	Insecure bool
+	// SampleRatio is the fraction of new traces that are sampled
+	SampleRatio float64
+}
+
+// BindFlags registers the tracing flags on fs
+func (o *Options) BindFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "tracing-enabled", false, "Export OpenTelemetry traces of reconciles and Google API calls over OTLP.")
+	fs.StringVar(&o.Endpoint, "tracing-otlp-endpoint", "",
+		"host:port of the OTLP gRPC collector, defaults to OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317.")
+	fs.BoolVar(&o.Insecure, "tracing-insecure", false, "Connect to the OTLP collector without TLS.")
+	fs.Float64Var(&o.SampleRatio, "tracing-sample-ratio", 1, "Fraction of new traces to sample, between 0 and 1.")
+}
+
+// Setup installs a global tracer provider exporting to the configured collector. The returned
+// function flushes and stops the exporter, it is a no-op when tracing is disabled.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	if !opts.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	var exporterOpts []otlptracegrpc.Option
+	if opts.Endpoint != "" {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpoint(opts.Endpoint))
+	}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+	tp := Install(sdktrace.WithBatcher(exporter), opts.SampleRatio)
+	return tp.Shutdown, nil
+}
+
+// Install sets a tracer provider exporting spans through processor as the global provider
+func Install(processor sdktrace.TracerProviderOption, sampleRatio float64) *sdktrace.TracerProvider {
+	tp := sdktrace.NewTracerProvider(
+		processor,
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName("stilas"))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp
+}
+
+// Tracer returns the tracer of stilas from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Reconciler wraps r so every reconcile of kind runs in its own span
+func Reconciler(kind string, r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		ctx, span := Tracer().Start(ctx, "Reconcile "+kind, trace.WithAttributes(
+			attribute.String("stilas.kind", kind),
+			semconv.K8SNamespaceName(req.Namespace),
+			attribute.String("k8s.resource.name", req.Name),
+		))
+		defer span.End()
+		result, err := r.Reconcile(ctx, req)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.SetAttributes(attribute.Bool("stilas.requeue", result.Requeue || result.RequeueAfter > 0))
+		return result, err
+	})
+}
+
+// SetProject records the GCP project of the reconciled resource on the span of ctx
+func SetProject(ctx context.Context, project string) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("gcp.project_id", project))
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Reconciler", func() {
+	var exporter *tracetest.InMemoryExporter
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-resource"}}
+
+	BeforeEach(func() {
+		exporter = tracetest.NewInMemoryExporter()
+		tp := Install(sdktrace.WithSyncer(exporter), 1)
+		DeferCleanup(tp.Shutdown)
+	})
+
+	It("Should create a span per reconcile with the resource attributes", func() {
+		var reconcileSpan trace.SpanContext
+		r := Reconciler("CloudRun", reconcile.Func(func(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+			reconcileSpan = trace.SpanContextFromContext(ctx)
+			SetProject(ctx, "test-project")
+			_, child := Tracer().Start(ctx, "run/GetService")
+			child.End()
+			return reconcile.Result{Requeue: true}, nil
+		}))
+		_, err := r.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name).To(Equal("run/GetService"))
+		Expect(spans[0].Parent.SpanID()).To(Equal(reconcileSpan.SpanID()))
+		Expect(spans[1].Name).To(Equal("Reconcile CloudRun"))
+		Expect(spans[1].Attributes).To(ContainElements(
+			attribute.String("stilas.kind", "CloudRun"),
+			attribute.String("k8s.namespace.name", "default"),
+			attribute.String("k8s.resource.name", "test-resource"),
+			attribute.String("gcp.project_id", "test-project"),
+			attribute.Bool("stilas.requeue", true),
+		))
+	})
+
+	It("Should record reconcile errors on the span", func() {
+		r := Reconciler("CloudDnsZone", reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, errors.New("boom")
+		}))
+		_, err := r.Reconcile(context.Background(), req)
+		Expect(err).To(MatchError("boom"))
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status.Code).To(Equal(codes.Error))
+		Expect(spans[0].Events).To(ContainElement(HaveField("Name", "exception")))
+	})
+})
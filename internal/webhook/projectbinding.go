@@ -0,0 +1,138 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook holds the admission webhooks that need more than the object under review, like
+// the GcpProjectBindings of the cluster. Webhooks that only look at the object live next to its type.
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/policy"
+)
+
+var projectbindinglog = logf.Log.WithName("projectbinding-webhook")
+
+// SetupProjectBindingWebhooksWithManager registers the webhooks rejecting CloudRuns and
+// CloudDnsZones targeting a project their namespace is not bound to, a nil projectPolicy allows all.
+// They are served next to the validating webhooks of the types, on paths of their own.
+func SetupProjectBindingWebhooksWithManager(mgr ctrl.Manager, projectPolicy *policy.ProjectPolicy) error {
+	validator := &ProjectBindingValidator{Policy: projectPolicy}
+	server := mgr.GetWebhookServer()
+	server.Register("/validate-projectbinding-gcp-stilas-418-cloud-v2-cloudrun",
+		admission.WithCustomValidator(mgr.GetScheme(), &gcpv2.CloudRun{}, validator))
+	server.Register("/validate-projectbinding-gcp-stilas-418-cloud-v1-clouddnszone",
+		admission.WithCustomValidator(mgr.GetScheme(), &gcpv1.CloudDnsZone{}, validator))
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-projectbinding-gcp-stilas-418-cloud-v2-cloudrun,mutating=false,failurePolicy=fail,sideEffects=None,groups=gcp.stilas.418.cloud,resources=cloudruns,verbs=create;update,versions=v2,name=vcloudrun-projectbinding.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-projectbinding-gcp-stilas-418-cloud-v1-clouddnszone,mutating=false,failurePolicy=fail,sideEffects=None,groups=gcp.stilas.418.cloud,resources=clouddnszones,verbs=create;update,versions=v1,name=vclouddnszone-projectbinding.kb.io,admissionReviewVersions=v1
+
+// ProjectBindingValidator rejects resources whose project or location is not allowed by a
+// GcpProjectBinding of their namespace
+type ProjectBindingValidator struct {
+	// Policy checks the resources, everything is allowed when nil so the webhooks can always be
+	// registered whether project bindings are enforced or not
+	Policy *policy.ProjectPolicy
+}
+
+var _ admission.CustomValidator = &ProjectBindingValidator{}
+
+// ValidateCreate implements admission.CustomValidator
+func (v *ProjectBindingValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(ctx, obj)
+}
+
+// ValidateUpdate implements admission.CustomValidator. Updates keeping the project and location,
+// like the removal of a finalizer, are allowed so resources can still be cleaned up after their
+// binding is removed.
+func (v *ProjectBindingValidator) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	oldTarget, err := targetOf(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	newTarget, err := targetOf(newObj)
+	if err != nil {
+		return nil, err
+	}
+	if oldTarget == newTarget {
+		return nil, nil
+	}
+	return nil, v.validate(ctx, newObj)
+}
+
+// ValidateDelete implements admission.CustomValidator, deletes are always allowed
+func (v *ProjectBindingValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *ProjectBindingValidator) validate(ctx context.Context, obj runtime.Object) error {
+	if v.Policy == nil {
+		return nil
+	}
+	target, err := targetOf(obj)
+	if err != nil {
+		return err
+	}
+	err = v.Policy.Check(ctx, target.namespace, target.project, target.location)
+	if policy.IsDenied(err) {
+		projectbindinglog.Info("denied", "namespace", target.namespace, "project", target.project, "location", target.location)
+		return apierrors.NewForbidden(target.resource, target.name, err)
+	}
+	return err
+}
+
+// target is where in GCP a resource is created
+type target struct {
+	resource  schema.GroupResource
+	name      string
+	namespace string
+	project   string
+	location  string
+}
+
+func targetOf(obj runtime.Object) (target, error) {
+	switch o := obj.(type) {
+	case *gcpv2.CloudRun:
+		return target{
+			resource:  gcpv2.GroupVersion.WithResource("cloudruns").GroupResource(),
+			name:      o.Name,
+			namespace: o.Namespace,
+			project:   o.Spec.ProjectID,
+			location:  o.Spec.Location,
+		}, nil
+	case *gcpv1.CloudDnsZone:
+		return target{
+			resource:  gcpv1.GroupVersion.WithResource("clouddnszones").GroupResource(),
+			name:      o.Name,
+			namespace: o.Namespace,
+			project:   o.Spec.ProjectID,
+		}, nil
+	default:
+		return target{}, fmt.Errorf("expected a CloudRun or CloudDnsZone object but got %T", obj)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gcpv1 "github.com/tjololo/stilas/api/gcp/v1"
+	gcpv2 "github.com/tjololo/stilas/api/gcp/v2"
+	"github.com/tjololo/stilas/internal/policy"
+)
+
+var _ = Describe("ProjectBindingValidator", func() {
+	ctx := context.Background()
+	var validator *ProjectBindingValidator
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(gcpv1.AddToScheme(scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&gcpv1.GcpProjectBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+				Spec: gcpv1.GcpProjectBindingSpec{
+					Namespaces: []string{"team-a"},
+					Projects:   []string{"team-a-prod"},
+					Locations:  []string{"europe-north1"},
+				},
+			},
+		).Build()
+		validator = &ProjectBindingValidator{Policy: &policy.ProjectPolicy{Client: c}}
+	})
+
+	cloudRun := func(project string, location string) *gcpv2.CloudRun {
+		return &gcpv2.CloudRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+			Spec:       gcpv2.CloudRunSpec{ProjectID: project, Location: location},
+		}
+	}
+
+	It("Should admit resources allowed by a binding", func() {
+		_, err := validator.ValidateCreate(ctx, cloudRun("team-a-prod", "europe-north1"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = validator.ValidateCreate(ctx, &gcpv1.CloudDnsZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "zone", Namespace: "team-a"},
+			Spec:       gcpv1.CloudDnsZoneSpec{ProjectID: "team-a-prod"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should forbid resources targeting another project or location", func() {
+		_, err := validator.ValidateCreate(ctx, cloudRun("team-b-prod", "europe-north1"))
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		_, err = validator.ValidateCreate(ctx, cloudRun("team-a-prod", "us-central1"))
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+	})
+
+	It("Should only check updates changing the project or location", func() {
+		denied := cloudRun("team-b-prod", "europe-north1")
+		updated := denied.DeepCopy()
+		updated.Finalizers = nil
+		_, err := validator.ValidateUpdate(ctx, denied, updated)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = validator.ValidateUpdate(ctx, cloudRun("team-a-prod", "europe-north1"), denied)
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+	})
+
+	It("Should admit everything when project bindings are not enforced", func() {
+		_, err := (&ProjectBindingValidator{}).ValidateCreate(ctx, cloudRun("team-b-prod", "us-central1"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+})